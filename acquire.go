@@ -0,0 +1,216 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidAcquireOption is returned by AcquireWith for options that don't fit the pool, e.g. a predicate
+// for entries of another type
+var ErrInvalidAcquireOption = fmt.Errorf("invalid acquire option")
+
+// AcquireOption tunes a single acquire of AcquireWith. Options are plain values, so passing them doesn't
+// allocate and the acquire stays as cheap as AcquireWithContext.
+type AcquireOption struct {
+	kind acquireOptionKind
+	d    time.Duration
+	v    any
+}
+
+type acquireOptionKind int
+
+const (
+	acquireTimeout acquireOptionKind = iota + 1
+	acquirePriority
+	acquirePredicate
+	acquireNoWait
+	acquireAffinity
+	acquireSpin
+)
+
+// WithTimeout makes the acquire fail with ErrAcquireTimeout if it didn't get an entry within d
+// (see AcquireWithTimeout)
+func WithTimeout(d time.Duration) AcquireOption {
+	return AcquireOption{kind: acquireTimeout, d: d}
+}
+
+// WithPriority queues the acquire ahead of the other waiting acquires, behind earlier acquires with
+// priority, e.g. for the retry of an acquire that timed out so it doesn't time out over and over again under
+// saturation (see Stats.Retries). Predicate acquires don't queue, so it can't be combined with WithPredicate.
+func WithPriority() AcquireOption {
+	return AcquireOption{kind: acquirePriority}
+}
+
+// WithPredicate acquires an entry for which match returns true (see AcquireMatch),
+// T must be the type of the entries of the pool
+func WithPredicate[T any](match func(*T) bool) AcquireOption {
+	return AcquireOption{kind: acquirePredicate, v: match}
+}
+
+// WithNoWait makes the acquire fail with ErrPoolExhausted instead of waiting if no entry is available
+// right away, lazy pools still create a new entry if they hold less entries than they should
+func WithNoWait() AcquireOption {
+	return AcquireOption{kind: acquireNoWait}
+}
+
+// WithAffinity prefers the entry last acquired with the same key if it's idle (see AcquireWithAffinity)
+func WithAffinity(key any) AcquireOption {
+	return AcquireOption{kind: acquireAffinity, v: key}
+}
+
+// WithSpin makes the acquire poll for an entry for up to d before it parks, overriding the duration set by
+// WithSpinAcquire (0 disables spinning), it can't be combined with WithPredicate
+func WithSpin(d time.Duration) AcquireOption {
+	return AcquireOption{kind: acquireSpin, d: d}
+}
+
+// acquireConfig is the combination of the AcquireOptions of an acquire
+type acquireConfig struct {
+	timeout    time.Duration
+	hasTimeout bool
+	priority   bool
+	noWait     bool
+	// func(*T) bool
+	match       any
+	affinity    any
+	hasAffinity bool
+	spin        time.Duration
+	hasSpin     bool
+	// set by Acquire, which can't report ErrOverloaded (see WithLoadShedding)
+	noShed bool
+}
+
+// acquireCanceled is the context of acquires combining WithPredicate and WithNoWait, which scan the idle
+// entries once without waiting
+var acquireCanceled = func() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}()
+
+// AcquireWith acquires an entry like AcquireWithContext, tuned by opts (e.g. WithTimeout or WithPredicate).
+// Options combine, e.g. WithPredicate and WithNoWait take a matching idle entry only if there is one.
+func (p *Pool[T]) AcquireWith(ctx context.Context, opts ...AcquireOption) (*T, error) {
+	var c acquireConfig
+	for _, o := range opts {
+		switch o.kind {
+		case acquireTimeout:
+			c.timeout, c.hasTimeout = o.d, true
+		case acquirePriority:
+			c.priority = true
+		case acquirePredicate:
+			c.match = o.v
+		case acquireNoWait:
+			c.noWait = true
+		case acquireAffinity:
+			c.affinity, c.hasAffinity = o.v, true
+		case acquireSpin:
+			if o.d < 0 {
+				return nil, p.wrapErr(opAcquire, fmt.Errorf("%w: spin duration %v is negative", ErrInvalidAcquireOption, o.d))
+			}
+			c.spin, c.hasSpin = o.d, true
+		}
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	v, err := p.acquireWith(ctx, c)
+	return v, p.wrapErr(opAcquire, err)
+}
+
+// acquireWith implements AcquireWith and the acquire methods wrapping it, ctx may be nil
+func (p *Pool[T]) acquireWith(ctx context.Context, c acquireConfig) (*T, error) {
+	if c.match != nil {
+		return p.acquireMatchWith(ctx, c)
+	}
+	if c.hasAffinity {
+		if v, ok := p.takeAffine(c.affinity); ok {
+			p.onAcquire(v)
+			p.bind(v, c.affinity)
+			return v, nil
+		}
+	}
+	// fast path: skip the timer and the (more expensive) multi-case select if an entry is available right away
+	v, ok := p.tryAcquire(ctx)
+	if !ok && !c.noWait && !c.noShed {
+		if err := p.shed(); err != nil {
+			return nil, err
+		}
+	}
+	// time spent spinning, which counts towards the timeout
+	var spun time.Duration
+	if !ok && !c.noWait {
+		spin := p.opts.spin
+		if c.hasSpin {
+			spin = c.spin
+		}
+		if c.hasTimeout {
+			spin = min(spin, c.timeout)
+		}
+		if spin > 0 {
+			start := time.Now()
+			v, ok = p.spinAcquire(ctx, spin)
+			spun = time.Since(start)
+		}
+	}
+	if !ok {
+		if c.noWait {
+			return nil, ErrPoolExhausted
+		}
+		if c.priority {
+			p.stats.retries.Add(1)
+		}
+		var timeout <-chan time.Time
+		if c.hasTimeout {
+			t := acquireTimer(c.timeout - spun)
+			defer releaseTimer(t)
+			timeout = t.C
+		}
+		var err error
+		if v, err = p.acquire(ctx, timeout, c.priority); err != nil {
+			return nil, err
+		}
+	}
+	if c.hasAffinity {
+		p.bind(v, c.affinity)
+	}
+	return v, nil
+}
+
+// acquireMatchWith implements acquires WithPredicate, the timeout and WithNoWait are applied to the context
+func (p *Pool[T]) acquireMatchWith(ctx context.Context, c acquireConfig) (*T, error) {
+	match, ok := c.match.(func(*T) bool)
+	if !ok {
+		return nil, fmt.Errorf("%w: predicate %T for entries of type %T", ErrInvalidAcquireOption, c.match, (*T)(nil))
+	}
+	// predicate acquires rescan the idle entries on every release instead of queuing or spinning
+	if c.priority || c.hasSpin {
+		return nil, fmt.Errorf("%w: WithPriority and WithSpin can't be combined with WithPredicate", ErrInvalidAcquireOption)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	parent := ctx
+	switch {
+	case c.noWait:
+		ctx = acquireCanceled
+	case c.hasTimeout:
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	v, err := p.acquireMatch(ctx, match)
+	switch {
+	case err == nil:
+		if c.hasAffinity {
+			p.bind(v, c.affinity)
+		}
+	case c.noWait && errors.Is(err, context.Canceled):
+		err = ErrPoolExhausted
+	case c.hasTimeout && errors.Is(err, context.DeadlineExceeded) && parent.Err() == nil:
+		err = ErrAcquireTimeout
+	}
+	return v, err
+}
@@ -0,0 +1,57 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAcquireWith(t *testing.T) {
+	pool := NewPool(2, func() *int { return new(int) })
+	defer pool.Close()
+	ctx := context.Background()
+	a, _ := pool.AcquireWith(ctx)
+	*a = 1
+	pool.Release(a)
+
+	e, err := pool.AcquireWith(ctx, WithPredicate(func(v *int) bool { return *v == 1 }))
+	if err != nil || e != a {
+		t.Fatalf("expected the matching entry but got %v, %v", e, err)
+	}
+	if _, err := pool.AcquireWith(ctx, WithPredicate(func(v *int) bool { return *v == 2 }), WithNoWait()); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("expected %v but got %v", ErrPoolExhausted, err)
+	}
+	if _, err := pool.AcquireWith(ctx, WithPredicate(func(v *int) bool { return *v == 2 }), WithTimeout(time.Millisecond)); !errors.Is(err, ErrAcquireTimeout) {
+		t.Errorf("expected %v but got %v", ErrAcquireTimeout, err)
+	}
+	if _, err := pool.AcquireWith(ctx, WithPredicate(func(*string) bool { return true })); !errors.Is(err, ErrInvalidAcquireOption) {
+		t.Errorf("expected %v but got %v", ErrInvalidAcquireOption, err)
+	}
+	for _, o := range []AcquireOption{WithPriority(), WithSpin(time.Millisecond)} {
+		if _, err := pool.AcquireWith(ctx, WithPredicate(func(*int) bool { return true }), o); !errors.Is(err, ErrInvalidAcquireOption) {
+			t.Errorf("expected %v but got %v", ErrInvalidAcquireOption, err)
+		}
+	}
+
+	b, _ := pool.AcquireWith(ctx, WithNoWait())
+	if _, err := pool.AcquireWith(ctx, WithNoWait()); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("expected %v but got %v", ErrPoolExhausted, err)
+	}
+	if _, err := pool.AcquireWith(ctx, WithTimeout(time.Millisecond), WithPriority()); !errors.Is(err, ErrAcquireTimeout) {
+		t.Errorf("expected %v but got %v", ErrAcquireTimeout, err)
+	}
+	if stats := pool.Stats(); stats.Retries != 1 {
+		t.Errorf("expected 1 retry but got %d", stats.Retries)
+	}
+
+	pool.Release(b)
+	if v, _ := pool.AcquireWith(ctx, WithAffinity("worker")); v != b {
+		t.Errorf("expected the idle entry")
+	}
+	pool.Release(b)
+	pool.Release(e)
+	if v, _ := pool.AcquireWith(ctx, WithAffinity("worker")); v != b {
+		t.Errorf("expected the entry last acquired with the key")
+	}
+}
@@ -0,0 +1,205 @@
+// Package admin provides an http.Handler to inspect and manage the pools of a
+// pool.Registry at runtime (the pool equivalent of net/http/pprof)
+//
+// The handler isn't registered automatically since it allows to modify pools,
+// mount it on an internal or authenticated endpoint:
+//
+//	mux.Handle("/debug/pools/", http.StripPrefix("/debug/pools", admin.NewHandler(pool.DefaultRegistry)))
+//
+// Routes (relative to the mount point):
+//
+//	GET  /                      stats of all registered pools
+//	GET  /metrics               stats of all registered pools in the Prometheus text format,
+//	                            including the wait buckets of pools created WithWaitBuckets
+//	GET  /ready                 200 if all registered pools are ready and 503 otherwise (see NewReadyHandler)
+//	GET  /{name}                stats of a single pool
+//	GET  /{name}/trace          lifecycle traces of the entries of a pool created WithLifecycleTrace
+//	POST /{name}/refresh        replace all idle entries (RefreshAll), lazy=true
+//	                            replaces them once they get acquired (RefreshLazy)
+//	POST /{name}/resize?size=N  change the number of entries (Resize)
+//	POST /{name}/pause          stop handing out entries (Pause)
+//	POST /{name}/resume         continue handing out entries (Resume)
+//	POST /{name}/drain          close the pool and wait for its entries (Drain),
+//	                            an optional timeout=<duration> limits the wait
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/epikur-io/go-pool"
+)
+
+// Creates a handler managing the pools of the given registry
+// (the pool.DefaultRegistry if reg is nil)
+func NewHandler(reg *pool.Registry) http.Handler {
+	if reg == nil {
+		reg = pool.DefaultRegistry
+	}
+	h := &handler{reg: reg}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", h.list)
+	mux.HandleFunc("GET /metrics", h.metrics)
+	mux.Handle("GET /ready", NewReadyHandler(reg))
+	mux.HandleFunc("GET /{name}", h.stats)
+	mux.HandleFunc("GET /{name}/trace", h.trace)
+	mux.HandleFunc("POST /{name}/refresh", h.managed(h.refresh))
+	mux.HandleFunc("POST /{name}/resize", h.managed(h.resize))
+	mux.HandleFunc("POST /{name}/pause", h.managed(h.pause))
+	mux.HandleFunc("POST /{name}/resume", h.managed(h.resume))
+	mux.HandleFunc("POST /{name}/drain", h.managed(h.drain))
+	return mux
+}
+
+type handler struct {
+	reg *pool.Registry
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+func (h *handler) list(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.reg.ListPools())
+}
+
+func (h *handler) stats(w http.ResponseWriter, r *http.Request) {
+	p, ok := h.reg.Get(r.PathValue("name"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("pool not found"))
+		return
+	}
+	writeJSON(w, http.StatusOK, p.Stats())
+}
+
+// tracer is implemented by pool.Pool
+type tracer interface {
+	Traces() []pool.EntryTrace
+}
+
+func (h *handler) trace(w http.ResponseWriter, r *http.Request) {
+	p, ok := h.reg.Get(r.PathValue("name"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("pool not found"))
+		return
+	}
+	tp, ok := p.(tracer)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, errors.New("pool doesn't support lifecycle traces"))
+		return
+	}
+	traces := tp.Traces()
+	if traces == nil {
+		writeError(w, http.StatusNotFound, errors.New("pool wasn't created WithLifecycleTrace"))
+		return
+	}
+	writeJSON(w, http.StatusOK, traces)
+}
+
+// managed looks up the pool of the request and responds with its stats after fn succeeded
+func (h *handler) managed(fn func(r *http.Request, p pool.ManagedPool) (int, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rp, ok := h.reg.Get(r.PathValue("name"))
+		if !ok {
+			writeError(w, http.StatusNotFound, errors.New("pool not found"))
+			return
+		}
+		p, ok := rp.(pool.ManagedPool)
+		if !ok {
+			writeError(w, http.StatusNotImplemented, errors.New("pool doesn't support runtime management"))
+			return
+		}
+		if status, err := fn(r, p); err != nil {
+			writeError(w, status, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, p.Stats())
+	}
+}
+
+// statusFor maps errors of pool operations to http status codes
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, pool.ErrPoolClosed):
+		return http.StatusConflict
+	case errors.Is(err, pool.ErrInvalidSize):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// lazyRefresher is implemented by pool.Pool
+type lazyRefresher interface {
+	RefreshLazy() error
+}
+
+func (h *handler) refresh(r *http.Request, p pool.ManagedPool) (int, error) {
+	if lazy, _ := strconv.ParseBool(r.URL.Query().Get("lazy")); lazy {
+		lp, ok := p.(lazyRefresher)
+		if !ok {
+			return http.StatusNotImplemented, errors.New("pool doesn't support lazy refreshes")
+		}
+		if err := lp.RefreshLazy(); err != nil {
+			return statusFor(err), err
+		}
+		return http.StatusOK, nil
+	}
+	if err := p.RefreshAll(r.Context()); err != nil {
+		return statusFor(err), err
+	}
+	return http.StatusOK, nil
+}
+
+func (h *handler) resize(r *http.Request, p pool.ManagedPool) (int, error) {
+	size, err := strconv.Atoi(r.URL.Query().Get("size"))
+	if err != nil {
+		return http.StatusBadRequest, errors.New("invalid size parameter")
+	}
+	if err := p.Resize(size); err != nil {
+		return statusFor(err), err
+	}
+	return http.StatusOK, nil
+}
+
+func (h *handler) pause(r *http.Request, p pool.ManagedPool) (int, error) {
+	p.Pause()
+	return http.StatusOK, nil
+}
+
+func (h *handler) resume(r *http.Request, p pool.ManagedPool) (int, error) {
+	p.Resume()
+	return http.StatusOK, nil
+}
+
+func (h *handler) drain(r *http.Request, p pool.ManagedPool) (int, error) {
+	ctx := r.Context()
+	if to := r.URL.Query().Get("timeout"); to != "" {
+		d, err := time.ParseDuration(to)
+		if err != nil {
+			return http.StatusBadRequest, errors.New("invalid timeout parameter")
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+	if err := p.Drain(ctx); err != nil {
+		return statusFor(err), err
+	}
+	return http.StatusOK, nil
+}
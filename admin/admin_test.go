@@ -0,0 +1,87 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/epikur-io/go-pool"
+)
+
+type entry struct{}
+
+func do(t *testing.T, h http.Handler, method, path string, v any) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("%s %s: invalid response %q: %v", method, path, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+func TestHandler(t *testing.T) {
+	reg := pool.NewRegistry()
+	pool.NewPool(2, func() *entry { return &entry{} }, pool.WithName("vms"), pool.WithMaxSize(4), pool.WithRegistry(reg))
+	h := NewHandler(reg)
+
+	var list []pool.Stats
+	if code := do(t, h, http.MethodGet, "/", &list); code != http.StatusOK || len(list) != 1 || list[0].Name != "vms" {
+		t.Errorf("unexpected list response %d: %+v", code, list)
+	}
+
+	var stats pool.Stats
+	if code := do(t, h, http.MethodPost, "/vms/resize?size=4", &stats); code != http.StatusOK || stats.Idle != 4 {
+		t.Errorf("unexpected resize response %d: %+v", code, stats)
+	}
+	if code := do(t, h, http.MethodPost, "/vms/resize?size=10", nil); code != http.StatusBadRequest {
+		t.Errorf("expected status %d but got %d", http.StatusBadRequest, code)
+	}
+	if code := do(t, h, http.MethodPost, "/vms/refresh", &stats); code != http.StatusOK || stats.Generation != 1 {
+		t.Errorf("unexpected refresh response %d: %+v", code, stats)
+	}
+	if code := do(t, h, http.MethodPost, "/vms/refresh?lazy=true", &stats); code != http.StatusOK || stats.Generation != 2 || stats.Migrated != 0 {
+		t.Errorf("unexpected lazy refresh response %d: %+v", code, stats)
+	}
+	if code := do(t, h, http.MethodPost, "/vms/pause", &stats); code != http.StatusOK || !stats.Paused {
+		t.Errorf("unexpected pause response %d: %+v", code, stats)
+	}
+	if code := do(t, h, http.MethodPost, "/vms/resume", &stats); code != http.StatusOK || stats.Paused {
+		t.Errorf("unexpected resume response %d: %+v", code, stats)
+	}
+	if code := do(t, h, http.MethodGet, "/vms", &stats); code != http.StatusOK || stats.Name != "vms" {
+		t.Errorf("unexpected stats response %d: %+v", code, stats)
+	}
+	if code := do(t, h, http.MethodPost, "/vms/drain?timeout=1s", &stats); code != http.StatusOK || !stats.Closed || stats.Idle != 0 {
+		t.Errorf("unexpected drain response %d: %+v", code, stats)
+	}
+	if code := do(t, h, http.MethodGet, "/vms", nil); code != http.StatusNotFound {
+		t.Errorf("expected status %d for a drained pool but got %d", http.StatusNotFound, code)
+	}
+}
+
+func TestTraceHandler(t *testing.T) {
+	reg := pool.NewRegistry()
+	p := pool.NewPool(1, func() *entry { return &entry{} }, pool.WithName("vms"), pool.WithRegistry(reg), pool.WithLifecycleTrace(4))
+	pool.NewPool(1, func() *entry { return &entry{} }, pool.WithName("plain"), pool.WithRegistry(reg))
+	p.Release(p.Acquire())
+	h := NewHandler(reg)
+
+	var traces []pool.EntryTrace
+	if code := do(t, h, http.MethodGet, "/vms/trace", &traces); code != http.StatusOK || len(traces) != 1 {
+		t.Fatalf("unexpected trace response %d: %+v", code, traces)
+	}
+	var ops []pool.TraceOp
+	for _, e := range traces[0].Events {
+		ops = append(ops, e.Op)
+	}
+	if len(ops) != 3 || ops[0] != pool.TraceCreate || ops[1] != pool.TraceAcquire || ops[2] != pool.TraceRelease {
+		t.Errorf("unexpected trace %v", ops)
+	}
+	if code := do(t, h, http.MethodGet, "/plain/trace", nil); code != http.StatusNotFound {
+		t.Errorf("expected status %d but got %d", http.StatusNotFound, code)
+	}
+}
@@ -0,0 +1,16 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/epikur-io/go-pool"
+	"github.com/epikur-io/go-pool/internal/promtext"
+)
+
+// metrics writes the stats of all registered pools in the Prometheus text format
+func (h *handler) metrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", promtext.ContentType)
+	stats := h.reg.ListPools()
+	promtext.Write(w, promtext.Metrics, stats)
+	promtext.WriteHistogram(w, promtext.AcquireWait, stats, func(s pool.Stats) *pool.WaitHistogram { return s.Waits })
+}
@@ -0,0 +1,38 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/epikur-io/go-pool"
+)
+
+func TestMetrics(t *testing.T) {
+	reg := pool.NewRegistry()
+	p := pool.NewPool(1, func() *entry { return &entry{} }, pool.WithName("vms"), pool.WithRegistry(reg),
+		pool.WithWaitBuckets([]time.Duration{time.Millisecond, time.Second}))
+	p.Release(p.Acquire())
+
+	rec := httptest.NewRecorder()
+	NewHandler(reg).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d but got %d", http.StatusOK, rec.Code)
+	}
+	body := rec.Body.String()
+	for _, line := range []string{
+		`pool_idle{pool="vms"} 1`,
+		`pool_acquired_total{pool="vms"} 1`,
+		"# TYPE pool_acquire_wait_seconds histogram",
+		`pool_acquire_wait_seconds_bucket{pool="vms",le="0.001"} 1`,
+		`pool_acquire_wait_seconds_bucket{pool="vms",le="1"} 1`,
+		`pool_acquire_wait_seconds_bucket{pool="vms",le="+Inf"} 1`,
+		`pool_acquire_wait_seconds_count{pool="vms"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("expected line %q in\n%s", line, body)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/epikur-io/go-pool"
+)
+
+// readiness is implemented by pool.Pool
+type readiness interface {
+	Ready() bool
+}
+
+type readyResponse struct {
+	Ready bool `json:"ready"`
+	// names of the pools that aren't ready
+	NotReady []string `json:"not_ready,omitempty"`
+}
+
+// NewReadyHandler creates a readiness probe (e.g. for Kubernetes) that responds with 200 once all
+// pools of the registry (the pool.DefaultRegistry if reg is nil) are ready and with 503 otherwise.
+// Only the pools with the given names are checked if names are passed, missing pools aren't ready.
+func NewReadyHandler(reg *pool.Registry, names ...string) http.Handler {
+	if reg == nil {
+		reg = pool.DefaultRegistry
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res := readyResponse{Ready: true}
+		check := func(name string, p pool.RegisteredPool, ok bool) {
+			if !ok || !ready(p) {
+				res.Ready = false
+				res.NotReady = append(res.NotReady, name)
+			}
+		}
+		if len(names) == 0 {
+			for _, p := range reg.Pools() {
+				check(p.Name(), p, true)
+			}
+		}
+		for _, name := range names {
+			p, ok := reg.Get(name)
+			check(name, p, ok)
+		}
+		status := http.StatusOK
+		if !res.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, res)
+	})
+}
+
+// ready reports whether p is ready, pools that don't report it are judged by their stats
+func ready(p pool.RegisteredPool) bool {
+	if r, ok := p.(readiness); ok {
+		return r.Ready()
+	}
+	s := p.Stats()
+	return !s.Closed && !s.Paused && s.State != pool.PoolCold
+}
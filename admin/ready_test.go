@@ -0,0 +1,31 @@
+package admin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/epikur-io/go-pool"
+)
+
+func TestReadyHandler(t *testing.T) {
+	reg := pool.NewRegistry()
+	vms := pool.NewPool(1, func() *entry { return &entry{} }, pool.WithName("vms"), pool.WithRegistry(reg))
+	pool.NewPool(1, func() *entry { return &entry{} }, pool.WithName("conns"), pool.WithRegistry(reg))
+
+	var res readyResponse
+	if code := do(t, NewHandler(reg), http.MethodGet, "/ready", &res); code != http.StatusOK || !res.Ready {
+		t.Errorf("unexpected ready response %d: %+v", code, res)
+	}
+	vms.Pause()
+	res = readyResponse{}
+	if code := do(t, NewReadyHandler(reg), http.MethodGet, "/", &res); code != http.StatusServiceUnavailable || len(res.NotReady) != 1 || res.NotReady[0] != "vms" {
+		t.Errorf("unexpected ready response %d: %+v", code, res)
+	}
+	res = readyResponse{}
+	if code := do(t, NewReadyHandler(reg, "conns"), http.MethodGet, "/", &res); code != http.StatusOK || !res.Ready {
+		t.Errorf("unexpected ready response %d: %+v", code, res)
+	}
+	if code := do(t, NewReadyHandler(reg, "conns", "missing"), http.MethodGet, "/", nil); code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d but got %d", http.StatusServiceUnavailable, code)
+	}
+}
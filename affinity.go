@@ -0,0 +1,43 @@
+package pool
+
+import (
+	"context"
+	"time"
+)
+
+// AcquireWithAffinity acquires an entry like AcquireWithContext but prefers the entry that was last acquired
+// using the same key (e.g. a worker id) if it's idle, which keeps caches and JIT state of the entry warm.
+// key must be comparable.
+func (p *Pool[T]) AcquireWithAffinity(ctx context.Context, key any) (*T, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	v, err := p.acquireWith(ctx, acquireConfig{affinity: key, hasAffinity: true})
+	return v, p.wrapErr(opAcquire, err)
+}
+
+// takeAffine takes the idle entry last acquired using key
+func (p *Pool[T]) takeAffine(key any) (*T, bool) {
+	if p.gate.Load() != nil || p.closed.Load() {
+		return nil, false
+	}
+	e, ok := p.affinity.Load(key)
+	if !ok {
+		return nil, false
+	}
+	v := e.(*T)
+	if !p.idle.remove(v) || !p.checkout(v) {
+		return nil, false
+	}
+	p.stats.affinityHits.Add(1)
+	p.observeWait(time.Time{})
+	return v, true
+}
+
+// bind makes v the preferred entry of key
+func (p *Pool[T]) bind(v *T, key any) {
+	if old := p.meta(v).affinity.Swap(&key); old != nil && *old != key {
+		p.affinity.CompareAndDelete(*old, v)
+	}
+	p.affinity.Store(key, v)
+}
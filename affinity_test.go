@@ -0,0 +1,35 @@
+package pool
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAffinity(t *testing.T) {
+	pool := NewPool(3, poolFactory)
+	defer pool.Close()
+	ctx := context.Background()
+
+	a, _ := pool.AcquireWithAffinity(ctx, 1)
+	b, _ := pool.AcquireWithAffinity(ctx, 2)
+	pool.Release(a)
+	pool.Release(b)
+	// FIFO order would hand out the third entry next
+	if v, _ := pool.AcquireWithAffinity(ctx, 2); v != b {
+		t.Errorf("expected entry of worker 2 but got %p", v)
+	} else {
+		pool.Release(v)
+	}
+	if v, _ := pool.AcquireWithAffinity(ctx, 1); v != a {
+		t.Errorf("expected entry of worker 1 but got %p", v)
+	} else {
+		pool.Replace(v)
+	}
+	// the destroyed entry isn't preferred anymore
+	if v, _ := pool.AcquireWithAffinity(ctx, 1); v == a {
+		t.Errorf("expected destroyed entry to be forgotten")
+	}
+	if stats := pool.Stats(); stats.AffinityHits != 2 {
+		t.Errorf("expected 2 affinity hits but got %+v", stats)
+	}
+}
@@ -0,0 +1,132 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ErrUnknownType is returned by AnyPool for values of types that weren't registered
+var ErrUnknownType = fmt.Errorf("type isn't registered with the pool")
+
+// AnyPool pools values of different types, e.g. mixed plugin objects or values of code migrating from
+// interface{} based pools. Values are kept in a pool per type (see KeyedPool), so the options, quotas and
+// stats of the generic pool apply to every type. The types of values are checked at runtime: values
+// must be of a registered type and values returned by factories must be of the registered type.
+// Registered types must be pointer types, acquired values are told apart by their address.
+type AnyPool struct {
+	keyed *KeyedPool[reflect.Type, any]
+
+	mux       sync.RWMutex
+	factories map[reflect.Type]func() any
+	// acquired values -> their entries
+	acquired sync.Map
+}
+
+// NewAnyPool creates a pool of values of different types, o configures the pools of the types
+// (keyed by the registered types)
+func NewAnyPool(o KeyedOptions[reflect.Type]) (*AnyPool, error) {
+	p := &AnyPool{factories: map[reflect.Type]func() any{}}
+	keyed, err := NewKeyedPool(p.create, o)
+	if err != nil {
+		return nil, err
+	}
+	p.keyed = keyed
+	return p, nil
+}
+
+// create calls the factory of typ, panics if it returns a value of another type
+func (p *AnyPool) create(typ reflect.Type) *any {
+	p.mux.RLock()
+	factory := p.factories[typ]
+	p.mux.RUnlock()
+	v := factory()
+	if t := reflect.TypeOf(v); t != typ {
+		panic(fmt.Sprintf("pool: factory of %v returned a value of type %v", typ, t))
+	}
+	return &v
+}
+
+// Register registers the type of sample, values of the type are created by factory. The type must be a pointer
+// type: released values are matched to their leases by identity, equal values (e.g. two ints) can't be told apart.
+func (p *AnyPool) Register(sample any, factory func() any) error {
+	return p.register(reflect.TypeOf(sample), factory)
+}
+
+// RegisterType registers the type T with p, values of T are created by factory. T must be a pointer type
+// (see Register).
+func RegisterType[T any](p *AnyPool, factory func() T) error {
+	if factory == nil {
+		return ErrMissingFactoryFunction
+	}
+	return p.register(reflect.TypeFor[T](), func() any { return factory() })
+}
+
+// register implements Register
+func (p *AnyPool) register(typ reflect.Type, factory func() any) error {
+	if typ == nil || typ.Kind() != reflect.Pointer {
+		return fmt.Errorf("values of type %v can't be pooled, they must be pointers", typ)
+	}
+	if factory == nil {
+		return ErrMissingFactoryFunction
+	}
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.factories[typ] = factory
+	return nil
+}
+
+// Acquire acquires a value of typ waiting until ctx is done (see KeyedPool.Acquire)
+func (p *AnyPool) Acquire(ctx context.Context, typ reflect.Type) (any, error) {
+	p.mux.RLock()
+	_, ok := p.factories[typ]
+	p.mux.RUnlock()
+	if !ok {
+		return nil, &PoolError{Op: opAcquire, Err: fmt.Errorf("%w: %v", ErrUnknownType, typ)}
+	}
+	e, err := p.keyed.Acquire(ctx, typ)
+	if err != nil {
+		return nil, err
+	}
+	p.acquired.Store(*e, e)
+	return *e, nil
+}
+
+// AcquireAs acquires a value of type T from p
+func AcquireAs[T any](ctx context.Context, p *AnyPool) (T, error) {
+	v, err := p.Acquire(ctx, reflect.TypeFor[T]())
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return v.(T), nil
+}
+
+// Release releases the acquired value v to the pool of its type
+func (p *AnyPool) Release(v any) error {
+	typ := reflect.TypeOf(v)
+	if typ == nil || typ.Kind() != reflect.Pointer {
+		return &PoolError{Op: opRelease, Err: fmt.Errorf("%w: %v", ErrUnknownType, typ)}
+	}
+	e, ok := p.acquired.LoadAndDelete(v)
+	if !ok {
+		return &PoolError{Op: opRelease, Err: ErrNotAcquired}
+	}
+	return p.keyed.Release(typ, e.(*any))
+}
+
+// Pool returns the pool of the values of typ, false if none was acquired yet
+func (p *AnyPool) Pool(typ reflect.Type) (*Pool[any], bool) {
+	return p.keyed.Pool(typ)
+}
+
+// Stats returns the stats of the pools of all types
+func (p *AnyPool) Stats() KeyedStats[reflect.Type] {
+	return p.keyed.Stats()
+}
+
+// Close closes the pools of all types
+func (p *AnyPool) Close() error {
+	return p.keyed.Close()
+}
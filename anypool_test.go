@@ -0,0 +1,67 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type pluginA struct{ name string }
+type pluginB struct{ id int }
+
+func TestAnyPool(t *testing.T) {
+	pool, err := NewAnyPool(KeyedOptions[reflect.Type]{Quota: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer pool.Close()
+	if err := RegisterType(pool, func() *pluginA { return &pluginA{name: "a"} }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := pool.Register(&pluginB{}, func() any { return &pluginB{id: 1} }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := pool.Register([]int{}, func() any { return []int{} }); err == nil {
+		t.Errorf("expected an error for a type that isn't comparable")
+	}
+	// equal values couldn't be told apart when they are released
+	if err := RegisterType(pool, func() int { return 1 }); err == nil {
+		t.Errorf("expected an error for a type that isn't a pointer")
+	}
+	if err := RegisterType(pool, func() any { return 1 }); err == nil {
+		t.Errorf("expected an error for an interface type")
+	}
+
+	ctx := context.Background()
+	a, err := AcquireAs[*pluginA](ctx, pool)
+	if err != nil || a.name != "a" {
+		t.Fatalf("expected a value of type *pluginA but got %v, %v", a, err)
+	}
+	b, err := pool.Acquire(ctx, reflect.TypeFor[*pluginB]())
+	if err != nil || b.(*pluginB).id != 1 {
+		t.Fatalf("expected a value of type *pluginB but got %v, %v", b, err)
+	}
+	if _, err := AcquireAs[string](ctx, pool); !errors.Is(err, ErrUnknownType) {
+		t.Errorf("expected %v but got %v", ErrUnknownType, err)
+	}
+	if err := pool.Release(a); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := pool.Release(a); !errors.Is(err, ErrNotAcquired) {
+		t.Errorf("expected %v but got %v", ErrNotAcquired, err)
+	}
+	if err := pool.Release(&pluginB{}); !errors.Is(err, ErrNotAcquired) {
+		t.Errorf("expected %v but got %v", ErrNotAcquired, err)
+	}
+	pool.Release(b)
+
+	// the value is reused
+	if again, _ := AcquireAs[*pluginA](ctx, pool); again != a {
+		t.Errorf("expected the released value")
+	}
+	stats := pool.Stats()
+	if len(stats.Keys) != 2 || stats.Keys[reflect.TypeFor[*pluginA]()].InUse != 1 || stats.InUse != 1 {
+		t.Errorf("expected stats per type but got %+v", stats)
+	}
+}
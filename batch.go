@@ -0,0 +1,110 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"iter"
+)
+
+// ReleaseResult reports what ReleaseAll did with the entries, entries counted by neither field failed to be
+// released (see the returned error)
+type ReleaseResult struct {
+	// entries put back into the pool (or handed to waiting acquires)
+	Released int
+	// entries taken out of the pool instead, e.g. expired, stale, failing their reset or beyond the size of the pool
+	Destroyed int
+}
+
+// ReleaseAll releases the entries of vs like Release, e.g. at the end of a batch job, putting the entries that are
+// kept back into the pool at once instead of locking the idle entries for every entry. err joins the errors of the
+// rejected releases, including ErrFailedToRelease for entries released twice or the pool has no room for.
+// Releases aren't deferred to the workers of WithAsyncRelease.
+func (p *Pool[T]) ReleaseAll(vs []*T) (ReleaseResult, error) {
+	var res ReleaseResult
+	var errs []error
+	kept := make([]*T, 0, len(vs))
+	for _, v := range vs {
+		orig := v
+		v, err := p.resolveNil(v)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !p.endHold(v) {
+			res.Destroyed++
+			continue
+		}
+		if err := p.checkRelease(orig); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		p.onRelease()
+		if p.checkin(v) && !p.discardExcess(v) {
+			kept = append(kept, v)
+		} else {
+			res.Destroyed++
+		}
+	}
+	res.Released = p.putAll(kept)
+	for range kept[res.Released:] {
+		p.unrelease()
+		errs = append(errs, ErrFailedToRelease)
+	}
+	return res, p.wrapErr(opRelease, errors.Join(errs...))
+}
+
+// putAll adds the entries of vs to the idle entries like put, the pool must already have checked them for
+// excess (see discardExcess). The entries added are moved to the front of vs, n is their number, the caller
+// keeps the others.
+func (p *Pool[T]) putAll(vs []*T) (n int) {
+	if len(vs) == 0 {
+		return 0
+	}
+	n = p.idle.putAll(vs)
+	p.afterPut()
+	p.updateState()
+	return n
+}
+
+// AcquireN returns an iterator acquiring n entries, yielding them as they become available. Entries available
+// right away are acquired as a batch, the iterator waits until ctx is done for the others. Yielded entries belong
+// to the loop body which must release them, entries acquired but not yielded yet get released if the loop is left
+// early. An error is yielded once (with a nil entry) and ends the iteration.
+func (p *Pool[T]) AcquireN(ctx context.Context, n int) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		var batch []*T
+		defer func() {
+			for _, v := range batch {
+				p.Release(v)
+			}
+		}()
+		for n > 0 {
+			if len(batch) == 0 {
+				for len(batch) < n {
+					v, ok := p.tryAcquire(ctx)
+					if !ok {
+						break
+					}
+					batch = append(batch, v)
+				}
+			}
+			if len(batch) == 0 {
+				v, err := p.acquire(ctx, nil, false)
+				if err != nil {
+					yield(nil, p.wrapErr(opAcquire, err))
+					return
+				}
+				batch = append(batch, v)
+			}
+			v := batch[0]
+			batch = batch[1:]
+			n--
+			if !yield(v, nil) {
+				return
+			}
+		}
+	}
+}
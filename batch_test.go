@@ -0,0 +1,127 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAcquireN(t *testing.T) {
+	pool := NewPool(3, poolFactory)
+	defer pool.Close()
+	ctx := context.Background()
+
+	var got []*poolItem
+	for v, err := range pool.AcquireN(ctx, 2) {
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		got = append(got, v)
+	}
+	if len(got) != 2 || pool.InUse() != 2 {
+		t.Errorf("expected 2 entries in use but got %d", pool.InUse())
+	}
+
+	// the remaining entry is acquired with the batch and released once the loop is left
+	for v := range pool.AcquireN(ctx, 1) {
+		got = append(got, v)
+		break
+	}
+	if pool.InUse() != 3 {
+		t.Errorf("expected 3 entries in use but got %d", pool.InUse())
+	}
+
+	// entries released while iterating are yielded
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		pool.Release(got[0])
+	}()
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	var errs []error
+	var acquired []*poolItem
+	for v, err := range pool.AcquireN(timeout, 2) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		acquired = append(acquired, v)
+	}
+	if n := len(acquired); n != 1 || len(errs) != 1 || !errors.Is(errs[0], context.DeadlineExceeded) {
+		t.Errorf("expected 1 entry and a timeout but got %d, %v", n, errs)
+	}
+}
+
+func TestAcquireNReleasesBatch(t *testing.T) {
+	pool := NewPool(3, poolFactory)
+	defer pool.Close()
+	for range pool.AcquireN(context.Background(), 3) {
+		break
+	}
+	if pool.InUse() != 1 || pool.Len() != 2 {
+		t.Errorf("expected the entries not yielded to be released but got %d in use", pool.InUse())
+	}
+}
+
+func TestReleaseAll(t *testing.T) {
+	var broken *poolItem
+	pool := NewPool(3, poolFactory, WithShouldRetain(func(v *poolItem) bool { return v != broken }))
+	defer pool.Close()
+	a, b, c := pool.Acquire(), pool.Acquire(), pool.Acquire()
+	broken = c
+
+	// a waiting acquire gets one of the entries
+	got := make(chan *poolItem)
+	go func() {
+		v, _ := pool.AcquireWithTimeout(time.Second)
+		got <- v
+	}()
+	for pool.WaiterCount() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	res, err := pool.ReleaseAll([]*poolItem{a, b, c, nil})
+	if res.Released != 2 || res.Destroyed != 1 {
+		t.Errorf("expected 2 released and 1 destroyed entry but got %+v", res)
+	}
+	if !errors.Is(err, ErrNilEntry) {
+		t.Errorf("expected ErrNilEntry but got %v", err)
+	}
+	if v := <-got; v == nil || v == c {
+		t.Errorf("expected the waiting acquire to get a released or new entry")
+	}
+	stats := pool.Stats()
+	if stats.Rejected != 1 || stats.InUse != 1 || stats.Idle != 2 {
+		t.Errorf("expected 1 rejected entry, 1 in use and 2 idle but got %+v", stats)
+	}
+	if res, err := pool.ReleaseAll(nil); res != (ReleaseResult{}) || err != nil {
+		t.Errorf("expected nothing to be released but got %+v, %v", res, err)
+	}
+}
+
+func TestReleaseAllRejected(t *testing.T) {
+	destroyed := 0
+	pool := NewPool(2, poolFactory, WithDestroyer(func(*poolItem) { destroyed++ }))
+	defer pool.Close()
+	a := pool.Acquire()
+	pool.Release(a)
+
+	// a is idle already and the pool has no room for a foreign entry
+	res, err := pool.ReleaseAll([]*poolItem{a, poolFactory()})
+	if res != (ReleaseResult{}) {
+		t.Errorf("expected nothing to be released but got %+v", res)
+	}
+	if !errors.Is(err, ErrFailedToRelease) {
+		t.Errorf("expected %v but got %v", ErrFailedToRelease, err)
+	}
+	if destroyed != 0 {
+		t.Errorf("expected no destroyed entries but got %d", destroyed)
+	}
+	stats := pool.Stats()
+	if stats.Idle != 2 || stats.Created != 2 || stats.Released != 1 {
+		t.Errorf("expected 2 idle entries, 2 created and 1 released but got %+v", stats)
+	}
+	if a, b := pool.Acquire(), pool.Acquire(); a == b {
+		t.Errorf("expected distinct entries but got %p twice", a)
+	}
+}
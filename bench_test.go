@@ -0,0 +1,269 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func benchFactory() *poolItem {
+	return new(poolItem)
+}
+
+func BenchmarkAcquireRelease(b *testing.B) {
+	pool := NewPool(8, benchFactory)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pool.Release(pool.Acquire())
+	}
+}
+
+// BenchmarkAcquireReleaseTracked measures the cost of recording the use of entries (see WithUsageTracking)
+func BenchmarkAcquireReleaseTracked(b *testing.B) {
+	pool := NewPool(8, benchFactory, WithUsageTracking())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pool.Release(pool.Acquire())
+	}
+}
+
+func BenchmarkAcquireWithTimeout(b *testing.B) {
+	pool := NewPool(8, benchFactory)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e, err := pool.AcquireWithTimeout(time.Second)
+		if err != nil {
+			b.Fatal(err)
+		}
+		pool.Release(e)
+	}
+}
+
+func BenchmarkAcquireWithContext(b *testing.B) {
+	pool := NewPool(8, benchFactory)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e, err := pool.AcquireWithContext(ctx)
+		if err != nil {
+			b.Fatal(err)
+		}
+		pool.Release(e)
+	}
+}
+
+func BenchmarkAcquireReleaseParallel(b *testing.B) {
+	pool := NewPool(64, benchFactory)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			pool.Release(pool.Acquire())
+		}
+	})
+}
+
+func BenchmarkAcquireWithTimeoutParallel(b *testing.B) {
+	pool := NewPool(64, benchFactory)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			e, err := pool.AcquireWithTimeout(time.Second)
+			if err != nil {
+				b.Fatal(err)
+			}
+			pool.Release(e)
+		}
+	})
+}
+
+func BenchmarkAcquireWithTimeoutExpired(b *testing.B) {
+	pool := NewPool(0, benchFactory)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := pool.AcquireWithTimeout(time.Nanosecond); err == nil {
+			b.Fatal("expected timeout")
+		}
+	}
+}
+
+// unpaddedCounters has the layout counters had before padding, for comparison
+type unpaddedCounters struct {
+	acquired atomic.Uint64
+	released atomic.Uint64
+	inUse    atomic.Int64
+}
+
+// BenchmarkCounters updates the acquire and release counters from goroutines acquiring and
+// releasing concurrently, with and without padding between the counters
+func BenchmarkCounters(b *testing.B) {
+	b.Run("padded", func(b *testing.B) {
+		var c counters
+		var workers atomic.Int64
+		b.RunParallel(func(pb *testing.PB) {
+			acquirer := workers.Add(1)%2 == 0
+			for pb.Next() {
+				if acquirer {
+					c.acquired.Add(1)
+				} else {
+					c.released.Add(1)
+				}
+			}
+		})
+	})
+	b.Run("unpadded", func(b *testing.B) {
+		var c unpaddedCounters
+		var workers atomic.Int64
+		b.RunParallel(func(pb *testing.PB) {
+			acquirer := workers.Add(1)%2 == 0
+			for pb.Next() {
+				if acquirer {
+					c.acquired.Add(1)
+				} else {
+					c.released.Add(1)
+				}
+			}
+		})
+	})
+}
+
+// BenchmarkAcquireReleaseScaling acquires and releases from 1 to 64 goroutines, each holding at most one
+// of the 64 entries. The idle entries aren't sharded, so ns/op is expected to grow with the contention on
+// their lock on machines with several cores; compare runs using benchstat to catch contention regressions.
+func BenchmarkAcquireReleaseScaling(b *testing.B) {
+	for _, n := range []int{1, 2, 4, 8, 16, 32, 64} {
+		b.Run(fmt.Sprintf("goroutines=%d", n), func(b *testing.B) {
+			pool := NewPool(64, benchFactory)
+			defer pool.Close()
+			b.ReportAllocs()
+			b.ResetTimer()
+			var wg sync.WaitGroup
+			for g := range n {
+				// spread b.N over the goroutines
+				ops := b.N / n
+				if g < b.N%n {
+					ops++
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for range ops {
+						pool.Release(pool.Acquire())
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	}
+}
+
+// BenchmarkEntryMeta updates the metadata of different entries from concurrent goroutines like acquires
+// do, with the metadata padded to cache lines and packed next to each other
+func BenchmarkEntryMeta(b *testing.B) {
+	touch := func(m *entryMeta) {
+		m.uses.Add(1)
+		m.lastUsed.Store(time.Now().UnixNano())
+	}
+	b.Run("padded", func(b *testing.B) {
+		metas := make([]paddedMeta, 64)
+		var workers atomic.Int64
+		b.RunParallel(func(pb *testing.PB) {
+			m := &metas[workers.Add(1)%64].entryMeta
+			for pb.Next() {
+				touch(m)
+			}
+		})
+	})
+	b.Run("packed", func(b *testing.B) {
+		metas := make([]entryMeta, 64)
+		var workers atomic.Int64
+		b.RunParallel(func(pb *testing.PB) {
+			m := &metas[workers.Add(1)%64]
+			for pb.Next() {
+				touch(m)
+			}
+		})
+	})
+}
+
+func BenchmarkStats(b *testing.B) {
+	pool := NewPool(8, benchFactory)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pool.Stats()
+	}
+}
+
+// BenchmarkAcquireReleasePolled acquires and releases concurrently while another goroutine
+// polls the size of the pool like a health endpoint would
+func BenchmarkAcquireReleasePolled(b *testing.B) {
+	pool := NewPool(8, benchFactory)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				_ = pool.Len() + pool.InUse() + pool.Cap()
+			}
+		}
+	}()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			pool.Release(pool.Acquire())
+		}
+	})
+}
+
+// BenchmarkPerPCache compares the acquire fast path against a per-P cache in front of the pool, prototyped
+// using sync.Pool (which keeps a private slot per P), and sync.Pool alone as the lower bound. The prototype
+// only shows what a per-P cache could gain, it's not a usable design: entries cached by a P are neither idle
+// nor in use for the pool, so they are invisible to Stats, Resize and the reaper, other Ps wait for them
+// although they aren't used, and sync.Pool drops cached entries on GC, leaking their slots (see README).
+func BenchmarkPerPCache(b *testing.B) {
+	b.Run("pool", func(b *testing.B) {
+		pool := NewPool(64, benchFactory)
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				pool.Release(pool.Acquire())
+			}
+		})
+	})
+	b.Run("cached", func(b *testing.B) {
+		pool := NewPool(64, benchFactory)
+		var cache sync.Pool
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				v, ok := cache.Get().(*poolItem)
+				if !ok {
+					v = pool.Acquire()
+				}
+				cache.Put(v)
+			}
+		})
+	})
+	b.Run("sync.Pool", func(b *testing.B) {
+		cache := sync.Pool{New: func() any { return benchFactory() }}
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				cache.Put(cache.Get())
+			}
+		})
+	})
+}
@@ -0,0 +1,111 @@
+package pool
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// budgetBuckets is the number of buckets the window of an ErrorBudget is split into
+const budgetBuckets = 10
+
+// ErrorBudget configures WithErrorBudget
+type ErrorBudget struct {
+	// fraction of failed validations and health checks (0-1) within the window that exhausts the budget
+	Threshold float64
+	// time span of the sliding window
+	Window time.Duration
+	// minimum number of checks within the window before the budget applies (1 if 0)
+	MinChecks int
+	// OnExhausted is called (in its own goroutine) once the budget is exhausted, before the refresh (optional)
+	OnExhausted func(BudgetExhausted)
+}
+
+// BudgetExhausted describes an exhausted error budget
+type BudgetExhausted struct {
+	// name of the pool
+	Pool string
+	// number of checks and failures within the window
+	Checks   int
+	Failures int
+	Stats    Stats
+}
+
+// WithErrorBudget refreshes all entries (see RefreshAll) once the fraction of failed validations and health
+// checks within a sliding window exceeds the threshold of b, e.g. when a shared backend restarted and every
+// pooled connection is stale. The window starts over after every refresh.
+func WithErrorBudget(b ErrorBudget) Option {
+	return func(o *options) {
+		o.errorBudget = &b
+	}
+}
+
+// errorWindow counts checks and failures in buckets covering the window of an ErrorBudget
+type errorWindow struct {
+	ErrorBudget
+	mux     sync.Mutex
+	buckets [budgetBuckets]errorBucket
+	// set while the refresh triggered by the budget is running
+	refreshing bool
+}
+
+type errorBucket struct {
+	// index of the bucket since the unix epoch
+	slot             int64
+	checks, failures int
+}
+
+// add records a check and reports whether it exhausted the budget, along with the counts of the window
+func (w *errorWindow) add(now time.Time, failed bool) (checks, failures int, exhausted bool) {
+	width := max(int64(w.Window)/budgetBuckets, 1)
+	slot := now.UnixNano() / width
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	b := &w.buckets[slot%budgetBuckets]
+	if b.slot != slot {
+		*b = errorBucket{slot: slot}
+	}
+	b.checks++
+	if failed {
+		b.failures++
+	}
+	for _, b := range w.buckets {
+		if slot-b.slot < budgetBuckets {
+			checks += b.checks
+			failures += b.failures
+		}
+	}
+	if w.refreshing || checks < max(w.MinChecks, 1) || float64(failures) <= w.Threshold*float64(checks) {
+		return checks, failures, false
+	}
+	w.refreshing = true
+	return checks, failures, true
+}
+
+// reset starts the window over once the refresh finished
+func (w *errorWindow) reset() {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	w.buckets = [budgetBuckets]errorBucket{}
+	w.refreshing = false
+}
+
+// observeCheck records the result of a validation or health check against the error budget
+func (p *Pool[T]) observeCheck(failed bool) {
+	w := p.errWindow
+	if w == nil {
+		return
+	}
+	checks, failures, exhausted := w.add(time.Now(), failed)
+	if !exhausted {
+		return
+	}
+	p.stats.budgetRefreshes.Add(1)
+	go func() {
+		defer w.reset()
+		if w.OnExhausted != nil {
+			w.OnExhausted(BudgetExhausted{Pool: p.opts.name, Checks: checks, Failures: failures, Stats: p.Stats()})
+		}
+		p.RefreshAll(context.Background())
+	}()
+}
@@ -0,0 +1,50 @@
+package pool
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type epochItem struct {
+	epoch int64
+}
+
+func TestErrorBudget(t *testing.T) {
+	var epoch atomic.Int64
+	exhausted := make(chan BudgetExhausted, 1)
+	pool := NewPool(4, func() *epochItem { return &epochItem{epoch: epoch.Load()} },
+		WithValidator(func(e *epochItem) bool { return e.epoch == epoch.Load() }),
+		WithErrorBudget(ErrorBudget{Threshold: 0.5, Window: time.Minute, MinChecks: 2, OnExhausted: func(e BudgetExhausted) {
+			exhausted <- e
+		}}),
+	)
+	defer pool.Close()
+
+	// the backend restarted
+	epoch.Add(1)
+	pool.Release(pool.Acquire())
+	select {
+	case e := <-exhausted:
+		if e.Checks != 2 || e.Failures != 2 {
+			t.Errorf("unexpected exhausted budget: %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected the error budget to be exhausted")
+	}
+	refreshed := func() bool {
+		for _, info := range pool.IdleEntries() {
+			if info.Generation != 1 {
+				return false
+			}
+		}
+		return pool.Stats().Generation == 1
+	}
+	deadline := time.Now().Add(time.Second)
+	for !refreshed() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if stats := pool.Stats(); !refreshed() || stats.BudgetRefreshes != 1 {
+		t.Errorf("expected all idle entries to be refreshed but got %+v", stats)
+	}
+}
@@ -0,0 +1,197 @@
+// Package bufpool pools byte buffers (*bytes.Buffer or *[]byte) of variable size
+//
+// Buffers are bucketed into size classes (4K, 64K and 1M by default), each class being a pool
+// of up to a given number of idle buffers. Get returns a buffer of the smallest class fitting
+// the requested size, Put returns a buffer to the class matching its capacity, buffers that grew
+// move to a larger class without allocating. Put trims the retained memory: buffers with more than
+// twice the capacity of their class are dropped, so single large requests don't stay retained and
+// a class retains at most perClass buffers of twice its size. Buffers are owned by the caller until
+// they are put back, dropping a buffer instead doesn't leak a slot of its class.
+package bufpool
+
+import (
+	"bytes"
+	"sync/atomic"
+
+	"github.com/epikur-io/go-pool"
+)
+
+// DefaultSizes are the default size classes
+var DefaultSizes = []int{4 << 10, 64 << 10, 1 << 20}
+
+// Buffer is the type of buffers a pool holds
+type Buffer interface {
+	bytes.Buffer | []byte
+}
+
+// Pool is a pool of byte buffers bucketed by capacity
+type Pool[T Buffer] struct {
+	classes []*class[T]
+	dropped atomic.Uint64
+	trimmed atomic.Uint64
+}
+
+// class is a pool of buffers with capacities between size and the size of the next class
+type class[T Buffer] struct {
+	size int
+	pool *pool.Pool[T]
+	// bytes held by idle buffers
+	retained atomic.Int64
+	gets     atomic.Uint64
+	misses   atomic.Uint64
+}
+
+// Stats of a buffer pool
+type Stats struct {
+	Classes []ClassStats `json:"classes"`
+	// bytes held by idle buffers of all classes
+	Retained int64 `json:"retained"`
+	// buffers not retained because they were too small, too large or their class was full
+	Dropped uint64 `json:"dropped"`
+	// dropped buffers that exceeded twice the size of their class
+	Trimmed uint64 `json:"trimmed"`
+}
+
+// ClassStats are the stats of a size class
+type ClassStats struct {
+	Size     int    `json:"size"`
+	Idle     int    `json:"idle"`
+	Retained int64  `json:"retained"`
+	Gets     uint64 `json:"gets"`
+	// gets that allocated a new buffer
+	Misses uint64 `json:"misses"`
+}
+
+// Creates a buffer pool retaining up to perClass idle buffers per size class,
+// sizes are the ascending capacities of the classes (DefaultSizes if empty)
+func New[T Buffer](perClass int, sizes ...int) *Pool[T] {
+	if len(sizes) == 0 {
+		sizes = DefaultSizes
+	}
+	p := &Pool[T]{}
+	for _, size := range sizes {
+		size := size
+		// buffers are only created on get, the pools store idle buffers
+		lp := pool.NewPool(perClass, func() *T { return newBuffer[T](size) }, pool.WithMinSize(0))
+		p.classes = append(p.classes, &class[T]{size: size, pool: lp})
+	}
+	return p
+}
+
+// Get returns an empty buffer with a capacity of at least n bytes
+func (p *Pool[T]) Get(n int) *T {
+	c := p.classFor(n, true)
+	if c == nil {
+		// too large to be pooled
+		return newBuffer[T](n)
+	}
+	c.gets.Add(1)
+	if b, ok := c.pool.TryTakeIdle(); ok {
+		// the caller owns the buffer until it's put back, so dropping it doesn't leak a slot of the class
+		c.pool.Hijack(b)
+		c.retained.Add(-int64(capacity(b)))
+		return b
+	}
+	c.misses.Add(1)
+	return newBuffer[T](c.size)
+}
+
+// Put returns b to the pool, b must not be used afterwards
+func (p *Pool[T]) Put(b *T) {
+	if b == nil {
+		return
+	}
+	reset(b)
+	n := capacity(b)
+	c := p.classFor(n, false)
+	if c == nil {
+		p.dropped.Add(1)
+		return
+	}
+	if n > 2*c.size {
+		// trim: the buffer grew between two classes, retaining it would hold more than twice the
+		// memory its class hands out
+		p.trimmed.Add(1)
+		p.dropped.Add(1)
+		return
+	}
+	c.retained.Add(int64(n))
+	if c.pool.TryPutIdle(b) != nil {
+		c.retained.Add(-int64(n))
+		p.dropped.Add(1)
+	}
+}
+
+// classFor returns the class for buffers of n bytes, rounding up for gets and down for puts
+func (p *Pool[T]) classFor(n int, up bool) *class[T] {
+	if up {
+		for _, c := range p.classes {
+			if n <= c.size {
+				return c
+			}
+		}
+		return nil
+	}
+	for i := len(p.classes) - 1; i >= 0; i-- {
+		if c := p.classes[i]; n >= c.size {
+			return c
+		}
+	}
+	return nil
+}
+
+// Returns the stats of the pool
+func (p *Pool[T]) Stats() Stats {
+	s := Stats{Dropped: p.dropped.Load(), Trimmed: p.trimmed.Load()}
+	for _, c := range p.classes {
+		cs := ClassStats{
+			Size:     c.size,
+			Idle:     c.pool.Len(),
+			Retained: c.retained.Load(),
+			Gets:     c.gets.Load(),
+			Misses:   c.misses.Load(),
+		}
+		s.Retained += cs.Retained
+		s.Classes = append(s.Classes, cs)
+	}
+	return s
+}
+
+// Close closes the pool, idle buffers are released to the garbage collector
+func (p *Pool[T]) Close() error {
+	for _, c := range p.classes {
+		c.pool.Close()
+		c.retained.Store(0)
+	}
+	return nil
+}
+
+func newBuffer[T Buffer](size int) *T {
+	var b T
+	switch b := any(&b).(type) {
+	case *bytes.Buffer:
+		b.Grow(size)
+	case *[]byte:
+		*b = make([]byte, 0, size)
+	}
+	return &b
+}
+
+func capacity[T Buffer](b *T) int {
+	switch b := any(b).(type) {
+	case *bytes.Buffer:
+		return b.Cap()
+	case *[]byte:
+		return cap(*b)
+	}
+	return 0
+}
+
+func reset[T Buffer](b *T) {
+	switch b := any(b).(type) {
+	case *bytes.Buffer:
+		b.Reset()
+	case *[]byte:
+		*b = (*b)[:0]
+	}
+}
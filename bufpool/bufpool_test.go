@@ -0,0 +1,108 @@
+package bufpool
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGetPut(t *testing.T) {
+	p := New[bytes.Buffer](2)
+	b := p.Get(100)
+	if b.Cap() < 4<<10 {
+		t.Errorf("expected a buffer of the 4K class but got capacity %d", b.Cap())
+	}
+	b.WriteString("hello")
+	p.Put(b)
+
+	if b2 := p.Get(10); b2 != b || b2.Len() != 0 {
+		t.Errorf("expected the reset buffer to be reused")
+	}
+	stats := p.Stats()
+	if stats.Classes[0].Gets != 2 || stats.Classes[0].Misses != 1 || stats.Retained != 0 {
+		t.Errorf("expected 2 gets, 1 miss and no retained bytes but got %+v", stats)
+	}
+}
+
+func TestSizeClasses(t *testing.T) {
+	p := New[[]byte](2, 1024, 4096)
+	small := p.Get(1)
+	large := p.Get(2000)
+	if cap(*small) != 1024 || cap(*large) != 4096 {
+		t.Errorf("expected capacities 1024 and 4096 but got %d and %d", cap(*small), cap(*large))
+	}
+
+	// take it from the pool, then grow it into the next class
+	p.Put(small)
+	small = p.Get(1)
+	*small = append(*small, make([]byte, 4000)...)
+	p.Put(small)
+	p.Put(large)
+	stats := p.Stats()
+	if stats.Classes[1].Idle != 2 || stats.Classes[1].Retained != int64(cap(*small)+4096) {
+		t.Errorf("expected both buffers in the 4096 class but got %+v", stats.Classes[1])
+	}
+	// the 1024 class doesn't allocate a new buffer for the one that moved, only once one is needed
+	if stats.Classes[0].Idle != 0 || stats.Classes[0].Retained != 0 {
+		t.Errorf("expected no buffer in the 1024 class but got %+v", stats.Classes[0])
+	}
+	small = p.Get(1)
+	if stats := p.Stats(); cap(*small) != 1024 || stats.Classes[0].Misses != 2 {
+		t.Errorf("expected a new buffer of the 1024 class but got %+v", stats.Classes[0])
+	}
+
+	// buffers growing beyond the largest class are dropped without a replacement
+	*small = append(*small, make([]byte, 10000)...)
+	p.Put(small)
+	huge := p.Get(10000)
+	p.Put(huge)
+	if stats := p.Stats(); stats.Dropped != 2 || stats.Classes[0].Idle != 0 {
+		t.Errorf("expected the huge buffers to be dropped but got %+v", stats)
+	}
+}
+
+func TestClassFull(t *testing.T) {
+	p := New[bytes.Buffer](1)
+	a, b := p.Get(1), p.Get(1)
+	p.Put(a)
+	p.Put(b)
+	if stats := p.Stats(); stats.Classes[0].Idle != 1 || stats.Dropped != 1 {
+		t.Errorf("expected 1 idle and 1 dropped buffer but got %+v", stats)
+	}
+}
+
+func TestTrim(t *testing.T) {
+	p := New[[]byte](4, 1024, 16384)
+	// a buffer of the 1024 class that grew within twice its size stays in its class
+	b := p.Get(1)
+	*b = append(*b, make([]byte, 1500)...)
+	p.Put(b)
+	// a buffer grown between the classes is trimmed instead of retaining 8K in the 1024 class
+	b = p.Get(1)
+	*b = append(*b, make([]byte, 8000)...)
+	p.Put(b)
+	stats := p.Stats()
+	if stats.Trimmed != 1 || stats.Classes[0].Idle != 0 {
+		t.Errorf("expected the grown buffer to be trimmed but got %+v", stats)
+	}
+	if stats.Retained != 0 {
+		t.Errorf("expected no retained bytes but got %d", stats.Retained)
+	}
+
+	// the slots of dropped buffers are reused
+	for range 4 {
+		p.Get(1)
+	}
+	for range 4 {
+		p.Put(p.Get(1))
+	}
+	bufs := make([]*[]byte, 4)
+	for i := range bufs {
+		bufs[i] = p.Get(1)
+	}
+	for _, b := range bufs {
+		p.Put(b)
+	}
+	if stats := p.Stats(); stats.Classes[0].Idle != 4 || stats.Retained != 4*1024 {
+		t.Errorf("expected 4 idle buffers retaining 4096 bytes but got %+v", stats)
+	}
+}
@@ -0,0 +1,107 @@
+//go:build chaos
+
+package pool
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+var ErrChaos = fmt.Errorf("failure injected by chaos mode")
+
+// Chaos configures the faults injected by WithChaos, rates are probabilities between 0 and 1
+type Chaos struct {
+	// rate of acquires that get delayed by a random duration up to MaxDelay
+	DelayRate float64
+	MaxDelay  time.Duration
+	// rate of entry creations (on acquire) that fail with ErrChaos
+	FactoryFailureRate float64
+	// rate of idle entries that are treated as invalid on acquire, they get destroyed and replaced
+	InvalidationRate float64
+	// seed of the random number generator, 0 uses the generator of the pool (see WithSeed)
+	Seed int64
+}
+
+// WithChaos injects faults into Acquire, AcquireWithTimeout, AcquireWithContext, Reserve and Run to test
+// how callers handle a misbehaving pool. Only available in builds using the chaos tag (go test -tags chaos).
+// Acquire returns nil for injected factory failures.
+func WithChaos(c Chaos) Option {
+	return func(o *options) {
+		o.chaos = &chaosState{Chaos: c}
+		if c.Seed != 0 {
+			o.chaos.rnd = newPRNG(c.Seed)
+		}
+	}
+}
+
+type chaosState struct {
+	Chaos
+	rnd *prng
+}
+
+// initChaos makes the injected faults use the generator of the pool unless the chaos config has a seed
+func (p *Pool[T]) initChaos() {
+	if c := p.opts.chaos; c != nil && c.rnd == nil {
+		c.rnd = p.rnd
+	}
+}
+
+// hit reports if a fault with the given rate should be injected
+func (c *chaosState) hit(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	return c.rnd.float64() < rate
+}
+
+// duration returns a random duration up to d
+func (c *chaosState) duration(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(c.rnd.int63n(int64(d)) + 1)
+}
+
+// chaosActive reports if faults get injected, acquires then skip their fast path
+func (p *Pool[T]) chaosActive() bool {
+	return p.opts.chaos != nil
+}
+
+// chaosDelay delays an acquire until the delay passed, ctx is done or timeout fires
+func (p *Pool[T]) chaosDelay(ctx context.Context, timeout <-chan time.Time) error {
+	c := p.opts.chaos
+	if c == nil || !c.hit(c.DelayRate) {
+		return nil
+	}
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+	t := time.NewTimer(c.duration(c.MaxDelay))
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-done:
+		p.timedOut()
+		return ctx.Err()
+	case <-timeout:
+		p.timedOut()
+		return ErrAcquireTimeout
+	}
+}
+
+// chaosCreate returns ErrChaos if the creation of an entry should fail
+func (p *Pool[T]) chaosCreate() error {
+	if c := p.opts.chaos; c != nil && c.hit(c.FactoryFailureRate) {
+		return ErrChaos
+	}
+	return nil
+}
+
+// chaosInvalidate reports if an idle entry should be treated as invalid
+func (p *Pool[T]) chaosInvalidate() bool {
+	c := p.opts.chaos
+	return c != nil && c.hit(c.InvalidationRate)
+}
@@ -0,0 +1,40 @@
+//go:build chaos
+
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChaos(t *testing.T) {
+	pool := NewPool(2, poolFactory, WithMinSize(0), WithChaos(Chaos{FactoryFailureRate: 1}))
+	defer pool.Close()
+	if _, err := pool.AcquireWithTimeout(time.Second); !errors.Is(err, ErrChaos) {
+		t.Errorf("expected ErrChaos but got %v", err)
+	}
+	if stats := pool.Stats(); stats.Created != 0 {
+		t.Errorf("expected no entries to be created but got %+v", stats)
+	}
+
+	pool = NewPool(1, poolFactory, WithChaos(Chaos{DelayRate: 1, MaxDelay: time.Hour, Seed: 1}))
+	defer pool.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := pool.AcquireWithContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded but got %v", err)
+	}
+
+	pool = NewPool(1, poolFactory, WithChaos(Chaos{InvalidationRate: 1}))
+	defer pool.Close()
+	entry := pool.Acquire()
+	pool.Release(entry)
+	if next := pool.Acquire(); next == entry {
+		t.Errorf("expected invalidated entry to be replaced")
+	}
+	if stats := pool.Stats(); stats.Invalid != 2 {
+		t.Errorf("expected 2 invalid entries but got %+v", stats)
+	}
+}
@@ -0,0 +1,127 @@
+package pool
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+var ErrInvalidCheckpoint = fmt.Errorf("invalid checkpoint")
+
+// checkpointMagic starts every checkpoint
+var checkpointMagic = []byte("GPC1")
+
+// Checkpoint writes the idle entries of the pool encoded by enc to w (e.g. compiled scripts or caches
+// to be restored after a restart), entries in use aren't included. The idle entries are taken out of
+// the pool one by one while they get encoded (see Transaction), it's not a snapshot: entries acquired
+// before Checkpoint took them are missed and entries released meanwhile may be included.
+func (p *Pool[T]) Checkpoint(ctx context.Context, w io.Writer, enc func(*T) ([]byte, error)) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	err := p.Transaction(ctx, func(tx *Tx[T]) error {
+		bw := bufio.NewWriter(w)
+		bw.Write(checkpointMagic)
+		var size [binary.MaxVarintLen64]byte
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			v, ok := tx.TryTakeIdle()
+			if !ok {
+				break
+			}
+			b, err := enc(v)
+			if err != nil {
+				return err
+			}
+			bw.Write(size[:binary.PutUvarint(size[:], uint64(len(b)))])
+			bw.Write(b)
+		}
+		return bw.Flush()
+	})
+	return p.wrapErr(opCheckpoint, err)
+}
+
+// Restore reads a checkpoint written by Checkpoint and replaces idle entries of the pool with the entries
+// decoded by dec. Decoded entries exceeding the idle entries are added if the pool holds less entries
+// than it should and destroyed otherwise. Nothing is restored if reading or decoding fails.
+func (p *Pool[T]) Restore(ctx context.Context, r io.Reader, dec func([]byte) (*T, error)) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	records, err := readCheckpoint(r)
+	if err != nil {
+		return p.wrapErr(opRestore, err)
+	}
+	restored := make([]*T, 0, len(records))
+	for _, b := range records {
+		v, err := dec(b)
+		if err == nil && v == nil {
+			err = ErrNilEntry
+		}
+		if err != nil {
+			for _, v := range restored {
+				p.discardReplacement(v)
+			}
+			return p.wrapErr(opRestore, err)
+		}
+		restored = append(restored, v)
+	}
+	rest := restored
+	err = p.Transaction(ctx, func(tx *Tx[T]) error {
+		for len(rest) > 0 {
+			v, ok := tx.TryTakeIdle()
+			if !ok {
+				break
+			}
+			tx.ReplaceWith(v, rest[0])
+			rest = rest[1:]
+		}
+		return nil
+	})
+	if err != nil {
+		// the replacements were discarded by the rollback
+		for _, v := range rest {
+			p.discardReplacement(v)
+		}
+		return p.wrapErr(opRestore, err)
+	}
+	for _, v := range rest {
+		if p.TryPutIdle(v) != nil {
+			p.discardReplacement(v)
+		}
+	}
+	return nil
+}
+
+// readCheckpoint reads the records of a checkpoint
+func readCheckpoint(r io.Reader) ([][]byte, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(checkpointMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || !bytes.Equal(magic, checkpointMagic) {
+		return nil, ErrInvalidCheckpoint
+	}
+	var records [][]byte
+	for {
+		n, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidCheckpoint, err)
+		}
+		// don't trust the size before the data was read
+		b, err := io.ReadAll(io.LimitReader(br, int64(min(n, 1<<62))))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidCheckpoint, err)
+		}
+		if uint64(len(b)) != n {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidCheckpoint, io.ErrUnexpectedEOF)
+		}
+		records = append(records, b)
+	}
+}
@@ -0,0 +1,62 @@
+package pool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestCheckpointRestore(t *testing.T) {
+	n := 0
+	factory := func() *int {
+		n++
+		v := n
+		return &v
+	}
+	enc := func(v *int) ([]byte, error) { return []byte(strconv.Itoa(*v * 10)), nil }
+	dec := func(b []byte) (*int, error) {
+		v, err := strconv.Atoi(string(b))
+		return &v, err
+	}
+
+	pool := NewPool(3, factory)
+	inUse := pool.Acquire()
+	var buf bytes.Buffer
+	if err := pool.Checkpoint(context.Background(), &buf, enc); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	pool.Release(inUse)
+	if stats := pool.Stats(); stats.Idle != 3 {
+		t.Errorf("expected the entries to be returned but got %+v", stats)
+	}
+
+	restored := NewPool(2, factory, WithMinSize(1))
+	if err := restored.Restore(context.Background(), bytes.NewReader(buf.Bytes()), dec); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	sum := 0
+	for range 2 {
+		sum += *restored.Acquire()
+	}
+	if stats := restored.Stats(); stats.InUse != 2 || stats.Idle != 0 || sum != 50 {
+		t.Errorf("expected the 2 checkpointed entries (20 and 30) but got %d and %+v", sum, stats)
+	}
+
+	if err := restored.Restore(context.Background(), bytes.NewReader([]byte("junk")), dec); !errors.Is(err, ErrInvalidCheckpoint) {
+		t.Errorf("expected ErrInvalidCheckpoint but got %v", err)
+	}
+	truncated := buf.Bytes()[:buf.Len()-1]
+	if err := restored.Restore(context.Background(), bytes.NewReader(truncated), dec); !errors.Is(err, ErrInvalidCheckpoint) {
+		t.Errorf("expected ErrInvalidCheckpoint for a truncated checkpoint but got %v", err)
+	}
+	failing := func(b []byte) (*int, error) { return nil, errors.New("corrupt") }
+	if err := restored.Restore(context.Background(), bytes.NewReader(buf.Bytes()), failing); err == nil {
+		t.Errorf("expected decoding error")
+	}
+	var nilCtx context.Context
+	if err := pool.Checkpoint(nilCtx, &bytes.Buffer{}, enc); err != nil {
+		t.Errorf("expected a nil context to default to the background context but got %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// WithAutoClose makes the pool close entries implementing io.Closer (e.g. connections, files or VMs) whenever
+// they get destroyed: on eviction, on failed validation, when the pool shrinks or gets closed. A destroyer set
+// using WithDestroyer runs before Close. NewPool panics if the entries don't implement io.Closer.
+func WithAutoClose() Option {
+	return func(o *options) {
+		o.autoClose = true
+	}
+}
+
+// autoClose extends the destroyer to close entries (see WithAutoClose)
+func (p *Pool[T]) autoClose() error {
+	if _, ok := any((*T)(nil)).(io.Closer); !ok {
+		return fmt.Errorf("%w: auto close requires %T to implement io.Closer", ErrInvalidOption, (*T)(nil))
+	}
+	destroy := p.destroyFunc
+	p.destroyFunc = func(ctx context.Context, v *T) error {
+		var err error
+		if destroy != nil {
+			err = destroy(ctx, v)
+		}
+		return errors.Join(err, any(v).(io.Closer).Close())
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package pool
+
+import (
+	"errors"
+	"testing"
+)
+
+type closerItem struct {
+	closed bool
+}
+
+func (c *closerItem) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestAutoClose(t *testing.T) {
+	var destroyed []*closerItem
+	pool := NewPool(2, func() *closerItem { return &closerItem{} },
+		WithAutoClose(),
+		WithDestroyer(func(c *closerItem) {
+			if c.closed {
+				t.Errorf("expected the destroyer to run before Close")
+			}
+			destroyed = append(destroyed, c)
+		}),
+	)
+	a := pool.Acquire()
+	pool.Replace(a)
+	if !a.closed || len(destroyed) != 1 {
+		t.Errorf("expected the replaced entry to be closed")
+	}
+	b := pool.Acquire()
+	pool.Close()
+	pool.Release(b)
+	if !b.closed || len(destroyed) != 3 {
+		t.Errorf("expected all entries to be closed on shutdown but got %d", len(destroyed))
+	}
+
+	if _, err := NewPoolE(1, poolFactory, WithAutoClose()); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("expected %v but got %v", ErrInvalidOption, err)
+	}
+}
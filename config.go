@@ -0,0 +1,278 @@
+package pool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ExhaustionPolicy defines what acquires do if all entries are in use
+type ExhaustionPolicy int
+
+const (
+	// wait until an entry gets released (default)
+	ExhaustionBlock ExhaustionPolicy = iota
+	// fail immediately with ErrPoolExhausted
+	ExhaustionFail
+)
+
+func (e ExhaustionPolicy) String() string {
+	switch e {
+	case ExhaustionBlock:
+		return "block"
+	case ExhaustionFail:
+		return "fail"
+	}
+	return fmt.Sprintf("ExhaustionPolicy(%d)", int(e))
+}
+
+func (e ExhaustionPolicy) MarshalText() ([]byte, error) {
+	return []byte(e.String()), nil
+}
+
+func (e *ExhaustionPolicy) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "", "block":
+		*e = ExhaustionBlock
+	case "fail":
+		*e = ExhaustionFail
+	default:
+		return fmt.Errorf("unknown exhaustion policy %q", text)
+	}
+	return nil
+}
+
+// Duration is a time.Duration that is (un)marshaled as a string like "1m30s",
+// JSON numbers are interpreted as nanoseconds
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var ns int64
+	if err := json.Unmarshal(data, &ns); err == nil {
+		*d = Duration(ns)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	return d.UnmarshalText([]byte(s))
+}
+
+// Config holds the tuning parameters of a pool so they can be kept in
+// configuration files (JSON or YAML), see NewPoolFromConfig
+type Config struct {
+	// registers the pool under the given name (see WithName)
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// number of entries the pool holds
+	Size int `json:"size" yaml:"size"`
+	// number of entries created up front and kept alive, all entries if not set (see WithMinSize)
+	Min *int `json:"min,omitempty" yaml:"min,omitempty"`
+	// capacity of the pool, Resize can grow the pool up to Max (see WithMaxSize)
+	Max int `json:"max,omitempty" yaml:"max,omitempty"`
+	// maximum lifetime of entries (see WithTTL)
+	TTL Duration `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+	// destroy entries that are idle for longer (see WithIdleTimeout)
+	IdleTimeout Duration `json:"idle_timeout,omitempty" yaml:"idle_timeout,omitempty"`
+	// "block" or "fail" (see WithExhaustionPolicy)
+	ExhaustionPolicy ExhaustionPolicy `json:"exhaustion_policy,omitempty" yaml:"exhaustion_policy,omitempty"`
+	// minimum time between validations of an entry (see WithValidationInterval)
+	ValidationInterval Duration `json:"validation_interval,omitempty" yaml:"validation_interval,omitempty"`
+	// maximum number of acquires waiting for an entry, 0 means unlimited (see WithMaxWaiters)
+	MaxWaiters int `json:"max_waiters,omitempty" yaml:"max_waiters,omitempty"`
+}
+
+// Validate returns an error listing all invalid parameters
+func (c Config) Validate() error {
+	var errs []error
+	if c.Size < 0 {
+		errs = append(errs, fmt.Errorf("%w: size %d is negative", ErrInvalidSize, c.Size))
+	}
+	if c.Max != 0 && c.Max < c.Size {
+		errs = append(errs, fmt.Errorf("%w: max %d is less than size %d", ErrInvalidSize, c.Max, c.Size))
+	}
+	if c.Min != nil && (*c.Min < 0 || *c.Min > c.Size) {
+		errs = append(errs, fmt.Errorf("%w: min %d is not within 0 and size %d", ErrInvalidSize, *c.Min, c.Size))
+	}
+	if c.TTL < 0 {
+		errs = append(errs, fmt.Errorf("ttl %v is negative", time.Duration(c.TTL)))
+	}
+	if c.IdleTimeout < 0 {
+		errs = append(errs, fmt.Errorf("idle timeout %v is negative", time.Duration(c.IdleTimeout)))
+	}
+	if c.ValidationInterval < 0 {
+		errs = append(errs, fmt.Errorf("validation interval %v is negative", time.Duration(c.ValidationInterval)))
+	}
+	if c.ExhaustionPolicy != ExhaustionBlock && c.ExhaustionPolicy != ExhaustionFail {
+		errs = append(errs, fmt.Errorf("unknown exhaustion policy %v", c.ExhaustionPolicy))
+	}
+	if c.MaxWaiters < 0 {
+		errs = append(errs, fmt.Errorf("max waiters %d is negative", c.MaxWaiters))
+	}
+	return errors.Join(errs...)
+}
+
+// Options returns the options corresponding to the config
+func (c Config) Options() []Option {
+	opts := []Option{
+		WithMaxSize(c.Max),
+		WithTTL(time.Duration(c.TTL)),
+		WithIdleTimeout(time.Duration(c.IdleTimeout)),
+		WithExhaustionPolicy(c.ExhaustionPolicy),
+		WithValidationInterval(time.Duration(c.ValidationInterval)),
+		WithMaxWaiters(c.MaxWaiters),
+	}
+	if c.Name != "" {
+		opts = append(opts, WithName(c.Name))
+	}
+	if c.Min != nil {
+		opts = append(opts, WithMinSize(*c.Min))
+	}
+	return opts
+}
+
+// config returns the configuration corresponding to the options of a pool of the given size
+func (o *options) config(size int) Config {
+	cfg := Config{
+		Name:               o.name,
+		Size:               size,
+		Max:                o.maxSize,
+		TTL:                Duration(o.ttl),
+		IdleTimeout:        Duration(o.idleTimeout),
+		ExhaustionPolicy:   o.exhaustion,
+		ValidationInterval: Duration(o.validationInterval),
+		MaxWaiters:         o.maxWaiters,
+	}
+	if o.minSize >= 0 {
+		minSize := o.minSize
+		cfg.Min = &minSize
+	}
+	return cfg
+}
+
+// Returns the current configuration of the pool
+func (p *Pool[T]) Config() Config {
+	s := p.settings.Load()
+	cfg := Config{
+		Name:               p.opts.name,
+		Size:               p.requestedSize(),
+		Max:                p.Cap(),
+		TTL:                Duration(s.ttl),
+		IdleTimeout:        Duration(s.idleTimeout),
+		ExhaustionPolicy:   s.exhaustion,
+		ValidationInterval: Duration(s.validationInterval),
+		MaxWaiters:         s.maxWaiters,
+	}
+	if s.minSize >= 0 {
+		minSize := s.minSize
+		cfg.Min = &minSize
+	}
+	return cfg
+}
+
+// ApplyConfig applies the changed parameters of cfg to the running pool, entries in use aren't affected
+// until they get released, an EventConfigApplied describing the changes gets emitted
+// the name and max (the capacity) can't be changed at runtime, a zero max or empty name is ignored
+func (p *Pool[T]) ApplyConfig(cfg Config) (err error) {
+	defer func() { err = p.wrapErr(opApplyConfig, err) }()
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if err := p.lock(); err != nil {
+		return err
+	}
+	defer p.unlock()
+	if p.closed.Load() {
+		return ErrPoolClosed
+	}
+	cur := p.Config()
+	if cfg.Name != "" && cfg.Name != cur.Name {
+		return fmt.Errorf("name can't be changed at runtime")
+	}
+	if cfg.Max != 0 && cfg.Max != cur.Max {
+		return fmt.Errorf("%w: max can't be changed at runtime", ErrInvalidSize)
+	}
+	if cfg.Size > cur.Max {
+		return fmt.Errorf("%w: size %d exceeds max %d", ErrInvalidSize, cfg.Size, cur.Max)
+	}
+	changes := cur.diff(cfg)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	prev := p.settings.Load()
+	s := *prev
+	s.minSize = -1
+	if cfg.Min != nil {
+		s.minSize = *cfg.Min
+	}
+	s.ttl = time.Duration(cfg.TTL)
+	s.idleTimeout = time.Duration(cfg.IdleTimeout)
+	s.exhaustion = cfg.ExhaustionPolicy
+	s.validationInterval = time.Duration(cfg.ValidationInterval)
+	s.maxWaiters = cfg.MaxWaiters
+	p.settings.Store(&s)
+	if !p.trackUse && prev.idleTimeout <= 0 && s.idleTimeout > 0 {
+		p.restartIdle()
+	}
+	p.applySettings(&s)
+	if err := p.resize(cfg.Size); err != nil {
+		return err
+	}
+	// a raised minimum size
+	p.refill()
+
+	p.emit(Event{Type: EventConfigApplied, Changes: changes})
+	return nil
+}
+
+// diff returns the runtime parameters that differ in other
+func (c Config) diff(other Config) []ConfigChange {
+	var changes []ConfigChange
+	add := func(field string, old, new any) {
+		if old != new {
+			changes = append(changes, ConfigChange{Field: field, Old: old, New: new})
+		}
+	}
+	add("size", c.Size, other.Size)
+	minOf := func(c Config) any {
+		if c.Min == nil {
+			return nil
+		}
+		return *c.Min
+	}
+	add("min", minOf(c), minOf(other))
+	add("ttl", c.TTL, other.TTL)
+	add("idle_timeout", c.IdleTimeout, other.IdleTimeout)
+	add("exhaustion_policy", c.ExhaustionPolicy, other.ExhaustionPolicy)
+	add("validation_interval", c.ValidationInterval, other.ValidationInterval)
+	add("max_waiters", c.MaxWaiters, other.MaxWaiters)
+	return changes
+}
+
+// Creates a new pool using the parameters of the given config,
+// additional options (e.g. hooks) are applied after the config and validated like by NewPoolE
+func NewPoolFromConfig[T any](cfg Config, factoryFunc func() *T, opts ...Option) (*Pool[T], error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return NewPoolE(cfg.Size, factoryFunc, append(cfg.Options(), opts...)...)
+}
@@ -0,0 +1,149 @@
+package pool
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConfigJSON(t *testing.T) {
+	data := `{
+		"name": "config-json",
+		"size": 4,
+		"min": 1,
+		"max": 8,
+		"ttl": "1h",
+		"idle_timeout": 60000000000,
+		"exhaustion_policy": "fail",
+		"validation_interval": "5s"
+	}`
+	var cfg Config
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Size != 4 || *cfg.Min != 1 || cfg.Max != 8 || cfg.ExhaustionPolicy != ExhaustionFail {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if time.Duration(cfg.TTL) != time.Hour || time.Duration(cfg.IdleTimeout) != time.Minute || time.Duration(cfg.ValidationInterval) != 5*time.Second {
+		t.Errorf("unexpected durations: %+v", cfg)
+	}
+
+	out, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var roundTrip Config
+	if err := json.Unmarshal(out, &roundTrip); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if roundTrip.TTL != cfg.TTL || roundTrip.ExhaustionPolicy != cfg.ExhaustionPolicy {
+		t.Errorf("expected %+v but got %+v", cfg, roundTrip)
+	}
+
+	if err := json.Unmarshal([]byte(`{"exhaustion_policy": "grow"}`), &cfg); err == nil {
+		t.Errorf("expected error for unknown exhaustion policy")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	min := 5
+	cfg := Config{Size: 2, Max: 1, Min: &min, TTL: -1}
+	err := cfg.Validate()
+	if !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("expected %v but got %v", ErrInvalidSize, err)
+	}
+	// every problem is reported
+	if n := len(err.(interface{ Unwrap() []error }).Unwrap()); n != 3 {
+		t.Errorf("expected 3 errors but got %d: %v", n, err)
+	}
+	if _, err := NewPoolFromConfig(cfg, poolFactory); err == nil {
+		t.Errorf("expected error for invalid config")
+	}
+}
+
+func TestNewPoolFromConfig(t *testing.T) {
+	min := 1
+	reg := NewRegistry()
+	pool, err := NewPoolFromConfig(Config{Name: "from-config", Size: 2, Min: &min, Max: 3, ExhaustionPolicy: ExhaustionFail}, poolFactory, WithRegistry(reg))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := reg.Get("from-config"); !ok {
+		t.Errorf("expected pool to be registered")
+	}
+	// lazy pool: only the minimum number of entries is created up front
+	if stats := pool.Stats(); stats.Idle != 1 || stats.Created != 1 || stats.Cap != 3 || stats.Size != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	pool.Acquire()
+	pool.Acquire()
+	if stats := pool.Stats(); stats.Created != 2 {
+		t.Errorf("expected 2 created entries but got %d", stats.Created)
+	}
+	if _, err := pool.AcquireWithTimeout(time.Second); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("expected %v but got %v", ErrPoolExhausted, err)
+	}
+
+	if _, err := NewPoolFromConfig(Config{Name: "from-config", Size: 1}, poolFactory, WithRegistry(reg)); !errors.Is(err, ErrDuplicatePoolName) {
+		t.Errorf("expected %v but got %v", ErrDuplicatePoolName, err)
+	}
+	// the additional options are validated too
+	if _, err := NewPoolFromConfig(Config{Size: 1}, poolFactory, WithReapInterval(-time.Second)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("expected %v but got %v", ErrInvalidOption, err)
+	}
+	if _, err := NewPoolFromConfig(Config{Size: 0}, poolFactory); !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("expected %v but got %v", ErrInvalidSize, err)
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	var events []Event
+	pool := NewPool(2, poolFactory, WithName("apply-config"), WithRegistry(NewRegistry()), WithMaxSize(4), WithEventHook(func(e Event) {
+		if e.Type == EventConfigApplied {
+			events = append(events, e)
+		}
+	}))
+	defer pool.Close()
+	inUse := pool.Acquire()
+
+	cfg := pool.Config()
+	if cfg.Size != 2 || cfg.Max != 4 || cfg.Min != nil {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if err := pool.ApplyConfig(cfg); err != nil || len(events) != 0 {
+		t.Errorf("expected unchanged config to be a no-op but got %v, %v", err, events)
+	}
+
+	cfg.Size = 4
+	cfg.TTL = Duration(time.Hour)
+	cfg.ExhaustionPolicy = ExhaustionFail
+	if err := pool.ApplyConfig(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats := pool.Stats(); stats.Size != 4 || stats.Idle != 3 || stats.InUse != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if len(events) != 1 || events[0].Type != EventConfigApplied || events[0].Pool != "apply-config" {
+		t.Fatalf("expected a single config event but got %+v", events)
+	}
+	changes := map[string]ConfigChange{}
+	for _, c := range events[0].Changes {
+		changes[c.Field] = c
+	}
+	if len(changes) != 3 || changes["size"].Old != 2 || changes["size"].New != 4 || changes["ttl"].New != Duration(time.Hour) {
+		t.Errorf("unexpected changes: %v", events[0].Changes)
+	}
+	if cur := pool.Config(); cur.TTL != cfg.TTL || cur.ExhaustionPolicy != ExhaustionFail {
+		t.Errorf("expected config %+v but got %+v", cfg, cur)
+	}
+	// the entry in use is unaffected
+	if err := pool.Release(inUse); err != nil || pool.Len() != 4 {
+		t.Errorf("expected entry to be released but got %v with %d idle entries", err, pool.Len())
+	}
+
+	cfg.Max = 8
+	if err := pool.ApplyConfig(cfg); !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("expected %v but got %v", ErrInvalidSize, err)
+	}
+}
@@ -0,0 +1,61 @@
+package pool
+
+import (
+	"cmp"
+	"slices"
+)
+
+// WithCostBudget bounds the pool by the total cost of its entries (e.g. their size in bytes) instead of
+// only their number. cost is called for new and released entries, no entries get created while the
+// budget is exhausted and the most expensive idle entries get destroyed while the pool exceeds it.
+// Its type must match the pools type or else NewPool panics.
+func WithCostBudget[T any](cost func(*T) int64, budget int64) Option {
+	return func(o *options) {
+		o.cost = cost
+		o.costBudget = budget
+	}
+}
+
+// full reports if the pool can't create another entry, live is the number of existing entries
+func (p *Pool[T]) full(live int64) bool {
+	return live >= p.target.Load() || (live > 0 && p.overBudget())
+}
+
+// overBudget reports if the entries of the pool exhaust its cost budget
+func (p *Pool[T]) overBudget() bool {
+	return p.costFunc != nil && p.cost.Load() >= p.opts.costBudget
+}
+
+// measure updates the cost of v
+func (p *Pool[T]) measure(v *T, m *entryMeta) {
+	if p.costFunc == nil {
+		return
+	}
+	c := p.costFunc(v)
+	p.cost.Add(c - m.cost.Swap(c))
+}
+
+// trimCost destroys idle entries while the pool exceeds its cost budget, the most expensive
+// ones first unless the pool has an eviction policy (see WithEvictionPolicy)
+func (p *Pool[T]) trimCost() {
+	if p.costFunc == nil || p.cost.Load() <= p.opts.costBudget {
+		return
+	}
+	idle := p.idle.snapshot()
+	if p.opts.evictionPolicy == nil {
+		slices.SortStableFunc(idle, func(a, b *T) int {
+			return cmp.Compare(p.meta(b).cost.Load(), p.meta(a).cost.Load())
+		})
+	} else {
+		idle = p.evictionOrder(idle)
+	}
+	for _, v := range idle {
+		if p.cost.Load() <= p.opts.costBudget {
+			return
+		}
+		if p.idle.remove(v) {
+			p.stats.costEvicted.Add(1)
+			p.destroy(v)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package pool
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type document struct{ size int64 }
+
+func documentCost(d *document) int64 { return d.size }
+
+func TestCostBudget(t *testing.T) {
+	pool := NewPool(4, func() *document { return &document{size: 10} }, WithCostBudget(documentCost, 100))
+	defer pool.Close()
+
+	a, b, c, d := pool.Acquire(), pool.Acquire(), pool.Acquire(), pool.Acquire()
+	a.size, b.size, c.size, d.size = 60, 30, 5, 5
+	pool.Release(a)
+	if stats := pool.Stats(); stats.Cost != 90 || stats.CostEvicted != 0 {
+		t.Errorf("expected a cost of 90 within the budget but got %+v", stats)
+	}
+	// exceeding the budget destroys the most expensive idle entry
+	pool.Release(b)
+	if stats := pool.Stats(); stats.Cost != 50 || stats.CostEvicted != 1 || stats.Idle != 1 {
+		t.Errorf("expected the most expensive entry to be evicted but got %+v", stats)
+	}
+	if v := pool.Acquire(); v != b {
+		t.Errorf("expected the cheaper entry to be kept")
+	}
+}
+
+func TestCostBudgetCreation(t *testing.T) {
+	pool := NewPool(4, func() *document { return &document{size: 10} }, WithMinSize(0), WithCostBudget(documentCost, 15))
+	defer pool.Close()
+
+	pool.Acquire()
+	b := pool.Acquire()
+	if _, err := pool.AcquireWithTimeout(10 * time.Millisecond); !errors.Is(err, ErrAcquireTimeout) {
+		t.Errorf("expected no entry to be created while the budget is exhausted but got %v", err)
+	}
+	pool.Release(b)
+	if _, err := pool.AcquireWithTimeout(10 * time.Millisecond); err != nil {
+		t.Errorf("expected an entry within the budget but got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package pool
+
+import (
+	"context"
+	"time"
+)
+
+// WithCreateConcurrency limits the number of factory calls running at the same time to n (e.g. 1 to
+// create entries one at a time). Acquires of a lazy pool waiting to create an entry take an entry
+// released in the meantime instead, so a burst of acquires on a cold pool doesn't stampede the backend.
+func WithCreateConcurrency(n int) Option {
+	return func(o *options) {
+		o.createConcurrency = n
+	}
+}
+
+// Creation describes an acquire that has to wait for a new entry to be created (see WithCreationNotify)
+type Creation struct {
+	// name of the pool
+	Pool string
+	// expected duration of the factory call (the mean of all factory calls), 0 before the first one
+	Expected time.Duration
+	// 95th percentile of the duration of recent factory calls
+	P95 time.Duration
+	// acquires waiting for an entry
+	Waiters int
+}
+
+// WithCreationNotify sets a function that gets called by acquires that have to wait for a new entry to be
+// created, before the factory gets called, with the context of the acquire and the expected duration of the
+// creation based on recent factory calls (see FactoryStats). Callers can show progress or fall back by
+// canceling the context: the acquire fails with the error of the context right away, the entry is put into
+// the pool once created.
+func WithCreationNotify(fn func(ctx context.Context, c Creation)) Option {
+	return func(o *options) {
+		o.creationNotify = fn
+	}
+}
+
+// createNotified creates a new entry for reserved space after notifying about the creation, if ctx is done
+// or timeout fires first the acquire gives up and the entry is put into the pool once created
+func (p *Pool[T]) createNotified(ctx context.Context, timeout <-chan time.Time) (*T, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := ctx.Err(); err != nil {
+		p.unreserve()
+		return nil, err
+	}
+	f := p.FactoryStats()
+	p.opts.creationNotify(ctx, Creation{Pool: p.opts.name, Expected: f.Avg, P95: f.P95, Waiters: int(p.waiters.Load())})
+	created := make(chan *T, 1)
+	go func() { created <- p.newEntry() }()
+	var err error
+	select {
+	case v := <-created:
+		return v, nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timeout:
+		err = ErrAcquireTimeout
+	}
+	p.timedOut()
+	go func() { p.put(<-created) }()
+	return nil, err
+}
+
+// createHeld creates a new entry, the caller must hold a token of p.createSem
+func (p *Pool[T]) createHeld() *T {
+	defer func() { <-p.createSem }()
+	v := p.construct()
+	p.adopt(v)
+	return v
+}
+
+// tryCreate creates a new entry for reserved space unless the creation limit is reached
+func (p *Pool[T]) tryCreate() (*T, bool) {
+	select {
+	case p.createSem <- struct{}{}:
+		return p.createHeld(), true
+	default:
+		return nil, false
+	}
+}
+
+// awaitCreate creates a new entry for reserved space once the creation limit allows it, if an entry gets
+// released in the meantime the reserved space is freed and the released entry is returned instead
+func (p *Pool[T]) awaitCreate(ctx context.Context, timeout <-chan time.Time) (*T, error) {
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+	for {
+		// subscribe before checking to not miss entries released in the meantime
+		released := p.idleSignal()
+		if v, ok := p.idle.tryGet(); ok {
+			if !p.checkout(v) {
+				continue
+			}
+			p.unreserve()
+			return v, nil
+		}
+		select {
+		case p.createSem <- struct{}{}:
+			return p.createHeld(), nil
+		case <-released:
+		case <-p.done:
+			p.unreserve()
+			return nil, ErrPoolClosed
+		case <-done:
+			p.unreserve()
+			p.timedOut()
+			return nil, ctx.Err()
+		case <-timeout:
+			p.unreserve()
+			p.timedOut()
+			return nil, ErrAcquireTimeout
+		}
+	}
+}
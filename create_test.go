@@ -0,0 +1,89 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCreateConcurrency(t *testing.T) {
+	var running, maxRunning atomic.Int32
+	factory := func() *poolItem {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for m := maxRunning.Load(); n > m && !maxRunning.CompareAndSwap(m, n); m = maxRunning.Load() {
+		}
+		time.Sleep(20 * time.Millisecond)
+		return new(poolItem)
+	}
+	pool := NewPool(10, factory, WithMinSize(0), WithCreateConcurrency(1))
+	defer pool.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := pool.AcquireWithTimeout(time.Second)
+			if err != nil {
+				t.Errorf("expected no error but got %v", err)
+				return
+			}
+			pool.Release(v)
+		}()
+	}
+	wg.Wait()
+	if n := maxRunning.Load(); n != 1 {
+		t.Errorf("expected 1 concurrent factory call but got %d", n)
+	}
+	// entries released during a creation are handed to waiting acquires instead of creating more
+	if stats := pool.Stats(); stats.Created >= 10 || stats.Acquired != 10 {
+		t.Errorf("expected less than 10 created entries for 10 acquires but got %+v", stats)
+	}
+}
+
+// cancelKey is the context key of the cancel function of an acquire
+type cancelKey struct{}
+
+func TestCreationNotify(t *testing.T) {
+	var notices []Creation
+	factory := func() *poolItem {
+		time.Sleep(20 * time.Millisecond)
+		return new(poolItem)
+	}
+	pool := NewPool(2, factory, WithMinSize(0), WithCreationNotify(func(ctx context.Context, c Creation) {
+		notices = append(notices, c)
+		if cancel, ok := ctx.Value(cancelKey{}).(context.CancelFunc); ok && c.Expected > 10*time.Millisecond {
+			// fall back instead of waiting for the slow factory
+			cancel()
+		}
+	}))
+	defer pool.Close()
+
+	if _, err := pool.AcquireWithContext(context.Background()); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = context.WithValue(ctx, cancelKey{}, cancel)
+	start := time.Now()
+	if _, err := pool.AcquireWithContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v but got %v", context.Canceled, err)
+	}
+	if d := time.Since(start); d > 15*time.Millisecond {
+		t.Errorf("expected the acquire to fall back right away but it took %v", d)
+	}
+	if len(notices) != 2 || notices[0].Expected != 0 || notices[1].Expected < 20*time.Millisecond {
+		t.Errorf("unexpected notices: %+v", notices)
+	}
+	// the entry is created anyway
+	deadline := time.Now().Add(time.Second)
+	for pool.Len() != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := pool.Len(); n != 1 {
+		t.Errorf("expected the created entry to be idle but got %d idle entries", n)
+	}
+}
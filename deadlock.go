@@ -0,0 +1,77 @@
+package pool
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+)
+
+var ErrWouldDeadlock = fmt.Errorf("operation would deadlock the calling goroutine")
+
+// WithDeadlockDetection makes the pool fail with ErrWouldDeadlock instead of hanging if a goroutine
+// calls a locking method (e.g. Resize or ApplyConfig) from inside LockedRun or Transaction, or waits
+// for an entry while it holds all entries of the pool itself.
+// Detection identifies goroutines using their stack trace, which makes acquiring more expensive.
+// Entries handed over to other goroutines are still attributed to the goroutine that acquired them.
+func WithDeadlockDetection() Option {
+	return func(o *options) {
+		o.deadlockDetection = true
+	}
+}
+
+// goid returns the id of the calling goroutine
+func goid() int64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	// "goroutine 123 [running]: ..."
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseInt(string(b), 10, 64)
+	return id
+}
+
+// lock acquires p.mux, failing with ErrWouldDeadlock if the calling goroutine holds it already
+func (p *Pool[T]) lock() error {
+	if !p.opts.deadlockDetection {
+		p.mux.Lock()
+		return nil
+	}
+	g := goid()
+	if p.lockOwner.Load() == g {
+		return ErrWouldDeadlock
+	}
+	p.mux.Lock()
+	p.lockOwner.Store(g)
+	return nil
+}
+
+func (p *Pool[T]) unlock() {
+	if p.opts.deadlockDetection {
+		p.lockOwner.Store(0)
+	}
+	p.mux.Unlock()
+}
+
+// selfBlocked reports if waiting for an entry would block forever as the calling goroutine
+// holds all entries of the pool and the pool can't grow (always false without WithDeadlockDetection)
+func (p *Pool[T]) selfBlocked() bool {
+	if !p.opts.deadlockDetection {
+		return false
+	}
+	live := p.live.Load()
+	if live == 0 || !p.full(live) {
+		return false
+	}
+	g := goid()
+	held := int64(0)
+	p.rangeMeta(func(m *entryMeta) bool {
+		if m.inUse.Load() && m.holder.Load() == g {
+			held++
+		}
+		return true
+	})
+	return held >= live
+}
@@ -0,0 +1,46 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDeadlockDetection(t *testing.T) {
+	pool := NewPool(1, poolFactory, WithDeadlockDetection())
+	defer pool.Close()
+
+	err := pool.LockedRun(func(p *Pool[poolItem]) error {
+		return p.Resize(1)
+	})
+	if !errors.Is(err, ErrWouldDeadlock) {
+		t.Errorf("expected ErrWouldDeadlock but got %v", err)
+	}
+	err = pool.Transaction(context.Background(), func(tx *Tx[poolItem]) error {
+		return pool.ApplyConfig(pool.Config())
+	})
+	if !errors.Is(err, ErrWouldDeadlock) {
+		t.Errorf("expected ErrWouldDeadlock but got %v", err)
+	}
+
+	entry := pool.Acquire()
+	if _, err := pool.AcquireWithTimeout(time.Second); !errors.Is(err, ErrWouldDeadlock) {
+		t.Errorf("expected ErrWouldDeadlock but got %v", err)
+	}
+	if _, err := pool.AcquireMatch(context.Background(), func(*poolItem) bool { return true }); !errors.Is(err, ErrWouldDeadlock) {
+		t.Errorf("expected ErrWouldDeadlock but got %v", err)
+	}
+
+	// other goroutines wait for the entry to be released
+	got := make(chan *poolItem)
+	go func() {
+		v, _ := pool.AcquireWithTimeout(time.Second)
+		got <- v
+	}()
+	time.Sleep(10 * time.Millisecond)
+	pool.Release(entry)
+	if v := <-got; v != entry {
+		t.Errorf("expected the released entry but got %v", v)
+	}
+}
@@ -0,0 +1,148 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrDegraded is wrapped by errors of health checks (see WithHealthCheck) for entries that still work but
+// shouldn't be preferred (e.g. a connection to a lagging replica), such entries are degraded instead of destroyed
+var ErrDegraded = fmt.Errorf("entry is degraded")
+
+// EntryHealth is the health of an entry
+type EntryHealth int
+
+const (
+	// the entry passed its last health check
+	EntryHealthy EntryHealth = iota
+	// the last health check failed with ErrDegraded, the entry is only handed out if no healthy entry is idle
+	EntryDegraded
+	// the health check failed, the entry got destroyed (or quarantined)
+	EntryDead
+)
+
+func (h EntryHealth) String() string {
+	switch h {
+	case EntryHealthy:
+		return "healthy"
+	case EntryDegraded:
+		return "degraded"
+	case EntryDead:
+		return "dead"
+	}
+	return fmt.Sprintf("EntryHealth(%d)", int(h))
+}
+
+// WithRepair sets a function that repairs degraded entries (see ErrDegraded) in the background, entries
+// for which it fails get destroyed and replaced. ctx is limited by the timeout of the health check. Its
+// type must match the pools type or else NewPool panics.
+func WithRepair[T any](fn func(ctx context.Context, e *T) error) Option {
+	return func(o *options) {
+		o.repair = fn
+	}
+}
+
+// degrade takes v, which failed its health check with ErrDegraded, out of the idle entries. It's handed
+// out right away if acquires are waiting since no healthy entry is idle then.
+func (p *Pool[T]) degrade(v *T) {
+	p.setDegraded(p.meta(v), true)
+	if p.waiters.Load() > 0 {
+		p.put(v)
+		return
+	}
+	p.degradedMux.Lock()
+	p.degraded = append(p.degraded, v)
+	p.degradedMux.Unlock()
+	if p.closed.Load() {
+		// closed concurrently
+		p.destroyDegraded()
+		return
+	}
+	if p.repairWake != nil {
+		select {
+		case p.repairWake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// setDegraded changes the health of the entry of m, counting the degraded entries
+func (p *Pool[T]) setDegraded(m *entryMeta, degraded bool) {
+	if m.degraded.CompareAndSwap(!degraded, degraded) {
+		if degraded {
+			p.degradedCount.Add(1)
+		} else {
+			p.degradedCount.Add(-1)
+		}
+	}
+}
+
+// takeDegraded takes the degraded entry that was degraded first
+func (p *Pool[T]) takeDegraded() (*T, bool) {
+	if p.degradedCount.Load() == 0 {
+		return nil, false
+	}
+	p.degradedMux.Lock()
+	defer p.degradedMux.Unlock()
+	if len(p.degraded) == 0 {
+		return nil, false
+	}
+	v := p.degraded[0]
+	p.degraded = p.degraded[1:]
+	return v, true
+}
+
+// destroyDegraded destroys the degraded entries of the closed pool
+func (p *Pool[T]) destroyDegraded() {
+	p.degradedMux.Lock()
+	degraded := p.degraded
+	p.degraded = nil
+	p.degradedMux.Unlock()
+	for _, v := range degraded {
+		p.destroy(v)
+	}
+}
+
+// repairer repairs degraded entries until the pool gets closed
+func (p *Pool[T]) repairer() {
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-p.repairWake:
+		}
+		for {
+			v, ok := p.takeDegraded()
+			if !ok {
+				break
+			}
+			p.repairEntry(context.Background(), v)
+		}
+	}
+}
+
+// repairEntry repairs v, it's put back as healthy entry or destroyed and replaced if the repair fails
+func (p *Pool[T]) repairEntry(ctx context.Context, v *T) {
+	if p.opts.healthTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.opts.healthTimeout)
+		defer cancel()
+	}
+	if err := p.repairFunc(ctx, v); err != nil {
+		p.stats.unhealthy.Add(1)
+		p.reject(v, errors.Join(ErrDegraded, err))
+		if p.grow() {
+			p.put(p.newEntry())
+		}
+		return
+	}
+	p.stats.repaired.Add(1)
+	p.setDegraded(p.meta(v), false)
+	p.put(v)
+}
+
+// healthy returns the number of entries that aren't degraded
+func (p *Pool[T]) healthy() int {
+	return int(max(p.live.Load()-p.creating.Load()-p.degradedCount.Load(), 0))
+}
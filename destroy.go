@@ -0,0 +1,101 @@
+package pool
+
+import "time"
+
+// WithAsyncDestroy moves calling the destroyer (see WithDestroyer) off the hot path: removed entries are
+// queued and destroyed by the given number of background workers once they were queued for at least
+// grace (e.g. to let in-flight requests on a connection finish), so Release never waits for a slow
+// destroyer. queueSize limits the number of pending destructions, entries removed while the queue is
+// full are destroyed right away. Drain waits until the pending destructions finished.
+func WithAsyncDestroy(workers, queueSize int, grace time.Duration) Option {
+	return func(o *options) {
+		o.destroyWorkers = workers
+		o.destroyQueueSize = queueSize
+		o.destroyGrace = grace
+	}
+}
+
+// pendingDestroy is an entry queued for destruction
+type pendingDestroy[T any] struct {
+	v        *T
+	queuedAt time.Time
+}
+
+// startDestroyers starts the workers of WithAsyncDestroy
+func (p *Pool[T]) startDestroyers() {
+	if p.opts.destroyWorkers <= 0 || p.destroyFunc == nil {
+		return
+	}
+	p.destroyQueue = make(chan pendingDestroy[T], max(p.opts.destroyQueueSize, 0))
+	for i := 0; i < p.opts.destroyWorkers; i++ {
+		go p.destroyer()
+	}
+}
+
+// destroyer destroys queued entries until the pool is drained
+func (p *Pool[T]) destroyer() {
+	for {
+		select {
+		case d := <-p.destroyQueue:
+			p.destroyPending(d)
+		case <-p.drained:
+			p.drainDestroys()
+			return
+		}
+	}
+}
+
+// destroyPending calls the destroyer for d once its grace period is over
+func (p *Pool[T]) destroyPending(d pendingDestroy[T]) {
+	if wait := time.Until(d.queuedAt.Add(p.opts.destroyGrace)); wait > 0 {
+		t := acquireTimer(wait)
+		<-t.C
+		releaseTimer(t)
+	}
+	p.runHook(p.destroyFunc, d.v)
+	p.destroying.Add(-1)
+	p.checkDrained()
+}
+
+// drainDestroys destroys all queued entries
+func (p *Pool[T]) drainDestroys() {
+	for {
+		select {
+		case d := <-p.destroyQueue:
+			p.destroyPending(d)
+		default:
+			return
+		}
+	}
+}
+
+// runDestroyer calls the destroyer for an entry that was removed from the pool
+func (p *Pool[T]) runDestroyer(v *T) {
+	if p.destroyFunc == nil {
+		return
+	}
+	if p.destroyQueue != nil && p.enqueueDestroy(v) {
+		return
+	}
+	p.runHook(p.destroyFunc, v)
+}
+
+// enqueueDestroy hands v to the workers of WithAsyncDestroy,
+// false is returned if the caller has to destroy it itself
+func (p *Pool[T]) enqueueDestroy(v *T) bool {
+	// counted before queuing so Drain doesn't finish while v is queued
+	p.destroying.Add(1)
+	select {
+	case p.destroyQueue <- pendingDestroy[T]{v: v, queuedAt: time.Now()}:
+	default:
+		p.destroying.Add(-1)
+		return false
+	}
+	// the workers may have stopped before v was queued
+	select {
+	case <-p.drained:
+		p.drainDestroys()
+	default:
+	}
+	return true
+}
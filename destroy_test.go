@@ -0,0 +1,51 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAsyncDestroy(t *testing.T) {
+	unblock := make(chan struct{})
+	destroyed := make(chan *poolItem, 2)
+	pool := NewPool(1, poolFactory, WithAsyncDestroy(1, 2, 0), WithDestroyer(func(e *poolItem) {
+		<-unblock
+		destroyed <- e
+	}))
+	e := pool.Acquire()
+	// doesn't wait for the destroyer
+	pool.Replace(e)
+	if stats := pool.Stats(); stats.PendingDestroys != 1 || stats.Idle != 1 {
+		t.Errorf("expected a pending destruction and a replacement but got %+v", stats)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := pool.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected drain to wait for the pending destructions but got %v", err)
+	}
+	close(unblock)
+	if err := pool.Drain(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(destroyed) != 2 || <-destroyed != e {
+		t.Errorf("expected both entries to be destroyed")
+	}
+	if n := pool.Stats().PendingDestroys; n != 0 {
+		t.Errorf("expected no pending destructions but got %d", n)
+	}
+}
+
+func TestAsyncDestroyGrace(t *testing.T) {
+	destroyed := make(chan time.Time, 1)
+	pool := NewPool(1, poolFactory, WithAsyncDestroy(1, 1, 20*time.Millisecond), WithDestroyer(func(e *poolItem) {
+		destroyed <- time.Now()
+	}))
+	defer pool.Close()
+	start := time.Now()
+	pool.Replace(pool.Acquire())
+	if d := (<-destroyed).Sub(start); d < 20*time.Millisecond {
+		t.Errorf("expected the entry to be destroyed after the grace period but it took %v", d)
+	}
+}
@@ -0,0 +1,119 @@
+package pool
+
+import (
+	"context"
+	"iter"
+	"sync"
+)
+
+// drainQueue holds entries of a closed pool until DrainIter yields them
+type drainQueue[T any] struct {
+	mux     sync.Mutex
+	entries []*T
+	stopped bool
+	// signals queued entries
+	ready chan struct{}
+}
+
+// push queues v, false is returned if DrainIter stopped
+func (q *drainQueue[T]) push(v *T) bool {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	if q.stopped {
+		return false
+	}
+	q.entries = append(q.entries, v)
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// pop takes the next queued entry
+func (q *drainQueue[T]) pop() (*T, bool) {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	if len(q.entries) == 0 {
+		return nil, false
+	}
+	v := q.entries[0]
+	q.entries = q.entries[1:]
+	return v, true
+}
+
+func (q *drainQueue[T]) isStopped() bool {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	return q.stopped
+}
+
+// stop stops queueing and returns the remaining entries
+func (q *drainQueue[T]) stop() []*T {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	q.stopped = true
+	rest := q.entries
+	q.entries = nil
+	return rest
+}
+
+// joinDrain returns the queue of running iterations or starts a new one
+func (p *Pool[T]) joinDrain() *drainQueue[T] {
+	for {
+		q := p.drainQueue.Load()
+		if q != nil && !q.isStopped() {
+			return q
+		}
+		next := &drainQueue[T]{ready: make(chan struct{}, 1)}
+		if p.drainQueue.CompareAndSwap(q, next) {
+			return next
+		}
+	}
+}
+
+// retire destroys an entry of the closed pool or hands it over to DrainIter
+func (p *Pool[T]) retire(v *T) {
+	if q := p.drainQueue.Load(); q != nil && q.push(v) {
+		return
+	}
+	p.destroy(v)
+}
+
+// DrainIter closes the pool and returns an iterator over its entries as they become available, idle
+// entries first and entries in use once they get released. Every entry is destroyed after the loop body
+// handled it (e.g. flushed its state), so the body must not release it. Iteration ends once all entries
+// are destroyed or ctx is done, entries not yielded get destroyed as usual when the loop is left early.
+// Concurrent iterations share the entries.
+func (p *Pool[T]) DrainIter(ctx context.Context) iter.Seq[*T] {
+	return func(yield func(*T) bool) {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		q := p.joinDrain()
+		defer func() {
+			for _, v := range q.stop() {
+				p.destroy(v)
+			}
+		}()
+		p.Close()
+		for {
+			if v, ok := q.pop(); ok {
+				cont := yield(v)
+				p.destroy(v)
+				if !cont {
+					return
+				}
+				continue
+			}
+			select {
+			case <-q.ready:
+			case <-p.drained:
+				// queued entries are still alive, so the queue is empty
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package pool
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDrainIter(t *testing.T) {
+	var destroyed atomic.Int32
+	pool := NewPool(3, poolFactory, WithDestroyer(func(*poolItem) { destroyed.Add(1) }))
+	inUse := pool.Acquire()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		pool.Release(inUse)
+	}()
+	var drained []*poolItem
+	for v := range pool.DrainIter(context.Background()) {
+		if int(destroyed.Load()) != len(drained) {
+			t.Errorf("expected entries to be destroyed after they were handled")
+		}
+		drained = append(drained, v)
+	}
+	if len(drained) != 3 || drained[2] != inUse {
+		t.Errorf("expected 3 entries with the one in use last but got %d", len(drained))
+	}
+	if destroyed.Load() != 3 {
+		t.Errorf("expected 3 destroyed entries but got %d", destroyed.Load())
+	}
+	if err := pool.Drain(context.Background()); err != nil {
+		t.Errorf("expected drained pool but got %v", err)
+	}
+}
+
+func TestDrainIterStop(t *testing.T) {
+	var destroyed atomic.Int32
+	pool := NewPool(3, poolFactory, WithDestroyer(func(*poolItem) { destroyed.Add(1) }))
+	for range pool.DrainIter(context.Background()) {
+		break
+	}
+	if destroyed.Load() != 3 {
+		t.Errorf("expected all entries to be destroyed after leaving the loop but got %d", destroyed.Load())
+	}
+
+	pool = NewPool(1, poolFactory, WithDestroyer(func(*poolItem) { destroyed.Add(1) }))
+	inUse := pool.Acquire()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	n := 0
+	for range pool.DrainIter(ctx) {
+		n++
+	}
+	if n != 0 {
+		t.Errorf("expected no entries but got %d", n)
+	}
+	pool.Release(inUse)
+	if destroyed.Load() != 4 {
+		t.Errorf("expected the released entry to be destroyed but got %d", destroyed.Load())
+	}
+}
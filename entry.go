@@ -0,0 +1,215 @@
+package pool
+
+import (
+	"runtime"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// EntryInfo describes the lifecycle of an entry
+type EntryInfo struct {
+	CreatedAt time.Time `json:"created_at"`
+	// last time the entry was acquired, zero if it never was or the pool doesn't track the use of its entries
+	// (see WithUsageTracking)
+	LastUsed time.Time `json:"last_used"`
+	// last time the entry was put back into the pool, its creation time if the pool doesn't track the use of
+	// its entries and has neither a ttl nor an idle timeout
+	IdleSince time.Time `json:"idle_since"`
+	// number of times the entry was acquired, 0 if the pool doesn't track the use of its entries
+	UseCount uint64 `json:"use_count"`
+	// value of Stats.Generation when the entry was created
+	Generation uint64 `json:"generation"`
+	InUse      bool   `json:"in_use"`
+	// healthy or degraded
+	Health EntryHealth `json:"health"`
+	// exempt from eviction by the ttl and idle timeout (see Lease.Pin)
+	Pinned bool `json:"pinned"`
+	// cost of the entry (see WithCostBudget)
+	Cost int64 `json:"cost,omitempty"`
+	// adapter specific information (see WithEntryMetadata)
+	Metadata any `json:"metadata,omitempty"`
+}
+
+// entryMeta holds the lifecycle data of an entry
+type entryMeta struct {
+	createdAt  time.Time
+	generation uint64
+	// unix nanoseconds
+	lastUsed    atomic.Int64
+	idleSince   atomic.Int64
+	validatedAt atomic.Int64
+	uses        atomic.Uint64
+	inUse       atomic.Bool
+	// goroutine that acquired the entry (see WithDeadlockDetection)
+	holder atomic.Int64
+	// cost of the entry (see WithCostBudget)
+	cost atomic.Int64
+	// key the entry was last acquired with (see AcquireWithAffinity)
+	affinity atomic.Pointer[any]
+	// set while the entry is degraded (see ErrDegraded)
+	degraded atomic.Bool
+	// set while the entry is exempt from eviction (see Lease.Pin)
+	pinned atomic.Bool
+	// goroutine and unix nanoseconds of the last release (see WithCheckedRelease)
+	releasedBy atomic.Int64
+	releasedAt atomic.Int64
+	// cleanup catching the entry getting lost while it's acquired (see WithLeakDetection)
+	cleanup runtime.Cleanup
+	// recent lifecycle events, nil unless the pool was created WithLifecycleTrace
+	trace *traceRing
+}
+
+// WithUsageTracking records when and how often entries are acquired and released in their metadata (see
+// EntryInfo), which costs a lookup of the metadata and a clock read on every acquire and release. It's
+// implied by WithLeakDetection, WithNilReplacement, WithLifecycleTrace, WithDeadlockDetection,
+// WithCheckedRelease, WithLoadShedding and WithEvictionPolicy, which rely on it, and by WithMaxHoldTime
+// and WithQuarantine, which report the EntryInfo.
+func WithUsageTracking() Option {
+	return func(o *options) {
+		o.usageTracking = true
+	}
+}
+
+// tracksUse reports whether acquires and releases record the use of entries in their metadata
+func (o *options) tracksUse() bool {
+	return o.usageTracking || o.leakDetection || o.nilReplacement || o.traceSize > 0 || o.deadlockDetection ||
+		o.checkedRelease || o.maxEstimatedWait > 0 || o.evictionPolicy != nil || o.maxHoldTime > 0 || o.quarantineSize > 0
+}
+
+// paddedMeta allocates the metadata of an entry on cache lines of its own, goroutines using different
+// entries update their metadata on every acquire and release and would otherwise invalidate each other's
+// cache lines if the allocator placed the metadata next to each other
+type paddedMeta struct {
+	entryMeta
+	_ [(cacheLineSize - unsafe.Sizeof(entryMeta{})%cacheLineSize) % cacheLineSize]byte
+}
+
+func (p *Pool[T]) newMeta() *entryMeta {
+	now := time.Now()
+	m := &new(paddedMeta).entryMeta
+	m.createdAt, m.generation = now, p.generation.Load()
+	if n := p.opts.traceSize; n > 0 {
+		m.trace = &traceRing{events: make([]TraceEvent, 0, n)}
+	}
+	m.idleSince.Store(now.UnixNano())
+	m.validatedAt.Store(now.UnixNano())
+	return m
+}
+
+// track starts tracking the metadata of a new entry
+func (p *Pool[T]) track(v *T) {
+	p.entries.Store(v, p.newMeta())
+}
+
+// untrack removes the metadata of a destroyed entry
+func (p *Pool[T]) untrack(v *T) {
+	var m *entryMeta
+	if meta, ok := p.entries.LoadAndDelete(v); ok {
+		m = meta.(*entryMeta)
+	} else if m, ok = p.unissue(v); !ok {
+		return
+	}
+	p.forget(m)
+	if key := m.affinity.Load(); key != nil {
+		p.affinity.CompareAndDelete(*key, v)
+	}
+}
+
+// forget releases the resources of the pool accounted to the entry of m
+func (p *Pool[T]) forget(m *entryMeta) {
+	p.cost.Add(-m.cost.Load())
+	p.setDegraded(m, false)
+	p.unpin(m)
+}
+
+// lookup returns the metadata of v, false if v isn't an entry of the pool
+func (p *Pool[T]) lookup(v *T) (*entryMeta, bool) {
+	if m, ok := p.entries.Load(v); ok {
+		return m.(*entryMeta), true
+	}
+	if !p.weakIssue() {
+		return nil, false
+	}
+	m, ok := p.issued.Load(issueKey(v))
+	if !ok {
+		return nil, false
+	}
+	return m.(*entryMeta), true
+}
+
+// rangeMeta calls fn for the metadata of all entries until it returns false
+func (p *Pool[T]) rangeMeta(fn func(m *entryMeta) bool) {
+	done := false
+	p.entries.Range(func(_, m any) bool {
+		done = !fn(m.(*entryMeta))
+		return !done
+	})
+	if !done && p.weakIssue() {
+		p.issued.Range(func(_, m any) bool {
+			return fn(m.(*entryMeta))
+		})
+	}
+}
+
+// meta returns the metadata of v, entries the pool didn't create are treated as new
+func (p *Pool[T]) meta(v *T) *entryMeta {
+	if m, ok := p.lookup(v); ok {
+		return m
+	}
+	m, _ := p.entries.LoadOrStore(v, p.newMeta())
+	return m.(*entryMeta)
+}
+
+func (m *entryMeta) info() EntryInfo {
+	info := EntryInfo{
+		CreatedAt:  m.createdAt,
+		IdleSince:  time.Unix(0, m.idleSince.Load()),
+		UseCount:   m.uses.Load(),
+		Generation: m.generation,
+		InUse:      m.inUse.Load(),
+		Pinned:     m.pinned.Load(),
+		Cost:       m.cost.Load(),
+	}
+	if m.degraded.Load() {
+		info.Health = EntryDegraded
+	}
+	if lastUsed := m.lastUsed.Load(); lastUsed != 0 {
+		info.LastUsed = time.Unix(0, lastUsed)
+	}
+	return info
+}
+
+// Returns the lifecycle information of an entry of the pool
+func (p *Pool[T]) EntryInfo(v *T) (EntryInfo, bool) {
+	m, ok := p.lookup(v)
+	if !ok {
+		return EntryInfo{}, false
+	}
+	info := p.describe(v, m)
+	if !p.trackUse {
+		// entries that aren't idle are in use
+		info.InUse = !p.idle.contains(v)
+	}
+	return info, true
+}
+
+// describe returns the lifecycle information of v including its metadata
+func (p *Pool[T]) describe(v *T, m *entryMeta) EntryInfo {
+	info := m.info()
+	if p.metadataFunc != nil {
+		info.Metadata = p.metadataFunc(v)
+	}
+	return info
+}
+
+// Returns the lifecycle information of all idle entries
+func (p *Pool[T]) IdleEntries() []EntryInfo {
+	var infos []EntryInfo
+	for _, v := range p.idle.snapshot() {
+		if m, ok := p.lookup(v); ok {
+			infos = append(infos, p.describe(v, m))
+		}
+	}
+	return infos
+}
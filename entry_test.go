@@ -0,0 +1,155 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestEntryInfo(t *testing.T) {
+	pool := NewPool(2, poolFactory, WithUsageTracking())
+	lease, err := pool.AcquireLease(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info := lease.Info()
+	if !info.InUse || info.UseCount != 1 || info.LastUsed.IsZero() || info.Generation != 0 {
+		t.Errorf("unexpected info: %+v", info)
+	}
+	if err := lease.Release(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	idle := pool.IdleEntries()
+	if len(idle) != 2 {
+		t.Fatalf("expected 2 idle entries but got %d", len(idle))
+	}
+	uses := idle[0].UseCount + idle[1].UseCount
+	if uses != 1 {
+		t.Errorf("expected 1 use in total but got %d", uses)
+	}
+
+	if err := pool.RefreshAll(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, info := range pool.IdleEntries() {
+		if info.Generation != 1 || info.UseCount != 0 {
+			t.Errorf("expected unused entries of generation 1 but got %+v", info)
+		}
+	}
+
+	if _, ok := pool.EntryInfo(new(poolItem)); ok {
+		t.Errorf("expected no info for an unknown entry")
+	}
+}
+
+func TestEntryMetadata(t *testing.T) {
+	pool := NewPool(1, func() *int { return new(int) }, WithEntryMetadata(func(v *int) any { return *v * 2 }))
+	v := pool.Acquire()
+	*v = 21
+	if info, _ := pool.EntryInfo(v); info.Metadata != 42 {
+		t.Errorf("expected metadata 42 but got %v", info.Metadata)
+	}
+	pool.Release(v)
+	if idle := pool.IdleEntries(); len(idle) != 1 || idle[0].Metadata != 42 {
+		t.Errorf("expected idle entry with metadata 42 but got %+v", idle)
+	}
+}
+
+func TestEntryInfoUntracked(t *testing.T) {
+	pool := NewPool(2, poolFactory)
+	v := pool.Acquire()
+	if info, ok := pool.EntryInfo(v); !ok || !info.InUse || info.UseCount != 0 {
+		t.Errorf("expected an untracked entry in use but got %+v, %v", info, ok)
+	}
+	pool.Release(v)
+	if info, _ := pool.EntryInfo(v); info.InUse {
+		t.Errorf("expected an idle entry but got %+v", info)
+	}
+	if idle := pool.IdleEntries(); len(idle) != 2 {
+		t.Errorf("expected 2 idle entries but got %d", len(idle))
+	}
+	if err := pool.Hijack(v); !errors.Is(err, ErrNotAcquired) {
+		t.Errorf("expected %v but got %v", ErrNotAcquired, err)
+	}
+	v = pool.Acquire()
+	if err := pool.Hijack(v); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := pool.Hijack(v); !errors.Is(err, ErrNotAcquired) {
+		t.Errorf("expected %v but got %v", ErrNotAcquired, err)
+	}
+}
+
+func TestForgetDropped(t *testing.T) {
+	pool := NewPool(2, poolFactory, WithNilReplacement())
+	held := pool.Acquire()
+	for range 10 {
+		pool.Acquire()
+		pool.Release(nil)
+	}
+	if info, ok := pool.EntryInfo(held); !ok || !info.InUse || info.UseCount != 1 {
+		t.Errorf("expected the info of the held entry but got %+v", info)
+	}
+
+	deadline := time.After(time.Second)
+	for {
+		runtime.GC()
+		n := 0
+		pool.rangeMeta(func(*entryMeta) bool {
+			n++
+			return true
+		})
+		if n == 2 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("expected metadata of 2 entries but got %d", n)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if _, ok := pool.EntryInfo(held); !ok {
+		t.Errorf("expected the held entry to keep its metadata")
+	}
+	pool.Release(held)
+	if stats := pool.Stats(); stats.InUse != 0 || stats.Idle != 2 {
+		t.Errorf("expected 2 idle entries but got %+v", stats)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	destroyed := 0
+	pool := NewPool(1, poolFactory, WithDestroyer(func(*poolItem) { destroyed++ }))
+	e := pool.Acquire()
+	if err := pool.Replace(e); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if destroyed != 1 || pool.Len() != 1 {
+		t.Errorf("expected 1 destroyed and 1 idle entry but got %d/%d", destroyed, pool.Len())
+	}
+	if next := pool.Acquire(); next == e {
+		t.Errorf("expected a fresh entry")
+	}
+}
+
+func TestLeaseContext(t *testing.T) {
+	pool := NewPool(1, poolFactory, WithLeaseContext())
+	defer pool.Close()
+
+	if _, ok := LeaseFromContext[poolItem](context.Background()); ok {
+		t.Errorf("expected no lease in empty context")
+	}
+	err := pool.RunWithContext(context.Background(), func(ctx context.Context, e *poolItem) error {
+		lease, ok := LeaseFromContext[poolItem](ctx)
+		if !ok || lease.Value() != e {
+			t.Errorf("expected lease of %p but got %v", e, lease.Value())
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected no error but got %v", err)
+	}
+}
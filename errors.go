@@ -0,0 +1,59 @@
+package pool
+
+import "fmt"
+
+// PoolError is returned by the operations of a pool, it names the pool (see WithName) and the operation
+// that failed and wraps the cause, use errors.Is to check for causes like ErrAcquireTimeout
+type PoolError struct {
+	Pool string
+	// failed operation, e.g. "acquire" or "release"
+	Op  string
+	Err error
+}
+
+func (e *PoolError) Error() string {
+	if e.Pool == "" {
+		return fmt.Sprintf("pool: %s: %v", e.Op, e.Err)
+	}
+	return fmt.Sprintf("pool %q: %s: %v", e.Pool, e.Op, e.Err)
+}
+
+func (e *PoolError) Unwrap() error {
+	return e.Err
+}
+
+// operations reported by PoolError
+const (
+	opAcquire      = "acquire"
+	opRelease      = "release"
+	opReplace      = "replace"
+	opPutIdle      = "put idle"
+	opReserve      = "reserve"
+	opLockedRun    = "locked run"
+	opTransaction  = "transaction"
+	opRefresh      = "refresh"
+	opResize       = "resize"
+	opDrain        = "drain"
+	opApplyConfig  = "apply config"
+	opCheckpoint   = "checkpoint"
+	opRestore      = "restore"
+	opHijack       = "hijack"
+	opPin          = "pin"
+	opSwap         = "swap"
+	opImportTotals = "import totals"
+)
+
+// wrapErr wraps err in a PoolError, nil and errors that are already wrapped are returned unchanged
+func (p *Pool[T]) wrapErr(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*PoolError); ok {
+		return err
+	}
+	if err == ErrAcquireTimeout && op == opAcquire && p.errTimeout != nil {
+		// timeouts are frequent and shouldn't allocate
+		return p.errTimeout
+	}
+	return &PoolError{Pool: p.opts.name, Op: op, Err: err}
+}
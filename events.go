@@ -0,0 +1,81 @@
+package pool
+
+import (
+	"fmt"
+	"time"
+)
+
+// EventType identifies the kind of an Event
+type EventType int
+
+const (
+	// the configuration of the pool was changed using ApplyConfig
+	EventConfigApplied EventType = iota
+	// the pool became cold, warm or saturated (see PoolState)
+	EventStateChanged
+	// the factory function created an entry
+	EventEntryCreated
+	// an acquired entry got lost without being released (see WithLeakDetection)
+	EventEntryLeaked
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventConfigApplied:
+		return "config_applied"
+	case EventStateChanged:
+		return "state_changed"
+	case EventEntryCreated:
+		return "entry_created"
+	case EventEntryLeaked:
+		return "entry_leaked"
+	}
+	return fmt.Sprintf("EventType(%d)", int(t))
+}
+
+// Event describes a change of a pool, see WithEventHook
+type Event struct {
+	Type EventType
+	// name of the pool
+	Pool string
+	Time time.Time
+	// changed parameters of an EventConfigApplied
+	Changes []ConfigChange
+	// new and previous state of an EventStateChanged
+	State    PoolState
+	Previous PoolState
+	// duration of the factory call of an EventEntryCreated
+	Duration time.Duration
+}
+
+// ConfigChange describes a changed parameter of a Config
+type ConfigChange struct {
+	// name of the parameter as used by the JSON encoding of Config
+	Field string
+	Old   any
+	New   any
+}
+
+func (c ConfigChange) String() string {
+	return fmt.Sprintf("%s: %v -> %v", c.Field, c.Old, c.New)
+}
+
+// WithEventHook adds a function that gets called synchronously for every event of the pool,
+// it must not block and must not call methods of the pool that change its state
+func WithEventHook(fn func(Event)) Option {
+	return func(o *options) {
+		o.eventHooks = append(o.eventHooks, fn)
+	}
+}
+
+// emit passes e to the event hooks
+func (p *Pool[T]) emit(e Event) {
+	if len(p.opts.eventHooks) == 0 {
+		return
+	}
+	e.Pool = p.opts.name
+	e.Time = time.Now()
+	for _, hook := range p.opts.eventHooks {
+		hook(e)
+	}
+}
@@ -0,0 +1,102 @@
+package pool
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// EvictionPolicy decides which idle entries get evicted first, by the reaper once they exceed the idle timeout
+// (see WithIdleTimeout) and while the pool exceeds its cost budget (see WithCostBudget)
+type EvictionPolicy interface {
+	// Pick returns the indexes of the candidates that may be evicted, in the order they should be evicted.
+	// Candidates it doesn't return are kept.
+	Pick(candidates []EntryInfo) []int
+}
+
+// EvictionFunc is an EvictionPolicy implemented by a function
+type EvictionFunc func(candidates []EntryInfo) []int
+
+func (f EvictionFunc) Pick(candidates []EntryInfo) []int {
+	return f(candidates)
+}
+
+var (
+	// EvictLRU evicts the least recently used entries first, entries that were never used before all others
+	EvictLRU EvictionPolicy = orderBy(func(a, b EntryInfo) int {
+		return a.LastUsed.Compare(b.LastUsed)
+	})
+	// EvictOldest evicts the entries created first
+	EvictOldest EvictionPolicy = orderBy(func(a, b EntryInfo) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	// EvictMostUsed evicts the entries acquired most often first (e.g. interpreters accumulating state)
+	EvictMostUsed EvictionPolicy = orderBy(func(a, b EntryInfo) int {
+		return cmp.Compare(b.UseCount, a.UseCount)
+	})
+	// EvictCostWeighted evicts the entries with the highest cost (see WithCostBudget) times the time they
+	// are idle first, so expensive entries that aren't needed are evicted before cheap or busy ones
+	EvictCostWeighted EvictionPolicy = EvictionFunc(func(candidates []EntryInfo) []int {
+		now := time.Now()
+		weight := func(i int) float64 {
+			return float64(max(candidates[i].Cost, 1)) * float64(now.Sub(candidates[i].IdleSince))
+		}
+		return order(len(candidates), func(i, j int) int {
+			return cmp.Compare(weight(j), weight(i))
+		})
+	})
+)
+
+// WithEvictionPolicy sets the policy deciding which idle entries get evicted first. By default the entries
+// idle the longest are evicted first by the idle timeout and the most expensive ones by the cost budget.
+func WithEvictionPolicy(policy EvictionPolicy) Option {
+	return func(o *options) {
+		o.evictionPolicy = policy
+	}
+}
+
+// orderBy returns a policy evicting all candidates ordered by compare
+func orderBy(compare func(a, b EntryInfo) int) EvictionFunc {
+	return func(candidates []EntryInfo) []int {
+		return order(len(candidates), func(i, j int) int {
+			return compare(candidates[i], candidates[j])
+		})
+	}
+}
+
+// order returns the indexes 0 to n-1 stably sorted by compare
+func order(n int, compare func(i, j int) int) []int {
+	indexes := make([]int, n)
+	for i := range indexes {
+		indexes[i] = i
+	}
+	slices.SortStableFunc(indexes, compare)
+	return indexes
+}
+
+// evictionOrder returns the idle entries of candidates that may be evicted in the order of the eviction policy,
+// candidates are returned unchanged without a policy
+func (p *Pool[T]) evictionOrder(candidates []*T) []*T {
+	policy := p.opts.evictionPolicy
+	if policy == nil || len(candidates) == 0 {
+		return candidates
+	}
+	infos := make([]EntryInfo, len(candidates))
+	for i, v := range candidates {
+		m, ok := p.lookup(v)
+		if !ok {
+			// destroyed concurrently, taking it out of the pool fails anyway
+			m = &entryMeta{}
+		}
+		infos[i] = p.describe(v, m)
+	}
+	picked := make([]bool, len(candidates))
+	var victims []*T
+	for _, i := range policy.Pick(infos) {
+		if i >= 0 && i < len(candidates) && !picked[i] {
+			picked[i] = true
+			victims = append(victims, candidates[i])
+		}
+	}
+	return victims
+}
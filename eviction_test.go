@@ -0,0 +1,51 @@
+package pool
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestEvictionPolicy(t *testing.T) {
+	pool := NewPool(3, poolFactory, WithMinSize(2), WithIdleTimeout(time.Minute), WithReapInterval(time.Hour), WithEvictionPolicy(EvictMostUsed))
+	defer pool.Close()
+	a, b, c := pool.Acquire(), pool.Acquire(), pool.Acquire()
+	pool.Release(a)
+	pool.Release(b)
+	pool.Release(c)
+	for range 2 {
+		v, _ := pool.AcquireMatch(context.Background(), func(v *poolItem) bool { return v == b })
+		pool.Release(v)
+	}
+	pool.reapIdle(time.Now().Add(time.Hour))
+	if _, ok := pool.EntryInfo(b); ok {
+		t.Errorf("expected the most used entry to be evicted")
+	}
+	if n := pool.Len(); n != 2 {
+		t.Errorf("expected 2 idle entries but got %d", n)
+	}
+}
+
+func TestEvictionOrder(t *testing.T) {
+	now := time.Now()
+	candidates := []EntryInfo{
+		{CreatedAt: now, LastUsed: now.Add(-time.Minute), IdleSince: now.Add(-time.Minute), UseCount: 1, Cost: 10},
+		{CreatedAt: now.Add(-time.Hour), LastUsed: now, IdleSince: now.Add(-time.Second), UseCount: 5, Cost: 100},
+		{CreatedAt: now.Add(-time.Minute), IdleSince: now.Add(-time.Hour), Cost: 1},
+	}
+	for _, tc := range []struct {
+		name     string
+		policy   EvictionPolicy
+		expected []int
+	}{
+		{"lru", EvictLRU, []int{2, 0, 1}},
+		{"oldest", EvictOldest, []int{1, 2, 0}},
+		{"most used", EvictMostUsed, []int{1, 0, 2}},
+		{"cost weighted", EvictCostWeighted, []int{2, 0, 1}},
+	} {
+		if order := tc.policy.Pick(candidates); !slices.Equal(order, tc.expected) {
+			t.Errorf("%s: expected %v but got %v", tc.name, tc.expected, order)
+		}
+	}
+}
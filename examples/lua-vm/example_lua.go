@@ -1,18 +1,18 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"sync"
 	"time"
 
 	"github.com/epikur-io/go-pool"
+	"github.com/epikur-io/go-pool/luapool"
 	lua "github.com/epikur-io/gopher-lua"
 )
 
 var luaCode = `
-	print("Hello world!")
-
 	function greet(name)
 		local greeting = "Hello: " .. name
 		print(greeting)
@@ -21,45 +21,52 @@ var luaCode = `
 `
 
 func main() {
-	factory := func() *lua.LState {
-		lvm := lua.NewState()
-		return lvm
+	vms, err := luapool.New(luapool.Options{
+		Size:    2000,
+		Scripts: []luapool.Script{{Name: "greet.lua", Source: luaCode}},
+	},
+		pool.WithName("lua-vms"),
+		// create 100 VMs up front and the rest in the background
+		pool.WithBackgroundFill(100),
+		pool.WithWaitHistory(4096),
+		pool.WithSlowAcquireThreshold(time.Second, func(s pool.SlowAcquire) {
+			log.Printf("slow acquire from %s: waited %s with %d waiters", s.Pool, s.Waited, s.Waiters)
+		}),
+	)
+	if err != nil {
+		log.Fatal(err)
 	}
-	pool := pool.NewPool(2000, factory)
-
-	// get an entry:
-	entry := pool.Acquire()
-	entry.DoString(luaCode)
-
-	// release entry
-	pool.Release(entry)
+	defer vms.Close()
 
 	wg := sync.WaitGroup{}
 	for i := range 2000 {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			// get an entry or timeout after 1 second:
-			entry, err := pool.AcquireWithTimeout(time.Second * 5)
-			if err != nil {
-				log.Printf("error acquiring from pool (#%d): %s", i, err)
-				return
-			}
+			// get a VM or timeout after 5 seconds:
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
 
 			// call some lua function
-			entry.DoString(luaCode)
-			if err := entry.CallByParam(lua.P{
-				Fn:      entry.GetGlobal("greet"),
-				NRet:    1,
-				Protect: true,
-			}, lua.LString("epikur #"+fmt.Sprint(i))); err != nil {
-				panic(err)
+			if _, err := vms.Call(ctx, "greet", 1, lua.LString("epikur #"+fmt.Sprint(i))); err != nil {
+				log.Printf("error calling greet (#%d): %s", i, err)
+				return
 			}
-
-			// release a new entry to te pool and drop the old one
 			fmt.Printf("## Finished iteration #%d\n", i)
-			pool.Release(nil)
 		}()
 	}
 	wg.Wait()
+
+	// swap the scripts, VMs get reloaded with the new version
+	if err := vms.RefreshScripts(context.Background(), []luapool.Script{{Name: "greet.lua", Source: `
+		function greet(name)
+			return "Hi: " .. name
+		end
+	`}}); err != nil {
+		log.Fatal(err)
+	}
+
+	p := vms.Pool()
+	fmt.Printf("stats: %+v\n", p.Stats())
+	fmt.Printf("waits: %+v\n", p.Snapshot(time.Minute))
 }
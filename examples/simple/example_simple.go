@@ -34,17 +34,17 @@ func main() {
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second*1)
 		defer cancel()
 		entry, err := pool.AcquireWithContext(ctx)
-		// release entry, since entry is nil, a new entry will be created and put into the pool
-		defer pool.Release(nil)
-		entry.DoSomeWork("B")
 		if err != nil {
 			log.Fatalln("error:", err)
 		}
+		// release entry
+		defer pool.Release(entry)
+		entry.DoSomeWork("B")
 	}
 	{
 		// automatically release entries back to the pool
 		err := pool.RunWithContext(context.Background(), func(ctx context.Context, e *PoolEntry) error {
-			// a freshly created entry will be automatically released on function exit using: `pool.Release(nil)`
+			// the used entry gets dropped and a freshly created entry is put into the pool on function exit
 			e.DoSomeWork("C")
 			return fmt.Errorf("dummy error")
 		})
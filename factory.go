@@ -0,0 +1,66 @@
+package pool
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// factorySamples is the number of recent factory calls kept for FactoryStats.P95
+const factorySamples = 128
+
+// FactoryStats summarizes the duration of the factory calls of a pool,
+// slow factories are a common cause of acquire timeouts
+type FactoryStats struct {
+	// total number of factory calls
+	Calls uint64        `json:"calls"`
+	Min   time.Duration `json:"min"`
+	Max   time.Duration `json:"max"`
+	// mean duration of all factory calls
+	Avg time.Duration `json:"avg"`
+	// 95th percentile of the duration of the last 128 factory calls
+	P95 time.Duration `json:"p95"`
+}
+
+// factoryLatency records the duration of factory calls without locking
+type factoryLatency struct {
+	calls atomic.Uint64
+	sum   atomic.Int64
+	min   atomic.Int64
+	max   atomic.Int64
+	// durations of recent calls
+	recent *waitRing
+}
+
+func newFactoryLatency() *factoryLatency {
+	l := &factoryLatency{recent: newWaitRing(factorySamples)}
+	l.min.Store(math.MaxInt64)
+	return l
+}
+
+func (l *factoryLatency) observe(at time.Time, d time.Duration) {
+	l.calls.Add(1)
+	l.sum.Add(int64(d))
+	for cur := l.min.Load(); int64(d) < cur && !l.min.CompareAndSwap(cur, int64(d)); cur = l.min.Load() {
+	}
+	for cur := l.max.Load(); int64(d) > cur && !l.max.CompareAndSwap(cur, int64(d)); cur = l.max.Load() {
+	}
+	l.recent.add(at, d)
+}
+
+func (l *factoryLatency) snapshot() FactoryStats {
+	s := FactoryStats{Calls: l.calls.Load()}
+	if s.Calls == 0 {
+		return s
+	}
+	s.Min = time.Duration(l.min.Load())
+	s.Max = time.Duration(l.max.Load())
+	s.Avg = time.Duration(l.sum.Load() / int64(s.Calls))
+	s.P95 = percentile(l.recent.waits(0), 95)
+	return s
+}
+
+// FactoryStats returns a summary of the duration of the factory calls of the pool
+func (p *Pool[T]) FactoryStats() FactoryStats {
+	return p.factoryTimes.snapshot()
+}
@@ -0,0 +1,35 @@
+package pool
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFactoryStats(t *testing.T) {
+	var durations []time.Duration
+	pool := NewPool(2, func() *poolItem {
+		time.Sleep(5 * time.Millisecond)
+		return poolFactory()
+	}, WithMinSize(1), WithEventHook(func(e Event) {
+		if e.Type == EventEntryCreated {
+			durations = append(durations, e.Duration)
+		}
+	}))
+	defer pool.Close()
+	pool.Acquire()
+	pool.Acquire()
+
+	stats := pool.FactoryStats()
+	if stats.Calls != 2 || len(durations) != 2 {
+		t.Fatalf("expected 2 factory calls but got %+v, events %v", stats, durations)
+	}
+	if stats.Min < 5*time.Millisecond || stats.Min > stats.Avg || stats.Avg > stats.P95 || stats.P95 > stats.Max {
+		t.Errorf("unexpected factory stats: %+v", stats)
+	}
+	if durations[0] < 5*time.Millisecond {
+		t.Errorf("expected the event to hold the duration of the factory call but got %v", durations[0])
+	}
+	if pool.Stats().Factory != stats {
+		t.Errorf("expected %+v but got %+v", stats, pool.Stats().Factory)
+	}
+}
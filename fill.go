@@ -0,0 +1,125 @@
+package pool
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// WithBackgroundFill creates only k entries while the pool gets created and the remaining ones in a background
+// goroutine, so services with large pools (e.g. thousands of VMs) start serving right away. Acquires don't wait
+// for the fill, they create entries on demand until the pool is full. FillProgress reports the progress,
+// WaitFilled waits for the fill and CancelFill stops it (closing the pool does as well).
+func WithBackgroundFill(k int) Option {
+	return func(o *options) {
+		o.backgroundFill = true
+		o.syncFill = k
+	}
+}
+
+// FillProgress is the progress of the background fill of a pool (see WithBackgroundFill)
+type FillProgress struct {
+	// entries created by the background fill so far and the entries it's meant to create
+	Created int `json:"created"`
+	Total   int `json:"total"`
+	// the fill finished, it ends early if acquires filled the pool in the meantime
+	Done bool `json:"done"`
+	// the fill got canceled using CancelFill or by closing the pool
+	Canceled bool `json:"canceled"`
+}
+
+// backgroundFill is the state of the background fill of a pool
+type backgroundFill struct {
+	total      int
+	created    atomic.Int64
+	canceled   atomic.Bool
+	cancel     chan struct{}
+	cancelOnce sync.Once
+	done       chan struct{}
+}
+
+// fill creates the initial entries of the pool, all of them unless it was created WithBackgroundFill
+// (see startFill)
+func (p *Pool[T]) fill() {
+	n := p.minSize()
+	k := n
+	if p.opts.backgroundFill {
+		k = min(max(p.opts.syncFill, 0), n)
+	}
+	for i := 0; i < k; i++ {
+		p.idle.put(p.create())
+	}
+	if k == n {
+		return
+	}
+	p.filling = &backgroundFill{total: n - k, cancel: make(chan struct{}), done: make(chan struct{})}
+}
+
+// startFill starts the background fill once the pool is set up
+func (p *Pool[T]) startFill() {
+	if p.filling != nil {
+		go p.fillBackground(p.filling)
+	}
+}
+
+// fillBackground creates the entries of the background fill f one by one
+func (p *Pool[T]) fillBackground(f *backgroundFill) {
+	defer close(f.done)
+	for i := 0; i < f.total; i++ {
+		select {
+		case <-f.cancel:
+			f.canceled.Store(true)
+			return
+		case <-p.done:
+			f.canceled.Store(true)
+			return
+		default:
+		}
+		if !p.grow() {
+			// filled by acquires in the meantime
+			return
+		}
+		p.put(p.newEntry())
+		f.created.Add(1)
+	}
+}
+
+// FillProgress reports the progress of the background fill (see WithBackgroundFill),
+// pools filled while they got created report a finished fill of 0 entries
+func (p *Pool[T]) FillProgress() FillProgress {
+	f := p.filling
+	if f == nil {
+		return FillProgress{Done: true}
+	}
+	progress := FillProgress{Created: int(f.created.Load()), Total: f.total}
+	select {
+	case <-f.done:
+		progress.Done = true
+		progress.Canceled = f.canceled.Load()
+	default:
+	}
+	return progress
+}
+
+// WaitFilled waits until the background fill finished or got canceled, or until ctx is done
+func (p *Pool[T]) WaitFilled(ctx context.Context) error {
+	if p.filling == nil {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	select {
+	case <-p.filling.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CancelFill stops the background fill, the pool creates the missing entries on demand
+func (p *Pool[T]) CancelFill() {
+	if f := p.filling; f != nil {
+		f.cancelOnce.Do(func() { close(f.cancel) })
+	}
+}
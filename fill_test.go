@@ -0,0 +1,59 @@
+package pool
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBackgroundFill(t *testing.T) {
+	release := make(chan struct{})
+	created := 0
+	pool := NewPool(10, func() *poolItem {
+		if created++; created > 2 {
+			<-release
+		}
+		return new(poolItem)
+	}, WithBackgroundFill(2))
+	defer pool.Close()
+	if pool.Len() != 2 {
+		t.Errorf("expected 2 entries created up front but got %d", pool.Len())
+	}
+	if p := pool.FillProgress(); p.Done || p.Total != 8 {
+		t.Errorf("expected the fill to be running but got %+v", p)
+	}
+	close(release)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := pool.WaitFilled(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p := pool.FillProgress(); !p.Done || p.Canceled || p.Created != 8 || pool.Len() != 10 {
+		t.Errorf("expected the pool to be filled but got %+v with %d entries", p, pool.Len())
+	}
+}
+
+func TestCancelFill(t *testing.T) {
+	release := make(chan struct{})
+	pool := NewPool(10, func() *poolItem {
+		<-release
+		return new(poolItem)
+	}, WithBackgroundFill(0))
+	defer pool.Close()
+	pool.CancelFill()
+	close(release)
+	pool.WaitFilled(context.Background())
+	if p := pool.FillProgress(); !p.Done || !p.Canceled || p.Created > 1 {
+		t.Errorf("expected the fill to be canceled but got %+v", p)
+	}
+	// the missing entries are created on demand
+	e, err := pool.AcquireWith(context.Background(), WithNoWait())
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	pool.Release(e)
+
+	if p := NewPool(1, poolFactory).FillProgress(); !p.Done || p.Total != 0 {
+		t.Errorf("expected a finished fill without WithBackgroundFill but got %+v", p)
+	}
+}
@@ -0,0 +1,148 @@
+// Package flockpool lets the pools of several processes on one host respect one global capacity (e.g. the
+// connection cap of a licensed backend) by coordinating through lock files. It's experimental.
+//
+// Every process opens a pool on the same directory with the same number of slots, an entry holds a slot
+// while it exists. Entries are opened lazily on acquire once a slot is free, waiting until the context of
+// the caller is done:
+//
+//	p, err := flockpool.New(flockpool.Options[Conn]{
+//		Dir:   "/run/myapp/backend-slots",
+//		Slots: 20, // across all processes
+//		Size:  5,  // per process
+//		Open:  dialBackend,
+//		Close: func(c *Conn) error { return c.Close() },
+//	})
+//	...
+//	c, err := p.Acquire(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	defer p.Release(c)
+//
+// Slots are lock files locked using flock, the operating system releases the locks of crashed processes.
+// Only unix platforms are supported.
+package flockpool
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/epikur-io/go-pool"
+	"github.com/epikur-io/go-pool/internal/adapter"
+)
+
+// Options of a pool
+type Options[T any] struct {
+	// Dir holds the lock files of the slots (required), it's created if necessary
+	Dir string
+	// Slots is the number of entries across all processes (required)
+	Slots int
+	// Size is the number of entries of this process (required)
+	Size int
+	// Open creates an entry once a slot is held (required)
+	Open func(ctx context.Context) (*T, error)
+	// Close cleans up a removed entry (optional)
+	Close func(e *T) error
+	// PollInterval is the interval of checking for a free slot while all are held (default 10ms)
+	PollInterval time.Duration
+}
+
+// Entry is a pooled entry, Value is nil until the entry is opened
+type Entry[T any] struct {
+	Value *T
+	token *Token
+}
+
+// Pool is a pool whose entries hold slots of a Semaphore
+type Pool[T any] struct {
+	sem   *Semaphore
+	open  func(ctx context.Context) (*T, error)
+	close func(e *T) error
+	pool  *pool.Pool[Entry[T]]
+}
+
+// Creates a pool sharing the o.Slots slots in o.Dir with other processes. Removed entries are closed
+// using o.Close and give up their slot, a destroyer passed in opts must free the slot itself.
+func New[T any](o Options[T], opts ...pool.Option) (*Pool[T], error) {
+	if o.Open == nil {
+		return nil, errors.New("flockpool: missing open function")
+	}
+	sem, err := OpenSemaphore(o.Dir, o.Slots)
+	if err != nil {
+		return nil, err
+	}
+	if o.PollInterval > 0 {
+		sem.poll = o.PollInterval
+	}
+	p := &Pool[T]{sem: sem, open: o.Open, close: o.Close}
+	defaults := []pool.Option{pool.WithDestroyerContext(p.destroy)}
+	p.pool, err = pool.NewPoolFromConfig(pool.Config{Size: o.Size}, func() *Entry[T] { return &Entry[T]{} }, append(defaults, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// destroy closes opened entries and frees their slot
+func (p *Pool[T]) destroy(_ context.Context, e *Entry[T]) error {
+	if e.token == nil {
+		return nil
+	}
+	var err error
+	if p.close != nil && e.Value != nil {
+		err = p.close(e.Value)
+	}
+	return errors.Join(err, e.token.Release())
+}
+
+// Returns the pool of this process, its size limits the slots this process takes at most
+func (p *Pool[T]) Pool() *pool.Pool[Entry[T]] {
+	return p.pool
+}
+
+// Returns the semaphore shared with the other processes
+func (p *Pool[T]) Semaphore() *Semaphore {
+	return p.sem
+}
+
+// Acquire acquires an entry, opening it once a slot is free if necessary, waiting until ctx is done
+func (p *Pool[T]) Acquire(ctx context.Context) (*Entry[T], error) {
+	return adapter.Acquire(ctx, p.pool, hasSlot[T], p.openEntry)
+}
+
+// hasSlot reports whether e took a slot and was opened
+func hasSlot[T any](e *Entry[T]) bool {
+	return e.token != nil
+}
+
+// openEntry takes a slot for e and opens it
+func (p *Pool[T]) openEntry(ctx context.Context, e *Entry[T]) error {
+	t, err := p.sem.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	v, err := p.open(ctx)
+	if err != nil {
+		t.Release()
+		return err
+	}
+	e.Value, e.token = v, t
+	return nil
+}
+
+// Releases an entry to the pool
+func (p *Pool[T]) Release(e *Entry[T]) error {
+	return p.pool.Release(e)
+}
+
+// Discard closes a broken entry and frees its slot for any process, the next acquire
+// of the entry takes a slot again
+func (p *Pool[T]) Discard(e *Entry[T]) error {
+	return p.pool.Replace(e)
+}
+
+// Close closes the pool and all idle entries, freeing their slots
+func (p *Pool[T]) Close() error {
+	return p.pool.Close()
+}
@@ -0,0 +1,115 @@
+package flockpool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type conn struct {
+	closed atomic.Bool
+}
+
+func newTestPool(t *testing.T, dir string) *Pool[conn] {
+	p, err := New(Options[conn]{
+		Dir:          dir,
+		Slots:        2,
+		Size:         2,
+		Open:         func(ctx context.Context) (*conn, error) { return &conn{}, nil },
+		Close:        func(c *conn) error { c.closed.Store(true); return nil },
+		PollInterval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func TestSharedSlots(t *testing.T) {
+	dir := t.TempDir()
+	a, b := newTestPool(t, dir), newTestPool(t, dir)
+	ctx := context.Background()
+
+	e1, err := a.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	e2, _ := a.Acquire(ctx)
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := b.Acquire(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the other pool to wait for a slot but got %v", err)
+	}
+	// released entries keep their slot
+	a.Release(e1)
+	if _, err := b.Semaphore().TryAcquire(); !errors.Is(err, ErrNoSlot) {
+		t.Errorf("expected %v but got %v", ErrNoSlot, err)
+	}
+
+	got := make(chan error, 1)
+	go func() {
+		e, err := b.Acquire(ctx)
+		if err == nil {
+			b.Release(e)
+		}
+		got <- err
+	}()
+	a.Discard(e2)
+	if err := <-got; err != nil {
+		t.Errorf("expected the freed slot to be taken but got %v", err)
+	}
+	if !e2.Value.closed.Load() {
+		t.Errorf("expected the discarded entry to be closed")
+	}
+	if a.Semaphore().Held() != 1 || b.Semaphore().Held() != 1 {
+		t.Errorf("expected every pool to hold 1 slot but got %d and %d", a.Semaphore().Held(), b.Semaphore().Held())
+	}
+}
+
+func TestTokenRelease(t *testing.T) {
+	sem, err := OpenSemaphore(t.TempDir(), 1)
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	tok, err := sem.TryAcquire()
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	if _, err := sem.TryAcquire(); !errors.Is(err, ErrNoSlot) {
+		t.Errorf("expected %v but got %v", ErrNoSlot, err)
+	}
+	tok.Release()
+	tok.Release()
+	if tok, err = sem.TryAcquire(); err != nil || tok.Slot() != 0 {
+		t.Errorf("expected the released slot but got %v", err)
+	}
+}
+
+func TestNilContext(t *testing.T) {
+	p := newTestPool(t, t.TempDir())
+	e, err := p.Acquire(nil)
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	p.Release(e)
+
+	sem, err := OpenSemaphore(t.TempDir(), 1)
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	sem.poll = time.Millisecond
+	tok, _ := sem.TryAcquire()
+	got := make(chan error, 1)
+	go func() {
+		_, err := sem.Acquire(nil)
+		got <- err
+	}()
+	time.Sleep(5 * time.Millisecond)
+	tok.Release()
+	if err := <-got; err != nil {
+		t.Errorf("expected the released slot but got %v", err)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package flockpool
+
+import "os"
+
+// tryLock isn't supported without flock
+func tryLock(f *os.File) (bool, error) {
+	return false, ErrUnsupported
+}
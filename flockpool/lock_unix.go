@@ -0,0 +1,18 @@
+//go:build unix
+
+package flockpool
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// tryLock locks f exclusively without blocking, false is returned if it's locked by another file
+func tryLock(f *os.File) (bool, error) {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, err
+}
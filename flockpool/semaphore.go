@@ -0,0 +1,135 @@
+package flockpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNoSlot is returned by TryAcquire if all slots are held
+	ErrNoSlot = errors.New("flockpool: all slots are held")
+	// ErrUnsupported is returned on platforms without file locks
+	ErrUnsupported = errors.New("flockpool: file locks aren't supported on this platform")
+)
+
+// default interval of polling for a free slot
+const defaultPollInterval = 10 * time.Millisecond
+
+// Semaphore is a counting semaphore shared by all processes on a host that open it with the same directory.
+// Every slot is a lock file in the directory, a slot is held while its file is locked. Locks are released
+// by the operating system once their process exits, so crashed processes don't leak slots.
+type Semaphore struct {
+	dir   string
+	slots int
+	// interval of polling for a free slot in Acquire
+	poll time.Duration
+
+	mux sync.Mutex
+	// slots held by this process
+	held map[int]*Token
+}
+
+// Token is a held slot of a Semaphore
+type Token struct {
+	sem  *Semaphore
+	slot int
+	f    *os.File
+	once sync.Once
+}
+
+// OpenSemaphore opens the semaphore of slots slots in dir, creating the directory if necessary. All
+// processes sharing the semaphore must use the same number of slots.
+func OpenSemaphore(dir string, slots int) (*Semaphore, error) {
+	if slots <= 0 {
+		return nil, fmt.Errorf("flockpool: %d slots", slots)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Semaphore{dir: dir, slots: slots, poll: defaultPollInterval, held: make(map[int]*Token)}, nil
+}
+
+// Returns the number of slots
+func (s *Semaphore) Slots() int {
+	return s.slots
+}
+
+// Returns the number of slots held by this process
+func (s *Semaphore) Held() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return len(s.held)
+}
+
+// TryAcquire takes a free slot without blocking, ErrNoSlot is returned if all slots are held
+func (s *Semaphore) TryAcquire() (*Token, error) {
+	for i := range s.slots {
+		s.mux.Lock()
+		_, held := s.held[i]
+		s.mux.Unlock()
+		if held {
+			continue
+		}
+		f, err := os.OpenFile(filepath.Join(s.dir, fmt.Sprintf("slot-%d.lock", i)), os.O_CREATE|os.O_RDWR, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := tryLock(f)
+		if err != nil || !ok {
+			f.Close()
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		t := &Token{sem: s, slot: i, f: f}
+		s.mux.Lock()
+		s.held[i] = t
+		s.mux.Unlock()
+		return t, nil
+	}
+	return nil, ErrNoSlot
+}
+
+// Acquire takes a free slot, polling until one is free or ctx is done (waiting forever if ctx is nil)
+func (s *Semaphore) Acquire(ctx context.Context) (*Token, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for {
+		t, err := s.TryAcquire()
+		if !errors.Is(err, ErrNoSlot) {
+			return t, err
+		}
+		timer := time.NewTimer(s.poll)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Returns the index of the slot
+func (t *Token) Slot() int {
+	return t.slot
+}
+
+// Release frees the slot, releasing a token more than once has no effect
+func (t *Token) Release() error {
+	var err error
+	t.once.Do(func() {
+		t.sem.mux.Lock()
+		delete(t.sem.held, t.slot)
+		t.sem.mux.Unlock()
+		// closing the file releases the lock
+		err = t.f.Close()
+	})
+	return err
+}
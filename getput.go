@@ -0,0 +1,22 @@
+package pool
+
+import "context"
+
+// GetPutPool is the Get/Put naming most Go pool libraries use (e.g. fatih/pool), code written against it can
+// switch to a Pool without rewriting its call sites
+type GetPutPool[T any] interface {
+	Get(ctx context.Context) (*T, error)
+	Put(v *T) error
+}
+
+var _ GetPutPool[any] = &Pool[any]{}
+
+// Get acquires an entry like AcquireWithContext
+func (p *Pool[T]) Get(ctx context.Context) (*T, error) {
+	return p.AcquireWithContext(ctx)
+}
+
+// Put releases an entry like Release
+func (p *Pool[T]) Put(v *T) error {
+	return p.Release(v)
+}
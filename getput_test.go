@@ -0,0 +1,21 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestGetPut(t *testing.T) {
+	var pool GetPutPool[poolItem] = NewPool(1, poolFactory)
+	e, err := pool.Get(context.Background())
+	if err != nil {
+		t.Fatalf("expected an entry but got %v", err)
+	}
+	if err := pool.Put(e); err != nil {
+		t.Errorf("expected no error but got %v", err)
+	}
+	if err := pool.Put(nil); !errors.Is(err, ErrNilEntry) {
+		t.Errorf("expected %v but got %v", ErrNilEntry, err)
+	}
+}
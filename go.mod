@@ -1,5 +1,8 @@
 module github.com/epikur-io/go-pool
 
-go 1.22.3
+go 1.24
 
-require github.com/epikur-io/gopher-lua v1.2.1
+require (
+	github.com/epikur-io/gopher-lua v1.2.1
+	golang.org/x/sync v0.11.0
+)
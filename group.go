@@ -0,0 +1,32 @@
+package pool
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// RunGroup runs fn n times concurrently, each invocation holding an entry of the pool.
+// At most Cap() invocations run at the same time, the first error cancels the context
+// passed to the other invocations and is returned once all of them returned.
+func (p *Pool[T]) RunGroup(ctx context.Context, n int, fn func(ctx context.Context, e *T) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(max(p.Cap(), 1))
+	for i := 0; i < n; i++ {
+		if ctx.Err() != nil {
+			break
+		}
+		g.Go(func() error {
+			e, err := p.AcquireWithContext(ctx)
+			if err != nil {
+				return err
+			}
+			defer p.Release(e)
+			return fn(ctx, e)
+		})
+	}
+	return g.Wait()
+}
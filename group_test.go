@@ -0,0 +1,52 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRunGroup(t *testing.T) {
+	pool := NewPool(4, poolFactory)
+	var runs, running, maxRunning atomic.Int64
+	err := pool.RunGroup(context.Background(), 1000, func(ctx context.Context, e *poolItem) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		runs.Add(1)
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	if runs.Load() != 1000 || maxRunning.Load() > 4 {
+		t.Errorf("expected 1000 runs with at most 4 concurrent but got %d with %d", runs.Load(), maxRunning.Load())
+	}
+	if stats := pool.Stats(); stats.InUse != 0 {
+		t.Errorf("expected all entries to be released but got %d in use", stats.InUse)
+	}
+}
+
+func TestRunGroupError(t *testing.T) {
+	pool := NewPool(2, poolFactory)
+	errFailed := errors.New("failed")
+	var runs atomic.Int64
+	err := pool.RunGroup(context.Background(), 1000, func(ctx context.Context, e *poolItem) error {
+		if runs.Add(1) == 10 {
+			return errFailed
+		}
+		return ctx.Err()
+	})
+	if err != errFailed {
+		t.Errorf("expected %v but got %v", errFailed, err)
+	}
+	if runs.Load() >= 1000 {
+		t.Errorf("expected remaining runs to be cancelled")
+	}
+}
@@ -0,0 +1,183 @@
+// Package grpcconn pools *grpc.ClientConn connections using grpcpool, checking their health using the gRPC
+// health checking protocol and rebalancing them once the resolver reports new addresses:
+//
+//	p, err := grpcconn.New(grpcconn.Options{
+//		Target:      "dns:///backend:443",
+//		DialOptions: []grpc.DialOption{grpc.WithTransportCredentials(creds)},
+//		Conns:       4,
+//	})
+//
+// It's a module of its own, so grpc isn't a dependency of the pool module.
+package grpcconn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/resolver"
+
+	"github.com/epikur-io/go-pool"
+	"github.com/epikur-io/go-pool/grpcpool"
+)
+
+// ErrNotServing is returned by the health check for servers not reporting SERVING
+var ErrNotServing = errors.New("grpcconn: not serving")
+
+// Options of a pool of grpc connections
+type Options struct {
+	// Target is the target of the connections (required), see grpc.NewClient
+	Target      string
+	DialOptions []grpc.DialOption
+	// Resolver resolves Target (the resolver registered for the scheme of Target by default), the pool
+	// watches the addresses it reports
+	Resolver resolver.Builder
+	// Conns and MaxStreams are passed to grpcpool
+	Conns      int
+	MaxStreams int
+	// Service is the service checked using the health checking protocol ("" checks the server)
+	Service string
+	// NoHealthCheck disables the health checks (e.g. for servers without the health service)
+	NoHealthCheck  bool
+	HealthInterval time.Duration
+	HealthTimeout  time.Duration
+}
+
+// Pool is a pool of grpc connections
+type Pool struct {
+	*grpcpool.Pool[*grpc.ClientConn]
+	// sorted addresses last reported by the resolver
+	addrs      atomic.Pointer[string]
+	rebalances atomic.Uint64
+
+	mux    sync.Mutex
+	closed bool
+	// running rebalances
+	wg sync.WaitGroup
+}
+
+// Creates a pool of connections to o.Target, opts configure the underlying pool of slots (see grpcpool.New)
+func New(o Options, opts ...pool.Option) (*Pool, error) {
+	if o.Target == "" {
+		return nil, errors.New("grpcconn: missing target")
+	}
+	b := o.Resolver
+	if b == nil {
+		scheme := resolver.GetDefaultScheme()
+		if u, err := url.Parse(o.Target); err == nil && resolver.Get(u.Scheme) != nil {
+			scheme = u.Scheme
+		}
+		if b = resolver.Get(scheme); b == nil {
+			return nil, fmt.Errorf("grpcconn: no resolver for the scheme %q", scheme)
+		}
+	}
+	p := &Pool{}
+	dialOpts := append(slices.Clone(o.DialOptions), grpc.WithResolvers(&watchBuilder{Builder: b, p: p}))
+	gopts := grpcpool.Options[*grpc.ClientConn]{
+		Dial: func(context.Context) (*grpc.ClientConn, error) {
+			return grpc.NewClient(o.Target, dialOpts...)
+		},
+		Conns:          o.Conns,
+		MaxStreams:     o.MaxStreams,
+		HealthInterval: o.HealthInterval,
+		HealthTimeout:  o.HealthTimeout,
+	}
+	if !o.NoHealthCheck {
+		gopts.HealthCheck = HealthCheck(o.Service)
+	}
+	conns, err := grpcpool.New(gopts, opts...)
+	if err != nil {
+		return nil, err
+	}
+	p.Pool = conns
+	return p, nil
+}
+
+// HealthCheck returns a health check (see grpcpool.Options) asking the health service of the server
+// whether service is serving ("" asks for the server)
+func HealthCheck(service string) func(ctx context.Context, c *grpc.ClientConn) error {
+	return func(ctx context.Context, c *grpc.ClientConn) error {
+		resp, err := healthpb.NewHealthClient(c).Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			return err
+		}
+		if resp.Status != healthpb.HealthCheckResponse_SERVING {
+			return fmt.Errorf("%w: %v", ErrNotServing, resp.Status)
+		}
+		return nil
+	}
+}
+
+// Returns the number of rebalances caused by the resolver reporting new addresses
+func (p *Pool) Rebalances() uint64 {
+	return p.rebalances.Load()
+}
+
+// Close closes the pool and all connections once running rebalances are done
+func (p *Pool) Close() error {
+	p.mux.Lock()
+	p.closed = true
+	p.mux.Unlock()
+	p.wg.Wait()
+	return p.Pool.Close()
+}
+
+// observe rebalances the connections if the resolver reported other addresses than before, all connections
+// report the same addresses so only the first one reporting a change rebalances
+func (p *Pool) observe(s resolver.State) {
+	var addrs []string
+	for _, a := range s.Addresses {
+		addrs = append(addrs, a.Addr)
+	}
+	for _, e := range s.Endpoints {
+		for _, a := range e.Addresses {
+			addrs = append(addrs, a.Addr)
+		}
+	}
+	slices.Sort(addrs)
+	key := strings.Join(slices.Compact(addrs), ",")
+	if prev := p.addrs.Swap(&key); prev == nil || *prev == key {
+		return
+	}
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if p.closed {
+		return
+	}
+	p.rebalances.Add(1)
+	// the resolver of the connection calls observe, closing the connection has to wait until it returned
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.Rebalance(context.Background())
+	}()
+}
+
+// watchBuilder builds the resolvers of the connections, reporting their updates to the pool
+type watchBuilder struct {
+	resolver.Builder
+	p *Pool
+}
+
+func (b *watchBuilder) Build(t resolver.Target, cc resolver.ClientConn, o resolver.BuildOptions) (resolver.Resolver, error) {
+	return b.Builder.Build(t, &watchConn{ClientConn: cc, p: b.p}, o)
+}
+
+// watchConn passes the updates of a resolver to the connection and the pool
+type watchConn struct {
+	resolver.ClientConn
+	p *Pool
+}
+
+func (c *watchConn) UpdateState(s resolver.State) error {
+	c.p.observe(s)
+	return c.ClientConn.UpdateState(s)
+}
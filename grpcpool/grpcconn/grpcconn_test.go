@@ -0,0 +1,128 @@
+package grpcconn
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+// newServer starts a grpc server serving the health service
+func newServer(t *testing.T) (string, *health.Server) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	hs := health.NewServer()
+	s := grpc.NewServer()
+	healthpb.RegisterHealthServer(s, hs)
+	go s.Serve(ln)
+	t.Cleanup(s.Stop)
+	return ln.Addr().String(), hs
+}
+
+func TestHealthCheck(t *testing.T) {
+	addr, hs := newServer(t)
+	p, err := New(Options{
+		Target:         "passthrough:///" + addr,
+		DialOptions:    []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())},
+		Service:        "echo",
+		HealthInterval: time.Nanosecond,
+	})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	defer p.Close()
+	ctx := context.Background()
+
+	hs.SetServingStatus("echo", healthpb.HealthCheckResponse_SERVING)
+	s, err := p.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	first := s.Conn()
+	if err := HealthCheck("echo")(ctx, first); err != nil {
+		t.Errorf("expected the service to be serving but got %v", err)
+	}
+	p.Release(s)
+
+	// the connection fails the health check on the next acquire and gets replaced
+	hs.SetServingStatus("echo", healthpb.HealthCheckResponse_NOT_SERVING)
+	if err := HealthCheck("echo")(ctx, first); !errors.Is(err, ErrNotServing) {
+		t.Errorf("expected %v but got %v", ErrNotServing, err)
+	}
+	s, err = p.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	if s.Conn() == first {
+		t.Errorf("expected the connection failing the health check to be replaced")
+	}
+	p.Release(s)
+}
+
+func TestRebalance(t *testing.T) {
+	a, _ := newServer(t)
+	b, _ := newServer(t)
+	r := manual.NewBuilderWithScheme("grpcconn")
+	r.InitialState(resolver.State{Addresses: []resolver.Address{{Addr: a}}})
+	p, err := New(Options{
+		Target:        "grpcconn:///backend",
+		DialOptions:   []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())},
+		Resolver:      r,
+		NoHealthCheck: true,
+	})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	defer p.Close()
+	ctx := context.Background()
+
+	s, err := p.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	first := s.Conn()
+	// connect, which builds the resolver
+	if err := HealthCheck("")(ctx, first); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	p.Release(s)
+	if n := p.Rebalances(); n != 0 {
+		t.Errorf("expected no rebalance for the initial addresses but got %d", n)
+	}
+
+	r.UpdateState(resolver.State{Addresses: []resolver.Address{{Addr: a}, {Addr: b}}})
+	if n := p.Rebalances(); n != 1 {
+		t.Fatalf("expected a rebalance for the new addresses but got %d", n)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s, err := p.Acquire(ctx)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		replaced := s.Conn() != first
+		p.Release(s)
+		if replaced {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the connection to be replaced after the rebalance")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// the same addresses don't rebalance again
+	r.UpdateState(resolver.State{Addresses: []resolver.Address{{Addr: b}, {Addr: a}}})
+	if n := p.Rebalances(); n != 1 {
+		t.Errorf("expected no rebalance for the same addresses but got %d", n)
+	}
+}
@@ -0,0 +1,352 @@
+// Package grpcpool pools client connections that multiplex concurrent streams (e.g. *grpc.ClientConn)
+//
+// Unlike entries of a pool.Pool, connections are shared: every connection can be used by up to
+// MaxStreams callers at the same time, callers acquire a Slot on the least loaded connection.
+// The package doesn't depend on grpc, the grpcconn module pools *grpc.ClientConn connections using the gRPC
+// health checking protocol and rebalances them once the resolver reports new addresses. Other health checks
+// are plugged in using Options.HealthCheck:
+//
+//	p, err := grpcpool.New(grpcpool.Options[*grpc.ClientConn]{
+//		Dial: func(ctx context.Context) (*grpc.ClientConn, error) {
+//			return grpc.NewClient(target, grpc.WithTransportCredentials(creds))
+//		},
+//		Conns:      4,
+//		MaxStreams: 100,
+//		HealthCheck: func(ctx context.Context, c *grpc.ClientConn) error {
+//			resp, err := healthpb.NewHealthClient(c).Check(ctx, &healthpb.HealthCheckRequest{})
+//			if err == nil && resp.Status != healthpb.HealthCheckResponse_SERVING {
+//				err = fmt.Errorf("status %v", resp.Status)
+//			}
+//			return err
+//		},
+//	})
+package grpcpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/epikur-io/go-pool"
+)
+
+const (
+	defaultMaxStreams     = 100
+	defaultHealthInterval = 10 * time.Second
+	defaultHealthTimeout  = time.Second
+)
+
+// Conn is a client connection, *grpc.ClientConn implements it
+type Conn interface {
+	Close() error
+}
+
+// Options of a connection pool
+type Options[C Conn] struct {
+	// Dial creates a new connection (required)
+	Dial func(ctx context.Context) (C, error)
+	// Conns is the number of connections (default 1)
+	Conns int
+	// MaxStreams is the number of concurrent streams per connection (default 100)
+	MaxStreams int
+	// HealthCheck checks connections before they are used, at most once per HealthInterval (optional)
+	HealthCheck    func(ctx context.Context, c C) error
+	HealthInterval time.Duration
+	HealthTimeout  time.Duration
+}
+
+// Pool is a pool of shared client connections
+type Pool[C Conn] struct {
+	opts  Options[C]
+	slots *pool.Pool[Slot[C]]
+
+	mux sync.Mutex
+	// connections new slots get assigned to
+	conns []*conn[C]
+	// incremented on every Rebalance
+	generation uint64
+	closed     bool
+	// number of connections being dialed outside of mux, dialed is closed and replaced once one is done
+	dialing int
+	dialed  chan struct{}
+}
+
+// conn is a shared connection
+type conn[C Conn] struct {
+	conn C
+	// number of slots assigned to the connection, guarded by Pool.mux
+	refs       int
+	generation uint64
+	broken     atomic.Bool
+	// unix nanoseconds of the last health check
+	checkedAt atomic.Int64
+	checkMux  sync.Mutex
+}
+
+// Slot is a stream reservation on a connection
+type Slot[C Conn] struct {
+	conn *conn[C]
+}
+
+// Returns the connection of the slot
+func (s *Slot[C]) Conn() C {
+	return s.conn.conn
+}
+
+// Creates a new connection pool, opts configure the underlying pool of slots
+func New[C Conn](o Options[C], opts ...pool.Option) (*Pool[C], error) {
+	if o.Dial == nil {
+		return nil, errors.New("grpcpool: missing dial function")
+	}
+	if o.Conns <= 0 {
+		o.Conns = 1
+	}
+	if o.MaxStreams <= 0 {
+		o.MaxStreams = defaultMaxStreams
+	}
+	if o.HealthInterval <= 0 {
+		o.HealthInterval = defaultHealthInterval
+	}
+	if o.HealthTimeout <= 0 {
+		o.HealthTimeout = defaultHealthTimeout
+	}
+	p := &Pool[C]{opts: o, dialed: make(chan struct{})}
+	defaults := []pool.Option{
+		// slots are assigned to connections on acquire
+		pool.WithMinSize(0),
+		pool.WithValidator(p.valid),
+		pool.WithReset(p.reset),
+		pool.WithDestroyer(p.unassign),
+	}
+	slots, err := pool.NewPoolFromConfig(pool.Config{Size: o.Conns * o.MaxStreams},
+		func() *Slot[C] { return &Slot[C]{} }, append(defaults, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	p.slots = slots
+	return p, nil
+}
+
+// Returns the underlying pool of slots (e.g. for stats)
+func (p *Pool[C]) Slots() *pool.Pool[Slot[C]] {
+	return p.slots
+}
+
+// Acquire reserves a stream on the least loaded connection, waiting until ctx is done
+func (p *Pool[C]) Acquire(ctx context.Context) (*Slot[C], error) {
+	s, err := p.slots.AcquireWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if s.conn == nil {
+		if err := p.assign(ctx, s); err != nil {
+			p.slots.Release(s)
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Releases a slot to the pool
+func (p *Pool[C]) Release(s *Slot[C]) error {
+	return p.slots.Release(s)
+}
+
+// Discard marks the connection of the slot as broken, it gets closed once all of its slots are released
+func (p *Pool[C]) Discard(s *Slot[C]) error {
+	if s.conn != nil {
+		s.conn.broken.Store(true)
+		p.mux.Lock()
+		p.remove(s.conn)
+		p.mux.Unlock()
+	}
+	return p.slots.Replace(s)
+}
+
+// Run acquires a slot and runs fn with its connection
+func (p *Pool[C]) Run(ctx context.Context, fn func(ctx context.Context, c C) error) error {
+	s, err := p.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer p.Release(s)
+	return fn(ctx, s.Conn())
+}
+
+// Rebalance replaces all connections (e.g. after the resolver reported new addresses),
+// connections in use are closed once all of their slots are released
+func (p *Pool[C]) Rebalance(ctx context.Context) error {
+	p.mux.Lock()
+	p.generation++
+	for _, c := range p.conns {
+		if c.refs == 0 {
+			c.conn.Close()
+		}
+	}
+	p.conns = nil
+	p.mux.Unlock()
+	return p.slots.RefreshAll(ctx)
+}
+
+// Close closes the pool and all connections, connections in use get closed once released
+func (p *Pool[C]) Close() error {
+	p.mux.Lock()
+	p.closed = true
+	p.mux.Unlock()
+	p.slots.Close()
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	var errs []error
+	for _, c := range p.conns {
+		if c.refs == 0 {
+			errs = append(errs, c.conn.Close())
+		}
+	}
+	p.conns = nil
+	return errors.Join(errs...)
+}
+
+// assign assigns s to the least loaded connection, dialing a new one if all are busy. Connections are
+// dialed without holding p.mux, the dial is reserved first so concurrent assigns don't exceed Conns.
+func (p *Pool[C]) assign(ctx context.Context, s *Slot[C]) error {
+	for {
+		p.mux.Lock()
+		var least *conn[C]
+		for _, c := range p.conns {
+			if c.refs < p.opts.MaxStreams && (least == nil || c.refs < least.refs) {
+				least = c
+			}
+		}
+		room := len(p.conns)+p.dialing < p.opts.Conns
+		if least != nil && (least.refs == 0 || !room) {
+			least.refs++
+			s.conn = least
+			p.mux.Unlock()
+			return nil
+		}
+		if least != nil || room || p.dialing == 0 {
+			break
+		}
+		// all connections are busy and the pending dials use up the remaining ones
+		dialed := p.dialed
+		p.mux.Unlock()
+		select {
+		case <-dialed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	p.dialing++
+	generation := p.generation
+	p.mux.Unlock()
+
+	cc, err := p.opts.Dial(ctx)
+
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.dialing--
+	close(p.dialed)
+	p.dialed = make(chan struct{})
+	if err != nil {
+		return err
+	}
+	if p.closed {
+		cc.Close()
+		return pool.ErrPoolClosed
+	}
+	c := &conn[C]{conn: cc, generation: generation, refs: 1}
+	c.checkedAt.Store(time.Now().UnixNano())
+	// a connection dialed before a Rebalance only serves s and gets closed once s is released
+	if generation == p.generation {
+		p.conns = append(p.conns, c)
+	}
+	s.conn = c
+	return nil
+}
+
+// unassign removes s from its connection, closing the connection if it's not used anymore
+func (p *Pool[C]) unassign(s *Slot[C]) {
+	c := s.conn
+	if c == nil {
+		return
+	}
+	s.conn = nil
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	c.refs--
+	if c.refs > 0 {
+		return
+	}
+	if p.closed || c.broken.Load() || c.generation != p.generation {
+		p.remove(c)
+		c.conn.Close()
+	}
+}
+
+// remove removes c from the connections new slots get assigned to, the caller must hold p.mux
+func (p *Pool[C]) remove(c *conn[C]) {
+	for i, e := range p.conns {
+		if e == c {
+			p.conns = append(p.conns[:i], p.conns[i+1:]...)
+			return
+		}
+	}
+}
+
+// errStale is returned by reset for slots of connections that shouldn't be used anymore
+var errStale = errors.New("stale connection")
+
+// reset removes released slots of broken or replaced connections from the pool
+func (p *Pool[C]) reset(_ context.Context, s *Slot[C]) error {
+	if p.stale(s) {
+		return errStale
+	}
+	return nil
+}
+
+// stale reports whether s belongs to a broken or replaced connection
+func (p *Pool[C]) stale(s *Slot[C]) bool {
+	c := s.conn
+	if c == nil {
+		return false
+	}
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return c.generation != p.generation || c.broken.Load()
+}
+
+// valid reports whether the connection of s can still be used
+func (p *Pool[C]) valid(s *Slot[C]) bool {
+	if s.conn == nil {
+		return true
+	}
+	return !p.stale(s) && p.healthy(s.conn)
+}
+
+// healthy checks the health of c if the last check is older than the health interval
+func (p *Pool[C]) healthy(c *conn[C]) bool {
+	if p.opts.HealthCheck == nil {
+		return true
+	}
+	if time.Now().UnixNano()-c.checkedAt.Load() < int64(p.opts.HealthInterval) {
+		return !c.broken.Load()
+	}
+	c.checkMux.Lock()
+	defer c.checkMux.Unlock()
+	// checked concurrently
+	if time.Now().UnixNano()-c.checkedAt.Load() < int64(p.opts.HealthInterval) {
+		return !c.broken.Load()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), p.opts.HealthTimeout)
+	defer cancel()
+	if err := p.opts.HealthCheck(ctx, c.conn); err != nil {
+		c.broken.Store(true)
+		p.mux.Lock()
+		p.remove(c)
+		p.mux.Unlock()
+	}
+	c.checkedAt.Store(time.Now().UnixNano())
+	return !c.broken.Load()
+}
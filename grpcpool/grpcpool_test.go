@@ -0,0 +1,162 @@
+package grpcpool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testConn struct {
+	id     int
+	closed atomic.Bool
+}
+
+func (c *testConn) Close() error {
+	c.closed.Store(true)
+	return nil
+}
+
+func newTestPool(t *testing.T, o Options[*testConn]) (*Pool[*testConn], *[]*testConn) {
+	var dialed []*testConn
+	o.Dial = func(ctx context.Context) (*testConn, error) {
+		c := &testConn{id: len(dialed)}
+		dialed = append(dialed, c)
+		return c, nil
+	}
+	p, err := New(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, &dialed
+}
+
+func TestStreams(t *testing.T) {
+	p, dialed := newTestPool(t, Options[*testConn]{Conns: 2, MaxStreams: 2})
+	ctx := context.Background()
+
+	var slots []*Slot[*testConn]
+	perConn := map[*testConn]int{}
+	for range 4 {
+		s, err := p.Acquire(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		perConn[s.Conn()]++
+		slots = append(slots, s)
+	}
+	if len(*dialed) != 2 || perConn[(*dialed)[0]] != 2 || perConn[(*dialed)[1]] != 2 {
+		t.Errorf("expected 2 streams on each of 2 connections but got %v", perConn)
+	}
+
+	tctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Acquire(tctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v but got %v", context.DeadlineExceeded, err)
+	}
+
+	for _, s := range slots {
+		p.Release(s)
+	}
+	p.Close()
+	for _, c := range *dialed {
+		if !c.closed.Load() {
+			t.Errorf("expected connection %d to be closed", c.id)
+		}
+	}
+}
+
+func TestRebalance(t *testing.T) {
+	p, dialed := newTestPool(t, Options[*testConn]{Conns: 1, MaxStreams: 2})
+	defer p.Close()
+	ctx := context.Background()
+
+	inUse, _ := p.Acquire(ctx)
+	idle, _ := p.Acquire(ctx)
+	p.Release(idle)
+	old := inUse.Conn()
+
+	if err := p.Rebalance(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s, _ := p.Acquire(ctx)
+	if s.Conn() == old || len(*dialed) != 2 {
+		t.Errorf("expected a new connection after rebalancing")
+	}
+	if old.closed.Load() {
+		t.Errorf("expected the connection in use not to be closed")
+	}
+	p.Release(inUse)
+	if !old.closed.Load() {
+		t.Errorf("expected the old connection to be closed once released")
+	}
+	p.Release(s)
+}
+
+func TestHealthCheck(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	p, dialed := newTestPool(t, Options[*testConn]{
+		MaxStreams:     1,
+		HealthInterval: time.Nanosecond,
+		HealthCheck: func(ctx context.Context, c *testConn) error {
+			if !healthy.Load() {
+				return errors.New("not serving")
+			}
+			return nil
+		},
+	})
+	defer p.Close()
+	ctx := context.Background()
+
+	s, _ := p.Acquire(ctx)
+	p.Release(s)
+	healthy.Store(false)
+	s, _ = p.Acquire(ctx)
+	p.Release(s)
+	if len(*dialed) != 2 || !(*dialed)[0].closed.Load() {
+		t.Errorf("expected the unhealthy connection to be replaced")
+	}
+}
+
+func TestDialUnlocked(t *testing.T) {
+	var dials atomic.Int32
+	block := make(chan struct{})
+	p, err := New(Options[*testConn]{
+		Dial: func(ctx context.Context) (*testConn, error) {
+			if dials.Add(1) == 2 {
+				<-block
+			}
+			return &testConn{}, nil
+		},
+		Conns:      2,
+		MaxStreams: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	ctx := context.Background()
+
+	first, _ := p.Acquire(ctx)
+	dialing := make(chan *Slot[*testConn])
+	go func() {
+		s, _ := p.Acquire(ctx)
+		dialing <- s
+	}()
+	for dials.Load() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	// the pending dial takes the second connection, the acquire shares the first one without waiting
+	tctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	s, err := p.Acquire(tctx)
+	if err != nil || s.Conn() != first.Conn() {
+		t.Errorf("expected a stream on the first connection while dialing but got %v", err)
+	}
+	close(block)
+	if dialed := <-dialing; dialed.Conn() == first.Conn() || dials.Load() != 2 {
+		t.Errorf("expected a stream on the dialed connection")
+	}
+}
@@ -0,0 +1,81 @@
+package pool
+
+import (
+	"context"
+	"errors"
+)
+
+// sweep periodically checks the health of idle entries until the pool gets closed
+func (p *Pool[T]) sweep() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		// abort running checks once the pool gets closed
+		<-p.done
+		cancel()
+	}()
+	for {
+		t := acquireTimer(p.opts.healthInterval)
+		select {
+		case <-p.done:
+			releaseTimer(t)
+			return
+		case <-t.C:
+		}
+		releaseTimer(t)
+		p.checkIdle(ctx)
+	}
+}
+
+// checkIdle checks the health of the current idle entries one by one,
+// unhealthy entries get destroyed and replaced by new ones
+func (p *Pool[T]) checkIdle(ctx context.Context) {
+	for n := p.Len(); n > 0 && ctx.Err() == nil; n-- {
+		v, ok := p.idle.tryGetOldest()
+		if !ok {
+			return
+		}
+		err := p.checkHealth(ctx, v)
+		if ctx.Err() == nil {
+			p.observeCheck(err != nil && !errors.Is(err, ErrDegraded))
+		}
+		if errors.Is(err, ErrDegraded) && ctx.Err() == nil {
+			p.degrade(v)
+			continue
+		}
+		if err != nil && ctx.Err() == nil {
+			p.stats.unhealthy.Add(1)
+			p.reject(v, err)
+			if p.grow() {
+				v = p.newEntry()
+			} else {
+				continue
+			}
+		} else if err == nil {
+			p.setDegraded(p.meta(v), false)
+		}
+		p.put(v)
+	}
+}
+
+// checkHealth runs the health check for v with the configured timeout
+func (p *Pool[T]) checkHealth(ctx context.Context, v *T) error {
+	if p.opts.healthTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.opts.healthTimeout)
+		defer cancel()
+	}
+	return p.healthFunc(ctx, v)
+}
+
+// CheckHealth checks the health of all idle entries immediately using the function set by
+// WithHealthCheck, unhealthy entries get destroyed and replaced by new ones
+func (p *Pool[T]) CheckHealth(ctx context.Context) {
+	if p.healthFunc == nil {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	p.checkIdle(ctx)
+}
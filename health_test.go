@@ -0,0 +1,116 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHealthCheck(t *testing.T) {
+	var mux sync.Mutex
+	dead := map[*poolItem]bool{}
+	pool := NewPool(3, poolFactory, WithHealthCheck(func(ctx context.Context, e *poolItem) error {
+		mux.Lock()
+		defer mux.Unlock()
+		if dead[e] {
+			return errors.New("dead")
+		}
+		return nil
+	}, 5*time.Millisecond, time.Second))
+	defer pool.Close()
+
+	a, b := pool.Acquire(), pool.Acquire()
+	mux.Lock()
+	dead[a] = true
+	dead[b] = true
+	mux.Unlock()
+	pool.Release(a)
+	pool.Release(b)
+
+	deadline := time.Now().Add(time.Second)
+	for pool.Stats().Unhealthy < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	stats := pool.Stats()
+	if stats.Unhealthy != 2 || stats.Created != 5 {
+		t.Errorf("expected 2 unhealthy and 5 created entries but got %+v", stats)
+	}
+	for range 3 {
+		if e := pool.Acquire(); e == a || e == b {
+			t.Errorf("expected dead entries to be replaced")
+		}
+	}
+}
+
+func TestCheckHealthTimeout(t *testing.T) {
+	pool := NewPool(1, poolFactory, WithHealthCheck(func(ctx context.Context, e *poolItem) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, 0, 5*time.Millisecond))
+	pool.CheckHealth(context.Background())
+	if stats := pool.Stats(); stats.Unhealthy != 1 || stats.Idle != 1 {
+		t.Errorf("expected the hanging entry to be replaced but got %+v", stats)
+	}
+}
+
+func TestDegradedEntries(t *testing.T) {
+	var mux sync.Mutex
+	degraded := map[*poolItem]bool{}
+	check := func(ctx context.Context, e *poolItem) error {
+		mux.Lock()
+		defer mux.Unlock()
+		if degraded[e] {
+			return fmt.Errorf("replica lagging: %w", ErrDegraded)
+		}
+		return nil
+	}
+	pool := NewPool(2, poolFactory, WithHealthCheck(check, 0, time.Second))
+	defer pool.Close()
+	a := pool.Acquire()
+	mux.Lock()
+	degraded[a] = true
+	mux.Unlock()
+	pool.Release(a)
+	pool.CheckHealth(context.Background())
+	if stats := pool.Stats(); stats.Degraded != 1 || stats.Healthy != 1 || stats.Idle != 1 || stats.Unhealthy != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if info, _ := pool.EntryInfo(a); info.Health != EntryDegraded {
+		t.Errorf("expected the entry to be degraded but got %v", info.Health)
+	}
+	// the degraded entry is only handed out once no healthy entry is idle
+	if e := pool.Acquire(); e == a {
+		t.Errorf("expected the healthy entry")
+	}
+	if e := pool.Acquire(); e != a {
+		t.Errorf("expected the degraded entry")
+	}
+
+	repaired := make(chan *poolItem, 1)
+	pool = NewPool(1, poolFactory, WithHealthCheck(check, 0, time.Second), WithRepair(func(ctx context.Context, e *poolItem) error {
+		mux.Lock()
+		delete(degraded, e)
+		mux.Unlock()
+		repaired <- e
+		return nil
+	}))
+	defer pool.Close()
+	b := pool.Acquire()
+	mux.Lock()
+	degraded[b] = true
+	mux.Unlock()
+	pool.Release(b)
+	pool.CheckHealth(context.Background())
+	if e := <-repaired; e != b {
+		t.Errorf("expected the degraded entry to be repaired")
+	}
+	if e := pool.Acquire(); e != b {
+		t.Errorf("expected the repaired entry to be put back")
+	}
+	if stats := pool.Stats(); stats.Repaired != 1 || stats.Degraded != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,47 @@
+package pool
+
+import "fmt"
+
+// ErrNotAcquired is returned for entries that aren't acquired from the pool
+var ErrNotAcquired = fmt.Errorf("entry isn't acquired from the pool")
+
+// Hijack takes the acquired entry v over from the pool: it no longer counts as an entry of the pool and
+// doesn't get destroyed, the caller is responsible for cleaning it up. Its slot is refilled right away if
+// acquires are waiting or the pool holds less than its minimum number of entries, otherwise on demand.
+func (p *Pool[T]) Hijack(v *T) error {
+	return p.hijack(v, false)
+}
+
+// endUse marks the entry v of m as no longer in use, false is returned if it isn't in use
+func (p *Pool[T]) endUse(v *T, m *entryMeta) bool {
+	if p.trackUse {
+		return m.inUse.CompareAndSwap(true, false)
+	}
+	// entries that aren't idle are in use, the metadata is gone after the first hijack
+	return !p.idle.contains(v)
+}
+
+// hijack implements Hijack, a replacement for v is created right away if refill is set
+func (p *Pool[T]) hijack(v *T, refill bool) error {
+	if v == nil {
+		return p.wrapErr(opHijack, ErrNilEntry)
+	}
+	if h := p.dropHold(v); h != nil && h.reclaimed {
+		// already abandoned, it's left to the caller as is
+		p.stats.hijacked.Add(1)
+		return nil
+	}
+	m, ok := p.lookup(v)
+	if !ok || !p.endUse(v, m) {
+		return p.wrapErr(opHijack, ErrNotAcquired)
+	}
+	p.stats.hijacked.Add(1)
+	p.stats.inUse.Add(-1)
+	p.abandon(v)
+	p.updateState()
+	if refill && p.grow() {
+		p.put(p.newEntry())
+	}
+	p.refill()
+	return nil
+}
@@ -0,0 +1,59 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestHijack(t *testing.T) {
+	destroyed := 0
+	pool := NewPool(1, poolFactory, WithDestroyer(func(*poolItem) { destroyed++ }))
+	e := pool.Acquire()
+	if err := pool.Hijack(e); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	if err := pool.Hijack(e); !errors.Is(err, ErrNotAcquired) {
+		t.Errorf("expected %v but got %v", ErrNotAcquired, err)
+	}
+	if _, ok := pool.EntryInfo(e); ok {
+		t.Errorf("expected the hijacked entry to leave the pool")
+	}
+	if next := pool.Acquire(); next == e {
+		t.Errorf("expected a new entry")
+	}
+	stats := pool.Stats()
+	if stats.Hijacked != 1 || stats.InUse != 1 || stats.Created != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	pool.Close()
+	if destroyed != 0 {
+		t.Errorf("expected the hijacked entry not to be destroyed but got %d destroyed entries", destroyed)
+	}
+}
+
+func TestLeaseHijack(t *testing.T) {
+	pool := NewPool(2, poolFactory, WithMinSize(0))
+	defer pool.Close()
+	lease, _ := pool.AcquireLease(context.Background())
+	e, err := lease.Hijack(false)
+	if err != nil || e != lease.Value() {
+		t.Fatalf("expected the leased entry but got %v", err)
+	}
+	if idle := pool.Len(); idle != 0 {
+		t.Errorf("expected no idle entry but got %d", idle)
+	}
+	lease, _ = pool.AcquireLease(context.Background())
+	if _, err := lease.Hijack(true); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	if idle := pool.Len(); idle != 1 {
+		t.Errorf("expected the hijacked entry to be replaced but got %d idle entries", idle)
+	}
+	if _, err := lease.Hijack(true); !errors.Is(err, ErrNotAcquired) {
+		t.Errorf("expected %v but got %v", ErrNotAcquired, err)
+	}
+	if stats := pool.Stats(); stats.Hijacked != 2 || stats.InUse != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,191 @@
+package pool
+
+import (
+	"math"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// WithWaitHistory keeps the wait times of the last n acquires in a ring buffer,
+// making them available using Percentile and Snapshot
+func WithWaitHistory(n int) Option {
+	return func(o *options) {
+		o.waitHistory = n
+	}
+}
+
+// WaitSnapshot summarizes the wait times of recent acquires
+type WaitSnapshot struct {
+	// time span covered by the snapshot
+	Window time.Duration `json:"window"`
+	// number of acquires within the window
+	Count int           `json:"count"`
+	Min   time.Duration `json:"min"`
+	Max   time.Duration `json:"max"`
+	Mean  time.Duration `json:"mean"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P99   time.Duration `json:"p99"`
+}
+
+// waitRing holds the most recent acquire wait times, samples are written without locking
+// so a concurrently overwritten sample may be read torn, which is acceptable for statistics
+type waitRing struct {
+	next    atomic.Uint64
+	samples []waitSample
+}
+
+type waitSample struct {
+	// unix nanoseconds, 0 if unused
+	at   atomic.Int64
+	wait atomic.Int64
+}
+
+func newWaitRing(n int) *waitRing {
+	return &waitRing{samples: make([]waitSample, n)}
+}
+
+func (r *waitRing) add(at time.Time, wait time.Duration) {
+	s := &r.samples[(r.next.Add(1)-1)%uint64(len(r.samples))]
+	s.wait.Store(int64(wait))
+	s.at.Store(at.UnixNano())
+}
+
+// waits returns the sorted wait times of acquires since the given unix nanoseconds
+func (r *waitRing) waits(since int64) []time.Duration {
+	waits := make([]time.Duration, 0, len(r.samples))
+	for i := range r.samples {
+		if at := r.samples[i].at.Load(); at != 0 && at >= since {
+			waits = append(waits, time.Duration(r.samples[i].wait.Load()))
+		}
+	}
+	slices.Sort(waits)
+	return waits
+}
+
+// percentile returns the q-th percentile (0-100) of sorted waits using the nearest rank
+func percentile(waits []time.Duration, q float64) time.Duration {
+	if len(waits) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(q / 100 * float64(len(waits))))
+	return waits[min(max(rank, 1), len(waits))-1]
+}
+
+// waitStart returns the start time of an acquire, zero if no wait history is kept
+func (p *Pool[T]) waitStart() time.Time {
+	if p.waits == nil && p.waitHist == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// observeWait records the wait time of an acquire that started at start (see waitStart)
+func (p *Pool[T]) observeWait(start time.Time) {
+	sink := p.opts.metricsSink
+	if p.waits == nil && p.waitHist == nil && sink == nil {
+		return
+	}
+	now := time.Now()
+	if start.IsZero() {
+		start = now
+	}
+	if sink != nil {
+		sink.ObserveAcquireWait(p.opts.name, now.Sub(start))
+	}
+	if p.waits != nil {
+		p.waits.add(now, now.Sub(start))
+	}
+	if p.waitHist != nil {
+		p.waitHist.observe(now.Sub(start))
+	}
+}
+
+// Percentile returns the q-th percentile (0-100) of the wait times of the acquires
+// kept by WithWaitHistory, 0 if no history is kept
+func (p *Pool[T]) Percentile(q float64) time.Duration {
+	if p.waits == nil {
+		return 0
+	}
+	return percentile(p.waits.waits(0), q)
+}
+
+// Snapshot summarizes the wait times of the acquires within the last window kept by WithWaitHistory,
+// the summary is empty if no history is kept
+func (p *Pool[T]) Snapshot(window time.Duration) WaitSnapshot {
+	s := WaitSnapshot{Window: window}
+	if p.waits == nil {
+		return s
+	}
+	waits := p.waits.waits(time.Now().Add(-window).UnixNano())
+	s.Count = len(waits)
+	if s.Count == 0 {
+		return s
+	}
+	var sum time.Duration
+	for _, w := range waits {
+		sum += w
+	}
+	s.Min, s.Max, s.Mean = waits[0], waits[len(waits)-1], sum/time.Duration(len(waits))
+	s.P50, s.P90, s.P99 = percentile(waits, 50), percentile(waits, 90), percentile(waits, 99)
+	return s
+}
+
+// WithWaitBuckets counts the wait times of acquires in buckets with the given upper bounds, the
+// distribution is reported as Stats.Waits (e.g. for a Prometheus histogram)
+func WithWaitBuckets(bounds []time.Duration) Option {
+	return func(o *options) {
+		o.waitBuckets = slices.Clone(bounds)
+		slices.Sort(o.waitBuckets)
+		o.waitBuckets = slices.Compact(o.waitBuckets)
+	}
+}
+
+// WaitHistogram is the distribution of the wait times of acquires
+type WaitHistogram struct {
+	// cumulative buckets, the last one counts all waits
+	Buckets []WaitBucket `json:"buckets"`
+	// number and sum of all wait times
+	Count uint64        `json:"count"`
+	Sum   time.Duration `json:"sum"`
+}
+
+// WaitBucket is a bucket of the wait time distribution
+type WaitBucket struct {
+	// upper bound of the bucket, the last bucket has no bound (math.MaxInt64)
+	UpperBound time.Duration `json:"le"`
+	// number of acquires that waited at most UpperBound, including the ones of the previous buckets
+	Count uint64 `json:"count"`
+}
+
+// waitHistogram counts wait times in buckets
+type waitHistogram struct {
+	bounds []time.Duration
+	// counts per bucket (not cumulative), the last one counts waits exceeding all bounds
+	counts []atomic.Uint64
+	sum    atomic.Int64
+}
+
+func newWaitHistogram(bounds []time.Duration) *waitHistogram {
+	return &waitHistogram{bounds: bounds, counts: make([]atomic.Uint64, len(bounds)+1)}
+}
+
+func (h *waitHistogram) observe(wait time.Duration) {
+	i, _ := slices.BinarySearch(h.bounds, wait)
+	h.counts[i].Add(1)
+	h.sum.Add(int64(wait))
+}
+
+func (h *waitHistogram) snapshot() *WaitHistogram {
+	buckets := make([]WaitBucket, len(h.counts))
+	var total uint64
+	for i := range h.counts {
+		total += h.counts[i].Load()
+		buckets[i] = WaitBucket{UpperBound: math.MaxInt64, Count: total}
+		if i < len(h.bounds) {
+			buckets[i].UpperBound = h.bounds[i]
+		}
+	}
+	return &WaitHistogram{Buckets: buckets, Count: total, Sum: time.Duration(h.sum.Load())}
+}
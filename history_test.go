@@ -0,0 +1,73 @@
+package pool
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	waits := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	for q, want := range map[float64]time.Duration{0: 1, 50: 5, 90: 9, 99: 10, 100: 10} {
+		if got := percentile(waits, q); got != want {
+			t.Errorf("expected percentile %v to be %v but got %v", q, want, got)
+		}
+	}
+}
+
+func TestWaitHistory(t *testing.T) {
+	pool := NewPool(1, poolFactory, WithWaitHistory(4))
+	defer pool.Close()
+
+	if s := pool.Snapshot(time.Minute); s.Count != 0 {
+		t.Errorf("expected empty snapshot but got %+v", s)
+	}
+	v := pool.Acquire()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		pool.Release(v)
+	}()
+	v, _ = pool.AcquireWithTimeout(time.Second)
+	pool.Release(v)
+
+	s := pool.Snapshot(time.Minute)
+	if s.Count != 2 || s.Min != 0 || s.Max < 20*time.Millisecond || s.P99 != s.Max {
+		t.Errorf("expected an immediate and a delayed acquire but got %+v", s)
+	}
+	if p := pool.Percentile(50); p != 0 {
+		t.Errorf("expected median of 0 but got %v", p)
+	}
+	// the ring only keeps the last 4 acquires
+	for i := 0; i < 4; i++ {
+		pool.Release(pool.Acquire())
+	}
+	if s := pool.Snapshot(time.Minute); s.Count != 4 || s.Max >= 20*time.Millisecond {
+		t.Errorf("expected only the last 4 immediate acquires but got %+v", s)
+	}
+}
+
+func TestWaitBuckets(t *testing.T) {
+	pool := NewPool(1, poolFactory, WithWaitBuckets([]time.Duration{time.Second, time.Millisecond, time.Second}))
+	if pool.Stats().Waits.Count != 0 {
+		t.Errorf("expected no waits")
+	}
+	v := pool.Acquire()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		pool.Release(v)
+	}()
+	pool.Release(pool.Acquire())
+
+	waits := pool.Stats().Waits
+	if waits.Count != 2 || waits.Sum < 10*time.Millisecond {
+		t.Fatalf("expected 2 waits of 10ms in total but got %+v", waits)
+	}
+	expected := []WaitBucket{{time.Millisecond, 1}, {time.Second, 2}, {math.MaxInt64, 2}}
+	if !slices.Equal(waits.Buckets, expected) {
+		t.Errorf("expected buckets %v but got %v", expected, waits.Buckets)
+	}
+	if NewPool(1, poolFactory).Stats().Waits != nil {
+		t.Errorf("expected no wait distribution without buckets")
+	}
+}
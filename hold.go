@@ -0,0 +1,118 @@
+package pool
+
+import (
+	"context"
+	"time"
+)
+
+// HoldExpired describes an entry held longer than the time set by WithMaxHoldTime
+type HoldExpired struct {
+	// name of the pool
+	Pool string
+	// time the entry is held so far
+	Held time.Duration
+	// lifecycle information of the entry
+	Info EntryInfo
+	// reports whether the entry was abandoned and its slot replaced (see WithReclaimExpiredHolds)
+	Reclaimed bool
+}
+
+// WithMaxHoldTime sets a function that gets called (in its own goroutine) once an entry is held for longer
+// than d without being released, pointing to leaked checkouts before they starve the pool
+func WithMaxHoldTime(d time.Duration, fn func(HoldExpired)) Option {
+	return func(o *options) {
+		o.maxHoldTime = d
+		o.holdExpiredFunc = fn
+	}
+}
+
+// WithReclaimExpiredHolds makes the pool abandon entries held longer than the max hold time (see WithMaxHoldTime):
+// they no longer count as existing entries so their slot gets refilled, releasing them later destroys them
+func WithReclaimExpiredHolds() Option {
+	return func(o *options) {
+		o.reclaimHolds = true
+	}
+}
+
+// hold is the expiry timer of an acquired entry
+type hold struct {
+	at     time.Time
+	stop   func() bool
+	cancel context.CancelFunc
+	// set once the entry got abandoned, guarded by Pool.holdMux
+	reclaimed bool
+}
+
+// startHold starts the expiry timer of the acquired entry v
+func (p *Pool[T]) startHold(v *T) {
+	d := p.opts.maxHoldTime
+	if d <= 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	h := &hold{at: time.Now(), cancel: cancel}
+	h.stop = context.AfterFunc(ctx, func() {
+		if ctx.Err() == context.DeadlineExceeded {
+			p.holdExpired(v, h)
+		}
+	})
+	p.holdMux.Lock()
+	p.holds[v] = h
+	p.holdMux.Unlock()
+}
+
+// endHold stops the expiry timer of the released entry v, false is returned if v was abandoned
+// and its slot already replaced, v got destroyed then
+func (p *Pool[T]) endHold(v *T) bool {
+	if h := p.dropHold(v); h == nil || !h.reclaimed {
+		return true
+	}
+	p.countDestroyed()
+	p.runDestroyer(v)
+	return false
+}
+
+// dropHold stops the expiry timer of v and returns it, nil if v isn't held
+func (p *Pool[T]) dropHold(v *T) *hold {
+	if p.opts.maxHoldTime <= 0 {
+		return nil
+	}
+	p.holdMux.Lock()
+	h, ok := p.holds[v]
+	delete(p.holds, v)
+	p.holdMux.Unlock()
+	if !ok {
+		return nil
+	}
+	h.stop()
+	h.cancel()
+	return h
+}
+
+// holdExpired reports v as held too long and abandons it if the pool reclaims expired holds
+func (p *Pool[T]) holdExpired(v *T, h *hold) {
+	defer h.cancel()
+	p.holdMux.Lock()
+	if p.holds[v] != h {
+		// released in the meantime
+		p.holdMux.Unlock()
+		return
+	}
+	h.reclaimed = p.opts.reclaimHolds
+	p.holdMux.Unlock()
+	p.stats.holdsExpired.Add(1)
+
+	e := HoldExpired{Pool: p.opts.name, Held: time.Since(h.at), Reclaimed: h.reclaimed}
+	e.Info, _ = p.EntryInfo(v)
+	if h.reclaimed {
+		p.stats.reclaimed.Add(1)
+		p.stats.inUse.Add(-1)
+		p.abandon(v)
+		p.updateState()
+		// waiting acquires (and eager pools) get a new entry instead
+		p.refill()
+	}
+	if fn := p.opts.holdExpiredFunc; fn != nil {
+		fn(e)
+	}
+}
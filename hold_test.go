@@ -0,0 +1,47 @@
+package pool
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMaxHoldTime(t *testing.T) {
+	expired := make(chan HoldExpired, 1)
+	var destroyed []*poolItem
+	pool := NewPool(1, poolFactory,
+		WithDestroyer(func(e *poolItem) { destroyed = append(destroyed, e) }),
+		WithMaxHoldTime(10*time.Millisecond, func(e HoldExpired) { expired <- e }),
+		WithReclaimExpiredHolds(),
+	)
+	defer pool.Close()
+
+	// released in time
+	pool.Release(pool.Acquire())
+	leaked := pool.Acquire()
+	select {
+	case e := <-expired:
+		if !e.Reclaimed || e.Held < 10*time.Millisecond || e.Info.UseCount != 2 {
+			t.Errorf("unexpected expired hold: %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected the leaked entry to expire")
+	}
+	// the slot of the leaked entry got refilled
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	v, err := pool.AcquireWithContext(ctx)
+	if err != nil || v == leaked {
+		t.Fatalf("expected a new entry but got %v, %v", v, err)
+	}
+	pool.Release(v)
+
+	// releasing the leaked entry late destroys it
+	pool.Release(leaked)
+	if len(destroyed) != 1 || destroyed[0] != leaked {
+		t.Errorf("expected the leaked entry to be destroyed but got %v", destroyed)
+	}
+	if stats := pool.Stats(); stats.HoldsExpired != 1 || stats.Reclaimed != 1 || stats.InUse != 0 || stats.Idle != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
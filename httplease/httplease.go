@@ -0,0 +1,103 @@
+// Package httplease ties leases of pool entries to the lifetime of http requests: Middleware acquires an entry
+// for every request, hands it to the handler through the request context and releases it once the handler
+// returns, even if it panics. Acquires are bound to the request context, so clients disconnecting while the
+// request waits for an entry don't leave an entry behind.
+//
+//	mux.Handle("/run", httplease.Middleware(vms, httplease.Options{Timeout: time.Second})(
+//		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//			vm, _ := httplease.Entry[lua.LState](r)
+//			...
+//		})))
+//
+// The lease is also available using pool.LeaseFromContext, e.g. in code below the handler that only gets
+// the context.
+package httplease
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/epikur-io/go-pool"
+)
+
+// Options of Middleware
+type Options struct {
+	// Timeout limits waiting for an entry, the request context applies regardless (optional)
+	Timeout time.Duration
+	// ErrorHandler responds to requests no entry could be acquired for,
+	// responds with 503 Service Unavailable by default (optional)
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// bindingKey is the context key of the binding of entries of type T
+type bindingKey[T any] struct{}
+
+// binding is the lease of a request
+type binding[T any] struct {
+	lease    pool.Lease[T]
+	once     sync.Once
+	released atomic.Bool
+	err      error
+}
+
+// release releases the lease once
+func (b *binding[T]) release() error {
+	b.once.Do(func() {
+		b.released.Store(true)
+		b.err = b.lease.Release()
+	})
+	return b.err
+}
+
+// Middleware acquires an entry of p for every request and releases it once the handler returned (or
+// released it early using Release). Requests no entry could be acquired for aren't passed to the handler.
+func Middleware[T any](p *pool.Pool[T], o Options) func(http.Handler) http.Handler {
+	onError := o.ErrorHandler
+	if onError == nil {
+		onError = func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			acquireCtx := ctx
+			if o.Timeout > 0 {
+				var cancel context.CancelFunc
+				acquireCtx, cancel = context.WithTimeout(ctx, o.Timeout)
+				defer cancel()
+			}
+			lease, err := p.AcquireLease(acquireCtx)
+			if err != nil {
+				onError(w, r, err)
+				return
+			}
+			b := &binding[T]{lease: lease}
+			defer b.release()
+			ctx = context.WithValue(pool.ContextWithLease(ctx, lease), bindingKey[T]{}, b)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Entry returns the entry acquired for r by Middleware, false if there is none or it was released already
+func Entry[T any](r *http.Request) (*T, bool) {
+	b, ok := r.Context().Value(bindingKey[T]{}).(*binding[T])
+	if !ok || b.released.Load() {
+		return nil, false
+	}
+	return b.lease.Value(), true
+}
+
+// Release releases the entry acquired for r before the handler returns, e.g. before streaming a long response
+// that doesn't need the entry anymore. The entry must not be used afterwards, releasing it again is a no-op.
+func Release[T any](r *http.Request) error {
+	b, ok := r.Context().Value(bindingKey[T]{}).(*binding[T])
+	if !ok {
+		return nil
+	}
+	return b.release()
+}
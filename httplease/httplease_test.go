@@ -0,0 +1,108 @@
+package httplease
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/epikur-io/go-pool"
+)
+
+type vm struct {
+	id int
+}
+
+func newTestPool(size int) *pool.Pool[vm] {
+	n := 0
+	return pool.NewPool(size, func() *vm { n++; return &vm{id: n} })
+}
+
+func TestMiddleware(t *testing.T) {
+	p := newTestPool(1)
+	defer p.Close()
+	var got *vm
+	h := Middleware(p, Options{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = Entry[vm](r)
+		if l, ok := pool.LeaseFromContext[vm](r.Context()); !ok || l.Value() != got {
+			t.Errorf("expected the lease in the request context")
+		}
+		if p.InUse() != 1 {
+			t.Errorf("expected the entry to be acquired while the handler runs")
+		}
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got == nil || rec.Code != http.StatusOK {
+		t.Errorf("expected the handler to get an entry but got %v (status %d)", got, rec.Code)
+	}
+	if p.InUse() != 0 {
+		t.Errorf("expected the entry to be released once the handler returned")
+	}
+}
+
+func TestMiddlewarePanic(t *testing.T) {
+	p := newTestPool(1)
+	defer p.Close()
+	h := Middleware(p, Options{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	func() {
+		defer func() { recover() }()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+	if p.InUse() != 0 {
+		t.Errorf("expected the entry to be released after a panic")
+	}
+}
+
+func TestMiddlewareRelease(t *testing.T) {
+	p := newTestPool(1)
+	defer p.Close()
+	h := Middleware(p, Options{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := Release[vm](r); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if _, ok := Entry[vm](r); ok {
+			t.Errorf("expected no entry after the early release")
+		}
+		if p.InUse() != 0 {
+			t.Errorf("expected the entry to be released early")
+		}
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if stats := p.Stats(); stats.Released != 1 {
+		t.Errorf("expected the entry to be released once but got %d releases", stats.Released)
+	}
+}
+
+func TestMiddlewareUnavailable(t *testing.T) {
+	p := newTestPool(1)
+	defer p.Close()
+	held := p.Acquire()
+	defer p.Release(held)
+	called := false
+	h := Middleware(p, Options{Timeout: time.Millisecond})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if called || rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without calling the handler but got %d", rec.Code)
+	}
+
+	// clients disconnecting while waiting don't hold an entry
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	h = Middleware(p, Options{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	if called || p.Stats().Waiters != 0 {
+		t.Errorf("expected the acquire to be abandoned")
+	}
+}
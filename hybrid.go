@@ -0,0 +1,131 @@
+package pool
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// HybridPool is a two tier pool for small, hot objects (e.g. encoders): a sync.Pool front tier reuses
+// objects without bounds and lets the garbage collector drop unused ones, while a bounded Pool keeps a
+// minimum number of warm objects that survive garbage collections
+type HybridPool[T any] struct {
+	front   sync.Pool
+	bounded *Pool[T]
+	factory func() *T
+
+	frontHits   atomic.Uint64
+	boundedHits atomic.Uint64
+	misses      atomic.Uint64
+}
+
+// HybridStats are the stats of a HybridPool
+type HybridStats struct {
+	// stats of the bounded tier
+	Bounded Stats `json:"bounded"`
+	// number of objects taken from the sync.Pool tier
+	FrontHits uint64 `json:"front_hits"`
+	// number of objects taken from the bounded tier
+	BoundedHits uint64 `json:"bounded_hits"`
+	// number of objects created because both tiers were empty
+	Misses uint64 `json:"misses"`
+}
+
+// Creates a new hybrid pool keeping size warm objects in its bounded tier,
+// the options are applied to the bounded tier
+func NewHybridPool[T any](size int, factoryFunc func() *T, opts ...Option) *HybridPool[T] {
+	return &HybridPool[T]{
+		bounded: NewPool(size, factoryFunc, opts...),
+		factory: factoryFunc,
+	}
+}
+
+// Get returns an object of the sync.Pool tier, an idle object of the bounded tier or a new object.
+// Objects of the bounded tier are taken over from it like objects of a sync.Pool, so dropping them
+// instead of putting them back doesn't leak a slot of the bounded tier, Put fills the slot again.
+func (h *HybridPool[T]) Get() *T {
+	if v, _ := h.front.Get().(*T); v != nil {
+		h.frontHits.Add(1)
+		return v
+	}
+	if v, ok := h.bounded.takeOwned(); ok {
+		h.boundedHits.Add(1)
+		return v
+	}
+	h.misses.Add(1)
+	return h.factory()
+}
+
+// Put gives v back, objects filling the free space of the bounded tier go to the bounded tier, all others
+// to the sync.Pool tier. Objects aren't reset (see WithReset) unless they go to the bounded tier, objects
+// failing the reset are destroyed.
+func (h *HybridPool[T]) Put(v *T) {
+	if v == nil {
+		return
+	}
+	if h.bounded.putOwned(v) {
+		return
+	}
+	h.front.Put(v)
+}
+
+// Returns the bounded tier of the pool
+func (h *HybridPool[T]) Bounded() *Pool[T] {
+	return h.bounded
+}
+
+func (h *HybridPool[T]) Stats() HybridStats {
+	return HybridStats{
+		Bounded:     h.bounded.Stats(),
+		FrontHits:   h.frontHits.Load(),
+		BoundedHits: h.boundedHits.Load(),
+		Misses:      h.misses.Load(),
+	}
+}
+
+// Close closes the bounded tier, Get keeps returning objects of the sync.Pool tier or new objects
+func (h *HybridPool[T]) Close() error {
+	return h.bounded.Close()
+}
+
+// takeOwned takes an idle entry over from the pool like Hijack but without refilling its slot,
+// putOwned or an acquire fills it again
+func (p *Pool[T]) takeOwned() (*T, bool) {
+	for {
+		v, ok := p.idle.tryGet()
+		if !ok {
+			return nil, false
+		}
+		if p.checkout(v) {
+			p.abandon(v)
+			p.updateState()
+			return v, true
+		}
+	}
+}
+
+// putOwned adds v to the idle entries like a released entry if the pool holds less entries than it should,
+// false is returned if it has no room for v. v counts as a new entry of the pool (e.g. for the ttl).
+func (p *Pool[T]) putOwned(v *T) bool {
+	if !p.grow() {
+		return false
+	}
+	p.track(v)
+	if p.resetFunc != nil {
+		if err := p.runHook(p.resetFunc, v); err == errHookTimeout {
+			// the reset is still running, v can't be reused nor destroyed
+			p.abandon(v)
+			return true
+		} else if err != nil {
+			p.destroy(v)
+			return true
+		}
+	}
+	if p.costFunc != nil {
+		p.measure(v, p.meta(v))
+	}
+	if !p.put(v) {
+		p.abandon(v)
+		return false
+	}
+	return true
+}
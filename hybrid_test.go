@@ -0,0 +1,52 @@
+package pool
+
+import "testing"
+
+func TestHybridPool(t *testing.T) {
+	pool := NewHybridPool(1, poolFactory)
+	defer pool.Close()
+
+	a, b := pool.Get(), pool.Get()
+	if stats := pool.Stats(); stats.BoundedHits != 1 || stats.Misses != 1 {
+		t.Errorf("expected a bounded hit and a miss but got %+v", stats)
+	}
+	pool.Put(a)
+	pool.Put(b)
+	if stats := pool.Stats(); stats.Bounded.Idle != 1 || stats.Bounded.InUse != 0 {
+		t.Errorf("expected the bounded entry to be idle again but got %+v", stats)
+	}
+	if raceEnabled {
+		// the race detector randomly drops objects put into a sync.Pool
+		return
+	}
+	// the surplus object went to the sync.Pool tier
+	if v := pool.Get(); v != b {
+		t.Errorf("expected object of the sync.Pool tier but got %p", v)
+	}
+	if v := pool.Get(); v != a {
+		t.Errorf("expected object of the bounded tier but got %p", v)
+	}
+}
+
+func TestHybridPoolDropped(t *testing.T) {
+	pool := NewHybridPool(2, poolFactory)
+	defer pool.Close()
+
+	// objects of the bounded tier that are dropped don't leak its slots
+	pool.Get()
+	pool.Get()
+	if stats := pool.Stats(); stats.BoundedHits != 2 || stats.Bounded.Idle != 0 || stats.Bounded.InUse != 0 {
+		t.Errorf("expected the bounded tier to be handed out but got %+v", stats)
+	}
+	a, b := poolFactory(), poolFactory()
+	pool.Put(a)
+	pool.Put(b)
+	if stats := pool.Stats(); stats.Bounded.Idle != 2 {
+		t.Errorf("expected the put objects to refill the bounded tier but got %+v", stats)
+	}
+	for range 2 {
+		if v := pool.Get(); v != a && v != b {
+			t.Errorf("expected object of the bounded tier but got %p", v)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// Package adapter implements the acquire and run logic shared by the adapter packages (netpool, procpool,
+// sqlpool, ...), whose pools create empty entries that get opened (dialed, started, instantiated) on their
+// first acquire using the context of the caller.
+package adapter
+
+import (
+	"context"
+
+	"github.com/epikur-io/go-pool"
+)
+
+// Acquire acquires an entry of p waiting until ctx is done and opens it unless opened reports it already is.
+// Entries that fail to open are released empty, so the next acquire of the entry retries.
+func Acquire[T any](ctx context.Context, p *pool.Pool[T], opened func(e *T) bool, open func(ctx context.Context, e *T) error) (*T, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	e, err := p.AcquireWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !opened(e) {
+		if err := open(ctx, e); err != nil {
+			p.Release(e)
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// Run runs fn with the acquired entry e and gives e back to p once fn returns: e gets released using
+// release unless broken reports that the error of fn left it unusable (any error if broken is nil),
+// then it's replaced. If fn panics e is replaced as well, its state is unknown.
+func Run[T any](p *pool.Pool[T], e *T, release func(e *T) error, broken func(err error) bool, fn func() error) (err error) {
+	done := false
+	defer func() {
+		if !done || (broken == nil && err != nil) || (broken != nil && broken(err)) {
+			p.Replace(e)
+		} else {
+			release(e)
+		}
+	}()
+	err = fn()
+	done = true
+	return err
+}
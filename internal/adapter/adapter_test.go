@@ -0,0 +1,57 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/epikur-io/go-pool"
+)
+
+type conn struct {
+	open bool
+}
+
+func TestAcquire(t *testing.T) {
+	p := pool.NewPool(1, func() *conn { return &conn{} })
+	opened := func(c *conn) bool { return c.open }
+	failing := errors.New("dial failed")
+	if _, err := Acquire(nil, p, opened, func(context.Context, *conn) error { return failing }); !errors.Is(err, failing) {
+		t.Errorf("expected %v but got %v", failing, err)
+	}
+	c, err := Acquire(context.Background(), p, opened, func(_ context.Context, c *conn) error {
+		c.open = true
+		return nil
+	})
+	if err != nil || !c.open {
+		t.Fatalf("expected the released entry to be opened on the next acquire but got %v", err)
+	}
+	p.Release(c)
+}
+
+func TestRun(t *testing.T) {
+	p := pool.NewPool(1, func() *conn { return &conn{} })
+	run := func(fn func() error) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = errors.New("panicked")
+			}
+		}()
+		return Run(p, p.Acquire(), p.Release, nil, fn)
+	}
+
+	run(func() error { return nil })
+	if stats := p.Stats(); stats.Created != 1 || stats.InUse != 0 {
+		t.Errorf("expected the entry to be released but got %+v", stats)
+	}
+	run(func() error { return errors.New("broken") })
+	if stats := p.Stats(); stats.Created != 2 || stats.InUse != 0 {
+		t.Errorf("expected the failed entry to be replaced but got %+v", stats)
+	}
+	if err := run(func() error { panic("fn") }); err == nil {
+		t.Errorf("expected the panic to be passed on")
+	}
+	if stats := p.Stats(); stats.Created != 3 || stats.InUse != 0 {
+		t.Errorf("expected the entry to be replaced after a panic but got %+v", stats)
+	}
+}
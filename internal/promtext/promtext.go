@@ -0,0 +1,90 @@
+// Package promtext writes the stats of pools in the Prometheus text format, it's shared by the admin and
+// promsink packages so both serve the same metrics
+package promtext
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/epikur-io/go-pool"
+)
+
+// ContentType is the content type of the text format
+const ContentType = "text/plain; version=0.0.4"
+
+// Metric is a value of the stats exported in the Prometheus text format
+type Metric struct {
+	Name, Kind, Help string
+	// nil for histograms
+	Value func(s pool.Stats) float64
+}
+
+var (
+	Capacity   = Metric{"pool_capacity", "gauge", "Capacity of the pool.", func(s pool.Stats) float64 { return float64(s.Cap) }}
+	Size       = Metric{"pool_size", "gauge", "Number of entries the pool should hold.", func(s pool.Stats) float64 { return float64(s.Size) }}
+	Idle       = Metric{"pool_idle", "gauge", "Entries waiting in the pool.", func(s pool.Stats) float64 { return float64(s.Idle) }}
+	InUse      = Metric{"pool_in_use", "gauge", "Entries currently acquired.", func(s pool.Stats) float64 { return float64(s.InUse) }}
+	Waiters    = Metric{"pool_waiters", "gauge", "Acquires waiting for an entry.", func(s pool.Stats) float64 { return float64(s.Waiters) }}
+	Acquired   = Metric{"pool_acquired_total", "counter", "Acquired entries.", func(s pool.Stats) float64 { return float64(s.Acquired) }}
+	Released   = Metric{"pool_released_total", "counter", "Released entries.", func(s pool.Stats) float64 { return float64(s.Released) }}
+	Created    = Metric{"pool_created_total", "counter", "Entries created by the factory.", func(s pool.Stats) float64 { return float64(s.Created) }}
+	Destroyed  = Metric{"pool_destroyed_total", "counter", "Entries destroyed by the pool.", func(s pool.Stats) float64 { return float64(s.Destroyed) }}
+	FactoryAvg = Metric{"pool_factory_avg_seconds", "gauge", "Mean duration of factory calls.", func(s pool.Stats) float64 { return s.Factory.Avg.Seconds() }}
+	FactoryP95 = Metric{"pool_factory_p95_seconds", "gauge", "95th percentile of the duration of recent factory calls.", func(s pool.Stats) float64 { return s.Factory.P95.Seconds() }}
+	Timeouts   = Metric{"pool_timeouts_total", "counter", "Acquires that timed out or got canceled.", func(s pool.Stats) float64 { return float64(s.Timeouts) }}
+
+	AcquireWait = Metric{Name: "pool_acquire_wait_seconds", Kind: "histogram", Help: "Wait times of acquires."}
+	Factory     = Metric{Name: "pool_factory_seconds", Kind: "histogram", Help: "Durations of factory calls."}
+)
+
+// Metrics are all counters and gauges derived from pool.Stats
+var Metrics = []Metric{Capacity, Size, Idle, InUse, Waiters, Acquired, Released, Created, Destroyed, FactoryAvg, FactoryP95, Timeouts}
+
+// Write writes the metrics of the stats of all pools, labeled by the name of the pool
+func Write(w io.Writer, metrics []Metric, stats []pool.Stats) {
+	for _, m := range metrics {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.Name, m.Help, m.Name, m.Kind)
+		for _, s := range stats {
+			fmt.Fprintf(w, "%s{pool=\"%s\"} %s\n", m.Name, label(s.Name), formatFloat(m.Value(s)))
+		}
+	}
+}
+
+// WriteHistogram writes the histogram m of the stats of all pools, hist returns nil for pools without one.
+// Nothing is written if no pool has a histogram.
+func WriteHistogram(w io.Writer, m Metric, stats []pool.Stats, hist func(s pool.Stats) *pool.WaitHistogram) {
+	header := false
+	for _, s := range stats {
+		h := hist(s)
+		if h == nil {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", m.Name, m.Help, m.Name)
+			header = true
+		}
+		for _, b := range h.Buckets {
+			le := "+Inf"
+			if b.UpperBound != math.MaxInt64 {
+				le = formatFloat(b.UpperBound.Seconds())
+			}
+			fmt.Fprintf(w, "%s_bucket{pool=\"%s\",le=\"%s\"} %d\n", m.Name, label(s.Name), le, b.Count)
+		}
+		fmt.Fprintf(w, "%s_sum{pool=\"%s\"} %s\n", m.Name, label(s.Name), formatFloat(h.Sum.Seconds()))
+		fmt.Fprintf(w, "%s_count{pool=\"%s\"} %d\n", m.Name, label(s.Name), h.Count)
+	}
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// label escapes a label value
+func label(v string) string {
+	return labelEscaper.Replace(v)
+}
@@ -0,0 +1,357 @@
+package pool
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// KeyedOptions configure a KeyedPool
+type KeyedOptions[K comparable] struct {
+	// Quota is the maximum number of entries of a key (required)
+	Quota int
+	// Quotas overrides Quota for single keys (optional)
+	Quotas map[K]int
+	// Cap limits the number of entries in use across all keys (no limit if 0)
+	Cap int
+	// Weight enables fair sharing of Cap: while acquires wait for the cap, keys holding less than their share
+	// (Cap split by the weights of the keys in use or waiting) are served first. All keys weigh 1 if Weight
+	// returns 0, fair sharing is disabled if Weight is nil.
+	Weight func(key K) int
+	// Options are applied to the pool of every key, WithName must not be used since the names would collide
+	Options []Option
+	// EvictAfter is the time after which the pool of a key without entries in use gets closed and
+	// removed, destroying its entries (pools aren't evicted if 0)
+	EvictAfter time.Duration
+	// MaxKeys limits the number of pools, the least recently used pool without entries in use gets
+	// evicted once a pool for a new key is needed (no limit if 0)
+	MaxKeys int
+	// OnSubPoolEvicted is called with the key and the final stats of every evicted pool (optional)
+	OnSubPoolEvicted func(key K, stats Stats)
+}
+
+// KeyedPool holds a pool of entries per key (e.g. connections per tenant), the pools are created on demand
+// and limited by per-key quotas and a global cap so a single key can't exhaust the shared capacity
+type KeyedPool[K comparable, T any] struct {
+	factory func(key K) *T
+	opts    KeyedOptions[K]
+
+	mux   sync.Mutex
+	pools map[K]*keyedPool[T]
+	// number of entries in use across all keys
+	inUse int
+	// closed once an entry is released
+	released chan struct{}
+	closed   bool
+	// closed when the pool gets closed
+	done    chan struct{}
+	evicted uint64
+}
+
+// keyedPool is the pool of a key
+type keyedPool[T any] struct {
+	pool   *Pool[T]
+	quota  int
+	weight int
+	// entries in use and waiting acquires, guarded by KeyedPool.mux
+	inUse   int
+	waiting int
+	// last time an entry was acquired or released
+	lastUsed time.Time
+}
+
+// KeyStats are the stats of a key of a KeyedPool
+type KeyStats struct {
+	Quota   int `json:"quota"`
+	InUse   int `json:"in_use"`
+	Waiting int `json:"waiting"`
+	// fair share of the cap, 0 if fair sharing is disabled
+	Share int `json:"share"`
+	// stats of the pool of the key
+	Pool Stats `json:"pool"`
+}
+
+// KeyedStats are the stats of a KeyedPool
+type KeyedStats[K comparable] struct {
+	Cap   int `json:"cap"`
+	InUse int `json:"in_use"`
+	// number of evicted pools (see KeyedOptions.EvictAfter and MaxKeys)
+	Evicted uint64 `json:"evicted"`
+	// stats of the keys with a pool
+	Keys map[K]KeyStats `json:"keys"`
+}
+
+// Creates a keyed pool, factoryFunc creates the entries of a key
+func NewKeyedPool[K comparable, T any](factoryFunc func(key K) *T, o KeyedOptions[K]) (*KeyedPool[K, T], error) {
+	if factoryFunc == nil {
+		return nil, ErrMissingFactoryFunction
+	}
+	if o.Quota <= 0 {
+		return nil, ErrInvalidSize
+	}
+	k := &KeyedPool[K, T]{
+		factory:  factoryFunc,
+		opts:     o,
+		pools:    map[K]*keyedPool[T]{},
+		released: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if o.EvictAfter > 0 {
+		go k.evictIdle()
+	}
+	return k, nil
+}
+
+// sub returns the pool of key, creating it if necessary, the caller must hold k.mux.
+// The pool evicted to make room for it is returned as well and must be closed (see evict).
+func (k *KeyedPool[K, T]) sub(key K) (*keyedPool[T], *evictedPool[K, T], error) {
+	if kp, ok := k.pools[key]; ok {
+		return kp, nil, nil
+	}
+	var evicted *evictedPool[K, T]
+	if k.opts.MaxKeys > 0 && len(k.pools) >= k.opts.MaxKeys {
+		evicted = k.evictLRU()
+	}
+	quota := k.opts.Quota
+	if q, ok := k.opts.Quotas[key]; ok {
+		quota = q
+	}
+	opts := append([]Option{WithMinSize(0)}, k.opts.Options...)
+	p, err := NewPoolE(quota, func() *T { return k.factory(key) }, opts...)
+	if err != nil {
+		return nil, evicted, err
+	}
+	kp := &keyedPool[T]{pool: p, quota: quota, weight: 1, lastUsed: time.Now()}
+	if k.opts.Weight != nil {
+		kp.weight = max(k.opts.Weight(key), 1)
+	}
+	k.pools[key] = kp
+	return kp, evicted, nil
+}
+
+// evictedPool is a pool removed from a KeyedPool that still has to be closed
+type evictedPool[K comparable, T any] struct {
+	key      K
+	pool     *Pool[T]
+	lastUsed time.Time
+}
+
+// idle reports whether kp has no entries in use nor waiting acquires
+func (kp *keyedPool[T]) idle() bool {
+	return kp.inUse == 0 && kp.waiting == 0
+}
+
+// evictLRU removes the least recently used idle pool, the caller must hold k.mux
+func (k *KeyedPool[K, T]) evictLRU() *evictedPool[K, T] {
+	var lru *evictedPool[K, T]
+	for key, kp := range k.pools {
+		if kp.idle() && (lru == nil || kp.lastUsed.Before(lru.lastUsed)) {
+			lru = &evictedPool[K, T]{key: key, pool: kp.pool, lastUsed: kp.lastUsed}
+		}
+	}
+	if lru != nil {
+		delete(k.pools, lru.key)
+		k.evicted++
+	}
+	return lru
+}
+
+// evictIdle periodically evicts pools that weren't used for EvictAfter until the pool gets closed
+func (k *KeyedPool[K, T]) evictIdle() {
+	t := time.NewTicker(max(k.opts.EvictAfter/2, time.Millisecond))
+	defer t.Stop()
+	for {
+		select {
+		case <-k.done:
+			return
+		case now := <-t.C:
+			var evicted []*evictedPool[K, T]
+			k.mux.Lock()
+			for key, kp := range k.pools {
+				if kp.idle() && now.Sub(kp.lastUsed) >= k.opts.EvictAfter {
+					delete(k.pools, key)
+					k.evicted++
+					evicted = append(evicted, &evictedPool[K, T]{key: key, pool: kp.pool, lastUsed: kp.lastUsed})
+				}
+			}
+			k.mux.Unlock()
+			// evict the least recently used pool first, independent of the iteration order of the map
+			slices.SortFunc(evicted, func(a, b *evictedPool[K, T]) int { return a.lastUsed.Compare(b.lastUsed) })
+			for _, e := range evicted {
+				k.evict(e)
+			}
+		}
+	}
+}
+
+// evict closes an evicted pool, destroying its entries, and calls the OnSubPoolEvicted hook
+func (k *KeyedPool[K, T]) evict(e *evictedPool[K, T]) {
+	if e == nil {
+		return
+	}
+	e.pool.Close()
+	if k.opts.OnSubPoolEvicted != nil {
+		k.opts.OnSubPoolEvicted(e.key, e.pool.Stats())
+	}
+}
+
+// Returns the pool of key if it exists
+func (k *KeyedPool[K, T]) Pool(key K) (*Pool[T], bool) {
+	k.mux.Lock()
+	defer k.mux.Unlock()
+	kp, ok := k.pools[key]
+	if !ok {
+		return nil, false
+	}
+	return kp.pool, true
+}
+
+// Acquire acquires an entry of key waiting until ctx is done, acquires wait if the quota of the key
+// or the cap is reached or, using fair sharing, keys holding less than their share are waiting
+func (k *KeyedPool[K, T]) Acquire(ctx context.Context, key K) (*T, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	k.mux.Lock()
+	if k.closed {
+		k.mux.Unlock()
+		return nil, &PoolError{Op: opAcquire, Err: ErrPoolClosed}
+	}
+	kp, evicted, err := k.sub(key)
+	if evicted != nil {
+		defer k.evict(evicted)
+	}
+	if err != nil {
+		k.mux.Unlock()
+		return nil, err
+	}
+	kp.waiting++
+	for !k.admit(kp) {
+		released := k.released
+		k.mux.Unlock()
+		select {
+		case <-released:
+		case <-ctx.Done():
+			k.mux.Lock()
+			kp.waiting--
+			// the next waiter may be admitted now
+			k.notify()
+			k.mux.Unlock()
+			kp.pool.timedOut()
+			return nil, kp.pool.wrapErr(opAcquire, ctx.Err())
+		}
+		k.mux.Lock()
+		if k.closed {
+			kp.waiting--
+			k.mux.Unlock()
+			return nil, kp.pool.wrapErr(opAcquire, ErrPoolClosed)
+		}
+	}
+	kp.waiting--
+	kp.inUse++
+	kp.lastUsed = time.Now()
+	k.inUse++
+	k.mux.Unlock()
+	// can't wait since the quota of the key isn't reached
+	v, err := kp.pool.AcquireWithContext(ctx)
+	if err != nil {
+		k.release(kp)
+	}
+	return v, err
+}
+
+// admit reports whether an acquire of kp may proceed, the caller must hold k.mux
+func (k *KeyedPool[K, T]) admit(kp *keyedPool[T]) bool {
+	if kp.inUse >= kp.quota {
+		return false
+	}
+	if k.opts.Cap <= 0 {
+		return true
+	}
+	if k.inUse >= k.opts.Cap {
+		return false
+	}
+	if k.opts.Weight == nil || kp.inUse < k.share(kp) {
+		return true
+	}
+	// kp exceeds its share, it only gets spare capacity nobody below its share waits for
+	for _, other := range k.pools {
+		if other != kp && other.waiting > 0 && other.inUse < k.share(other) {
+			return false
+		}
+	}
+	return true
+}
+
+// share returns the fair share of the cap of kp, the caller must hold k.mux
+func (k *KeyedPool[K, T]) share(kp *keyedPool[T]) int {
+	weights := 0
+	for _, other := range k.pools {
+		if other == kp || other.inUse > 0 || other.waiting > 0 {
+			weights += other.weight
+		}
+	}
+	return max(k.opts.Cap*kp.weight/weights, 1)
+}
+
+// notify wakes up waiting acquires, the caller must hold k.mux
+func (k *KeyedPool[K, T]) notify() {
+	close(k.released)
+	k.released = make(chan struct{})
+}
+
+// release frees the capacity held by an entry of kp
+func (k *KeyedPool[K, T]) release(kp *keyedPool[T]) {
+	k.mux.Lock()
+	kp.inUse--
+	kp.lastUsed = time.Now()
+	k.inUse--
+	k.notify()
+	k.mux.Unlock()
+}
+
+// Releases an entry of key
+func (k *KeyedPool[K, T]) Release(key K, v *T) error {
+	k.mux.Lock()
+	kp, ok := k.pools[key]
+	k.mux.Unlock()
+	if !ok {
+		return &PoolError{Op: opRelease, Err: ErrFailedToRelease}
+	}
+	if err := kp.pool.Release(v); err != nil {
+		return err
+	}
+	k.release(kp)
+	return nil
+}
+
+func (k *KeyedPool[K, T]) Stats() KeyedStats[K] {
+	k.mux.Lock()
+	defer k.mux.Unlock()
+	stats := KeyedStats[K]{Cap: k.opts.Cap, InUse: k.inUse, Evicted: k.evicted, Keys: make(map[K]KeyStats, len(k.pools))}
+	for key, kp := range k.pools {
+		ks := KeyStats{Quota: kp.quota, InUse: kp.inUse, Waiting: kp.waiting, Pool: kp.pool.Stats()}
+		if k.opts.Cap > 0 && k.opts.Weight != nil {
+			ks.Share = k.share(kp)
+		}
+		stats.Keys[key] = ks
+	}
+	return stats
+}
+
+// Close closes the pools of all keys
+func (k *KeyedPool[K, T]) Close() error {
+	k.mux.Lock()
+	defer k.mux.Unlock()
+	if k.closed {
+		return nil
+	}
+	k.closed = true
+	close(k.done)
+	k.notify()
+	for _, kp := range k.pools {
+		kp.pool.Close()
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestKeyedPool(t *testing.T) {
+	created := map[string]int{}
+	pool, err := NewKeyedPool(func(key string) *poolItem {
+		created[key]++
+		return new(poolItem)
+	}, KeyedOptions[string]{Quota: 2, Quotas: map[string]int{"small": 1}, Cap: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer pool.Close()
+	ctx := context.Background()
+	timeout := func() context.Context {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+		t.Cleanup(cancel)
+		return ctx
+	}
+
+	a, _ := pool.Acquire(ctx, "small")
+	if _, err := pool.Acquire(timeout(), "small"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the quota of the key to be reached but got %v", err)
+	}
+	b, _ := pool.Acquire(ctx, "large")
+	pool.Acquire(ctx, "large")
+	if _, err := pool.Acquire(timeout(), "other"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the cap to be reached but got %v", err)
+	}
+	pool.Release("small", a)
+	pool.Release("large", b)
+	stats := pool.Stats()
+	if stats.InUse != 1 || stats.Keys["large"].InUse != 1 || stats.Keys["small"].Pool.Idle != 1 || stats.Keys["other"].Pool.Created != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if created["small"] != 1 || created["large"] != 2 {
+		t.Errorf("expected entries to be created per key on demand but got %v", created)
+	}
+	if err := pool.Release("missing", a); !errors.Is(err, ErrFailedToRelease) {
+		t.Errorf("expected %v but got %v", ErrFailedToRelease, err)
+	}
+}
+
+func TestKeyedPoolFairSharing(t *testing.T) {
+	pool, _ := NewKeyedPool(func(string) *poolItem { return new(poolItem) }, KeyedOptions[string]{
+		Quota:  4,
+		Cap:    4,
+		Weight: func(key string) int { return map[string]int{"noisy": 1, "quiet": 1}[key] },
+	})
+	defer pool.Close()
+	ctx := context.Background()
+	noisy := make([]*poolItem, 4)
+	for i := range noisy {
+		noisy[i], _ = pool.Acquire(ctx, "noisy")
+	}
+	acquired := make(chan *poolItem)
+	go func() {
+		v, _ := pool.Acquire(ctx, "quiet")
+		acquired <- v
+	}()
+	for pool.Stats().Keys["quiet"].Waiting == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if share := pool.Stats().Keys["noisy"].Share; share != 2 {
+		t.Errorf("expected a share of 2 but got %d", share)
+	}
+	// the noisy key exceeds its share and has to wait for the quiet key
+	go pool.Acquire(ctx, "noisy")
+	time.Sleep(5 * time.Millisecond)
+	pool.Release("noisy", noisy[0])
+	<-acquired
+	if stats := pool.Stats(); stats.Keys["quiet"].InUse != 1 || stats.Keys["noisy"].InUse != 3 || stats.Keys["noisy"].Waiting != 1 {
+		t.Errorf("expected the quiet key to be served first but got %+v", stats.Keys)
+	}
+}
+
+func TestKeyedPoolEviction(t *testing.T) {
+	destroyed := make(chan *poolItem, 1)
+	evicted := make(chan string, 1)
+	pool, _ := NewKeyedPool(func(string) *poolItem { return new(poolItem) }, KeyedOptions[string]{
+		Quota:      1,
+		EvictAfter: 10 * time.Millisecond,
+		Options:    []Option{WithDestroyer(func(e *poolItem) { destroyed <- e })},
+		OnSubPoolEvicted: func(key string, stats Stats) {
+			if !stats.Closed || stats.Destroyed != 1 {
+				t.Errorf("expected the evicted pool to be closed but got %+v", stats)
+			}
+			evicted <- key
+		},
+	})
+	defer pool.Close()
+	ctx := context.Background()
+	e, _ := pool.Acquire(ctx, "user")
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := pool.Pool("user"); !ok {
+		t.Errorf("expected the pool of a key in use not to be evicted")
+	}
+	pool.Release("user", e)
+	if key := <-evicted; key != "user" || <-destroyed != e {
+		t.Errorf("expected the pool of the key to be evicted but got %q", key)
+	}
+	if _, ok := pool.Pool("user"); ok || pool.Stats().Evicted != 1 {
+		t.Errorf("expected the pool to be removed")
+	}
+}
+
+func TestKeyedPoolMaxKeys(t *testing.T) {
+	var evicted []string
+	pool, _ := NewKeyedPool(func(string) *poolItem { return new(poolItem) }, KeyedOptions[string]{
+		Quota:            1,
+		MaxKeys:          2,
+		OnSubPoolEvicted: func(key string, _ Stats) { evicted = append(evicted, key) },
+	})
+	defer pool.Close()
+	ctx := context.Background()
+	a, _ := pool.Acquire(ctx, "a")
+	b, _ := pool.Acquire(ctx, "b")
+	pool.Release("a", a)
+	// b is in use, the least recently used idle pool gets evicted
+	c, _ := pool.Acquire(ctx, "c")
+	if len(evicted) != 1 || evicted[0] != "a" {
+		t.Errorf("expected a to be evicted but got %v", evicted)
+	}
+	pool.Release("b", b)
+	pool.Release("c", c)
+	if stats := pool.Stats(); len(stats.Keys) != 2 || stats.Evicted != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
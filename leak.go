@@ -0,0 +1,120 @@
+package pool
+
+import (
+	"runtime"
+	"unsafe"
+)
+
+// LeakedEntry describes an entry that became garbage while it was acquired (see WithLeakDetection)
+type LeakedEntry struct {
+	// name of the pool
+	Pool string
+	// lifecycle information of the entry when it got lost
+	Info EntryInfo
+}
+
+// WithLeakDetection catches acquired entries that become garbage without being released: the pool doesn't keep
+// acquired entries reachable but registers a cleanup (see runtime.AddCleanup) for them, once an entry got lost
+// it's counted by Stats.Leaked, reported to fn (optional, called in its own goroutine) and as EventEntryLeaked,
+// and its slot gets refilled. Leaks are detected once the garbage collector noticed them, cleanups of small
+// entries without pointers may not run at all (see runtime.AddCleanup). Lost entries aren't destroyed.
+// Leak detection can't be combined with WithMaxHoldTime, which keeps entries in use reachable, and
+// WithNilReplacement, which drops entries on purpose, and doesn't support zero-sized entries.
+func WithLeakDetection(fn func(LeakedEntry)) Option {
+	return func(o *options) {
+		o.leakDetection = true
+		o.leakFunc = fn
+	}
+}
+
+// zeroSized reports whether values of T take no memory, their pointers don't identify them
+func zeroSized[T any]() bool {
+	var v T
+	return unsafe.Sizeof(v) == 0
+}
+
+// issueKey is the key of an acquired entry that doesn't keep it reachable
+func issueKey[T any](v *T) uintptr {
+	return uintptr(unsafe.Pointer(v))
+}
+
+// issuedEntry identifies an acquired entry in its cleanup
+type issuedEntry struct {
+	key  uintptr
+	meta *entryMeta
+}
+
+// weakIssue reports whether acquired entries are watched for becoming garbage, either to detect leaks or
+// to forget the metadata of entries dropped by releasing nil (see WithNilReplacement)
+func (p *Pool[T]) weakIssue() bool {
+	return p.opts.leakDetection || p.opts.nilReplacement && !zeroSized[T]()
+}
+
+// issue stops keeping the acquired entry v reachable and watches it for getting lost
+func (p *Pool[T]) issue(v *T, m *entryMeta) {
+	if !p.weakIssue() {
+		return
+	}
+	key := issueKey(v)
+	p.entries.Delete(v)
+	if old, loaded := p.issued.Swap(key, m); loaded {
+		// an entry lost at the same address whose cleanup didn't run yet
+		p.collected(old.(*entryMeta))
+	}
+	m.cleanup = runtime.AddCleanup(v, p.leaked, issuedEntry{key: key, meta: m})
+}
+
+// unissue stops watching the acquired entry v, its metadata is returned if it was issued
+func (p *Pool[T]) unissue(v *T) (*entryMeta, bool) {
+	if !p.weakIssue() {
+		return nil, false
+	}
+	m, ok := p.issued.LoadAndDelete(issueKey(v))
+	if !ok {
+		return nil, false
+	}
+	m.(*entryMeta).cleanup.Stop()
+	return m.(*entryMeta), true
+}
+
+// reissue makes the released entry v reachable again
+func (p *Pool[T]) reissue(v *T) {
+	if m, ok := p.unissue(v); ok {
+		p.entries.Store(v, m)
+	}
+}
+
+// leaked is the cleanup of issued entries
+func (p *Pool[T]) leaked(e issuedEntry) {
+	if p.issued.CompareAndDelete(e.key, e.meta) {
+		p.collected(e.meta)
+	}
+}
+
+// collected handles the acquired entry of m becoming garbage, with nil replacement it was dropped and
+// its slot already got refilled by releasing nil, only its metadata is left to forget
+func (p *Pool[T]) collected(m *entryMeta) {
+	if p.opts.leakDetection {
+		p.lost(m)
+		return
+	}
+	p.forget(m)
+}
+
+// lost removes the lost entry of m from the pools accounting and refills its slot
+func (p *Pool[T]) lost(m *entryMeta) {
+	p.stats.leaked.Add(1)
+	p.stats.inUse.Add(-1)
+	p.forget(m)
+	p.live.Add(-1)
+	p.updateState()
+	p.checkDrained()
+	p.emit(Event{Type: EventEntryLeaked})
+	leak := LeakedEntry{Pool: p.opts.name, Info: m.info()}
+	go func() {
+		if fn := p.opts.leakFunc; fn != nil {
+			fn(leak)
+		}
+		p.refill()
+	}()
+}
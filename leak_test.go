@@ -0,0 +1,71 @@
+package pool
+
+import (
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type leakItem struct {
+	data [64]byte
+	next *leakItem
+}
+
+func TestLeakDetection(t *testing.T) {
+	leaks := make(chan LeakedEntry, 1)
+	var events atomic.Int32
+	pool := NewPool(2, func() *leakItem { return &leakItem{} }, WithLeakDetection(func(l LeakedEntry) { leaks <- l }),
+		WithEventHook(func(e Event) {
+			if e.Type == EventEntryLeaked {
+				events.Add(1)
+			}
+		}))
+	defer pool.Close()
+
+	// released entries aren't leaks
+	kept := pool.Acquire()
+	if info, ok := pool.EntryInfo(kept); !ok || !info.InUse {
+		t.Errorf("expected the acquired entry to be known")
+	}
+	pool.Release(kept)
+
+	func() {
+		lost := pool.Acquire()
+		lost.data[0] = 1
+	}()
+	var leak LeakedEntry
+	deadline := time.After(5 * time.Second)
+wait:
+	for {
+		runtime.GC()
+		select {
+		case leak = <-leaks:
+			break wait
+		case <-deadline:
+			t.Fatalf("expected the lost entry to be detected")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if !leak.Info.InUse || leak.Info.UseCount != 1 {
+		t.Errorf("expected the info of the lost entry but got %+v", leak.Info)
+	}
+	deadline = time.After(time.Second)
+	for pool.Len() != 2 {
+		select {
+		case <-deadline:
+			t.Fatalf("expected the slot to be refilled but got %d idle entries", pool.Len())
+		case <-time.After(time.Millisecond):
+		}
+	}
+	if stats := pool.Stats(); stats.Leaked != 1 || stats.InUse != 0 || stats.Created != 3 || events.Load() != 1 {
+		t.Errorf("expected 1 leaked entry but got %+v", stats)
+	}
+
+	if _, err := NewPoolE(1, func() *struct{} { return &struct{}{} }, WithLeakDetection(nil)); err == nil {
+		t.Errorf("expected an error for zero-sized entries")
+	}
+	if _, err := NewPoolE(1, poolFactory, WithLeakDetection(nil), WithNilReplacement()); err == nil {
+		t.Errorf("expected an error combined with nil replacement")
+	}
+}
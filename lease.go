@@ -0,0 +1,70 @@
+package pool
+
+import "context"
+
+// Lease is an acquired entry bound to its pool
+type Lease[T any] struct {
+	pool  *Pool[T]
+	value *T
+}
+
+// AcquireLease acquires an entry like AcquireWithContext and returns it as a lease
+func (p *Pool[T]) AcquireLease(ctx context.Context) (Lease[T], error) {
+	v, err := p.AcquireWithContext(ctx)
+	if err != nil {
+		return Lease[T]{}, err
+	}
+	return Lease[T]{pool: p, value: v}, nil
+}
+
+// Returns the leased entry
+func (l Lease[T]) Value() *T {
+	return l.value
+}
+
+// Returns the lifecycle information of the leased entry
+func (l Lease[T]) Info() EntryInfo {
+	info, _ := l.pool.EntryInfo(l.value)
+	return info
+}
+
+// Returns the generation of the leased entry (see RefreshAll)
+func (l Lease[T]) Generation() uint64 {
+	return l.Info().Generation
+}
+
+// Reports whether the leased entry is of an old generation,
+// such entries get destroyed instead of being put back once released
+func (l Lease[T]) Stale() bool {
+	return l.Generation() < l.pool.generation.Load()
+}
+
+// Releases the leased entry to its pool
+func (l Lease[T]) Release() error {
+	return l.pool.Release(l.value)
+}
+
+// Hijack takes the leased entry over from the pool (see Pool.Hijack) and returns it, the caller is
+// responsible for cleaning it up. A replacement is created right away if refill is set, e.g. when the
+// entry is handed to a long-lived consumer like a streaming session and the pool should keep its size.
+func (l Lease[T]) Hijack(refill bool) (*T, error) {
+	if err := l.pool.hijack(l.value, refill); err != nil {
+		return nil, err
+	}
+	return l.value, nil
+}
+
+// leaseKey is the context key of leases of entries of type T
+type leaseKey[T any] struct{}
+
+// ContextWithLease returns a copy of ctx holding l (see WithLeaseContext)
+func ContextWithLease[T any](ctx context.Context, l Lease[T]) context.Context {
+	return context.WithValue(ctx, leaseKey[T]{}, l)
+}
+
+// LeaseFromContext returns the lease of type T held by ctx (see WithLeaseContext),
+// the innermost lease is returned if calls of RunWithContext of pools of the same type are nested
+func LeaseFromContext[T any](ctx context.Context) (Lease[T], bool) {
+	l, ok := ctx.Value(leaseKey[T]{}).(Lease[T])
+	return l, ok
+}
@@ -0,0 +1,234 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// checkout prepares an idle entry for being handed out, entries that expired
+// or failed validation get destroyed and false is returned
+func (p *Pool[T]) checkout(v *T) bool {
+	s := p.settings.Load()
+	if s.ttl <= 0 && p.validateFunc == nil && !p.migrating.Load() {
+		return true
+	}
+	now := time.Now()
+	m := p.meta(v)
+	if p.migrating.Load() && m.generation < p.generation.Load() {
+		// replaced by a new entry for the acquire (see RefreshLazy)
+		p.stats.stale.Add(1)
+		p.destroy(v)
+		return false
+	}
+	expired := s.ttl > 0 && now.Sub(m.createdAt) >= s.ttl && !m.pinned.Load()
+	validate := !expired && p.validateFunc != nil && now.UnixNano()-m.validatedAt.Load() >= int64(s.validationInterval)
+
+	if expired {
+		p.stats.expired.Add(1)
+		p.destroy(v)
+		return false
+	}
+	if validate {
+		var err error
+		if !p.validateFunc(v) {
+			err = ErrInvalidEntry
+		}
+		p.observeCheck(err != nil)
+		p.trace(m, TraceValidate, err)
+		if err != nil {
+			p.stats.invalid.Add(1)
+			p.reject(v, ErrInvalidEntry)
+			return false
+		}
+		m.validatedAt.Store(now.UnixNano())
+	}
+	return true
+}
+
+// checkin prepares a released entry for being put back into the pool, entries that exceeded their ttl,
+// are of an old generation or shouldn't be retained (see WithShouldRetain) get destroyed and false is returned
+func (p *Pool[T]) checkin(v *T) bool {
+	s := p.settings.Load()
+	// the metadata is only looked up if the release depends on it
+	var m *entryMeta
+	var expired, stale bool
+	if p.trackUse || s.ttl > 0 || s.idleTimeout > 0 || p.generation.Load() > 0 || p.costFunc != nil {
+		now := time.Now()
+		p.reissue(v)
+		m = p.meta(v)
+		p.observeHold(time.Duration(now.UnixNano() - m.lastUsed.Load()))
+		p.trace(m, TraceRelease, nil)
+		m.inUse.Store(false)
+		m.idleSince.Store(now.UnixNano())
+		expired = s.ttl > 0 && now.Sub(m.createdAt) >= s.ttl && !m.pinned.Load()
+		stale = m.generation < p.generation.Load()
+	}
+	rejected := !expired && !stale && p.retainFunc != nil && !p.retainFunc(v)
+
+	if expired || stale || rejected {
+		switch {
+		case expired:
+			p.stats.expired.Add(1)
+		case stale:
+			p.stats.stale.Add(1)
+		default:
+			p.stats.rejected.Add(1)
+		}
+		p.destroy(v)
+		p.refill()
+		return false
+	}
+	if p.resetFunc != nil {
+		err := p.runHook(p.resetFunc, v)
+		if m != nil {
+			p.trace(m, TraceReset, err)
+		}
+		if err != nil {
+			if err == errHookTimeout {
+				// the reset is still running, v can't be reused nor destroyed
+				p.abandon(v)
+			} else {
+				p.destroy(v)
+			}
+			p.refill()
+			return false
+		}
+	}
+	p.measure(v, m)
+	return true
+}
+
+// errHookTimeout is returned by runHook if the hook didn't return in time
+var errHookTimeout = errors.New("hook timed out")
+
+// runHook calls fn for v with a context limited by the maintenance timeout, if fn doesn't return
+// in time it keeps running in the background and errHookTimeout is returned
+func (p *Pool[T]) runHook(fn func(context.Context, *T) error, v *T) error {
+	d := p.opts.maintenanceTimeout
+	if d <= 0 {
+		return fn(context.Background(), v)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(ctx, v)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		p.stats.hookTimeouts.Add(1)
+		return errHookTimeout
+	}
+}
+
+// abandon removes v from the pools accounting without destroying it
+func (p *Pool[T]) abandon(v *T) {
+	p.live.Add(-1)
+	p.untrack(v)
+	p.checkDrained()
+}
+
+// applySettings starts the reaper if the settings require it
+func (p *Pool[T]) applySettings(s *settings) {
+	if s.ttl > 0 || s.idleTimeout > 0 {
+		p.reaperOnce.Do(func() { go p.reap() })
+	}
+	select {
+	case p.reaperWake <- struct{}{}:
+	default:
+	}
+}
+
+// tickInterval returns how often the reaper checks idle entries, 0 if it's not needed
+func (s *settings) tickInterval() time.Duration {
+	if s.ttl <= 0 && s.idleTimeout <= 0 {
+		return 0
+	}
+	if s.reapInterval > 0 {
+		return s.reapInterval
+	}
+	d := s.ttl
+	if d <= 0 || (s.idleTimeout > 0 && s.idleTimeout < d) {
+		d = s.idleTimeout
+	}
+	return max(d/2, time.Millisecond)
+}
+
+// reap periodically destroys expired idle entries until the pool gets closed
+func (p *Pool[T]) reap() {
+	for {
+		var t *time.Timer
+		var tc <-chan time.Time
+		if d := p.settings.Load().tickInterval(); d > 0 {
+			t = acquireTimer(d)
+			tc = t.C
+		}
+		select {
+		case <-p.done:
+		case <-p.reaperWake:
+		case now := <-tc:
+			p.reapIdle(now)
+		}
+		if t != nil {
+			releaseTimer(t)
+		}
+		if p.closed.Load() {
+			return
+		}
+	}
+}
+
+// restartIdle records the idle entries as idle since now, releases only record when an entry became
+// idle if the pool tracks the use of its entries or has an idle timeout (see checkin)
+func (p *Pool[T]) restartIdle() {
+	now := time.Now().UnixNano()
+	for _, v := range p.idle.snapshot() {
+		if m, ok := p.lookup(v); ok {
+			m.idleSince.Store(now)
+		}
+	}
+}
+
+// reapIdle destroys idle entries that exceeded their ttl or idle timeout, the idle timeout doesn't shrink the
+// pool below its minimum size and evicts the entries idle the longest first (see WithEvictionPolicy).
+// The entries are checked on a snapshot and taken out of the pool one by one, so the reaper neither locks
+// the pool nor blocks acquires and releases while it checks the entries.
+func (p *Pool[T]) reapIdle(now time.Time) {
+	s := p.settings.Load()
+	defer p.refill()
+	// entries that exceeded the idle timeout, idle the longest first
+	var expired, idle []*T
+	for _, v := range p.idle.snapshot() {
+		m, ok := p.lookup(v)
+		if !ok || m.pinned.Load() {
+			continue
+		}
+		if s.ttl > 0 && now.Sub(m.createdAt) >= s.ttl {
+			if p.idle.remove(v) {
+				expired = append(expired, v)
+			}
+			continue
+		}
+		if s.idleTimeout > 0 && now.UnixNano()-m.idleSince.Load() >= int64(s.idleTimeout) {
+			idle = append(idle, v)
+		}
+	}
+	// number of entries the idle timeout may remove
+	excess := p.live.Load() - int64(len(expired)) - int64(p.minSize())
+	for _, v := range p.evictionOrder(idle) {
+		if excess <= 0 {
+			break
+		}
+		if p.idle.remove(v) {
+			expired = append(expired, v)
+			excess--
+		}
+	}
+	for _, v := range expired {
+		p.stats.expired.Add(1)
+		p.destroy(v)
+	}
+}
@@ -0,0 +1,256 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTTL(t *testing.T) {
+	pool := NewPool(1, poolFactory, WithTTL(20*time.Millisecond), WithReapInterval(time.Hour))
+	defer pool.Close()
+
+	entry := pool.Acquire()
+	time.Sleep(30 * time.Millisecond)
+	// expired entries get destroyed on release and replaced
+	pool.Release(entry)
+	if next := pool.Acquire(); next == entry {
+		t.Errorf("expected expired entry to be replaced")
+	} else {
+		pool.Release(next)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	// expired idle entries get destroyed on acquire
+	pool.Acquire()
+	if stats := pool.Stats(); stats.Expired != 2 || stats.Created != 3 {
+		t.Errorf("expected 2 expired and 3 created entries but got %+v", stats)
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	pool := NewPool(3, poolFactory, WithMinSize(1), WithIdleTimeout(20*time.Millisecond), WithReapInterval(5*time.Millisecond))
+	defer pool.Close()
+
+	a, b, c := pool.Acquire(), pool.Acquire(), pool.Acquire()
+	pool.Release(a)
+	pool.Release(b)
+	pool.Release(c)
+	if pool.Len() != 3 {
+		t.Errorf("expected 3 idle entries but got %d", pool.Len())
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for pool.Len() > 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	// the pool doesn't shrink below its minimum size
+	time.Sleep(30 * time.Millisecond)
+	if stats := pool.Stats(); stats.Idle != 1 || stats.Expired != 2 {
+		t.Errorf("expected 1 idle and 2 expired entries but got %+v", stats)
+	}
+}
+
+func TestValidator(t *testing.T) {
+	valid := map[*poolItem]bool{}
+	pool := NewPool(2, poolFactory, WithValidator(func(e *poolItem) bool {
+		return !valid[e]
+	}))
+	a, b := pool.Acquire(), pool.Acquire()
+	valid[a] = true
+	pool.Release(a)
+	pool.Release(b)
+
+	// a fails validation and gets replaced
+	for range 2 {
+		if e := pool.Acquire(); e == a {
+			t.Errorf("expected invalid entry not to be handed out")
+		}
+	}
+	if stats := pool.Stats(); stats.Invalid != 1 || stats.Created != 3 {
+		t.Errorf("expected 1 invalid and 3 created entries but got %+v", stats)
+	}
+}
+
+func TestValidationInterval(t *testing.T) {
+	calls := 0
+	pool := NewPool(1, poolFactory, WithValidationInterval(time.Hour), WithValidator(func(e *poolItem) bool {
+		calls++
+		return true
+	}))
+	for range 3 {
+		pool.Release(pool.Acquire())
+	}
+	if calls != 0 {
+		t.Errorf("expected no validations within the interval but got %d", calls)
+	}
+}
+
+func TestValidatorTypeMismatch(t *testing.T) {
+	if _, err := newPool(1, poolFactory, []Option{WithValidator(func(*int) bool { return true })}); err == nil {
+		t.Errorf("expected error for a validator of the wrong type")
+	}
+}
+
+func TestReset(t *testing.T) {
+	pool := NewPool(2, poolFactory, WithReset(func(ctx context.Context, e *poolItem) error {
+		if *e == "broken" {
+			return errors.New("reset failed")
+		}
+		*e = nil
+		return nil
+	}))
+	a, b := pool.Acquire(), pool.Acquire()
+	*a = "used"
+	*b = "broken"
+	pool.Release(a)
+	pool.Release(b)
+
+	if stats := pool.Stats(); stats.Idle != 2 || stats.Destroyed != 1 {
+		t.Errorf("expected 2 idle and 1 destroyed entry but got %+v", stats)
+	}
+	if *a != nil {
+		t.Errorf("expected entry to be reset but got %v", *a)
+	}
+}
+
+func TestMaintenanceTimeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	destroyed := make(chan struct{}, 1)
+	pool := NewPool(1, poolFactory, WithMaintenanceTimeout(10*time.Millisecond), WithReset(func(ctx context.Context, e *poolItem) error {
+		// ignores ctx
+		<-block
+		return nil
+	}), WithDestroyerContext(func(ctx context.Context, e *poolItem) error {
+		<-ctx.Done()
+		destroyed <- struct{}{}
+		return ctx.Err()
+	}))
+
+	start := time.Now()
+	pool.Release(pool.Acquire())
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("expected release not to hang but it took %v", d)
+	}
+	stats := pool.Stats()
+	if stats.HookTimeouts != 1 || stats.Idle != 1 || stats.Destroyed != 0 {
+		t.Errorf("expected the entry to be abandoned and replaced but got %+v", stats)
+	}
+
+	pool.Close()
+	<-destroyed
+	if stats := pool.Stats(); stats.HookTimeouts != 2 {
+		t.Errorf("expected 2 hook timeouts but got %d", stats.HookTimeouts)
+	}
+}
+
+func TestMaxWaiters(t *testing.T) {
+	pool := NewPool(1, poolFactory, WithMaxWaiters(1))
+	e := pool.Acquire()
+	waiting := make(chan *poolItem)
+	go func() {
+		waiting <- pool.Acquire()
+	}()
+	for pool.waiters.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	if _, err := pool.AcquireWithTimeout(time.Second); !errors.Is(err, ErrPoolSaturated) {
+		t.Errorf("expected %v but got %v", ErrPoolSaturated, err)
+	}
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Errorf("expected the acquire to fail immediately but it took %v", d)
+	}
+	pool.Release(e)
+	pool.Release(<-waiting)
+	if stats := pool.Stats(); stats.Saturated != 1 {
+		t.Errorf("expected 1 saturated acquire but got %d", stats.Saturated)
+	}
+}
+
+func TestMaxWaitersCancel(t *testing.T) {
+	pool := NewPool(1, poolFactory, WithMaxWaiters(2))
+	e := pool.Acquire()
+	waiting := make(chan *poolItem)
+	go func() {
+		waiting <- pool.Acquire()
+	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error)
+	go func() {
+		_, err := pool.AcquireWithContext(ctx)
+		canceled <- err
+	}()
+	for pool.Stats().Waiters != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	// the canceled acquire isn't at the head of the queue
+	cancel()
+	if err := <-canceled; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v but got %v", context.Canceled, err)
+	}
+	if n := pool.Stats().Waiters; n != 1 {
+		t.Errorf("expected 1 waiter after the cancellation but got %d", n)
+	}
+	if _, err := pool.AcquireWithTimeout(time.Millisecond); !errors.Is(err, ErrAcquireTimeout) {
+		t.Errorf("expected the acquire to be admitted and time out but got %v", err)
+	}
+	pool.Release(e)
+	pool.Release(<-waiting)
+	if stats := pool.Stats(); stats.Waiters != 0 || stats.Saturated != 0 {
+		t.Errorf("expected no waiters and no saturated acquires but got %+v", stats)
+	}
+}
+
+func TestSlowAcquireThreshold(t *testing.T) {
+	slow := make(chan SlowAcquire, 1)
+	pool := NewPool(1, poolFactory, WithName("slow"), WithRegistry(NewRegistry()),
+		WithSlowAcquireThreshold(10*time.Millisecond, func(s SlowAcquire) { slow <- s }))
+	e := pool.Acquire()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		pool.Release(e)
+	}()
+	pool.Release(pool.Acquire())
+
+	select {
+	case s := <-slow:
+		if s.Pool != "slow" || s.Waiters != 1 || s.Stats.InUse != 1 {
+			t.Errorf("unexpected slow acquire report: %+v", s)
+		}
+	default:
+		t.Errorf("expected the slow acquire to be reported")
+	}
+	// fast acquires aren't reported
+	pool.Release(pool.Acquire())
+	time.Sleep(20 * time.Millisecond)
+	if len(slow) != 0 {
+		t.Errorf("expected no report for a fast acquire")
+	}
+}
+
+func TestShouldRetain(t *testing.T) {
+	type buffer struct{ data []byte }
+	pool := NewPool(1, func() *buffer { return &buffer{} }, WithShouldRetain(func(b *buffer) bool {
+		return cap(b.data) <= 1024
+	}))
+	defer pool.Close()
+
+	b := pool.Acquire()
+	b.data = make([]byte, 4096)
+	pool.Release(b)
+	if next := pool.Acquire(); next == b {
+		t.Errorf("expected oversized entry to be replaced")
+	} else {
+		pool.Release(next)
+	}
+	if stats := pool.Stats(); stats.Rejected != 1 || stats.Destroyed != 1 || stats.Idle != 1 {
+		t.Errorf("expected 1 rejected entry but got %+v", stats)
+	}
+	if _, err := NewPoolE(1, poolFactory, WithShouldRetain(func(*buffer) bool { return true })); err == nil {
+		t.Errorf("expected error for mismatching hook type")
+	}
+}
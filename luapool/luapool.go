@@ -0,0 +1,229 @@
+// Package luapool pools gopher-lua VMs (*lua.LState) with a set of preloaded scripts
+//
+// Scripts are compiled once into function prototypes when the pool is created, new VMs only
+// run the prototypes instead of parsing the sources again. VMs get the context of the caller
+// while they are acquired and are closed when they are removed from the pool. RefreshScripts
+// swaps the scripts at runtime, VMs loaded with the previous scripts are replaced:
+//
+//	p, err := luapool.New(luapool.Options{
+//		Size:    100,
+//		Scripts: []luapool.Script{{Name: "greet.lua", Source: src}},
+//	})
+//	...
+//	ret, err := p.Call(ctx, "greet", 1, lua.LString("epikur"))
+package luapool
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/epikur-io/go-pool"
+	"github.com/epikur-io/go-pool/internal/adapter"
+	lua "github.com/epikur-io/gopher-lua"
+	"github.com/epikur-io/gopher-lua/parse"
+)
+
+// Script is a named lua chunk loaded into every VM
+type Script struct {
+	Name   string
+	Source string
+}
+
+// Options of a VM pool
+type Options struct {
+	// Size is the number of VMs (required)
+	Size int
+	// Scripts are loaded into every VM in the given order
+	Scripts []Script
+	// State configures new VMs
+	State lua.Options
+	// Setup prepares new VMs before the scripts are loaded, e.g. to preload modules (optional)
+	Setup func(L *lua.LState) error
+}
+
+// VM is a pooled lua VM
+type VM struct {
+	*lua.LState
+	// scripts the VM was loaded with
+	scripts *scriptSet
+	// error of loading the scripts, VMs that failed to load get discarded
+	err error
+}
+
+// scriptSet is a compiled set of scripts
+type scriptSet struct {
+	names  []string
+	protos []*lua.FunctionProto
+}
+
+// Pool is a pool of lua VMs
+type Pool struct {
+	opts    Options
+	scripts atomic.Pointer[scriptSet]
+	pool    *pool.Pool[VM]
+}
+
+// Creates a pool of VMs running o.Scripts, which are compiled once up front. VMs loaded with scripts
+// replaced by RefreshScripts are dropped on acquire and removed VMs get closed, unless opts bring a
+// validator or destroyer of their own.
+func New(o Options, opts ...pool.Option) (*Pool, error) {
+	p := &Pool{opts: o}
+	scripts, err := p.compile(o.Scripts)
+	if err != nil {
+		return nil, err
+	}
+	p.scripts.Store(scripts)
+	defaults := []pool.Option{
+		pool.WithDestroyer(closeVM),
+		pool.WithValidator(p.valid),
+	}
+	vms, err := pool.NewPoolFromConfig(pool.Config{Size: o.Size}, p.newVM, append(defaults, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	p.pool = vms
+	return p, nil
+}
+
+// compile compiles scripts and loads them into a throwaway VM to report errors early
+func (p *Pool) compile(scripts []Script) (*scriptSet, error) {
+	set := &scriptSet{}
+	for _, s := range scripts {
+		chunk, err := parse.Parse(strings.NewReader(s.Source), s.Name)
+		if err != nil {
+			return nil, fmt.Errorf("luapool: parsing %s: %w", s.Name, err)
+		}
+		proto, err := lua.Compile(chunk, s.Name)
+		if err != nil {
+			return nil, fmt.Errorf("luapool: compiling %s: %w", s.Name, err)
+		}
+		set.names = append(set.names, s.Name)
+		set.protos = append(set.protos, proto)
+	}
+	vm := p.load(set)
+	defer vm.Close()
+	if vm.err != nil {
+		return nil, vm.err
+	}
+	return set, nil
+}
+
+// newVM is the factory of the pool
+func (p *Pool) newVM() *VM {
+	return p.load(p.scripts.Load())
+}
+
+// load creates a VM and runs the compiled scripts
+func (p *Pool) load(set *scriptSet) *VM {
+	vm := &VM{LState: lua.NewState(p.opts.State), scripts: set}
+	if p.opts.Setup != nil {
+		if err := p.opts.Setup(vm.LState); err != nil {
+			vm.err = fmt.Errorf("luapool: setup: %w", err)
+			return vm
+		}
+	}
+	for i, proto := range set.protos {
+		vm.Push(vm.NewFunctionFromProto(proto))
+		if err := vm.PCall(0, lua.MultRet, nil); err != nil {
+			vm.err = fmt.Errorf("luapool: loading %s: %w", set.names[i], err)
+			return vm
+		}
+		vm.SetTop(0)
+	}
+	return vm
+}
+
+// closeVM is the destroyer of the pool
+func closeVM(vm *VM) {
+	vm.Close()
+}
+
+// valid reports whether vm was loaded with the current scripts
+func (p *Pool) valid(vm *VM) bool {
+	return vm.err == nil && vm.scripts == p.scripts.Load()
+}
+
+// Returns the pool holding the VMs, e.g. to limit how long a VM lives using its config
+func (p *Pool) Pool() *pool.Pool[VM] {
+	return p.pool
+}
+
+// Acquire acquires a VM waiting until ctx is done, the VM is bound to ctx until it's released
+func (p *Pool) Acquire(ctx context.Context) (*VM, error) {
+	vm, err := p.pool.AcquireWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if vm.err != nil {
+		err := vm.err
+		p.pool.Replace(vm)
+		return nil, err
+	}
+	if ctx != nil {
+		vm.SetContext(ctx)
+	}
+	return vm, nil
+}
+
+// Releases a VM to the pool
+func (p *Pool) Release(vm *VM) error {
+	vm.RemoveContext()
+	vm.SetTop(0)
+	return p.pool.Release(vm)
+}
+
+// Discard closes a VM that shouldn't be reused (e.g. after it was interrupted),
+// a VM loading the current scripts takes its place
+func (p *Pool) Discard(vm *VM) error {
+	return p.pool.Replace(vm)
+}
+
+// Run acquires a VM and runs fn with it, the VM gets discarded if fn panics or ctx is done when fn returns
+// since interrupted VMs may be left in an inconsistent state
+func (p *Pool) Run(ctx context.Context, fn func(L *lua.LState) error) error {
+	vm, err := p.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	interrupted := func(error) bool { return ctx != nil && ctx.Err() != nil }
+	return adapter.Run(p.pool, vm, p.Release, interrupted, func() error { return fn(vm.LState) })
+}
+
+// Call calls the global function fn with args and returns its nret results
+func (p *Pool) Call(ctx context.Context, fn string, nret int, args ...lua.LValue) ([]lua.LValue, error) {
+	var ret []lua.LValue
+	err := p.Run(ctx, func(L *lua.LState) error {
+		f, ok := L.GetGlobal(fn).(*lua.LFunction)
+		if !ok {
+			return fmt.Errorf("luapool: %s is not a function", fn)
+		}
+		if err := L.CallByParam(lua.P{Fn: f, NRet: nret, Protect: true}, args...); err != nil {
+			return err
+		}
+		ret = make([]lua.LValue, nret)
+		for i := range ret {
+			ret[i] = L.Get(i - nret)
+		}
+		L.Pop(nret)
+		return nil
+	})
+	return ret, err
+}
+
+// RefreshScripts compiles scripts and replaces all VMs with ones loading them,
+// VMs in use get replaced once released. The previous scripts stay active if scripts fail to compile.
+func (p *Pool) RefreshScripts(ctx context.Context, scripts []Script) error {
+	set, err := p.compile(scripts)
+	if err != nil {
+		return err
+	}
+	p.scripts.Store(set)
+	return p.pool.RefreshAll(ctx)
+}
+
+// Close closes the pool and all idle VMs
+func (p *Pool) Close() error {
+	return p.pool.Close()
+}
@@ -0,0 +1,89 @@
+package luapool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	lua "github.com/epikur-io/gopher-lua"
+)
+
+const greet = `
+function greet(name)
+	return "Hello " .. name
+end
+`
+
+func TestCall(t *testing.T) {
+	p, err := New(Options{Size: 2, Scripts: []Script{{Name: "greet.lua", Source: greet}}})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	defer p.Close()
+	ret, err := p.Call(context.Background(), "greet", 1, lua.LString("epikur"))
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	if len(ret) != 1 || ret[0].String() != "Hello epikur" {
+		t.Errorf("expected greeting but got %v", ret)
+	}
+	if _, err := p.Call(context.Background(), "missing", 1); err == nil {
+		t.Errorf("expected error calling a missing function")
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	if _, err := New(Options{Size: 1, Scripts: []Script{{Name: "broken.lua", Source: "function ("}}}); err == nil {
+		t.Errorf("expected error for a script that doesn't parse")
+	}
+	if _, err := New(Options{Size: 1, Scripts: []Script{{Name: "fail.lua", Source: `error("boom")`}}}); err == nil {
+		t.Errorf("expected error for a script that fails to load")
+	}
+}
+
+func TestContext(t *testing.T) {
+	p, err := New(Options{Size: 1, Scripts: []Script{{Name: "loop.lua", Source: "function spin() while true do end end"}}})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	defer p.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := p.Call(ctx, "spin", 0); err == nil {
+		t.Errorf("expected the call to be interrupted")
+	}
+	// the interrupted VM was replaced
+	if stats := p.Pool().Stats(); stats.Destroyed != 1 {
+		t.Errorf("expected the interrupted VM to be destroyed but got %+v", stats)
+	}
+	if err := p.Run(context.Background(), func(L *lua.LState) error {
+		return L.DoString("x = 1")
+	}); err != nil {
+		t.Errorf("expected no error but got %v", err)
+	}
+}
+
+func TestRefreshScripts(t *testing.T) {
+	p, err := New(Options{Size: 2, Scripts: []Script{{Name: "v.lua", Source: "function version() return 1 end"}}})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	defer p.Close()
+	held, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	if err := p.RefreshScripts(context.Background(), []Script{{Name: "broken.lua", Source: "function ("}}); err == nil {
+		t.Errorf("expected error refreshing with a broken script")
+	}
+	if err := p.RefreshScripts(context.Background(), []Script{{Name: "v.lua", Source: "function version() return 2 end"}}); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	p.Release(held)
+	for i := 0; i < 3; i++ {
+		ret, err := p.Call(context.Background(), "version", 1)
+		if err != nil || ret[0] != lua.LNumber(2) {
+			t.Errorf("expected version 2 but got %v (%v)", ret, err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package pool
+
+import (
+	"fmt"
+	"time"
+)
+
+// OverflowPolicy sets what Release does if the queue of WithAsyncRelease is full
+type OverflowPolicy int
+
+const (
+	// reset and validate the entry in the releasing goroutine (default)
+	OverflowInline OverflowPolicy = iota
+	// wait until the queue has space
+	OverflowBlock
+	// destroy the entry, it gets replaced on demand
+	OverflowDestroy
+)
+
+func (o OverflowPolicy) String() string {
+	switch o {
+	case OverflowInline:
+		return "inline"
+	case OverflowBlock:
+		return "block"
+	case OverflowDestroy:
+		return "destroy"
+	}
+	return fmt.Sprintf("OverflowPolicy(%d)", int(o))
+}
+
+// WithAsyncRelease moves the maintenance of released entries (reset, ttl and validation, see WithReset
+// and WithValidator) off the hot path: Release queues the entry and returns, one of the given number
+// of background workers prepares it and puts it back into the pool. queueSize limits the number of
+// queued entries, overflow sets what happens to entries released while the queue is full.
+// TryRelease and TryReleaseWithContext still prepare entries in the releasing goroutine.
+func WithAsyncRelease(workers, queueSize int, overflow OverflowPolicy) Option {
+	return func(o *options) {
+		o.releaseWorkers = workers
+		o.releaseQueueSize = queueSize
+		o.releaseOverflow = overflow
+	}
+}
+
+// startMaintenance starts the workers of WithAsyncRelease
+func (p *Pool[T]) startMaintenance() {
+	if p.opts.releaseWorkers <= 0 {
+		return
+	}
+	p.releaseQueue = make(chan *T, max(p.opts.releaseQueueSize, 0))
+	for i := 0; i < p.opts.releaseWorkers; i++ {
+		go p.maintain()
+	}
+}
+
+// maintain prepares queued entries until the pool gets closed
+func (p *Pool[T]) maintain() {
+	for {
+		select {
+		case v := <-p.releaseQueue:
+			p.recycle(v)
+		case <-p.done:
+			p.drainReleases()
+			return
+		}
+	}
+}
+
+// enqueueRelease hands a released entry to the maintenance workers,
+// false is returned if the caller has to prepare it itself
+func (p *Pool[T]) enqueueRelease(v *T) bool {
+	if p.closed.Load() {
+		return false
+	}
+	// queued entries aren't in use anymore (see checkin)
+	p.meta(v).inUse.Store(false)
+	select {
+	case p.releaseQueue <- v:
+	default:
+		p.stats.releaseOverflows.Add(1)
+		switch p.opts.releaseOverflow {
+		case OverflowBlock:
+			select {
+			case p.releaseQueue <- v:
+			case <-p.done:
+				return false
+			}
+		case OverflowDestroy:
+			p.destroy(v)
+			p.refill()
+			return true
+		default:
+			return false
+		}
+	}
+	// the workers may have stopped before v was queued
+	if p.closed.Load() {
+		p.drainReleases()
+	}
+	return true
+}
+
+// drainReleases prepares all queued entries
+func (p *Pool[T]) drainReleases() {
+	for {
+		select {
+		case v := <-p.releaseQueue:
+			p.recycle(v)
+		default:
+			return
+		}
+	}
+}
+
+// recycle prepares a released entry and puts it back into the pool
+func (p *Pool[T]) recycle(v *T) {
+	if !p.checkin(v) {
+		return
+	}
+	if p.validateFunc != nil {
+		if !p.validateFunc(v) {
+			p.stats.invalid.Add(1)
+			p.reject(v, ErrInvalidEntry)
+			p.refill()
+			return
+		}
+		// skip the validation on acquire (see WithValidationInterval)
+		p.meta(v).validatedAt.Store(time.Now().UnixNano())
+	}
+	p.put(v)
+}
@@ -0,0 +1,64 @@
+package pool
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAsyncRelease(t *testing.T) {
+	started := make(chan struct{}, 3)
+	gate := make(chan struct{})
+	pool := NewPool(3, poolFactory, WithAsyncRelease(1, 1, OverflowDestroy), WithReset(func(ctx context.Context, e *poolItem) error {
+		started <- struct{}{}
+		<-gate
+		return nil
+	}))
+	defer pool.Close()
+
+	a, b, c := pool.Acquire(), pool.Acquire(), pool.Acquire()
+	start := time.Now()
+	pool.Release(a)
+	// wait until the worker resets a, b is queued and c overflows
+	<-started
+	pool.Release(b)
+	pool.Release(c)
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Errorf("expected Release not to wait for the reset but took %v", d)
+	}
+	if stats := pool.Stats(); stats.ReleaseOverflows != 1 || stats.Destroyed != 1 || stats.InUse != 0 {
+		t.Errorf("expected 1 overflowing entry to be destroyed but got %+v", stats)
+	}
+
+	close(gate)
+	// the destroyed entry got replaced
+	got := map[*poolItem]bool{}
+	for i := 0; i < 3; i++ {
+		v, err := pool.AcquireWithTimeout(time.Second)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		got[v] = true
+	}
+	if !got[a] || !got[b] || got[c] {
+		t.Errorf("expected reset entries and a replacement of the destroyed one")
+	}
+}
+
+func TestAsyncReleaseValidation(t *testing.T) {
+	valid := true
+	pool := NewPool(1, poolFactory, WithAsyncRelease(1, 1, OverflowBlock), WithValidator(func(e *poolItem) bool {
+		return valid
+	}), WithValidationInterval(time.Hour))
+	defer pool.Close()
+
+	entry := pool.Acquire()
+	valid = false
+	pool.Release(entry)
+	if next, _ := pool.AcquireWithTimeout(time.Second); next == entry {
+		t.Errorf("expected invalid entry to be replaced")
+	}
+	if stats := pool.Stats(); stats.Invalid != 1 {
+		t.Errorf("expected 1 invalid entry but got %+v", stats)
+	}
+}
@@ -0,0 +1,165 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+)
+
+// RefreshAll replaces all idle entries with freshly created ones (e.g. after the configuration
+// of the entries changed), entries in use get destroyed and replaced once released.
+// Idle entries are taken out of the pool and replaced one by one without locking the pool, acquires
+// and releases continue meanwhile and entries acquired before they got replaced are replaced on release.
+func (p *Pool[T]) RefreshAll(ctx context.Context) (err error) {
+	defer func() { err = p.wrapErr(opRefresh, err) }()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if p.closed.Load() {
+		return ErrPoolClosed
+	}
+	gen := p.generation.Add(1)
+	p.migrating.Store(true)
+	for _, v := range p.idle.snapshot() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if m, ok := p.lookup(v); !ok || m.generation >= gen || !p.idle.remove(v) {
+			continue
+		}
+		p.destroy(v)
+		if p.grow() {
+			p.put(p.newEntry())
+		}
+	}
+	return nil
+}
+
+// RefreshLazy starts a new generation of entries without replacing any entry up front, so a refresh never
+// stalls acquires: idle entries of an old generation are replaced by new ones when they get acquired and
+// entries in use once they get released. Stats.Migrated reports the progress, entries staying idle keep
+// their generation until they get acquired (RefreshAll replaces them right away).
+func (p *Pool[T]) RefreshLazy() error {
+	if p.closed.Load() {
+		return p.wrapErr(opRefresh, ErrPoolClosed)
+	}
+	p.generation.Add(1)
+	p.migrating.Store(true)
+	return nil
+}
+
+// migrated returns the percentage of entries of the current generation
+func (p *Pool[T]) migrated() float64 {
+	if !p.migrating.Load() {
+		return 100
+	}
+	gen := p.generation.Load()
+	var current, total int
+	p.rangeMeta(func(m *entryMeta) bool {
+		total++
+		if m.generation >= gen {
+			current++
+		}
+		return true
+	})
+	if current == total {
+		p.migrating.Store(false)
+		if gen != p.generation.Load() {
+			// refreshed concurrently
+			p.migrating.Store(true)
+		}
+		return 100
+	}
+	return float64(current) * 100 / float64(total)
+}
+
+// Resize changes the number of entries the pool holds, size must not exceed Cap()
+// surplus idle entries get destroyed immediately, surplus entries in use once they get released
+func (p *Pool[T]) Resize(size int) (err error) {
+	defer func() { err = p.wrapErr(opResize, err) }()
+	if size < 0 || size > p.Cap() {
+		return fmt.Errorf("%w: %d (capacity %d)", ErrInvalidSize, size, p.Cap())
+	}
+	if err := p.lock(); err != nil {
+		return err
+	}
+	defer p.unlock()
+	return p.resize(size)
+}
+
+// resize implements Resize, the caller must hold p.mux
+func (p *Pool[T]) resize(size int) error {
+	if p.closed.Load() {
+		return ErrPoolClosed
+	}
+	if p.opts.resizeRate > 0 {
+		p.resizeGradually(size)
+		return nil
+	}
+	p.target.Store(int64(size))
+	defer p.updateState()
+	for p.live.Load() > int64(size) {
+		v, ok := p.idle.tryGetOldest()
+		if !ok {
+			return nil
+		}
+		if !p.shrink() {
+			p.idle.put(v)
+			return nil
+		}
+		p.destroyEntry(v)
+	}
+	for p.live.Load() < int64(size) {
+		p.put(p.create())
+	}
+	return nil
+}
+
+// Pause stops handing out entries, acquires wait until the pool gets resumed
+func (p *Pool[T]) Pause() {
+	gate := make(chan struct{})
+	p.gate.CompareAndSwap(nil, &gate)
+}
+
+// Resume continues handing out entries after Pause
+func (p *Pool[T]) Resume() {
+	if gate := p.gate.Swap(nil); gate != nil {
+		close(*gate)
+	}
+}
+
+// Reports whether the pool is paused
+func (p *Pool[T]) Paused() bool {
+	return p.gate.Load() != nil
+}
+
+// Close closes the pool and destroys all idle entries, entries in use get destroyed once released
+// acquires on a closed pool fail with ErrPoolClosed
+func (p *Pool[T]) Close() error {
+	p.closeOnce.Do(func() {
+		p.closed.Store(true)
+		close(p.done)
+		p.Resume()
+		if p.opts.name != "" && p.opts.registry != nil {
+			p.opts.registry.Unregister(p.opts.name)
+		}
+	})
+	p.destroyIdle()
+	p.destroyDegraded()
+	p.ClearQuarantine()
+	p.checkDrained()
+	return nil
+}
+
+// Drain closes the pool and waits until all entries in use got released and destroyed
+func (p *Pool[T]) Drain(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	p.Close()
+	select {
+	case <-p.drained:
+		return nil
+	case <-ctx.Done():
+		return p.wrapErr(opDrain, ctx.Err())
+	}
+}
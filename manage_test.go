@@ -0,0 +1,214 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRefreshAll(t *testing.T) {
+	var destroyed atomic.Int32
+	pool := NewPool(3, poolFactory, WithDestroyer(func(*poolItem) { destroyed.Add(1) }))
+	inUse := pool.Acquire()
+
+	if err := pool.RefreshAll(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if destroyed.Load() != 2 {
+		t.Errorf("expected 2 destroyed entries but got %d", destroyed.Load())
+	}
+	stats := pool.Stats()
+	if stats.Idle != 2 || stats.Created != 5 || stats.Generation != 1 {
+		t.Errorf("unexpected stats after refresh: %+v", stats)
+	}
+	pool.Release(inUse)
+	if pool.Len() != 3 {
+		t.Errorf("expected pool to be full but got %d entries", pool.Len())
+	}
+}
+
+func TestMaintenanceUnlocked(t *testing.T) {
+	pool := NewPool(3, poolFactory, WithTTL(time.Minute), WithReapInterval(time.Hour))
+	defer pool.Close()
+	inUse := pool.Acquire()
+	// maintenance doesn't wait for the lock of LockedRun
+	err := pool.LockedRun(func(p *Pool[poolItem]) error {
+		done := make(chan error, 1)
+		go func() {
+			p.reapIdle(time.Now().Add(time.Hour))
+			done <- p.RefreshAll(context.Background())
+		}()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(time.Second):
+			return errors.New("maintenance blocked by LockedRun")
+		}
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	stats := pool.Stats()
+	if stats.Expired != 2 || stats.Idle != 2 || stats.Generation != 1 {
+		t.Errorf("expected the idle entries to be reaped and refreshed but got %+v", stats)
+	}
+	for range 2 {
+		v, _ := pool.TryTakeIdle()
+		if info, _ := pool.EntryInfo(v); info.Generation != 1 {
+			t.Errorf("expected an entry of generation 1 but got %d", info.Generation)
+		}
+		defer pool.Release(v)
+	}
+	pool.Release(inUse)
+}
+
+func TestRefreshLazy(t *testing.T) {
+	pool := NewPool(2, poolFactory)
+	old := pool.Acquire()
+	idle, _ := pool.TryTakeIdle()
+	pool.Release(idle)
+
+	if err := pool.RefreshLazy(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if stats := pool.Stats(); stats.Migrated != 0 || stats.Idle != 1 || stats.Generation != 1 {
+		t.Errorf("expected no entry to be replaced up front but got %+v", stats)
+	}
+	// the idle entry of the old generation gets replaced for the acquire
+	e := pool.Acquire()
+	if e == idle {
+		t.Errorf("expected a new entry")
+	}
+	if stats := pool.Stats(); stats.Migrated != 50 || stats.Stale != 1 {
+		t.Errorf("expected half of the entries to be migrated but got %+v", stats)
+	}
+	pool.Release(old)
+	pool.Release(e)
+	if stats := pool.Stats(); stats.Migrated != 100 || stats.Idle != 2 || stats.Created != 4 {
+		t.Errorf("expected all entries to be migrated but got %+v", stats)
+	}
+}
+
+func TestResize(t *testing.T) {
+	var destroyed atomic.Int32
+	pool := NewPool(2, poolFactory, WithMaxSize(4), WithDestroyer(func(*poolItem) { destroyed.Add(1) }))
+	if pool.Cap() != 4 || pool.Len() != 2 {
+		t.Errorf("expected 2 of 4 entries but got %d/%d", pool.Len(), pool.Cap())
+	}
+
+	if err := pool.Resize(4); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if pool.Len() != 4 {
+		t.Errorf("expected 4 entries but got %d", pool.Len())
+	}
+
+	a, b := pool.Acquire(), pool.Acquire()
+	if err := pool.Resize(1); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	// both idle entries got destroyed, the pool still holds one entry too many
+	if pool.Len() != 0 || destroyed.Load() != 2 {
+		t.Errorf("expected no idle entries and 2 destroyed but got %d/%d", pool.Len(), destroyed.Load())
+	}
+	pool.Release(a)
+	pool.Release(b)
+	if pool.Len() != 1 || destroyed.Load() != 3 {
+		t.Errorf("expected 1 idle entry and 3 destroyed but got %d/%d", pool.Len(), destroyed.Load())
+	}
+
+	if err := pool.Resize(5); !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("expected %v but got %v", ErrInvalidSize, err)
+	}
+	if err := pool.Resize(-1); !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("expected %v but got %v", ErrInvalidSize, err)
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	pool := NewPool(1, poolFactory)
+	pool.Pause()
+	if !pool.Paused() {
+		t.Errorf("expected pool to be paused")
+	}
+	if _, err := pool.AcquireWithTimeout(10 * time.Millisecond); !errors.Is(err, ErrAcquireTimeout) {
+		t.Errorf("expected %v but got %v", ErrAcquireTimeout, err)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		pool.Resume()
+	}()
+	if _, err := pool.AcquireWithTimeout(time.Second); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDrain(t *testing.T) {
+	var destroyed atomic.Int32
+	reg := NewRegistry()
+	pool := NewPool(2, poolFactory, WithName("drain"), WithRegistry(reg), WithDestroyer(func(*poolItem) { destroyed.Add(1) }))
+	entry := pool.Acquire()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	err := pool.Drain(ctx)
+	cancel()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v but got %v", context.DeadlineExceeded, err)
+	}
+	if destroyed.Load() != 1 {
+		t.Errorf("expected 1 destroyed entry but got %d", destroyed.Load())
+	}
+	if _, ok := reg.Get("drain"); ok {
+		t.Errorf("expected closed pool to be unregistered")
+	}
+	if _, err := pool.AcquireWithTimeout(time.Second); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("expected %v but got %v", ErrPoolClosed, err)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		pool.Release(entry)
+	}()
+	if err := pool.Drain(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if destroyed.Load() != 2 || pool.Len() != 0 {
+		t.Errorf("expected all entries to be destroyed but got %d destroyed, %d idle", destroyed.Load(), pool.Len())
+	}
+}
+
+func TestCloseWakesWaiters(t *testing.T) {
+	pool := NewPool(0, poolFactory)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		pool.Close()
+	}()
+	if v := pool.Acquire(); v != nil {
+		t.Errorf("expected nil from a closed pool but got %v", v)
+	}
+}
+
+func TestReleaseStale(t *testing.T) {
+	pool := NewPool(2, poolFactory)
+	lease, _ := pool.AcquireLease(context.Background())
+	if lease.Stale() || lease.Generation() != 0 {
+		t.Errorf("expected a current entry of generation 0")
+	}
+	pool.RefreshAll(context.Background())
+	if !lease.Stale() {
+		t.Errorf("expected the entry to be stale after RefreshAll")
+	}
+	old := lease.Value()
+	lease.Release()
+
+	stats := pool.Stats()
+	if stats.Stale != 1 || stats.Idle != 2 {
+		t.Errorf("expected the stale entry to be replaced but got %+v", stats)
+	}
+	if _, ok := pool.EntryInfo(old); ok {
+		t.Errorf("expected the stale entry to be destroyed")
+	}
+}
@@ -0,0 +1,119 @@
+package pool
+
+import "context"
+
+// AcquireMatch acquires an idle entry for which match returns true (e.g. a connection to a specific shard).
+// If no idle entry matches, a new entry is created if the pool holds less entries than it should and
+// returned if it matches, otherwise AcquireMatch waits until an entry gets released or ctx is done
+// (or fails with ErrPoolExhausted if the pool uses ExhaustionFail).
+// match is called while the idle entries are locked and must not use the pool.
+func (p *Pool[T]) AcquireMatch(ctx context.Context, match func(*T) bool) (*T, error) {
+	v, err := p.acquireWith(ctx, acquireConfig{match: match})
+	return v, p.wrapErr(opAcquire, err)
+}
+
+// acquireMatch implements AcquireMatch
+func (p *Pool[T]) acquireMatch(ctx context.Context, match func(*T) bool) (*T, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if t := p.watchSlow(); t != nil {
+		defer t.Stop()
+	}
+	start := p.waitStart()
+	for {
+		if p.closed.Load() {
+			return nil, ErrPoolClosed
+		}
+		if gate := p.gate.Load(); gate != nil {
+			select {
+			case <-*gate:
+			case <-p.done:
+			case <-ctx.Done():
+				p.timedOut()
+				return nil, ctx.Err()
+			}
+			continue
+		}
+		// subscribe before scanning to not miss entries released in the meantime
+		released := p.idleSignal()
+		if v := p.takeMatch(match); v != nil {
+			p.observeWait(start)
+			p.onAcquire(v)
+			return v, nil
+		}
+		if p.expand() {
+			v := p.newEntry()
+			if match(v) {
+				p.observeWait(start)
+				p.onAcquire(v)
+				return v, nil
+			}
+			p.put(v)
+			continue
+		}
+		if p.settings.Load().exhaustion == ExhaustionFail {
+			return nil, ErrPoolExhausted
+		}
+		if err := p.waitIdle(ctx, released); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// takeMatch takes the first idle entry for which match returns true
+func (p *Pool[T]) takeMatch(match func(*T) bool) *T {
+	for {
+		v := p.idle.takeMatch(match)
+		if v == nil || p.checkout(v) {
+			return v
+		}
+	}
+}
+
+// waitIdle waits until an entry gets released, the pool gets closed or ctx is done
+func (p *Pool[T]) waitIdle(ctx context.Context, released <-chan struct{}) error {
+	if !p.addWaiter() {
+		return ErrPoolSaturated
+	}
+	defer p.removeWaiter()
+	// entries destroyed in the meantime are only replaced for waiters (see refill)
+	if !p.full(p.live.Load()) {
+		return nil
+	}
+	if p.selfBlocked() {
+		return ErrWouldDeadlock
+	}
+	select {
+	case <-released:
+		return nil
+	case <-p.done:
+		return ErrPoolClosed
+	case <-ctx.Done():
+		p.timedOut()
+		return ctx.Err()
+	}
+}
+
+// idleSignal returns a channel that gets closed once an entry is put into the pool
+func (p *Pool[T]) idleSignal() <-chan struct{} {
+	for {
+		if c := p.idleNotify.Load(); c != nil {
+			return *c
+		}
+		c := make(chan struct{})
+		if p.idleNotify.CompareAndSwap(nil, &c) {
+			return c
+		}
+	}
+}
+
+// notifyIdle wakes up AcquireMatch calls waiting for released entries
+func (p *Pool[T]) notifyIdle() {
+	if p.idleNotify.Load() == nil {
+		return
+	}
+	if c := p.idleNotify.Swap(nil); c != nil {
+		close(*c)
+	}
+}
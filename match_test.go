@@ -0,0 +1,68 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type shardConn struct {
+	shard int
+}
+
+func TestAcquireMatch(t *testing.T) {
+	next := 0
+	pool := NewPool(4, func() *shardConn {
+		next++
+		return &shardConn{shard: next % 2}
+	})
+	isShard := func(shard int) func(*shardConn) bool {
+		return func(c *shardConn) bool { return c.shard == shard }
+	}
+
+	a, err := pool.AcquireMatch(context.Background(), isShard(0))
+	if err != nil || a.shard != 0 {
+		t.Fatalf("expected a connection to shard 0 but got %v, %v", a, err)
+	}
+	b, _ := pool.AcquireMatch(context.Background(), isShard(0))
+	if b == nil || b.shard != 0 || b == a {
+		t.Fatalf("expected another connection to shard 0 but got %v", b)
+	}
+	if pool.Len() != 2 {
+		t.Errorf("expected 2 idle entries but got %d", pool.Len())
+	}
+
+	// waits until a matching entry gets released
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		pool.Release(a)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if c, err := pool.AcquireMatch(ctx, isShard(0)); err != nil || c != a {
+		t.Errorf("expected the released connection but got %v, %v", c, err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := pool.AcquireMatch(ctx, isShard(2)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v but got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestAcquireMatchCreates(t *testing.T) {
+	next := 0
+	pool := NewPool(2, func() *shardConn {
+		next++
+		return &shardConn{shard: next}
+	}, WithMinSize(1), WithExhaustionPolicy(ExhaustionFail))
+
+	c, err := pool.AcquireMatch(context.Background(), func(c *shardConn) bool { return c.shard == 2 })
+	if err != nil || c.shard != 2 {
+		t.Errorf("expected a new connection to shard 2 but got %v, %v", c, err)
+	}
+	if _, err := pool.AcquireMatch(context.Background(), func(c *shardConn) bool { return c.shard == 3 }); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("expected %v but got %v", ErrPoolExhausted, err)
+	}
+}
@@ -0,0 +1,66 @@
+package pool
+
+import "time"
+
+// MetricsSink receives the metrics of a pool as they change, so pools can report to any metrics system
+// without the pool depending on it (see WithMetricsSink, the promsink package and the otelsink module).
+// The pool calls the sink synchronously on the hot path: implementations must be safe for concurrent use,
+// must not block and must not call methods of the pool. pool is the name of the pool (see WithName).
+type MetricsSink interface {
+	// ObserveAcquireWait is called for every acquire that got an entry with the time it waited
+	ObserveAcquireWait(pool string, d time.Duration)
+	// IncTimeout is called for every acquire that timed out or got canceled
+	IncTimeout(pool string)
+	// SetIdle and SetInUse are called with the number of idle entries and entries in use whenever
+	// an entry got acquired or put back into the pool
+	SetIdle(pool string, n int)
+	SetInUse(pool string, n int)
+	// IncCreated and IncDestroyed are called for every entry created and destroyed
+	IncCreated(pool string)
+	IncDestroyed(pool string)
+	// ObserveFactory is called with the duration of every factory call
+	ObserveFactory(pool string, d time.Duration)
+}
+
+// NopMetricsSink ignores all metrics, sinks interested in some metrics only can embed it
+// (which also keeps them compiling if methods get added to MetricsSink)
+type NopMetricsSink struct{}
+
+func (NopMetricsSink) ObserveAcquireWait(string, time.Duration) {}
+func (NopMetricsSink) IncTimeout(string)                        {}
+func (NopMetricsSink) SetIdle(string, int)                      {}
+func (NopMetricsSink) SetInUse(string, int)                     {}
+func (NopMetricsSink) IncCreated(string)                        {}
+func (NopMetricsSink) IncDestroyed(string)                      {}
+func (NopMetricsSink) ObserveFactory(string, time.Duration)     {}
+
+// WithMetricsSink reports the metrics of the pool to sink as they change (see MetricsSink)
+func WithMetricsSink(sink MetricsSink) Option {
+	return func(o *options) {
+		o.metricsSink = sink
+	}
+}
+
+// timedOut counts an acquire that timed out or got canceled
+func (p *Pool[T]) timedOut() {
+	p.stats.timeouts.Add(1)
+	if s := p.opts.metricsSink; s != nil {
+		s.IncTimeout(p.opts.name)
+	}
+}
+
+// countDestroyed counts a destroyed entry
+func (p *Pool[T]) countDestroyed() {
+	p.stats.destroyed.Add(1)
+	if s := p.opts.metricsSink; s != nil {
+		s.IncDestroyed(p.opts.name)
+	}
+}
+
+// reportGauges passes the number of idle entries and entries in use to the metrics sink
+func (p *Pool[T]) reportGauges() {
+	if s := p.opts.metricsSink; s != nil {
+		s.SetIdle(p.opts.name, p.Len())
+		s.SetInUse(p.opts.name, p.InUse())
+	}
+}
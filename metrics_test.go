@@ -0,0 +1,75 @@
+package pool
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingSink counts the calls of the metrics it's interested in
+type recordingSink struct {
+	NopMetricsSink
+	mux                                sync.Mutex
+	pool                               string
+	waits, timeouts, created, destroys int
+	idle, inUse                        int
+}
+
+func (s *recordingSink) ObserveAcquireWait(pool string, d time.Duration) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.pool = pool
+	s.waits++
+}
+
+func (s *recordingSink) IncTimeout(string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.timeouts++
+}
+
+func (s *recordingSink) SetIdle(_ string, n int) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.idle = n
+}
+
+func (s *recordingSink) SetInUse(_ string, n int) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.inUse = n
+}
+
+func (s *recordingSink) IncCreated(string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.created++
+}
+
+func (s *recordingSink) IncDestroyed(string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.destroys++
+}
+
+func TestMetricsSink(t *testing.T) {
+	sink := &recordingSink{}
+	pool := NewPool(2, poolFactory, WithName("sink"), WithMetricsSink(sink))
+	a, b := pool.Acquire(), pool.Acquire()
+	if _, err := pool.AcquireWithTimeout(time.Millisecond); err == nil {
+		t.Fatalf("expected a timeout")
+	}
+	sink.mux.Lock()
+	if sink.pool != "sink" || sink.waits != 2 || sink.timeouts != 1 || sink.created != 2 || sink.inUse != 2 || sink.idle != 0 {
+		t.Errorf("expected 2 acquires, 1 timeout and 2 entries in use but got %+v", sink)
+	}
+	sink.mux.Unlock()
+	pool.Release(a)
+	pool.Replace(b)
+	pool.Close()
+	sink.mux.Lock()
+	defer sink.mux.Unlock()
+	if sink.destroys != 3 || sink.created != 3 || sink.inUse != 0 {
+		t.Errorf("expected all 3 entries to be destroyed but got %+v", sink)
+	}
+}
@@ -0,0 +1,257 @@
+// Package netpool pools network connections (net.Conn), e.g. TCP connections to a backend
+//
+// Connections are dialed lazily on acquire using the context of the caller, probed before
+// they are handed out and closed when they are removed from the pool. IdleTimeout should be
+// set below the idle limit of the server so connections closed by the server aren't reused.
+// Connections use TLS if Options.TLS is set, the TLS state of every connection is reported
+// as metadata of its entry (see pool.EntryInfo):
+//
+//	p, err := netpool.New(netpool.Options{
+//		Network:     "tcp",
+//		Address:     "cache:6379",
+//		Size:        10,
+//		IdleTimeout: 30 * time.Second,
+//	})
+//	...
+//	err = p.Run(ctx, func(ctx context.Context, c net.Conn) error {
+//		_, err := c.Write([]byte("PING\r\n"))
+//		return err
+//	})
+package netpool
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/epikur-io/go-pool"
+	"github.com/epikur-io/go-pool/internal/adapter"
+)
+
+// default timeout of dialing a connection
+const defaultDialTimeout = 5 * time.Second
+
+// Options of a connection pool
+type Options struct {
+	// Network and Address to dial (required)
+	Network string
+	Address string
+	// Size is the number of connections (required)
+	Size int
+	// DialTimeout limits dialing a connection (default 5 seconds)
+	DialTimeout time.Duration
+	// KeepAlive is the interval of TCP keep-alive probes (see net.Dialer)
+	KeepAlive time.Duration
+	// Dial replaces the default dialer (optional)
+	Dial func(ctx context.Context, network, address string) (net.Conn, error)
+	// IdleTimeout closes connections idle for longer, 0 keeps them
+	IdleTimeout time.Duration
+	// MaxLifetime closes connections older than it, 0 keeps them
+	MaxLifetime time.Duration
+	// TLS enables TLS using the config (optional), the server name defaults to the host of Address.
+	// Renegotiation requested by the server is handled according to TLS.Renegotiation.
+	TLS *tls.Config
+	// CertExpiryMargin closes connections whose server certificate expires within the margin
+	CertExpiryMargin time.Duration
+	// SessionTimeout closes connections whose handshake is older, it should be set below the TLS
+	// session timeout of the server so connections are recycled before the server drops them
+	SessionTimeout time.Duration
+}
+
+// Conn is a pooled connection, Conn is nil until the connection is dialed
+type Conn struct {
+	net.Conn
+	releasedAt time.Time
+	tls        atomic.Pointer[TLSInfo]
+}
+
+// TLSInfo describes the TLS session of a connection
+type TLSInfo struct {
+	Version            uint16    `json:"version"`
+	CipherSuite        uint16    `json:"cipher_suite"`
+	ServerName         string    `json:"server_name"`
+	NegotiatedProtocol string    `json:"negotiated_protocol,omitempty"`
+	DidResume          bool      `json:"did_resume"`
+	HandshakeAt        time.Time `json:"handshake_at"`
+	// expiry of the server certificate
+	CertExpiry time.Time `json:"cert_expiry"`
+}
+
+// Returns the TLS session of the connection, nil if it doesn't use TLS or isn't dialed yet
+func (c *Conn) TLS() *TLSInfo {
+	return c.tls.Load()
+}
+
+// Pool is a pool of network connections
+type Pool struct {
+	opts Options
+	pool *pool.Pool[Conn]
+}
+
+// Creates a pool of connections to o.Address. Idle connections are probed for a peer that hung up before
+// they are handed out and closed once removed, a validator or destroyer passed in opts replaces the probe
+// or the close.
+func New(o Options, opts ...pool.Option) (*Pool, error) {
+	if o.Network == "" || o.Address == "" {
+		return nil, errors.New("netpool: missing network or address")
+	}
+	if o.DialTimeout <= 0 {
+		o.DialTimeout = defaultDialTimeout
+	}
+	if o.Dial == nil {
+		d := &net.Dialer{KeepAlive: o.KeepAlive}
+		o.Dial = d.DialContext
+	}
+	if o.TLS != nil && o.TLS.ServerName == "" {
+		host, _, err := net.SplitHostPort(o.Address)
+		if err != nil {
+			return nil, err
+		}
+		o.TLS = o.TLS.Clone()
+		o.TLS.ServerName = host
+	}
+	p := &Pool{opts: o}
+	defaults := []pool.Option{
+		pool.WithValidator(p.valid),
+		pool.WithDestroyerContext(closeConn),
+		pool.WithEntryMetadata(metadata),
+	}
+	cfg := pool.Config{Size: o.Size, TTL: pool.Duration(o.MaxLifetime), IdleTimeout: pool.Duration(o.IdleTimeout)}
+	if o.IdleTimeout > 0 {
+		// connections closed for being idle are dialed again on demand
+		minSize := 0
+		cfg.Min = &minSize
+	}
+	conns, err := pool.NewPoolFromConfig(cfg, func() *Conn { return &Conn{} }, append(defaults, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	p.pool = conns
+	return p, nil
+}
+
+// valid reports whether the connection of c can be reused
+func (p *Pool) valid(c *Conn) bool {
+	if c.Conn == nil {
+		return true
+	}
+	// the reaper might not have run yet
+	if p.opts.IdleTimeout > 0 && !c.releasedAt.IsZero() && time.Since(c.releasedAt) > p.opts.IdleTimeout {
+		return false
+	}
+	if info := c.TLS(); info != nil {
+		if p.opts.SessionTimeout > 0 && time.Since(info.HandshakeAt) > p.opts.SessionTimeout {
+			return false
+		}
+		if p.opts.CertExpiryMargin > 0 && !info.CertExpiry.IsZero() && time.Until(info.CertExpiry) < p.opts.CertExpiryMargin {
+			return false
+		}
+	}
+	if tc, ok := c.Conn.(*tls.Conn); ok {
+		return probeTLS(tc) == nil
+	}
+	return probe(c.Conn) == nil
+}
+
+// metadata reports the TLS session of connections as metadata of their entries
+func metadata(c *Conn) any {
+	if info := c.TLS(); info != nil {
+		return *info
+	}
+	return nil
+}
+
+// closeConn closes dialed connections
+func closeConn(_ context.Context, c *Conn) error {
+	if c.Conn == nil {
+		return nil
+	}
+	err := c.Close()
+	if errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+// Returns the pool holding the connections, entries of connections not dialed yet have a nil Conn
+func (p *Pool) Pool() *pool.Pool[Conn] {
+	return p.pool
+}
+
+// Acquire acquires a connection, dialing it if necessary, waiting until ctx is done
+func (p *Pool) Acquire(ctx context.Context) (*Conn, error) {
+	return adapter.Acquire(ctx, p.pool, dialed, p.dial)
+}
+
+// dialed reports whether c is connected
+func dialed(c *Conn) bool {
+	return c.Conn != nil
+}
+
+// dial connects c, performing the TLS handshake if the pool uses TLS
+func (p *Pool) dial(ctx context.Context, c *Conn) error {
+	ctx, cancel := context.WithTimeout(ctx, p.opts.DialTimeout)
+	defer cancel()
+	conn, err := p.opts.Dial(ctx, p.opts.Network, p.opts.Address)
+	if err != nil {
+		return err
+	}
+	if p.opts.TLS == nil {
+		c.Conn = conn
+		return nil
+	}
+	tc := tls.Client(conn, p.opts.TLS)
+	if err := tc.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return err
+	}
+	state := tc.ConnectionState()
+	info := &TLSInfo{
+		Version:            state.Version,
+		CipherSuite:        state.CipherSuite,
+		ServerName:         state.ServerName,
+		NegotiatedProtocol: state.NegotiatedProtocol,
+		DidResume:          state.DidResume,
+		HandshakeAt:        time.Now(),
+	}
+	if len(state.PeerCertificates) > 0 {
+		info.CertExpiry = state.PeerCertificates[0].NotAfter
+	}
+	c.Conn = tc
+	c.tls.Store(info)
+	return nil
+}
+
+// Releases a connection to the pool, deadlines set while it was used are cleared
+func (p *Pool) Release(c *Conn) error {
+	if c.Conn != nil {
+		if err := c.SetDeadline(time.Time{}); err != nil {
+			return p.pool.Replace(c)
+		}
+		c.releasedAt = time.Now()
+	}
+	return p.pool.Release(c)
+}
+
+// Discard closes a broken connection (e.g. after a read timeout), its slot dials anew on the next acquire
+func (p *Pool) Discard(c *Conn) error {
+	return p.pool.Replace(c)
+}
+
+// Run acquires a connection and runs fn with it. The connection is closed if fn fails or panics, a
+// response may be left half-read on it.
+func (p *Pool) Run(ctx context.Context, fn func(ctx context.Context, c net.Conn) error) error {
+	c, err := p.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	return adapter.Run(p.pool, c, p.Release, nil, func() error { return fn(ctx, c.Conn) })
+}
+
+// Close closes the pool and all idle connections
+func (p *Pool) Close() error {
+	return p.pool.Close()
+}
@@ -0,0 +1,137 @@
+package netpool
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testServer accepts connections and echoes lines, it can close or write to its connections
+type testServer struct {
+	ln    net.Listener
+	mux   sync.Mutex
+	conns []net.Conn
+}
+
+func newTestServer(t *testing.T) *testServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	s := &testServer{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mux.Lock()
+			s.conns = append(s.conns, c)
+			s.mux.Unlock()
+			go func() {
+				r := bufio.NewReader(c)
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					c.Write([]byte(line))
+				}
+			}()
+		}
+	}()
+	return s
+}
+
+// each runs fn for all accepted connections
+func (s *testServer) each(fn func(c net.Conn)) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for _, c := range s.conns {
+		fn(c)
+	}
+}
+
+func echo(ctx context.Context, c net.Conn) error {
+	if _, err := c.Write([]byte("ping\n")); err != nil {
+		return err
+	}
+	_, err := bufio.NewReader(c).ReadString('\n')
+	return err
+}
+
+func TestRun(t *testing.T) {
+	s := newTestServer(t)
+	p, err := New(Options{Network: "tcp", Address: s.ln.Addr().String(), Size: 2})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	defer p.Close()
+	for i := 0; i < 5; i++ {
+		if err := p.Run(context.Background(), echo); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+	}
+	if stats := p.Pool().Stats(); stats.Destroyed != 0 {
+		t.Errorf("expected the connection to be reused but got %+v", stats)
+	}
+	if _, err := New(Options{Size: 1}); err == nil {
+		t.Errorf("expected error for a missing address")
+	}
+}
+
+func TestProbe(t *testing.T) {
+	s := newTestServer(t)
+	p, err := New(Options{Network: "tcp", Address: s.ln.Addr().String(), Size: 1})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	defer p.Close()
+	if err := p.Run(context.Background(), echo); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	// closed by the server
+	s.each(func(c net.Conn) { c.Close() })
+	time.Sleep(10 * time.Millisecond)
+	if err := p.Run(context.Background(), echo); err != nil {
+		t.Errorf("expected the closed connection to be replaced but got %v", err)
+	}
+
+	// unexpected data
+	s.each(func(c net.Conn) { c.Write([]byte("stale\n")) })
+	time.Sleep(10 * time.Millisecond)
+	if err := p.Run(context.Background(), echo); err != nil {
+		t.Errorf("expected the connection with unread data to be replaced but got %v", err)
+	}
+	if stats := p.Pool().Stats(); stats.Destroyed != 2 {
+		t.Errorf("expected 2 destroyed connections but got %+v", stats)
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	s := newTestServer(t)
+	p, err := New(Options{Network: "tcp", Address: s.ln.Addr().String(), Size: 1, IdleTimeout: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	defer p.Close()
+	c, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	first := c.Conn
+	p.Release(c)
+	time.Sleep(30 * time.Millisecond)
+	if c, err = p.Acquire(context.Background()); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	if c.Conn == first {
+		t.Errorf("expected the idle connection to be replaced")
+	}
+	p.Release(c)
+}
@@ -0,0 +1,42 @@
+package netpool
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"os"
+	"time"
+)
+
+// errUnexpectedData is returned by probe for connections with unread data
+var errUnexpectedData = errors.New("netpool: unexpected data on idle connection")
+
+// timeout of probes that need to wait for a read
+const probeTimeout = time.Millisecond
+
+// probeDeadline checks whether an idle connection is still open using a read with a short deadline,
+// used for connections that don't expose their file descriptor
+func probeDeadline(c net.Conn) error {
+	if err := c.SetReadDeadline(time.Now().Add(probeTimeout)); err != nil {
+		return err
+	}
+	defer c.SetReadDeadline(time.Time{})
+	var buf [1]byte
+	n, err := c.Read(buf[:])
+	if n > 0 {
+		return errUnexpectedData
+	}
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return nil
+	}
+	return err
+}
+
+// probeTLS checks whether an idle TLS connection is still open, pending data might be a TLS record
+// like a session ticket or an alert that is processed using a read on the TLS connection
+func probeTLS(c *tls.Conn) error {
+	if err := probe(c.NetConn()); err != errUnexpectedData {
+		return err
+	}
+	return probeDeadline(c)
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package netpool
+
+import "net"
+
+// probe checks whether an idle connection is still open
+func probe(c net.Conn) error {
+	return probeDeadline(c)
+}
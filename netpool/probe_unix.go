@@ -0,0 +1,43 @@
+//go:build unix
+
+package netpool
+
+import (
+	"errors"
+	"io"
+	"net"
+	"syscall"
+)
+
+// probe checks whether an idle connection is still open using a non-blocking peek,
+// connections that got closed by the peer or received unexpected data fail the probe
+func probe(c net.Conn) error {
+	sc, ok := c.(syscall.Conn)
+	if !ok {
+		return probeDeadline(c)
+	}
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var buf [1]byte
+	var n int
+	var perr error
+	err = rc.Read(func(fd uintptr) bool {
+		n, _, perr = syscall.Recvfrom(int(fd), buf[:], syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		// don't wait for the connection to become readable
+		return true
+	})
+	switch {
+	case err != nil:
+		return err
+	case errors.Is(perr, syscall.EAGAIN) || errors.Is(perr, syscall.EWOULDBLOCK):
+		return nil
+	case perr != nil:
+		return perr
+	case n == 0:
+		return io.EOF
+	default:
+		return errUnexpectedData
+	}
+}
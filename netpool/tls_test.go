@@ -0,0 +1,131 @@
+package netpool
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+// newTLSServer starts a TLS echo server using a self-signed certificate expiring at notAfter
+func newTLSServer(t *testing.T, notAfter time.Time) (string, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		IsCA:         true,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	ln, err := tls.Listen("tcp", "localhost:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				r := bufio.NewReader(c)
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					c.Write([]byte(line))
+				}
+			}()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	return net.JoinHostPort("localhost", port), roots
+}
+
+func TestTLS(t *testing.T) {
+	addr, roots := newTLSServer(t, time.Now().Add(24*time.Hour))
+	p, err := New(Options{Network: "tcp", Address: addr, Size: 1, TLS: &tls.Config{RootCAs: roots}})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	defer p.Close()
+	for i := 0; i < 3; i++ {
+		if err := p.Run(context.Background(), echo); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		// let the session tickets of TLS 1.3 arrive
+		time.Sleep(5 * time.Millisecond)
+	}
+	if stats := p.Pool().Stats(); stats.Destroyed != 0 {
+		t.Errorf("expected the connection to be reused but got %+v", stats)
+	}
+	infos := p.Pool().IdleEntries()
+	if len(infos) != 1 {
+		t.Fatalf("expected 1 idle entry but got %d", len(infos))
+	}
+	info, ok := infos[0].Metadata.(TLSInfo)
+	if !ok || info.ServerName != "localhost" || info.Version != tls.VersionTLS13 || info.CertExpiry.IsZero() {
+		t.Errorf("expected TLS metadata but got %+v", infos[0].Metadata)
+	}
+}
+
+func TestTLSRecycle(t *testing.T) {
+	addr, roots := newTLSServer(t, time.Now().Add(time.Hour))
+	p, err := New(Options{Network: "tcp", Address: addr, Size: 1, TLS: &tls.Config{RootCAs: roots}, CertExpiryMargin: 2 * time.Hour})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	defer p.Close()
+	for i := 0; i < 2; i++ {
+		if err := p.Run(context.Background(), echo); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+	}
+	if stats := p.Pool().Stats(); stats.Destroyed != 1 {
+		t.Errorf("expected the connection with an expiring certificate to be replaced but got %+v", stats)
+	}
+
+	p, err = New(Options{Network: "tcp", Address: addr, Size: 1, TLS: &tls.Config{RootCAs: roots}, SessionTimeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	defer p.Close()
+	if err := p.Run(context.Background(), echo); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if err := p.Run(context.Background(), echo); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	if stats := p.Pool().Stats(); stats.Destroyed != 1 {
+		t.Errorf("expected the connection to be recycled after the session timeout but got %+v", stats)
+	}
+}
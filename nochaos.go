@@ -0,0 +1,21 @@
+//go:build !chaos
+
+package pool
+
+import (
+	"context"
+	"time"
+)
+
+// chaosState is only available in builds using the chaos tag (see chaos.go)
+type chaosState struct{}
+
+func (p *Pool[T]) initChaos() {}
+
+func (p *Pool[T]) chaosActive() bool { return false }
+
+func (p *Pool[T]) chaosDelay(context.Context, <-chan time.Time) error { return nil }
+
+func (p *Pool[T]) chaosCreate() error { return nil }
+
+func (p *Pool[T]) chaosInvalidate() bool { return false }
@@ -0,0 +1,435 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidOption is wrapped by the errors of invalid options and combinations of options (see NewPoolE)
+var ErrInvalidOption = fmt.Errorf("invalid option")
+
+// Option configures optional behavior of a pool
+type Option func(*options)
+
+type options struct {
+	// create a new entry using the factory function when nil is released
+	nilReplacement bool
+	unsafeAccess   bool
+	lifo           bool
+	unbounded      bool
+	// see WithDeadlockDetection
+	deadlockDetection bool
+	// see WithCreateConcurrency
+	createConcurrency int
+	// see WithAsyncRelease
+	releaseWorkers   int
+	releaseQueueSize int
+	releaseOverflow  OverflowPolicy
+	// see WithAsyncDestroy
+	destroyWorkers   int
+	destroyQueueSize int
+	destroyGrace     time.Duration
+	// see WithWaitHistory
+	waitHistory int
+	// see WithWaitBuckets
+	waitBuckets []time.Duration
+	// see WithLeaseContext
+	leaseContext bool
+	// see WithChaos
+	chaos *chaosState
+	// see WithSeed
+	seed     int64
+	seeded   bool
+	name     string
+	registry *Registry
+	maxSize  int
+	settings
+	// func(*T), resolved when the pool gets created
+	destroyer any
+	// see WithAutoClose
+	autoClose bool
+	// func(context.Context, *T) error, resolved when the pool gets created
+	reset              any
+	maintenanceTimeout time.Duration
+	// func(*T) bool, resolved when the pool gets created
+	validator any
+	// func(*T) bool, resolved when the pool gets created
+	shouldRetain any
+	// func(*T) any, resolved when the pool gets created
+	metadata any
+	// func(*T) int64, resolved when the pool gets created
+	cost       any
+	costBudget int64
+	// func(context.Context, *T) error, resolved when the pool gets created
+	healthCheck    any
+	healthInterval time.Duration
+	// func(context.Context, *T) error, resolved when the pool gets created
+	repair        any
+	healthTimeout time.Duration
+	eventHooks    []func(Event)
+	// func(Quarantined[T]), resolved when the pool gets created
+	quarantineInspect any
+	quarantineSize    int
+
+	slowAcquireThreshold time.Duration
+	slowAcquireFunc      func(SlowAcquire)
+	// see WithLifecycleTrace
+	traceSize int
+	// see WithErrorBudget
+	errorBudget *ErrorBudget
+	// see WithMaxHoldTime
+	maxHoldTime     time.Duration
+	holdExpiredFunc func(HoldExpired)
+	reclaimHolds    bool
+	// see WithEvictionPolicy
+	evictionPolicy EvictionPolicy
+	// see WithWaitQueue
+	waitQueue WaitQueue
+	// see WithMaxPinned
+	maxPinned int
+	// see WithCreationNotify
+	creationNotify func(context.Context, Creation)
+	// see WithCheckedRelease
+	checkedRelease bool
+	// see WithResizeRate
+	resizeRate int
+	// see WithBackgroundFill
+	backgroundFill bool
+	syncFill       int
+	// see WithLeakDetection
+	leakDetection bool
+	leakFunc      func(LeakedEntry)
+	// see WithSpinAcquire
+	spin time.Duration
+	// see WithLoadShedding
+	maxEstimatedWait time.Duration
+	// see WithMetricsSink
+	metricsSink MetricsSink
+	// see WithUsageTracking
+	usageTracking bool
+}
+
+// settings are the options that can be changed at runtime (see ApplyConfig)
+type settings struct {
+	// -1 if all entries get created up front
+	minSize            int
+	ttl                time.Duration
+	idleTimeout        time.Duration
+	reapInterval       time.Duration
+	validationInterval time.Duration
+	exhaustion         ExhaustionPolicy
+	// 0 if the number of waiting acquires isn't limited
+	maxWaiters int
+}
+
+func newOptions(opts []Option) options {
+	o := options{registry: DefaultRegistry, settings: settings{minSize: -1}}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
+
+// validate returns an error listing all invalid options and combinations of options that would be ignored
+func (o *options) validate(size int) error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrInvalidOption, fmt.Sprintf(format, args...)))
+	}
+	if size == 0 && o.maxSize == 0 && !o.unbounded {
+		errs = append(errs, fmt.Errorf("%w: size and max are 0, the pool can't hold any entries", ErrInvalidSize))
+	}
+	errs = append(errs, o.config(size).Validate())
+	if o.lifo && o.unsafeAccess {
+		invalid("LIFO order isn't supported WithUnsafeAccess")
+	}
+	if o.waitQueue != nil && o.unsafeAccess {
+		invalid("custom wait queues aren't supported WithUnsafeAccess")
+	}
+	if o.reapInterval < 0 {
+		invalid("reap interval %v is negative", o.reapInterval)
+	} else if o.reapInterval > 0 && o.ttl <= 0 && o.idleTimeout <= 0 {
+		invalid("reap interval %v without a ttl or idle timeout", o.reapInterval)
+	}
+	if o.idleTimeout > 0 && o.minSize < 0 && !o.unbounded {
+		invalid("idle timeout %v without a min size, the pool doesn't shrink below its size", o.idleTimeout)
+	}
+	if o.validationInterval > 0 && o.validator == nil {
+		invalid("validation interval %v without a validator", o.validationInterval)
+	}
+	if o.maxWaiters > 0 && o.exhaustion == ExhaustionFail {
+		invalid("max waiters %d with the exhaustion policy %v, acquires never wait", o.maxWaiters, o.exhaustion)
+	}
+	if o.maintenanceTimeout < 0 {
+		invalid("maintenance timeout %v is negative", o.maintenanceTimeout)
+	}
+	if o.createConcurrency < 0 {
+		invalid("create concurrency %d is negative", o.createConcurrency)
+	}
+	if o.healthCheck != nil && o.healthInterval <= 0 {
+		invalid("health check interval %v isn't positive", o.healthInterval)
+	}
+	if o.repair != nil && o.healthCheck == nil {
+		invalid("repair without a health check, entries never get degraded")
+	}
+	if o.healthTimeout < 0 {
+		invalid("health check timeout %v is negative", o.healthTimeout)
+	}
+	if o.releaseWorkers < 0 || o.releaseQueueSize < 0 {
+		invalid("async release workers %d or queue size %d is negative", o.releaseWorkers, o.releaseQueueSize)
+	}
+	if o.releaseOverflow < OverflowInline || o.releaseOverflow > OverflowDestroy {
+		invalid("unknown overflow policy %v", o.releaseOverflow)
+	}
+	if o.destroyWorkers < 0 || o.destroyQueueSize < 0 || o.destroyGrace < 0 {
+		invalid("async destroy workers %d, queue size %d or grace %v is negative", o.destroyWorkers, o.destroyQueueSize, o.destroyGrace)
+	} else if o.destroyWorkers > 0 && o.destroyer == nil && !o.autoClose {
+		invalid("async destroy without a destroyer")
+	}
+	if o.waitHistory < 0 {
+		invalid("wait history %d is negative", o.waitHistory)
+	}
+	if len(o.waitBuckets) > 0 && o.waitBuckets[0] <= 0 {
+		invalid("wait bucket %v isn't positive", o.waitBuckets[0])
+	}
+	if o.cost != nil && o.costBudget <= 0 {
+		invalid("cost budget %d isn't positive", o.costBudget)
+	}
+	if b := o.errorBudget; b != nil {
+		if b.Threshold <= 0 || b.Threshold > 1 {
+			invalid("error budget threshold %v isn't within (0, 1]", b.Threshold)
+		}
+		if b.Window <= 0 {
+			invalid("error budget window %v isn't positive", b.Window)
+		}
+		if o.validator == nil && o.healthCheck == nil {
+			invalid("error budget without a validator or health check")
+		}
+	}
+	if o.traceSize < 0 {
+		invalid("lifecycle trace size %d is negative", o.traceSize)
+	}
+	if o.maxHoldTime < 0 {
+		invalid("max hold time %v is negative", o.maxHoldTime)
+	} else if o.reclaimHolds && o.maxHoldTime == 0 {
+		invalid("reclaiming expired holds without a max hold time")
+	}
+	if o.maxPinned < 0 {
+		invalid("max pinned entries %d is negative", o.maxPinned)
+	}
+	if o.maxEstimatedWait < 0 {
+		invalid("max estimated wait %v is negative", o.maxEstimatedWait)
+	}
+	if o.spin < 0 {
+		invalid("spin duration %v is negative", o.spin)
+	}
+	if o.resizeRate < 0 {
+		invalid("resize rate %d is negative", o.resizeRate)
+	}
+	if o.syncFill < 0 {
+		invalid("synchronous fill %d is negative", o.syncFill)
+	}
+	if o.leakDetection && (o.maxHoldTime > 0 || o.nilReplacement) {
+		invalid("leak detection can't be combined with a max hold time or nil replacement")
+	}
+	if o.quarantineInspect != nil && o.quarantineSize <= 0 {
+		invalid("quarantine size %d isn't positive", o.quarantineSize)
+	}
+	return errors.Join(errs...)
+}
+
+// WithNilReplacement makes Release(nil) (and the TryRelease variants) put a
+// freshly created entry into the pool instead of failing with ErrNilEntry.
+// The pool doesn't keep acquired entries reachable then, the metadata of a dropped
+// entry is forgotten once the garbage collector collected it (see runtime.AddCleanup).
+func WithNilReplacement() Option {
+	return func(o *options) {
+		o.nilReplacement = true
+	}
+}
+
+// WithUnsafeAccess allows accessing the internal channel of the pool using UnsafeChannel.
+// Maintenance (e.g. the reaper) inspects the idle entries by taking them out of the channel
+// one at a time and putting them back.
+func WithUnsafeAccess() Option {
+	return func(o *options) {
+		o.unsafeAccess = true
+	}
+}
+
+// WithLIFO hands out the most recently released entry first instead of the one idle the longest,
+// keeping the remaining entries idle so they can be reaped by the idle timeout
+// (not supported WithUnsafeAccess)
+func WithLIFO() Option {
+	return func(o *options) {
+		o.lifo = true
+	}
+}
+
+// WithLeaseContext makes RunWithContext pass a context holding the lease of the acquired entry to fn,
+// so functions deep down the call stack can reach the entry using LeaseFromContext
+func WithLeaseContext() Option {
+	return func(o *options) {
+		o.leaseContext = true
+	}
+}
+
+// WithUnbounded makes acquires create a new entry instead of waiting if no entry is idle,
+// the size of the pool only limits the number of entries kept once they get released
+func WithUnbounded() Option {
+	return func(o *options) {
+		o.unbounded = true
+	}
+}
+
+// WithName names the pool and registers it in the DefaultRegistry
+// (or the registry given by WithRegistry), NewPool panics if the name is already taken
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithRegistry sets the registry named pools get registered in
+func WithRegistry(r *Registry) Option {
+	return func(o *options) {
+		o.registry = r
+	}
+}
+
+// WithMaxSize sets the capacity of the pool which allows to grow the pool using Resize,
+// the size given to NewPool is the initial number of entries
+func WithMaxSize(n int) Option {
+	return func(o *options) {
+		o.maxSize = n
+	}
+}
+
+// WithDestroyer sets a function that gets called for every entry removed from the pool
+// (e.g. to close connections), its type must match the pools type or else NewPool panics
+func WithDestroyer[T any](fn func(*T)) Option {
+	return func(o *options) {
+		o.destroyer = fn
+	}
+}
+
+// WithDestroyerContext is like WithDestroyer for destroyers that may block,
+// ctx is limited by the maintenance timeout (see WithMaintenanceTimeout)
+func WithDestroyerContext[T any](fn func(ctx context.Context, e *T) error) Option {
+	return func(o *options) {
+		o.destroyer = fn
+	}
+}
+
+// WithReset sets a function that prepares released entries for reuse (e.g. rolls back
+// transactions), entries for which it fails get destroyed, ctx is limited by the maintenance
+// timeout (see WithMaintenanceTimeout), its type must match the pools type or else NewPool panics
+func WithReset[T any](fn func(ctx context.Context, e *T) error) Option {
+	return func(o *options) {
+		o.reset = fn
+	}
+}
+
+// WithMaintenanceTimeout limits the duration of reset and destroy hooks, hooks not returning in
+// time keep running in the background while the releasing goroutine continues, entries
+// whose reset timed out are dropped from the pool (counted by Stats.HookTimeouts)
+func WithMaintenanceTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.maintenanceTimeout = d
+	}
+}
+
+// WithMinSize makes the pool lazy: only n entries get created up front and are kept alive,
+// the remaining entries up to the pools size get created on demand
+func WithMinSize(n int) Option {
+	return func(o *options) {
+		o.minSize = n
+	}
+}
+
+// WithTTL sets the maximum lifetime of entries, expired entries get destroyed
+// instead of being handed out or put back into the pool
+func WithTTL(d time.Duration) Option {
+	return func(o *options) {
+		o.ttl = d
+	}
+}
+
+// WithIdleTimeout destroys entries that were idle for at least d,
+// the pool doesn't shrink below its minimum size (see WithMinSize)
+func WithIdleTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.idleTimeout = d
+	}
+}
+
+// WithReapInterval sets how often idle entries are checked for their ttl and idle timeout,
+// defaults to half of the shorter duration
+func WithReapInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.reapInterval = d
+	}
+}
+
+// WithExhaustionPolicy sets what acquires do if all entries are in use
+func WithExhaustionPolicy(policy ExhaustionPolicy) Option {
+	return func(o *options) {
+		o.exhaustion = policy
+	}
+}
+
+// WithMaxWaiters limits the number of acquires waiting for an entry,
+// additional acquires fail immediately with ErrPoolSaturated
+func WithMaxWaiters(n int) Option {
+	return func(o *options) {
+		o.maxWaiters = n
+	}
+}
+
+// WithValidator sets a function that checks idle entries before they are handed out,
+// invalid entries get destroyed, its type must match the pools type or else NewPool panics
+func WithValidator[T any](fn func(*T) bool) Option {
+	return func(o *options) {
+		o.validator = fn
+	}
+}
+
+// WithShouldRetain sets a function consulted when entries get released, entries for which it returns
+// false get destroyed instead of being put back (e.g. buffers that grew too large or connections marked
+// broken), its type must match the pools type or else NewPool panics
+func WithShouldRetain[T any](fn func(*T) bool) Option {
+	return func(o *options) {
+		o.shouldRetain = fn
+	}
+}
+
+// WithEntryMetadata sets a function returning adapter specific information about an entry
+// (e.g. the TLS state of a connection) reported as EntryInfo.Metadata, its type must match
+// the pools type or else NewPool panics
+func WithEntryMetadata[T any](fn func(*T) any) Option {
+	return func(o *options) {
+		o.metadata = fn
+	}
+}
+
+// WithValidationInterval skips the validation of entries that were validated less than d ago
+func WithValidationInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.validationInterval = d
+	}
+}
+
+// WithHealthCheck periodically checks all idle entries using fn (e.g. a ping), entries for
+// which fn returns an error get destroyed and replaced, timeout limits the duration of
+// each check (no limit if 0), its type must match the pools type or else NewPool panics
+func WithHealthCheck[T any](fn func(ctx context.Context, e *T) error, interval, timeout time.Duration) Option {
+	return func(o *options) {
+		o.healthCheck = fn
+		o.healthInterval = interval
+		o.healthTimeout = timeout
+	}
+}
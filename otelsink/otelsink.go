@@ -0,0 +1,100 @@
+// Package otelsink implements pool.MetricsSink recording the metrics of pools to OpenTelemetry instruments
+// of a meter, labeled by the name of the pool:
+//
+//	sink, err := otelsink.New(otel.Meter("github.com/epikur-io/go-pool"))
+//	p := pool.NewPool(10, factory, pool.WithName("vms"), pool.WithMetricsSink(sink))
+//
+// It's a module of its own, so the OpenTelemetry API isn't a dependency of the pool module. The instruments
+// use the names of the Prometheus metrics of the promsink and admin packages, durations are recorded in
+// seconds.
+package otelsink
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/epikur-io/go-pool"
+)
+
+// Attribute is the key of the attribute holding the name of the pool
+const Attribute = "pool"
+
+// Sink records the metrics of any number of pools to the instruments of a meter
+type Sink struct {
+	idle, inUse                  metric.Int64Gauge
+	timeouts, created, destroyed metric.Int64Counter
+	waits, factory               metric.Float64Histogram
+	// pool name -> metric.MeasurementOption
+	attrs sync.Map
+}
+
+var _ pool.MetricsSink = (*Sink)(nil)
+
+// New creates the instruments of the sink using meter
+func New(meter metric.Meter) (*Sink, error) {
+	s := &Sink{}
+	var err error
+	if s.idle, err = meter.Int64Gauge("pool_idle", metric.WithDescription("Entries waiting in the pool.")); err != nil {
+		return nil, err
+	}
+	if s.inUse, err = meter.Int64Gauge("pool_in_use", metric.WithDescription("Entries currently acquired.")); err != nil {
+		return nil, err
+	}
+	if s.timeouts, err = meter.Int64Counter("pool_timeouts_total", metric.WithDescription("Acquires that timed out or got canceled.")); err != nil {
+		return nil, err
+	}
+	if s.created, err = meter.Int64Counter("pool_created_total", metric.WithDescription("Entries created by the factory.")); err != nil {
+		return nil, err
+	}
+	if s.destroyed, err = meter.Int64Counter("pool_destroyed_total", metric.WithDescription("Entries destroyed by the pool.")); err != nil {
+		return nil, err
+	}
+	if s.waits, err = meter.Float64Histogram("pool_acquire_wait_seconds", metric.WithDescription("Wait times of acquires."), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if s.factory, err = meter.Float64Histogram("pool_factory_seconds", metric.WithDescription("Durations of factory calls."), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// attr returns the attributes of the pool called name, created on first use so recording doesn't allocate
+func (s *Sink) attr(name string) metric.MeasurementOption {
+	if o, ok := s.attrs.Load(name); ok {
+		return o.(metric.MeasurementOption)
+	}
+	o, _ := s.attrs.LoadOrStore(name, metric.WithAttributeSet(attribute.NewSet(attribute.String(Attribute, name))))
+	return o.(metric.MeasurementOption)
+}
+
+func (s *Sink) ObserveAcquireWait(name string, d time.Duration) {
+	s.waits.Record(context.Background(), d.Seconds(), s.attr(name))
+}
+
+func (s *Sink) IncTimeout(name string) {
+	s.timeouts.Add(context.Background(), 1, s.attr(name))
+}
+
+func (s *Sink) SetIdle(name string, n int) {
+	s.idle.Record(context.Background(), int64(n), s.attr(name))
+}
+
+func (s *Sink) SetInUse(name string, n int) {
+	s.inUse.Record(context.Background(), int64(n), s.attr(name))
+}
+
+func (s *Sink) IncCreated(name string) {
+	s.created.Add(context.Background(), 1, s.attr(name))
+}
+
+func (s *Sink) IncDestroyed(name string) {
+	s.destroyed.Add(context.Background(), 1, s.attr(name))
+}
+
+func (s *Sink) ObserveFactory(name string, d time.Duration) {
+	s.factory.Record(context.Background(), d.Seconds(), s.attr(name))
+}
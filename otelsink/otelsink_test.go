@@ -0,0 +1,70 @@
+package otelsink
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/epikur-io/go-pool"
+)
+
+type entry struct{}
+
+func TestSink(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	sink, err := New(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test"))
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	p := pool.NewPool(1, func() *entry { return &entry{} }, pool.WithName("vms"), pool.WithMetricsSink(sink))
+	defer p.Close()
+	v := p.Acquire()
+	if _, err := p.AcquireWithTimeout(time.Millisecond); err == nil {
+		t.Fatalf("expected a timeout")
+	}
+	p.Release(v)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	got := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Gauge[int64]:
+				checkAttr(t, m.Name, data.DataPoints[0].Attributes)
+				got[m.Name] = data.DataPoints[0].Value
+			case metricdata.Sum[int64]:
+				checkAttr(t, m.Name, data.DataPoints[0].Attributes)
+				got[m.Name] = data.DataPoints[0].Value
+			case metricdata.Histogram[float64]:
+				checkAttr(t, m.Name, data.DataPoints[0].Attributes)
+				got[m.Name] = int64(data.DataPoints[0].Count)
+			}
+		}
+	}
+	for name, want := range map[string]int64{
+		"pool_idle":                 1,
+		"pool_in_use":               0,
+		"pool_created_total":        1,
+		"pool_timeouts_total":       1,
+		"pool_acquire_wait_seconds": 1,
+		"pool_factory_seconds":      1,
+	} {
+		if n, ok := got[name]; !ok || n != want {
+			t.Errorf("expected %s to be %d but got %d (%v)", name, want, n, ok)
+		}
+	}
+}
+
+func checkAttr(t *testing.T, name string, attrs attribute.Set) {
+	t.Helper()
+	if v, ok := attrs.Value(Attribute); !ok || v.AsString() != "vms" {
+		t.Errorf("expected %s to be labeled with the pool but got %v", name, attrs)
+	}
+}
@@ -0,0 +1,67 @@
+package pool
+
+import "fmt"
+
+// ErrPinLimit is returned when pinning an entry would exceed the maximum number of pinned entries
+var ErrPinLimit = fmt.Errorf("too many pinned entries")
+
+// WithMaxPinned sets the maximum number of entries pinned at the same time (see Lease.Pin), 1 by default
+func WithMaxPinned(n int) Option {
+	return func(o *options) {
+		o.maxPinned = n
+	}
+}
+
+// Pin exempts the leased entry from eviction by the ttl and idle timeout until it gets unpinned, even
+// across releases (e.g. the VM that has an expensive per-tenant cache warmed). The number of pinned
+// entries is limited (see WithMaxPinned), pinning fails with ErrPinLimit once the limit is reached.
+// The entry still gets destroyed if it fails validation, by RefreshAll or once the pool is closed.
+func (l Lease[T]) Pin() error {
+	return l.pool.wrapErr(opPin, l.pool.pin(l.value))
+}
+
+// Unpin makes the leased entry subject to eviction again
+func (l Lease[T]) Unpin() {
+	if m, ok := l.pool.lookup(l.value); ok {
+		l.pool.unpin(m)
+	}
+}
+
+// Reports whether the leased entry is pinned
+func (l Lease[T]) Pinned() bool {
+	m, ok := l.pool.lookup(l.value)
+	return ok && m.pinned.Load()
+}
+
+// pin pins the entry v unless the maximum number of pinned entries is reached
+func (p *Pool[T]) pin(v *T) error {
+	m, ok := p.lookup(v)
+	if !ok {
+		return ErrNotAcquired
+	}
+	if m.pinned.Load() {
+		return nil
+	}
+	limit := int64(max(p.opts.maxPinned, 1))
+	for {
+		n := p.pinned.Load()
+		if n >= limit {
+			return ErrPinLimit
+		}
+		if p.pinned.CompareAndSwap(n, n+1) {
+			break
+		}
+	}
+	if !m.pinned.CompareAndSwap(false, true) {
+		// pinned concurrently
+		p.pinned.Add(-1)
+	}
+	return nil
+}
+
+// unpin unpins the entry of m
+func (p *Pool[T]) unpin(m *entryMeta) {
+	if m.pinned.CompareAndSwap(true, false) {
+		p.pinned.Add(-1)
+	}
+}
@@ -0,0 +1,48 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPin(t *testing.T) {
+	pool := NewPool(2, poolFactory, WithMinSize(0), WithTTL(20*time.Millisecond), WithIdleTimeout(20*time.Millisecond), WithReapInterval(5*time.Millisecond))
+	defer pool.Close()
+	pinned, _ := pool.AcquireLease(context.Background())
+	other, _ := pool.AcquireLease(context.Background())
+	if err := pinned.Pin(); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	if err := other.Pin(); !errors.Is(err, ErrPinLimit) {
+		t.Errorf("expected %v but got %v", ErrPinLimit, err)
+	}
+	pinned.Release()
+	other.Release()
+
+	time.Sleep(60 * time.Millisecond)
+	info, ok := pool.EntryInfo(pinned.Value())
+	if !ok || !info.Pinned {
+		t.Fatalf("expected the pinned entry to be kept but got %+v", info)
+	}
+	if _, ok := pool.EntryInfo(other.Value()); ok {
+		t.Errorf("expected the entry that isn't pinned to be evicted")
+	}
+	if stats := pool.Stats(); stats.Pinned != 1 || stats.Idle != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	// pinned entries aren't evicted by the ttl when acquired either
+	lease, _ := pool.AcquireLease(context.Background())
+	if lease.Value() != pinned.Value() || !lease.Pinned() {
+		t.Fatalf("expected the pinned entry")
+	}
+	lease.Unpin()
+	lease.Release()
+	if _, ok := pool.EntryInfo(pinned.Value()); ok {
+		t.Errorf("expected the unpinned entry to be evicted")
+	}
+	if stats := pool.Stats(); stats.Pinned != 0 {
+		t.Errorf("expected no pinned entries but got %d", stats.Pinned)
+	}
+}
@@ -0,0 +1,155 @@
+package pool
+
+import (
+	"context"
+	"sync"
+)
+
+// MapOption configures Map
+type MapOption func(*mapOptions)
+
+type mapOptions struct {
+	concurrency int
+	ordered     bool
+}
+
+// WithConcurrency limits the number of jobs Map processes concurrently (default is the capacity of the pool)
+func WithConcurrency(n int) MapOption {
+	return func(o *mapOptions) {
+		o.concurrency = n
+	}
+}
+
+// WithOrderedOutput makes Map emit results in the order of their jobs instead of their completion
+func WithOrderedOutput() MapOption {
+	return func(o *mapOptions) {
+		o.ordered = true
+	}
+}
+
+// mapResult is the result of a single job in ordered mode
+type mapResult[R any] struct {
+	value R
+	err   error
+}
+
+// Map runs fn for every job read from in, each invocation holding an entry of p.
+// The results are sent to the returned result channel, the first error (of fn or ctx)
+// stops processing and is sent to the error channel. Both channels are closed once
+// in is closed and all jobs are processed or processing stopped.
+func Map[T, J, R any](ctx context.Context, p *Pool[T], in <-chan J, fn func(e *T, job J) (R, error), opts ...MapOption) (<-chan R, <-chan error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	o := mapOptions{concurrency: p.Cap()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.concurrency <= 0 {
+		o.concurrency = 1
+	}
+
+	out := make(chan R)
+	errs := make(chan error, 1)
+	ctx, cancel := context.WithCancel(ctx)
+	var failOnce sync.Once
+	fail := func(err error) {
+		failOnce.Do(func() {
+			errs <- err
+			cancel()
+		})
+	}
+	send := func(v R) {
+		select {
+		case out <- v:
+		case <-ctx.Done():
+		}
+	}
+
+	// futures of the jobs in the order they were read (ordered mode only)
+	var order chan chan mapResult[R]
+	if o.ordered {
+		order = make(chan chan mapResult[R], o.concurrency)
+	}
+	sem := make(chan struct{}, o.concurrency)
+	var wg sync.WaitGroup
+
+	run := func(job J, fut chan mapResult[R]) {
+		defer wg.Done()
+		defer func() { <-sem }()
+		var r mapResult[R]
+		e, err := p.AcquireWithContext(ctx)
+		if err == nil {
+			r.value, r.err = fn(e, job)
+			p.Release(e)
+		} else {
+			r.err = err
+		}
+		if r.err != nil {
+			fail(r.err)
+		}
+		if fut != nil {
+			fut <- r
+		} else if r.err == nil {
+			send(r.value)
+		}
+	}
+
+	dispatch := func() {
+		defer wg.Wait()
+		for {
+			var job J
+			select {
+			case j, ok := <-in:
+				if !ok {
+					return
+				}
+				job = j
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			var fut chan mapResult[R]
+			if order != nil {
+				fut = make(chan mapResult[R], 1)
+				// blocks while too many results wait for an earlier job
+				select {
+				case order <- fut:
+				case <-ctx.Done():
+					<-sem
+					return
+				}
+			}
+			wg.Add(1)
+			go run(job, fut)
+		}
+	}
+
+	go func() {
+		defer cancel()
+		defer close(errs)
+		defer close(out)
+		if order == nil {
+			dispatch()
+		} else {
+			go func() {
+				dispatch()
+				close(order)
+			}()
+			for fut := range order {
+				if r := <-fut; r.err == nil {
+					send(r.value)
+				}
+			}
+		}
+		// report cancellation of the parent context
+		if err := ctx.Err(); err != nil {
+			fail(err)
+		}
+	}()
+	return out, errs
+}
@@ -0,0 +1,68 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func jobs(n int) <-chan int {
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		for i := range n {
+			in <- i
+		}
+	}()
+	return in
+}
+
+func TestMapOrdered(t *testing.T) {
+	pool := NewPool(4, poolFactory)
+	out, errs := Map(context.Background(), pool, jobs(50), func(e *poolItem, job int) (string, error) {
+		// finish out of order
+		time.Sleep(time.Duration(50-job) * 10 * time.Microsecond)
+		return strconv.Itoa(job), nil
+	}, WithOrderedOutput())
+
+	i := 0
+	for r := range out {
+		if r != strconv.Itoa(i) {
+			t.Errorf("expected result %d but got %s", i, r)
+		}
+		i++
+	}
+	if err := <-errs; err != nil {
+		t.Error(err)
+	}
+	if i != 50 {
+		t.Errorf("expected 50 results but got %d", i)
+	}
+	if stats := pool.Stats(); stats.InUse != 0 {
+		t.Errorf("expected all entries to be released but got %d in use", stats.InUse)
+	}
+}
+
+func TestMapError(t *testing.T) {
+	pool := NewPool(2, poolFactory)
+	errFailed := errors.New("failed")
+	out, errs := Map(context.Background(), pool, jobs(100), func(e *poolItem, job int) (int, error) {
+		if job == 10 {
+			return 0, errFailed
+		}
+		return job, nil
+	}, WithConcurrency(1))
+
+	n := 0
+	for range out {
+		n++
+	}
+	if err := <-errs; err != errFailed {
+		t.Errorf("expected %v but got %v", errFailed, err)
+	}
+	if n != 10 {
+		t.Errorf("expected 10 results before the error but got %d", n)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -12,6 +13,13 @@ var (
 	// Error messages
 	ErrFailedToRelease        = fmt.Errorf("failed to release to pool")
 	ErrMissingFactoryFunction = fmt.Errorf("missing factory function")
+	ErrAcquireTimeout         = fmt.Errorf("timeout while acquiring from pool")
+	ErrNilEntry               = fmt.Errorf("nil entry released to pool")
+	ErrPoolClosed             = fmt.Errorf("pool is closed")
+	ErrInvalidSize            = fmt.Errorf("invalid pool size")
+	ErrPoolExhausted          = fmt.Errorf("pool is exhausted")
+	ErrUnsafeAccess           = fmt.Errorf("pool wasn't created WithUnsafeAccess")
+	ErrPoolSaturated          = fmt.Errorf("too many acquires waiting for the pool")
 )
 
 // Generic pool implementation
@@ -22,71 +30,563 @@ type Pooler[T any] interface {
 	Acquire() *T
 	AcquireWithTimeout(time.Duration) (*T, error)
 	AcquireWithContext(context.Context) (*T, error)
-	Release(*T)
+	AcquireMatch(context.Context, func(*T) bool) (*T, error)
+	Release(*T) error
+	Replace(*T) error
 	TryRelease(*T) error
 	TryReleaseWithContext(context.Context, *T) error
 	LockedRun(func(p *Pool[T]) error) error
+	Transaction(context.Context, func(tx *Tx[T]) error) error
 	Channel() chan *T
+	UnsafeChannel() chan *T
+	TryTakeIdle() (*T, bool)
+	TryPutIdle(*T) error
 	FactoryFunc() func() *T
+	Name() string
+	Stats() Stats
+	RefreshAll(context.Context) error
+	Resize(int) error
+	Pause()
+	Resume()
+	Close() error
+	Drain(context.Context) error
+	Config() Config
+	ApplyConfig(Config) error
 }
 
 var _ Pooler[any] = &Pool[any]{}
 
 // Creates a new pool with the given size/capacity
 // factoryFunc returns the the type the pool should hold must be provided or else the call will panic
-func NewPool[T any](size int, factoryFunc func() *T) *Pool[T] {
+// a negative size panics with ErrInvalidSize, acquires of a pool of size 0 wait until it gets resized
+// (see WithMaxSize) unless the pool is unbounded (see WithUnbounded)
+func NewPool[T any](size int, factoryFunc func() *T, opts ...Option) *Pool[T] {
+	lp, err := newPool(size, factoryFunc, opts)
+	if err != nil {
+		panic(err)
+	}
+	return lp
+}
+
+// Creates a new pool like NewPool but returns an error instead of panicking, the size and the options
+// are validated (see Config.Validate), including combinations of options that would be ignored (e.g. a reap
+// interval without a ttl), and all problems are reported at once wrapping ErrInvalidSize or ErrInvalidOption
+func NewPoolE[T any](size int, factoryFunc func() *T, opts ...Option) (*Pool[T], error) {
+	o := newOptions(opts)
+	var errs []error
+	if factoryFunc == nil {
+		errs = append(errs, ErrMissingFactoryFunction)
+	}
+	errs = append(errs, o.validate(size))
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return newPool(size, factoryFunc, opts)
+}
+
+func newPool[T any](size int, factoryFunc func() *T, opts []Option) (*Pool[T], error) {
 	if factoryFunc == nil {
-		panic(ErrMissingFactoryFunction)
+		return nil, ErrMissingFactoryFunction
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("%w: size %d is negative", ErrInvalidSize, size)
+	}
+	lp := &Pool[T]{size: size, opts: newOptions(opts)}
+	lp.factoryFunc.Store(&factoryFunc)
+	if destroyFunc, ok := lp.opts.destroyer.(func(*T)); ok {
+		lp.destroyFunc = func(_ context.Context, v *T) error {
+			destroyFunc(v)
+			return nil
+		}
+	} else if err := hookOption(lp.opts.destroyer, "destroyer", &lp.destroyFunc); err != nil {
+		return nil, err
+	}
+	if lp.opts.autoClose {
+		if err := lp.autoClose(); err != nil {
+			return nil, err
+		}
+	}
+	if lp.opts.lifo && lp.opts.unsafeAccess {
+		return nil, fmt.Errorf("LIFO order isn't supported WithUnsafeAccess")
+	}
+	if lp.opts.waitQueue != nil && lp.opts.unsafeAccess {
+		return nil, fmt.Errorf("custom wait queues aren't supported WithUnsafeAccess")
+	}
+	if lp.opts.leakDetection && zeroSized[T]() {
+		return nil, fmt.Errorf("leak detection isn't supported for zero-sized entries")
+	}
+	if err := hookOption(lp.opts.reset, "reset", &lp.resetFunc); err != nil {
+		return nil, err
+	}
+	if err := hookOption(lp.opts.validator, "validator", &lp.validateFunc); err != nil {
+		return nil, err
+	}
+	if err := hookOption(lp.opts.shouldRetain, "should retain", &lp.retainFunc); err != nil {
+		return nil, err
+	}
+	if err := hookOption(lp.opts.metadata, "entry metadata", &lp.metadataFunc); err != nil {
+		return nil, err
+	}
+	if err := hookOption(lp.opts.cost, "cost", &lp.costFunc); err != nil {
+		return nil, err
+	}
+	if err := hookOption(lp.opts.healthCheck, "health check", &lp.healthFunc); err != nil {
+		return nil, err
+	}
+	if err := hookOption(lp.opts.repair, "repair", &lp.repairFunc); err != nil {
+		return nil, err
+	}
+	if lp.opts.quarantineSize > 0 {
+		lp.quarantine = &quarantine[T]{size: lp.opts.quarantineSize}
+		if err := hookOption(lp.opts.quarantineInspect, "quarantine", &lp.quarantineFunc); err != nil {
+			return nil, err
+		}
+	}
+	if lp.opts.name != "" && lp.opts.registry != nil {
+		if _, ok := lp.opts.registry.Get(lp.opts.name); ok {
+			return nil, fmt.Errorf("%w: %q", ErrDuplicatePoolName, lp.opts.name)
+		}
 	}
-	lp := &Pool[T]{size: size, factoryFunc: factoryFunc}
 	lp.init()
-	return lp
+	if lp.opts.name != "" && lp.opts.registry != nil {
+		if err := lp.opts.registry.Register(lp); err != nil {
+			lp.Close()
+			return nil, err
+		}
+	}
+	return lp, nil
+}
+
+// hookOption resolves a type independent hook option to the function type of the pool
+func hookOption[F any](hook any, name string, fn *F) error {
+	if hook == nil {
+		return nil
+	}
+	f, ok := hook.(F)
+	if !ok {
+		return fmt.Errorf("%s has type %T, expected %T", name, hook, f)
+	}
+	*fn = f
+	return nil
 }
 
 type Pool[T any] struct {
 	// size of the pool
 	size int
-	// factory function to fill the pool
-	factoryFunc func() *T
-	pool        chan *T
-	mux         sync.Mutex
+	// factory function to fill the pool, swapped by SwapWith
+	factoryFunc atomic.Pointer[func() *T]
+	// optional function called for entries removed from the pool
+	destroyFunc func(context.Context, *T) error
+	// optional function preparing released entries for reuse
+	resetFunc func(context.Context, *T) error
+	// optional function checking entries before they are handed out
+	validateFunc func(*T) bool
+	// optional function deciding if released entries are put back
+	retainFunc func(*T) bool
+	// optional function describing entries (see WithEntryMetadata)
+	metadataFunc func(*T) any
+	// preallocated error of timed out acquires
+	errTimeout *PoolError
+	// optional function returning the cost of an entry (see WithCostBudget)
+	costFunc func(*T) int64
+	// optional function checking idle entries in the background
+	healthFunc func(context.Context, *T) error
+	// optional function repairing degraded entries (see WithRepair)
+	repairFunc func(context.Context, *T) error
+	// degraded entries waiting for repair, oldest first
+	degraded    []*T
+	degradedMux sync.Mutex
+	// number of degraded entries, including the ones in use or being repaired
+	degradedCount atomic.Int64
+	// number of pinned entries (see Lease.Pin)
+	pinned atomic.Int64
+	// wakes up the repairer
+	repairWake chan struct{}
+	// entries that failed validation or a health check (see WithQuarantine)
+	quarantine *quarantine[T]
+	// optional function inspecting quarantined entries
+	quarantineFunc func(Quarantined[T])
+	// idle entries
+	idle  idleStore[T]
+	mux   sync.Mutex
+	opts  options
+	stats counters
+
+	// number of entries the pool should hold (see Resize)
+	target atomic.Int64
+	// size a rate limited resize moves the pool to (see WithResizeRate)
+	resizeTo   atomic.Int64
+	resizeWake chan struct{}
+	// nil unless the pool was created WithBackgroundFill
+	filling *backgroundFill
+	// metadata of the acquired entries by their address (see WithLeakDetection and WithNilReplacement)
+	issued sync.Map
+	// number of existing entries (idle and in use)
+	live atomic.Int64
+	// total cost of the existing entries (see WithCostBudget)
+	cost atomic.Int64
+	// incremented on every RefreshAll
+	generation atomic.Uint64
+	// set until all entries are of the current generation (see Stats.Migrated)
+	migrating atomic.Bool
+	// set while the pool is paused, closed on resume
+	gate atomic.Pointer[chan struct{}]
+	// number of acquires waiting for an entry
+	waiters atomic.Int64
+	// moving average of the time entries are held in nanoseconds (see WithLoadShedding)
+	holdAvg atomic.Int64
+	// number of running factory calls
+	creating atomic.Int64
+	// last state reported by an EventStateChanged
+	state atomic.Int32
+	// random decisions of the pool (see WithSeed)
+	rnd *prng
+	// limits concurrent factory calls (see WithCreateConcurrency)
+	createSem chan struct{}
+	// recent validation and health check results (see WithErrorBudget)
+	errWindow *errorWindow
+	// durations of factory calls (see FactoryStats)
+	factoryTimes *factoryLatency
+	// wait times of recent acquires (see WithWaitHistory)
+	waits *waitRing
+	// distribution of acquire wait times (see WithWaitBuckets)
+	waitHist *waitHistogram
+	// released entries waiting for maintenance (see WithAsyncRelease)
+	releaseQueue chan *T
+	// removed entries waiting for the destroyer (see WithAsyncDestroy)
+	destroyQueue chan pendingDestroy[T]
+	// number of queued destructions
+	destroying atomic.Int64
+	// closed once an entry is put into the pool (see AcquireMatch)
+	idleNotify atomic.Pointer[chan struct{}]
+	// closed once the number of waiting acquires changes (see WatchWaiters)
+	waitersNotify atomic.Pointer[chan struct{}]
+	// goroutine holding mux (see WithDeadlockDetection)
+	lockOwner atomic.Int64
+	// acquired entry -> expiry timer (see WithMaxHoldTime)
+	holds   map[*T]*hold
+	holdMux sync.Mutex
+
+	// options that can be changed at runtime
+	settings atomic.Pointer[settings]
+	// *T -> *entryMeta of all existing entries
+	entries sync.Map
+	// set if acquires and releases record the use of entries in their metadata (see WithUsageTracking)
+	trackUse bool
+	// affinity key -> *T preferred by AcquireWithAffinity
+	affinity   sync.Map
+	reaperOnce sync.Once
+	// wakes up the reaper if its settings changed
+	reaperWake chan struct{}
+
+	closed    atomic.Bool
+	closeOnce sync.Once
+	// closed when the pool gets closed
+	done chan struct{}
+	// closed when all entries of a closed pool have been destroyed
+	drained     chan struct{}
+	drainedOnce sync.Once
+	// hands entries of the closed pool over to DrainIter
+	drainQueue atomic.Pointer[drainQueue[T]]
 }
 
 func (p *Pool[T]) init() {
 	p.mux = sync.Mutex{}
-	p.pool = make(chan *T, p.size)
-	// fill the pool
-	for i := 0; i < p.size; i++ {
-		p.pool <- p.factoryFunc()
+	p.trackUse = p.opts.tracksUse()
+	if p.opts.unsafeAccess {
+		p.idle = newChanStore[T](max(p.size, p.opts.maxSize))
+	} else {
+		s := newRingStore[T](max(p.size, p.opts.maxSize), p.opts.lifo)
+		s.queue = p.opts.waitQueue
+		p.idle = s
+	}
+	p.done = make(chan struct{})
+	p.drained = make(chan struct{})
+	p.factoryTimes = newFactoryLatency()
+	p.rnd = p.opts.newRand()
+	if b := p.opts.errorBudget; b != nil {
+		p.errWindow = &errorWindow{ErrorBudget: *b}
+	}
+	p.initChaos()
+	p.errTimeout = &PoolError{Pool: p.opts.name, Op: opAcquire, Err: ErrAcquireTimeout}
+	p.target.Store(int64(p.size))
+	s := p.opts.settings
+	p.settings.Store(&s)
+	p.reaperWake = make(chan struct{}, 1)
+	p.applySettings(&s)
+	if n := p.opts.waitHistory; n > 0 {
+		p.waits = newWaitRing(n)
+	}
+	if len(p.opts.waitBuckets) > 0 {
+		p.waitHist = newWaitHistogram(p.opts.waitBuckets)
+	}
+	if n := p.opts.createConcurrency; n > 0 {
+		p.createSem = make(chan struct{}, n)
+	}
+	if p.opts.maxHoldTime > 0 {
+		p.holds = map[*T]*hold{}
+	}
+	// fill the pool, lazy pools only create their minimum number of entries
+	p.fill()
+	if p.healthFunc != nil && p.opts.healthInterval > 0 {
+		go p.sweep()
+	}
+	if p.repairFunc != nil {
+		p.repairWake = make(chan struct{}, 1)
+		go p.repairer()
+	}
+	p.startMaintenance()
+	p.startDestroyers()
+	p.startResizer()
+	p.startFill()
+	p.updateState()
+}
+
+// minSize returns the number of entries the pool keeps alive
+func (p *Pool[T]) minSize() int {
+	target := int(p.target.Load())
+	minSize := p.settings.Load().minSize
+	if minSize < 0 || minSize > target {
+		return target
+	}
+	return minSize
+}
+
+// create returns a new entry from the factory function
+func (p *Pool[T]) create() *T {
+	p.live.Add(1)
+	return p.newEntry()
+}
+
+// newEntry returns a new entry for which space was already reserved in the accounting
+func (p *Pool[T]) newEntry() *T {
+	if p.createSem != nil {
+		p.createSem <- struct{}{}
+		return p.createHeld()
+	}
+	v := p.construct()
+	p.adopt(v)
+	return v
+}
+
+// construct calls the factory function, counting the entries being created (see State)
+// and recording the duration of the call (see FactoryStats)
+func (p *Pool[T]) construct() *T {
+	p.creating.Add(1)
+	defer p.creating.Add(-1)
+	start := time.Now()
+	v := (*p.factoryFunc.Load())()
+	d := time.Since(start)
+	p.factoryTimes.observe(start, d)
+	if s := p.opts.metricsSink; s != nil {
+		s.ObserveFactory(p.opts.name, d)
+	}
+	p.emit(Event{Type: EventEntryCreated, Duration: d})
+	return v
+}
+
+// adopt makes v an entry of the pool, space must already be reserved in the accounting
+func (p *Pool[T]) adopt(v *T) {
+	p.stats.created.Add(1)
+	if s := p.opts.metricsSink; s != nil {
+		s.IncCreated(p.opts.name)
+	}
+	p.track(v)
+	m := p.meta(v)
+	p.trace(m, TraceCreate, nil)
+	p.measure(v, m)
+}
+
+// grow reserves space for a new entry if the pool holds less entries than it should
+func (p *Pool[T]) grow() bool {
+	for {
+		live := p.live.Load()
+		if p.full(live) || p.closed.Load() {
+			return false
+		}
+		if p.live.CompareAndSwap(live, live+1) {
+			return true
+		}
+	}
+}
+
+// expand reserves space for a new entry to hand out, unbounded pools exceed their size if necessary
+func (p *Pool[T]) expand() bool {
+	if p.grow() {
+		return true
+	}
+	if !p.opts.unbounded || p.closed.Load() {
+		return false
+	}
+	p.live.Add(1)
+	return true
+}
+
+// refill creates entries while the pool holds less than its minimum number of entries
+// or acquires are waiting for entries that can't be released anymore
+func (p *Pool[T]) refill() {
+	for !p.closed.Load() {
+		live := p.live.Load()
+		if p.full(live) || (live >= int64(p.minSize()) && p.waiters.Load() == 0) {
+			return
+		}
+		if p.live.CompareAndSwap(live, live+1) {
+			p.put(p.newEntry())
+		}
+	}
+}
+
+// destroy removes v from the pools accounting and calls the destroyer
+func (p *Pool[T]) destroy(v *T) {
+	p.live.Add(-1)
+	p.destroyEntry(v)
+}
+
+// destroyEntry calls the destroyer for an entry that was already removed from the accounting
+func (p *Pool[T]) destroyEntry(v *T) {
+	p.dispose(v)
+	p.updateState()
+}
+
+// dispose implements destroyEntry without updating the state of the pool
+func (p *Pool[T]) dispose(v *T) {
+	p.countDestroyed()
+	p.untrack(v)
+	p.runDestroyer(v)
+	p.checkDrained()
+}
+
+// checkDrained signals Drain once all entries of a closed pool are destroyed
+func (p *Pool[T]) checkDrained() {
+	if p.closed.Load() && p.live.Load() <= 0 && p.destroying.Load() <= 0 {
+		p.drainedOnce.Do(func() { close(p.drained) })
+	}
+}
+
+// shrink removes an entry from the accounting if the pool holds more entries than it should
+func (p *Pool[T]) shrink() bool {
+	for {
+		live := p.live.Load()
+		if live <= p.target.Load() {
+			return false
+		}
+		if p.live.CompareAndSwap(live, live-1) {
+			return true
+		}
 	}
 }
 
+// discardExcess destroys v if the pool is closed or holds more entries than it should
+func (p *Pool[T]) discardExcess(v *T) bool {
+	if p.closed.Load() {
+		p.retire(v)
+		return true
+	}
+	if p.shrink() {
+		p.destroyEntry(v)
+		return true
+	}
+	return false
+}
+
+// Returns the name of the pool (see WithName)
+func (p *Pool[T]) Name() string {
+	return p.opts.name
+}
+
+// Returns the number of idle entries, Len, Cap and InUse are atomic reads
+// that don't contend with acquires and releases (e.g. for health endpoints)
 func (p *Pool[T]) Len() int {
-	return len(p.pool)
+	return p.idle.len()
 }
 
 func (p *Pool[T]) Cap() int {
-	return cap(p.pool)
+	return p.idle.cap()
+}
+
+// Returns the number of acquired entries
+func (p *Pool[T]) InUse() int {
+	// entries written to the channel directly aren't tracked
+	return int(max(p.stats.inUse.Load(), 0))
 }
 
 // Acquires a lock and  executes function f
+// f can deadlock itself using the pool directly, Transaction is a safer alternative.
+// The lock excludes Resize, ApplyConfig, SwapWith, Transaction and other LockedRun calls only: acquires,
+// releases and maintenance (the reaper and RefreshAll) don't take it.
 func (p *Pool[T]) LockedRun(f func(p *Pool[T]) error) error {
-	p.mux.Lock()
-	defer p.mux.Unlock()
+	if err := p.lock(); err != nil {
+		return p.wrapErr(opLockedRun, err)
+	}
+	defer p.unlock()
 	return f(p)
 }
 
+// Deprecated: use TryTakeIdle and TryPutIdle or UnsafeChannel
 func (p *Pool[T]) Channel() chan *T {
-	return p.pool
+	return p.UnsafeChannel()
+}
+
+// UnsafeChannel returns the channel holding the idle entries, writing to it bypasses the pools accounting
+// panics with ErrUnsafeAccess unless the pool was created WithUnsafeAccess
+func (p *Pool[T]) UnsafeChannel() chan *T {
+	s, ok := p.idle.(*chanStore[T])
+	if !ok {
+		panic(ErrUnsafeAccess)
+	}
+	return s.ch
+}
+
+// TryTakeIdle takes an idle entry without blocking or creating new entries,
+// the entry must be given back using Release or Replace
+func (p *Pool[T]) TryTakeIdle() (*T, bool) {
+	for {
+		v, ok := p.idle.tryGet()
+		if !ok {
+			return nil, false
+		}
+		if p.checkout(v) {
+			p.onAcquire(v)
+			return v, true
+		}
+	}
+}
+
+// TryPutIdle puts v into the pool without blocking, v is either an acquired entry (see TryRelease)
+// or a new entry that's added if the pool holds less entries than it should
+func (p *Pool[T]) TryPutIdle(v *T) error {
+	if v == nil {
+		return p.wrapErr(opPutIdle, ErrNilEntry)
+	}
+	if _, ok := p.lookup(v); ok {
+		return p.TryRelease(v)
+	}
+	if p.closed.Load() {
+		return p.wrapErr(opPutIdle, ErrPoolClosed)
+	}
+	if !p.grow() {
+		return p.wrapErr(opPutIdle, ErrFailedToRelease)
+	}
+	p.adopt(v)
+	p.put(v)
+	return nil
 }
 
 func (p *Pool[T]) FactoryFunc() func() *T {
-	return p.factoryFunc
+	return *p.factoryFunc.Load()
 }
 
+// Acquires an entry and runs fn with it, the used entry gets dropped
+// and a freshly created one is put into the pool afterwards
 func (p *Pool[T]) Run(fn func(e *T) error) error {
-	e := p.Acquire()
-	defer p.Release(nil)
+	e, ok := p.tryAcquire(nil)
+	if !ok {
+		var err error
+		if e, err = p.acquire(nil, nil, false); err != nil {
+			return p.wrapErr(opAcquire, err)
+		}
+	}
+	defer p.replace(e)
 	return fn(e)
 }
 
@@ -98,73 +598,420 @@ func (p *Pool[T]) RunWithContext(ctx context.Context, fn func(ctx context.Contex
 	if err != nil {
 		return err
 	}
-	defer p.Release(nil)
+	defer p.replace(e)
+	if p.opts.leaseContext {
+		ctx = ContextWithLease(ctx, Lease[T]{pool: p, value: e})
+	}
 	return fn(ctx, e)
 }
 
-func (p *Pool[T]) AcquireWithTimeout(to time.Duration) (*T, error) {
-	c := time.After(to)
-	select {
-	case v := <-p.pool:
+// Replace destroys the acquired entry v and releases a freshly created entry instead
+func (p *Pool[T]) Replace(v *T) error {
+	if v == nil {
+		return p.wrapErr(opReplace, ErrNilEntry)
+	}
+	p.replace(v)
+	return nil
+}
+
+// replace destroys v and puts a freshly created entry into the pool
+func (p *Pool[T]) replace(v *T) {
+	if !p.endHold(v) {
+		return
+	}
+	p.onRelease()
+	p.live.Add(-1)
+	// the state is updated once the replacement is put, v's removal alone doesn't make the pool cold
+	p.dispose(v)
+	if p.closed.Load() || p.full(p.live.Load()) {
+		p.updateState()
+		return
+	}
+	p.put(p.create())
+}
+
+// resolveNil returns the entry to release for v, which is a new entry
+// if v is nil and nil replacement is enabled
+func (p *Pool[T]) resolveNil(v *T) (*T, error) {
+	if v != nil {
 		return v, nil
-	case <-c:
-		return nil, errors.New("timeout")
 	}
+	if !p.opts.nilReplacement {
+		return nil, ErrNilEntry
+	}
+	// the dropped entry is replaced by the new one, its metadata is forgotten once it's collected
+	p.live.Add(-1)
+	return p.create(), nil
+}
+
+// tryAcquire takes an idle entry without blocking, lazy pools
+// create a new entry if they hold less entries than they should
+func (p *Pool[T]) tryAcquire(ctx context.Context) (*T, bool) {
+	if p.chaosActive() {
+		return nil, false
+	}
+	v, ok := p.tryReserve()
+	if !ok {
+		return nil, false
+	}
+	if v == nil && p.opts.creationNotify != nil {
+		var err error
+		if v, err = p.createNotified(ctx, nil); err != nil {
+			return nil, false
+		}
+	} else if v == nil && p.createSem != nil {
+		// don't wait for the creation limit on the fast path
+		if v, ok = p.tryCreate(); !ok {
+			p.unreserve()
+			return nil, false
+		}
+	}
+	p.observeWait(time.Time{})
+	return p.materialize(v), true
+}
+
+// tryReserve takes an idle entry without blocking or reserves space for a new entry,
+// v is nil if space was reserved
+func (p *Pool[T]) tryReserve() (v *T, ok bool) {
+	for {
+		if p.gate.Load() != nil || p.closed.Load() {
+			return nil, false
+		}
+		if v, ok := p.idle.tryGet(); ok {
+			if !p.checkout(v) {
+				continue
+			}
+			return v, true
+		}
+		if !p.expand() {
+			// degraded entries are only used if there's no healthy one
+			if v, ok := p.takeDegraded(); ok && p.checkout(v) {
+				return v, true
+			}
+			return nil, false
+		}
+		return nil, true
+	}
+}
+
+// unreserve frees space reserved for a new entry
+func (p *Pool[T]) unreserve() {
+	p.live.Add(-1)
+	p.checkDrained()
+	// waiting acquires get a new entry instead
+	p.refill()
+}
+
+// materialize creates the entry for reserved space if v is nil and marks it as acquired
+func (p *Pool[T]) materialize(v *T) *T {
+	if v == nil {
+		v = p.newEntry()
+	}
+	p.onAcquire(v)
+	return v
+}
+
+// acquire waits for an idle entry until ctx is done or timeout fires, both are optional,
+// priority queues it ahead of the other waiting acquires (see WithPriority)
+func (p *Pool[T]) acquire(ctx context.Context, timeout <-chan time.Time, priority bool) (*T, error) {
+	start := p.waitStart()
+	v, err := p.reserve(ctx, timeout, priority)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil && p.opts.creationNotify != nil {
+		if v, err = p.createNotified(ctx, timeout); err != nil {
+			return nil, err
+		}
+	} else if v == nil && p.createSem != nil {
+		if v, err = p.awaitCreate(ctx, timeout); err != nil {
+			return nil, err
+		}
+	}
+	p.observeWait(start)
+	return p.materialize(v), nil
+}
+
+// reserve waits for an idle entry or space for a new entry until ctx is done or timeout fires,
+// v is nil if space was reserved
+func (p *Pool[T]) reserve(ctx context.Context, timeout <-chan time.Time, priority bool) (*T, error) {
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+	if t := p.watchSlow(); t != nil {
+		defer t.Stop()
+	}
+	if err := p.chaosDelay(ctx, timeout); err != nil {
+		return nil, err
+	}
+	for {
+		if p.closed.Load() {
+			return nil, ErrPoolClosed
+		}
+		if gate := p.gate.Load(); gate != nil {
+			// wait until the pool gets resumed
+			select {
+			case <-*gate:
+			case <-p.done:
+			case <-done:
+				p.timedOut()
+				return nil, ctx.Err()
+			case <-timeout:
+				p.timedOut()
+				return nil, ErrAcquireTimeout
+			}
+			continue
+		}
+		if v, ok := p.tryReserve(); ok {
+			if v == nil {
+				if err := p.chaosCreate(); err != nil {
+					p.unreserve()
+					return nil, err
+				}
+			} else if p.chaosInvalidate() {
+				p.stats.invalid.Add(1)
+				p.destroy(v)
+				continue
+			}
+			return v, nil
+		}
+		if p.settings.Load().exhaustion == ExhaustionFail {
+			return nil, ErrPoolExhausted
+		}
+		v, err := p.wait(ctx, done, timeout, priority)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil && p.checkout(v) {
+			return v, nil
+		}
+	}
+}
+
+// wait blocks until an idle entry is available, v is nil if the caller should try again
+func (p *Pool[T]) wait(ctx context.Context, done <-chan struct{}, timeout <-chan time.Time, priority bool) (*T, error) {
+	if !p.addWaiter() {
+		return nil, ErrPoolSaturated
+	}
+	defer p.removeWaiter()
+	// check again after registering as waiter, entries that got
+	// destroyed in the meantime are only replaced for waiters (see refill)
+	if !p.full(p.live.Load()) {
+		return nil, nil
+	}
+	if p.selfBlocked() {
+		return nil, ErrWouldDeadlock
+	}
+	get := p.idle.get
+	if priority {
+		get = p.idle.getFirst
+	}
+	if p.opts.waitQueue != nil {
+		w := &Waiter{Context: ctx, Since: time.Now(), Retry: priority}
+		get = func(done <-chan struct{}, timeout <-chan time.Time, closed <-chan struct{}) (*T, waitResult) {
+			return p.idle.getQueued(w, done, timeout, closed)
+		}
+	}
+	v, res := get(done, timeout, p.done)
+	switch res {
+	case waitClosed:
+		return nil, ErrPoolClosed
+	case waitDone:
+		p.timedOut()
+		return nil, ctx.Err()
+	case waitTimeout:
+		p.timedOut()
+		return nil, ErrAcquireTimeout
+	}
+	return v, nil
+}
+
+// addWaiter registers an acquire as waiting unless the maximum number of waiters is reached
+func (p *Pool[T]) addWaiter() bool {
+	n := p.waiters.Add(1)
+	if limit := p.settings.Load().maxWaiters; limit > 0 && n > int64(limit) {
+		p.waiters.Add(-1)
+		p.stats.saturated.Add(1)
+		return false
+	}
+	p.notifyWaiters()
+	return true
+}
+
+// AcquireWithTimeout acquires an entry like AcquireWith(nil, WithTimeout(to))
+func (p *Pool[T]) AcquireWithTimeout(to time.Duration) (*T, error) {
+	v, err := p.acquireWith(nil, acquireConfig{timeout: to, hasTimeout: true})
+	return v, p.wrapErr(opAcquire, err)
 }
 
+// AcquireWithContext acquires an entry, waiting until ctx is done (see AcquireWith)
 func (p *Pool[T]) AcquireWithContext(ctx context.Context) (*T, error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case v := <-p.pool:
-		return v, nil
+	v, err := p.acquireWith(ctx, acquireConfig{})
+	return v, p.wrapErr(opAcquire, err)
+}
+
+// AcquireOpts tune a single acquire (see AcquireWithOpts)
+//
+// Deprecated: use AcquireWith and its options instead, Retry is WithPriority.
+type AcquireOpts struct {
+	// Retry marks the acquire as the retry of an acquire that already timed out, it gets queued ahead of
+	// the other waiting acquires so retries don't time out over and over again under saturation
+	Retry bool
+}
+
+// AcquireWithOpts acquires an entry like AcquireWithContext, tuned by o
+//
+// Deprecated: use AcquireWith(ctx, WithPriority()) instead.
+func (p *Pool[T]) AcquireWithOpts(ctx context.Context, o AcquireOpts) (*T, error) {
+	if o.Retry {
+		return p.AcquireWith(ctx, WithPriority())
 	}
+	return p.AcquireWith(ctx)
 }
 
 // Acquire an entry from the pool (blocking)
+// returns nil if the pool is closed
 func (p *Pool[T]) Acquire() *T {
-	return <-p.pool
+	v, _ := p.acquireWith(nil, acquireConfig{noShed: true})
+	return v
+}
+
+// put adds v to the idle entries unless the pool is closed or holds too many entries, false is returned
+// if the idle entries reject v because it's idle already or the pool has no room for it (it wasn't
+// acquired from the pool), the caller keeps v then
+func (p *Pool[T]) put(v *T) bool {
+	if p.discardExcess(v) {
+		return true
+	}
+	if !p.idle.put(v) {
+		return false
+	}
+	p.afterPut()
+	p.updateState()
+	return true
+}
+
+// afterPut wakes up AcquireMatch calls (and acquires waiting to create an entry) and destroys entries that got released concurrently to closing the pool
+func (p *Pool[T]) afterPut() {
+	p.notifyIdle()
+	p.trimCost()
+	if p.closed.Load() {
+		p.destroyIdle()
+	}
+}
+
+// destroyIdle destroys all idle entries of the closed pool
+func (p *Pool[T]) destroyIdle() {
+	for {
+		v, ok := p.idle.tryGet()
+		if !ok {
+			return
+		}
+		p.retire(v)
+	}
 }
 
 // Releases an entry to the pool (blocking)
-// if v is nil a new type gets created on the fly
-func (p *Pool[T]) Release(v *T) {
-	if v == nil {
-		v = p.factoryFunc()
+// releasing nil fails with ErrNilEntry unless the pool was created
+// WithNilReplacement, then a new entry gets created on the fly
+// entries released to a closed pool get destroyed
+// releasing an idle entry again or an entry the full pool has no room for fails with ErrFailedToRelease
+func (p *Pool[T]) Release(v *T) error {
+	orig := v
+	v, err := p.resolveNil(v)
+	if err != nil {
+		return p.wrapErr(opRelease, err)
+	}
+	if !p.endHold(v) {
+		return nil
+	}
+	if err := p.checkRelease(orig); err != nil {
+		return p.wrapErr(opRelease, err)
 	}
-	p.pool <- v
+	p.onRelease()
+	if p.releaseQueue != nil && p.enqueueRelease(v) {
+		return nil
+	}
+	if p.checkin(v) && !p.put(v) {
+		p.unrelease()
+		return p.wrapErr(opRelease, ErrFailedToRelease)
+	}
+	return nil
 }
 
 // Try to release an entry to the pool (non-blocking)
-// if v is nil a new entry gets created on the fly
+// nil is handled like in Release
 func (p *Pool[T]) TryRelease(v *T) error {
-	if v == nil {
-		v = p.factoryFunc()
+	orig := v
+	v, err := p.resolveNil(v)
+	if err != nil {
+		return p.wrapErr(opRelease, err)
+	}
+	if !p.endHold(v) {
+		return nil
 	}
-	select {
-	case p.pool <- v:
-	default:
-		return ErrFailedToRelease
+	if err := p.checkRelease(orig); err != nil {
+		return p.wrapErr(opRelease, err)
 	}
+	if p.discardExcess(v) || !p.checkin(v) {
+		p.onRelease()
+		return nil
+	}
+	if !p.idle.tryPut(v) {
+		p.discard(orig, v)
+		return p.wrapErr(opRelease, ErrFailedToRelease)
+	}
+	p.onRelease()
+	p.afterPut()
 	return nil
 }
 
-// Try to release an entry to the pool (non-blocking)
-// if v is nil a new entry gets created on the fly
+// Try to release an entry to the pool, waiting until ctx is done
+// nil is handled like in Release
 func (p *Pool[T]) TryReleaseWithContext(ctx context.Context, v *T) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	if v == nil {
-		v = p.factoryFunc()
+	orig := v
+	v, err := p.resolveNil(v)
+	if err != nil {
+		return p.wrapErr(opRelease, err)
+	}
+	if !p.endHold(v) {
+		return nil
 	}
-	select {
-	case p.pool <- v:
-	case <-ctx.Done():
-		return ctx.Err()
+	if err := p.checkRelease(orig); err != nil {
+		return p.wrapErr(opRelease, err)
 	}
+	if p.discardExcess(v) || !p.checkin(v) {
+		p.onRelease()
+		return nil
+	}
+	if !p.idle.putWait(v, ctx.Done()) {
+		p.discard(orig, v)
+		return p.wrapErr(opRelease, ctx.Err())
+	}
+	p.onRelease()
+	p.afterPut()
 	return nil
 }
+
+// discard undoes resolveNil for an entry that couldn't be released
+func (p *Pool[T]) discard(orig, v *T) {
+	if orig == nil {
+		// nothing was dropped since the entry couldn't be released
+		p.live.Add(1)
+		p.destroy(v)
+		return
+	}
+	// the caller keeps the entry (see WithCheckedRelease)
+	if p.trackUse {
+		m := p.meta(v)
+		m.inUse.Store(true)
+		p.issue(v, m)
+	}
+}
@@ -2,7 +2,9 @@ package pool
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 )
@@ -10,7 +12,7 @@ import (
 type poolItem any
 
 func poolFactory() *poolItem {
-	return nil
+	return new(poolItem)
 }
 
 func TestFactoryFunc(t *testing.T) {
@@ -103,7 +105,91 @@ func TestTryRelease(t *testing.T) {
 	if err != nil {
 		t.Errorf("unexpected error: %e", err)
 	}
+
+	// pool is full
+	err = pool.TryRelease(poolFactory())
+	if !errors.Is(err, ErrFailedToRelease) {
+		t.Errorf("expected %v but got %v", ErrFailedToRelease, err)
+	}
 }
+
+func TestReleaseForeignEntry(t *testing.T) {
+	pool := NewPool(1, poolFactory)
+	// the pool is full
+	if err := pool.Release(poolFactory()); !errors.Is(err, ErrFailedToRelease) {
+		t.Errorf("expected %v but got %v", ErrFailedToRelease, err)
+	}
+	pool.Acquire()
+	if _, err := pool.AcquireWithTimeout(10 * time.Millisecond); !errors.Is(err, ErrAcquireTimeout) {
+		t.Errorf("expected %v but got %v", ErrAcquireTimeout, err)
+	}
+	if s := pool.Stats(); s.Created != 1 || s.InUse != 1 {
+		t.Errorf("expected 1 created entry in use but got %d created and %d in use", s.Created, s.InUse)
+	}
+}
+
+func TestReleaseTwice(t *testing.T) {
+	destroyed := 0
+	pool := NewPool(2, poolFactory, WithDestroyer(func(*poolItem) { destroyed++ }))
+	entry := pool.Acquire()
+	if err := pool.Release(entry); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := pool.Release(entry); !errors.Is(err, ErrFailedToRelease) {
+		t.Errorf("expected %v but got %v", ErrFailedToRelease, err)
+	}
+	if pool.Len() != 2 {
+		t.Errorf("expected 2 entries but got %d", pool.Len())
+	}
+	a, b := pool.Acquire(), pool.Acquire()
+	if a == b {
+		t.Errorf("expected distinct entries but got %p twice", a)
+	}
+	if destroyed != 0 {
+		t.Errorf("expected no destroyed entries but got %d", destroyed)
+	}
+}
+
+func TestReleaseNil(t *testing.T) {
+	pool := NewPool(2, poolFactory)
+	pool.Acquire()
+	if err := pool.Release(nil); !errors.Is(err, ErrNilEntry) {
+		t.Errorf("expected %v but got %v", ErrNilEntry, err)
+	}
+	if err := pool.TryRelease(nil); !errors.Is(err, ErrNilEntry) {
+		t.Errorf("expected %v but got %v", ErrNilEntry, err)
+	}
+	if err := pool.TryReleaseWithContext(context.Background(), nil); !errors.Is(err, ErrNilEntry) {
+		t.Errorf("expected %v but got %v", ErrNilEntry, err)
+	}
+	if pool.Len() != 1 {
+		t.Errorf("expected 1 entry but got %d", pool.Len())
+	}
+
+	pool = NewPool(2, poolFactory, WithNilReplacement())
+	pool.Acquire()
+	if err := pool.Release(nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if pool.Len() != 2 {
+		t.Errorf("expected pool to be full but got %d entries", pool.Len())
+	}
+}
+
+func TestRunReplacesEntry(t *testing.T) {
+	pool := NewPool(1, poolFactory)
+	var used *poolItem
+	if err := pool.Run(func(e *poolItem) error {
+		used = e
+		return nil
+	}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if e := pool.Acquire(); e == used {
+		t.Errorf("expected a fresh entry after Run")
+	}
+}
+
 func TestTryReleaseWithContext(t *testing.T) {
 	pool := NewPool(2, poolFactory)
 
@@ -124,7 +210,7 @@ func TestTryReleaseWithContext(t *testing.T) {
 }
 
 func TestUpdate(t *testing.T) {
-	pool := NewPool(2, poolFactory)
+	pool := NewPool(2, poolFactory, WithNilReplacement())
 	entries := []*poolItem{}
 	for range 2 {
 		entry := pool.Acquire()
@@ -162,7 +248,7 @@ func TestUpdate(t *testing.T) {
 }
 
 func TestUpdateTimeout(t *testing.T) {
-	pool := NewPool(3, poolFactory)
+	pool := NewPool(3, poolFactory, WithUnsafeAccess())
 	for range 3 {
 		pool.Acquire()
 	}
@@ -175,7 +261,7 @@ func TestUpdateTimeout(t *testing.T) {
 		for i := 0; i < p.Cap(); i++ {
 			// empty the Pool
 			select {
-			case <-p.Channel():
+			case <-p.UnsafeChannel():
 				removedInstances++
 			case <-tc:
 				return fmt.Errorf("timeout")
@@ -185,7 +271,7 @@ func TestUpdateTimeout(t *testing.T) {
 		for i := 0; i < p.Cap(); i++ {
 			// fill the Pool
 			select {
-			case p.Channel() <- factoryFunc():
+			case p.UnsafeChannel() <- factoryFunc():
 				updatedInstances++
 			case <-tc:
 				return fmt.Errorf("timeout")
@@ -204,3 +290,159 @@ func TestUpdateTimeout(t *testing.T) {
 		t.Errorf("expected %d updated instances but got %d", pool.Len(), updatedInstances)
 	}
 }
+
+func TestAcquireReleaseAllocs(t *testing.T) {
+	pool := NewPool(2, func() *poolItem { return new(poolItem) })
+	ctx := context.Background()
+
+	tests := map[string]func(){
+		"Acquire": func() {
+			pool.Release(pool.Acquire())
+		},
+		"AcquireWithTimeout": func() {
+			e, _ := pool.AcquireWithTimeout(time.Second)
+			pool.Release(e)
+		},
+		"AcquireWithContext": func() {
+			e, _ := pool.AcquireWithContext(ctx)
+			pool.Release(e)
+		},
+		"AcquireWith": func() {
+			e, _ := pool.AcquireWith(ctx, WithTimeout(time.Second), WithPriority())
+			pool.Release(e)
+		},
+		"TryRelease": func() {
+			_ = pool.TryRelease(pool.Acquire())
+		},
+		"TryReleaseWithContext": func() {
+			_ = pool.TryReleaseWithContext(ctx, pool.Acquire())
+		},
+	}
+	for name, fn := range tests {
+		if allocs := testing.AllocsPerRun(100, fn); allocs != 0 {
+			t.Errorf("%s: expected 0 allocations but got %v", name, allocs)
+		}
+	}
+}
+
+func TestUnsafeChannel(t *testing.T) {
+	defer func() {
+		if r := recover(); r != ErrUnsafeAccess {
+			t.Errorf("expected panic with %v but got %v", ErrUnsafeAccess, r)
+		}
+	}()
+	NewPool(1, poolFactory).UnsafeChannel()
+}
+
+func TestTryTakePutIdle(t *testing.T) {
+	pool := NewPool(2, poolFactory, WithMinSize(1))
+	a, ok := pool.TryTakeIdle()
+	if !ok || a == nil {
+		t.Fatalf("expected an idle entry")
+	}
+	// doesn't create new entries
+	if _, ok := pool.TryTakeIdle(); ok {
+		t.Errorf("expected no idle entry to be left")
+	}
+	if err := pool.TryPutIdle(new(poolItem)); err != nil {
+		t.Error(err)
+	}
+	// no space left for another entry
+	if err := pool.TryPutIdle(new(poolItem)); !errors.Is(err, ErrFailedToRelease) {
+		t.Errorf("expected %v but got %v", ErrFailedToRelease, err)
+	}
+	if err := pool.TryPutIdle(a); err != nil {
+		t.Error(err)
+	}
+	if stats := pool.Stats(); stats.Idle != 2 || stats.Size != 2 || stats.Created != 2 {
+		t.Errorf("expected 2 idle entries but got %+v", stats)
+	}
+}
+
+func TestNewPoolE(t *testing.T) {
+	if _, err := NewPoolE[poolItem](-1, nil, WithMinSize(3)); !errors.Is(err, ErrMissingFactoryFunction) || !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("expected missing factory and invalid size to be reported but got %v", err)
+	}
+	if _, err := NewPoolE(0, poolFactory); !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("expected ErrInvalidSize for a pool without capacity but got %v", err)
+	}
+	if _, err := NewPoolE(2, poolFactory, WithMaxSize(1)); !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("expected ErrInvalidSize for max less than size but got %v", err)
+	}
+	_, err := NewPoolE(2, poolFactory,
+		WithReapInterval(time.Second),
+		WithValidationInterval(time.Second),
+		WithMaxWaiters(1), WithExhaustionPolicy(ExhaustionFail),
+		WithAsyncDestroy(1, 1, 0),
+	)
+	if !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("expected %v but got %v", ErrInvalidOption, err)
+	}
+	for _, problem := range []string{"reap interval", "validation interval", "max waiters", "async destroy"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("expected the %s to be reported but got %v", problem, err)
+		}
+	}
+	if _, err := NewPoolE(2, poolFactory, WithTTL(time.Minute), WithReapInterval(time.Second), WithIdleTimeout(time.Minute), WithMinSize(1)); err != nil {
+		t.Errorf("expected no error but got %v", err)
+	}
+	pool, err := NewPoolE(0, poolFactory, WithMaxSize(2))
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	defer pool.Close()
+	if pool.Cap() != 2 {
+		t.Errorf("expected capacity of 2 but got %d", pool.Cap())
+	}
+}
+
+func TestUnbounded(t *testing.T) {
+	func() {
+		defer func() {
+			if err, _ := recover().(error); !errors.Is(err, ErrInvalidSize) {
+				t.Errorf("expected panic with ErrInvalidSize but got %v", err)
+			}
+		}()
+		NewPool(-1, poolFactory)
+	}()
+
+	pool := NewPool(2, poolFactory, WithUnbounded())
+	defer pool.Close()
+	var entries []*poolItem
+	for i := 0; i < 5; i++ {
+		v, err := pool.AcquireWithTimeout(time.Millisecond)
+		if err != nil {
+			t.Fatalf("expected acquire to create an entry but got %v", err)
+		}
+		entries = append(entries, v)
+	}
+	for _, v := range entries {
+		if err := pool.TryRelease(v); err != nil {
+			t.Errorf("expected release to succeed but got %v", err)
+		}
+	}
+	if stats := pool.Stats(); stats.Idle != 2 || stats.Created != 5 || stats.Destroyed != 3 {
+		t.Errorf("expected 2 idle and 3 destroyed entries but got %+v", stats)
+	}
+}
+
+func TestPoolError(t *testing.T) {
+	pool := NewPool(1, poolFactory, WithName("db"))
+	defer pool.Close()
+	v := pool.Acquire()
+	_, err := pool.AcquireWithTimeout(time.Millisecond)
+	var perr *PoolError
+	if !errors.As(err, &perr) || perr.Pool != "db" || perr.Op != "acquire" {
+		t.Fatalf("expected PoolError of pool db and op acquire but got %v", err)
+	}
+	if !errors.Is(err, ErrAcquireTimeout) {
+		t.Errorf("expected error to wrap ErrAcquireTimeout but got %v", err)
+	}
+	if err.Error() != `pool "db": acquire: timeout while acquiring from pool` {
+		t.Errorf("unexpected error message %q", err.Error())
+	}
+	if err := pool.Release(nil); !errors.As(err, &perr) || perr.Op != "release" || perr.Err != ErrNilEntry {
+		t.Errorf("expected PoolError of op release wrapping ErrNilEntry but got %v", err)
+	}
+	pool.Release(v)
+}
@@ -0,0 +1,434 @@
+// Package poolmock provides an in-memory fake implementing pool.Pooler for unit tests.
+// The fake never blocks: acquires fail right away if all entries are in use, which keeps
+// tests independent of timing. Its behavior can be scripted:
+//
+//	m := poolmock.New(2, newConn)
+//	m.FailNextAcquire(errors.New("connection refused"))
+//	m.Deliver(brokenConn)
+//	runApp(m)
+//	if got := m.Methods(); !slices.Equal(got, []string{"AcquireWithContext", "AcquireWithContext", "Replace"}) {
+//		t.Errorf("unexpected calls %v", got)
+//	}
+package poolmock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/epikur-io/go-pool"
+)
+
+// ErrUnsupported is returned by LockedRun and Transaction, which need a real pool
+var ErrUnsupported = fmt.Errorf("poolmock: %w", errors.ErrUnsupported)
+
+// Call is a recorded method call of the mock
+type Call[T any] struct {
+	Method string
+	// entry passed to or returned by the call (if any)
+	Entry *T
+	Err   error
+}
+
+// Mock is a fake pool, it's safe for concurrent use
+type Mock[T any] struct {
+	mux     sync.Mutex
+	name    string
+	size    int
+	factory func() *T
+	idle    []*T
+	inUse   map[*T]bool
+	// scripted results of the next acquires
+	errs      []error
+	delivered []*T
+	calls     []Call[T]
+	stats     pool.Stats
+	paused    bool
+	closed    bool
+}
+
+var _ pool.Pooler[any] = &Mock[any]{}
+
+// New returns a mock holding up to size entries created lazily by factory (new(T) if nil)
+func New[T any](size int, factory func() *T) *Mock[T] {
+	if factory == nil {
+		factory = func() *T { return new(T) }
+	}
+	return &Mock[T]{size: size, factory: factory, inUse: make(map[*T]bool)}
+}
+
+// WithName sets the name returned by Name
+func (m *Mock[T]) WithName(name string) *Mock[T] {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.name = name
+	return m
+}
+
+// FailNextAcquire makes the next acquires fail with errs, one error per acquire
+func (m *Mock[T]) FailNextAcquire(errs ...error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.errs = append(m.errs, errs...)
+}
+
+// Deliver makes the next acquires return entries in the given order instead of idle or new entries
+func (m *Mock[T]) Deliver(entries ...*T) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.delivered = append(m.delivered, entries...)
+}
+
+// Calls returns the recorded method calls in order
+func (m *Mock[T]) Calls() []Call[T] {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	return append([]Call[T](nil), m.calls...)
+}
+
+// Methods returns the names of the recorded method calls in order
+func (m *Mock[T]) Methods() []string {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	methods := make([]string, len(m.calls))
+	for i, c := range m.calls {
+		methods[i] = c.Method
+	}
+	return methods
+}
+
+// InUse returns the entries that are acquired and not released yet
+func (m *Mock[T]) InUse() []*T {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	entries := make([]*T, 0, len(m.inUse))
+	for v := range m.inUse {
+		entries = append(entries, v)
+	}
+	return entries
+}
+
+// record appends a call, the caller must hold m.mux
+func (m *Mock[T]) record(method string, v *T, err error) {
+	m.calls = append(m.calls, Call[T]{Method: method, Entry: v, Err: err})
+}
+
+// acquire hands out the next entry matching (nil matches all), exhausted is returned if none is available
+func (m *Mock[T]) acquire(method string, match func(*T) bool, exhausted error) (*T, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	v, err := m.next(match, exhausted)
+	m.record(method, v, err)
+	return v, err
+}
+
+// next implements acquire, the caller must hold m.mux
+func (m *Mock[T]) next(match func(*T) bool, exhausted error) (*T, error) {
+	if m.closed {
+		return nil, pool.ErrPoolClosed
+	}
+	if len(m.errs) > 0 {
+		err := m.errs[0]
+		m.errs = m.errs[1:]
+		return nil, err
+	}
+	if m.paused || len(m.inUse) >= m.size {
+		m.stats.Timeouts++
+		return nil, exhausted
+	}
+	if len(m.delivered) > 0 && (match == nil || match(m.delivered[0])) {
+		v := m.delivered[0]
+		m.delivered = m.delivered[1:]
+		return m.take(v), nil
+	}
+	for i, v := range m.idle {
+		if match == nil || match(v) {
+			m.idle = append(m.idle[:i:i], m.idle[i+1:]...)
+			return m.take(v), nil
+		}
+	}
+	if len(m.idle)+len(m.inUse) < m.size {
+		v := m.factory()
+		m.stats.Created++
+		if match == nil || match(v) {
+			return m.take(v), nil
+		}
+		m.idle = append(m.idle, v)
+	}
+	m.stats.Timeouts++
+	return nil, exhausted
+}
+
+// take marks v as acquired, the caller must hold m.mux
+func (m *Mock[T]) take(v *T) *T {
+	m.inUse[v] = true
+	m.stats.Acquired++
+	return v
+}
+
+// release takes back an acquired entry, the caller must hold m.mux
+func (m *Mock[T]) release(v *T, keep bool) error {
+	if v == nil {
+		return pool.ErrNilEntry
+	}
+	if !m.inUse[v] {
+		return pool.ErrFailedToRelease
+	}
+	delete(m.inUse, v)
+	m.stats.Released++
+	if keep && !m.closed && len(m.idle)+len(m.inUse) < m.size {
+		m.idle = append(m.idle, v)
+	} else {
+		m.stats.Destroyed++
+	}
+	return nil
+}
+
+func (m *Mock[T]) Len() int {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.record("Len", nil, nil)
+	return len(m.idle)
+}
+
+func (m *Mock[T]) Cap() int {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.record("Cap", nil, nil)
+	return m.size
+}
+
+// Acquire returns nil if the acquire fails
+func (m *Mock[T]) Acquire() *T {
+	v, _ := m.acquire("Acquire", nil, pool.ErrPoolExhausted)
+	return v
+}
+
+func (m *Mock[T]) AcquireWithTimeout(time.Duration) (*T, error) {
+	return m.acquire("AcquireWithTimeout", nil, pool.ErrAcquireTimeout)
+}
+
+func (m *Mock[T]) AcquireWithContext(ctx context.Context) (*T, error) {
+	if ctx != nil && ctx.Err() != nil {
+		m.mux.Lock()
+		defer m.mux.Unlock()
+		m.record("AcquireWithContext", nil, ctx.Err())
+		return nil, ctx.Err()
+	}
+	return m.acquire("AcquireWithContext", nil, context.DeadlineExceeded)
+}
+
+// AcquireMatch returns the first idle entry matching, new entries are only returned if they match
+func (m *Mock[T]) AcquireMatch(_ context.Context, match func(*T) bool) (*T, error) {
+	return m.acquire("AcquireMatch", match, pool.ErrPoolExhausted)
+}
+
+func (m *Mock[T]) Release(v *T) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	err := m.release(v, true)
+	m.record("Release", v, err)
+	return err
+}
+
+func (m *Mock[T]) Replace(v *T) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	err := m.release(v, false)
+	m.record("Replace", v, err)
+	return err
+}
+
+func (m *Mock[T]) TryRelease(v *T) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	err := m.release(v, true)
+	m.record("TryRelease", v, err)
+	return err
+}
+
+func (m *Mock[T]) TryReleaseWithContext(_ context.Context, v *T) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	err := m.release(v, true)
+	m.record("TryReleaseWithContext", v, err)
+	return err
+}
+
+// LockedRun fails with ErrUnsupported
+func (m *Mock[T]) LockedRun(func(p *pool.Pool[T]) error) error {
+	return m.unsupported("LockedRun")
+}
+
+// Transaction fails with ErrUnsupported
+func (m *Mock[T]) Transaction(context.Context, func(tx *pool.Tx[T]) error) error {
+	return m.unsupported("Transaction")
+}
+
+func (m *Mock[T]) unsupported(method string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.record(method, nil, ErrUnsupported)
+	return ErrUnsupported
+}
+
+// Channel panics with pool.ErrUnsafeAccess
+func (m *Mock[T]) Channel() chan *T {
+	return m.UnsafeChannel()
+}
+
+// UnsafeChannel panics with pool.ErrUnsafeAccess
+func (m *Mock[T]) UnsafeChannel() chan *T {
+	panic(pool.ErrUnsafeAccess)
+}
+
+func (m *Mock[T]) TryTakeIdle() (*T, bool) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if len(m.idle) == 0 || m.closed {
+		m.record("TryTakeIdle", nil, nil)
+		return nil, false
+	}
+	v := m.take(m.idle[0])
+	m.idle = m.idle[1:]
+	m.record("TryTakeIdle", v, nil)
+	return v, true
+}
+
+func (m *Mock[T]) TryPutIdle(v *T) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	var err error
+	switch {
+	case v == nil:
+		err = pool.ErrNilEntry
+	case m.inUse[v]:
+		err = m.release(v, true)
+	case m.closed:
+		err = pool.ErrPoolClosed
+	case len(m.idle)+len(m.inUse) >= m.size:
+		err = pool.ErrFailedToRelease
+	default:
+		m.idle = append(m.idle, v)
+		m.stats.Created++
+	}
+	m.record("TryPutIdle", v, err)
+	return err
+}
+
+func (m *Mock[T]) FactoryFunc() func() *T {
+	return m.factory
+}
+
+func (m *Mock[T]) Name() string {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	return m.name
+}
+
+func (m *Mock[T]) Stats() pool.Stats {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	s := m.stats
+	s.Name, s.Cap, s.Size = m.name, m.size, m.size
+	s.Idle, s.InUse = len(m.idle), len(m.inUse)
+	s.Paused, s.Closed = m.paused, m.closed
+	return s
+}
+
+// RefreshAll drops the idle entries, new entries are created on acquire
+func (m *Mock[T]) RefreshAll(context.Context) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	var err error
+	if m.closed {
+		err = pool.ErrPoolClosed
+	} else {
+		m.stats.Destroyed += uint64(len(m.idle))
+		m.stats.Generation++
+		m.idle = nil
+	}
+	m.record("RefreshAll", nil, err)
+	return err
+}
+
+func (m *Mock[T]) Resize(size int) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	err := m.resize(size)
+	m.record("Resize", nil, err)
+	return err
+}
+
+// resize changes the number of entries, the caller must hold m.mux
+func (m *Mock[T]) resize(size int) error {
+	if size < 0 {
+		return fmt.Errorf("%w: %d", pool.ErrInvalidSize, size)
+	}
+	if m.closed {
+		return pool.ErrPoolClosed
+	}
+	m.size = size
+	if n := len(m.idle) + len(m.inUse) - size; n > 0 {
+		n = min(n, len(m.idle))
+		m.stats.Destroyed += uint64(n)
+		m.idle = m.idle[n:]
+	}
+	return nil
+}
+
+// Pause makes acquires fail until Resume is called
+func (m *Mock[T]) Pause() {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.paused = true
+	m.record("Pause", nil, nil)
+}
+
+func (m *Mock[T]) Resume() {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.paused = false
+	m.record("Resume", nil, nil)
+}
+
+func (m *Mock[T]) Close() error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.closed = true
+	m.stats.Destroyed += uint64(len(m.idle))
+	m.idle = nil
+	m.record("Close", nil, nil)
+	return nil
+}
+
+// Drain closes the mock without waiting for entries in use
+func (m *Mock[T]) Drain(context.Context) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.closed = true
+	m.stats.Destroyed += uint64(len(m.idle))
+	m.idle = nil
+	m.record("Drain", nil, nil)
+	return nil
+}
+
+func (m *Mock[T]) Config() pool.Config {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	return pool.Config{Name: m.name, Size: m.size}
+}
+
+// ApplyConfig applies the size of cfg, other parameters are ignored
+func (m *Mock[T]) ApplyConfig(cfg pool.Config) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	err := cfg.Validate()
+	if err == nil {
+		err = m.resize(cfg.Size)
+	}
+	m.record("ApplyConfig", nil, err)
+	return err
+}
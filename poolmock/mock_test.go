@@ -0,0 +1,78 @@
+package poolmock
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/epikur-io/go-pool"
+	"github.com/epikur-io/go-pool/pooltest"
+)
+
+type entry struct{ id int }
+
+func TestMock(t *testing.T) {
+	m := New[entry](1, nil)
+	errRefused := errors.New("refused")
+	first := &entry{id: 1}
+	m.FailNextAcquire(errRefused)
+	m.Deliver(first)
+
+	if _, err := m.AcquireWithContext(context.Background()); err != errRefused {
+		t.Errorf("expected scripted error but got %v", err)
+	}
+	v, err := m.AcquireWithContext(context.Background())
+	if err != nil || v != first {
+		t.Errorf("expected delivered entry but got %v, %v", v, err)
+	}
+	if _, err := m.AcquireWithContext(context.Background()); err != context.DeadlineExceeded {
+		t.Errorf("expected exhausted mock to fail but got %v", err)
+	}
+	if err := m.Release(v); err != nil {
+		t.Errorf("expected no error but got %v", err)
+	}
+	if err := m.Release(v); err != pool.ErrFailedToRelease {
+		t.Errorf("expected ErrFailedToRelease but got %v", err)
+	}
+	if next := m.Acquire(); next != first {
+		t.Errorf("expected released entry to be reused but got %v", next)
+	}
+
+	want := []string{"AcquireWithContext", "AcquireWithContext", "AcquireWithContext", "Release", "Release", "Acquire"}
+	if got := m.Methods(); !slices.Equal(got, want) {
+		t.Errorf("expected calls %v but got %v", want, got)
+	}
+	if calls := m.Calls(); calls[0].Err != errRefused || calls[1].Entry != first {
+		t.Errorf("expected recorded errors and entries but got %+v", calls)
+	}
+	if s := m.Stats(); s.InUse != 1 || s.Acquired != 2 || s.Released != 1 || s.Created != 0 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+	if err := m.LockedRun(nil); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported but got %v", err)
+	}
+}
+
+func TestMockMatch(t *testing.T) {
+	id := 0
+	m := New(2, func() *entry { id++; return &entry{id: id} })
+	odd := func(e *entry) bool { return e.id%2 == 1 }
+	even := func(e *entry) bool { return e.id%2 == 0 }
+
+	a, err := m.AcquireMatch(context.Background(), odd)
+	if err != nil || a.id != 1 {
+		t.Errorf("expected new matching entry but got %v, %v", a, err)
+	}
+	if _, err := m.AcquireMatch(context.Background(), odd); err != pool.ErrPoolExhausted {
+		t.Errorf("expected ErrPoolExhausted but got %v", err)
+	}
+	// the entry created by the failed match is idle now
+	if b, err := m.AcquireMatch(context.Background(), even); err != nil || b.id != 2 {
+		t.Errorf("expected idle matching entry but got %v, %v", b, err)
+	}
+}
+
+func TestMockInvariants(t *testing.T) {
+	pooltest.Harness[entry]{New: func() pooltest.Pool[entry] { return New[entry](3, nil) }}.Run(t)
+}
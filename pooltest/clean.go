@@ -0,0 +1,53 @@
+package pooltest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/epikur-io/go-pool"
+)
+
+// AssertClean fails t unless p is clean (see Clean), e.g. at the end of a test using the pool:
+//
+//	p := pool.NewPool(4, newConn, pool.WithCheckedRelease())
+//	defer pooltest.AssertClean(t, p)
+func AssertClean(t testing.TB, p interface{ Stats() pool.Stats }) {
+	t.Helper()
+	if err := Clean(p); err != nil {
+		t.Error(err)
+	}
+}
+
+// Clean returns all the problems found in the stats of p, or nil if there are none:
+//
+//   - entries still in use or acquires still waiting for an entry
+//   - accounting that doesn't add up (more releases than acquires, more entries than the capacity)
+//   - problems reported by the pool: invalid releases (see pool.WithCheckedRelease), leaked entries
+//     (see pool.WithLeakDetection), entries held for too long (see pool.WithMaxHoldTime) and timed out hooks
+func Clean(p interface{ Stats() pool.Stats }) error {
+	s := p.Stats()
+	var errs []error
+	check := func(failed bool, format string, args ...any) {
+		if failed {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	check(s.InUse != 0, "%d entries still in use", s.InUse)
+	check(s.Waiters != 0, "%d acquires still waiting", s.Waiters)
+	check(s.Released > s.Acquired, "%d released but only %d acquired entries", s.Released, s.Acquired)
+	check(s.Created < s.Destroyed, "%d destroyed but only %d created entries", s.Destroyed, s.Created)
+	check(s.Idle+s.InUse > s.Cap, "%d entries exceed the capacity of %d", s.Idle+s.InUse, s.Cap)
+	check(s.InvalidReleases != 0, "%d invalid releases", s.InvalidReleases)
+	check(s.Leaked != 0, "%d leaked entries", s.Leaked)
+	check(s.HoldsExpired != 0, "%d entries held for too long", s.HoldsExpired)
+	check(s.HookTimeouts != 0, "%d timed out hooks", s.HookTimeouts)
+	if len(errs) == 0 {
+		return nil
+	}
+	name := s.Name
+	if name == "" {
+		name = "pool"
+	}
+	return fmt.Errorf("pooltest: %s isn't clean: %w", name, errors.Join(errs...))
+}
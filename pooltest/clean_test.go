@@ -0,0 +1,32 @@
+package pooltest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/epikur-io/go-pool"
+)
+
+func TestAssertClean(t *testing.T) {
+	p := pool.NewPool(2, newEntry, pool.WithCheckedRelease())
+	defer p.Close()
+	v := p.Acquire()
+	p.Release(v)
+	AssertClean(t, p)
+
+	held := p.Acquire()
+	p.Release(v)
+	err := Clean(p)
+	if err == nil {
+		t.Fatalf("expected the pool not to be clean")
+	}
+	for _, problem := range []string{"1 entries still in use", "1 invalid releases"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("expected %q to be reported but got %v", problem, err)
+		}
+	}
+	p.Release(held)
+	if err := Clean(p); err == nil || strings.Contains(err.Error(), "in use") {
+		t.Errorf("expected only the invalid release to be reported but got %v", err)
+	}
+}
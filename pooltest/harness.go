@@ -0,0 +1,275 @@
+// Package pooltest checks pools and pool wrappers against the invariants of pool.Pool
+// using randomly generated sequences of concurrent operations:
+//
+//   - the number of entries in use never exceeds Cap()
+//   - an entry is never handed out again before it got released
+//   - releasing an acquired entry succeeds and the stats (if provided) add up
+//
+// Run the harness from a test, or call Check from a fuzz target to let the fuzzer pick seeds:
+//
+//	func TestWrapper(t *testing.T) {
+//		pooltest.Harness[Conn]{New: func() pooltest.Pool[Conn] { return newWrapper() }}.Run(t)
+//	}
+//
+//	func FuzzWrapper(f *testing.F) {
+//		h := pooltest.Harness[Conn]{New: func() pooltest.Pool[Conn] { return newWrapper() }}
+//		f.Fuzz(func(t *testing.T, seed int64) {
+//			if err := h.Check(seed); err != nil {
+//				t.Fatal(err)
+//			}
+//		})
+//	}
+//
+// AssertClean checks the stats of a pool at the end of a test for entries that are still in use and for
+// problems reported by the pool, e.g. invalid releases or leaked entries.
+package pooltest
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/epikur-io/go-pool"
+)
+
+// Pool is the part of pool.Pooler the harness uses, pools may additionally provide
+// Stats() pool.Stats to check their accounting and Close() error to get closed after a run
+type Pool[T any] interface {
+	Cap() int
+	Len() int
+	AcquireWithTimeout(time.Duration) (*T, error)
+	AcquireWithContext(context.Context) (*T, error)
+	Release(*T) error
+	Replace(*T) error
+}
+
+var _ Pool[any] = pool.Pooler[any](nil)
+
+// op is an operation of a generated sequence
+type op int
+
+const (
+	opAcquire op = iota
+	opAcquireContext
+	opRelease
+	opReplace
+	opHold
+	numOps
+)
+
+func (o op) String() string {
+	return [...]string{"acquire", "acquire with context", "release", "replace", "hold"}[o]
+}
+
+// Harness checks the pools returned by New against the invariants of pool.Pool
+type Harness[T any] struct {
+	// New returns the pool under test, it's called for every run
+	New func() Pool[T]
+	// seed of the first run, following runs use the next seeds (default 1)
+	Seed int64
+	// number of runs (default 20)
+	Runs int
+	// number of goroutines using the pool concurrently (default 4)
+	Workers int
+	// number of operations per goroutine (default 100)
+	Ops int
+	// timeout of acquires, failed acquires are expected while all entries are in use (default 1ms)
+	AcquireTimeout time.Duration
+}
+
+func (h Harness[T]) withDefaults() Harness[T] {
+	if h.Seed == 0 {
+		h.Seed = 1
+	}
+	if h.Runs <= 0 {
+		h.Runs = 20
+	}
+	if h.Workers <= 0 {
+		h.Workers = 4
+	}
+	if h.Ops <= 0 {
+		h.Ops = 100
+	}
+	if h.AcquireTimeout <= 0 {
+		h.AcquireTimeout = time.Millisecond
+	}
+	return h
+}
+
+// Run checks Runs generated sequences and fails t with the seed reproducing the first violation
+func (h Harness[T]) Run(t testing.TB) {
+	t.Helper()
+	h = h.withDefaults()
+	for i := 0; i < h.Runs; i++ {
+		if err := h.Check(h.Seed + int64(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Check runs the sequence generated from seed against a new pool and returns the first invariant
+// violation, as the operations run concurrently a seed doesn't reproduce the exact interleaving
+func (h Harness[T]) Check(seed int64) error {
+	h = h.withDefaults()
+	if h.New == nil {
+		return fmt.Errorf("pooltest: missing New function")
+	}
+	p := h.New()
+	if c, ok := p.(interface{ Close() error }); ok {
+		defer c.Close()
+	}
+	rnd := rand.New(rand.NewSource(seed))
+	seqs := make([][]op, h.Workers)
+	for i := range seqs {
+		seqs[i] = make([]op, h.Ops)
+		for j := range seqs[i] {
+			seqs[i][j] = op(rnd.Intn(int(numOps)))
+		}
+	}
+
+	c := &checker[T]{pool: p, held: make(map[*T]int)}
+	var wg sync.WaitGroup
+	for i, seq := range seqs {
+		wg.Add(1)
+		go func(worker int, seq []op, seed int64) {
+			defer wg.Done()
+			c.run(worker, seq, rand.New(rand.NewSource(seed)), h.AcquireTimeout)
+		}(i, seq, rnd.Int63())
+	}
+	wg.Wait()
+	if c.err != nil {
+		return fmt.Errorf("pooltest: seed %d: %w", seed, c.err)
+	}
+	if err := c.checkIdle(); err != nil {
+		return fmt.Errorf("pooltest: seed %d: %w", seed, err)
+	}
+	return nil
+}
+
+// checker tracks the entries in use and records the first violation
+type checker[T any] struct {
+	pool Pool[T]
+	mux  sync.Mutex
+	// entry -> worker holding it
+	held map[*T]int
+	err  error
+}
+
+func (c *checker[T]) fail(err error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+func (c *checker[T]) failed() bool {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.err != nil
+}
+
+// acquired records v as held by worker
+func (c *checker[T]) acquired(worker int, v *T) error {
+	if v == nil {
+		return fmt.Errorf("worker %d acquired nil without error", worker)
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if other, ok := c.held[v]; ok {
+		return fmt.Errorf("entry %p handed out to worker %d while held by worker %d", v, worker, other)
+	}
+	c.held[v] = worker
+	if n, capacity := len(c.held), c.pool.Cap(); n > capacity {
+		return fmt.Errorf("%d entries in use exceed the capacity of %d", n, capacity)
+	}
+	return nil
+}
+
+// releasing stops tracking v, it must be called before v is given back to the pool
+func (c *checker[T]) releasing(v *T) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	delete(c.held, v)
+}
+
+func (c *checker[T]) run(worker int, seq []op, rnd *rand.Rand, timeout time.Duration) {
+	var held []*T
+	defer func() {
+		for _, v := range held {
+			c.releasing(v)
+			if err := c.pool.Release(v); err != nil {
+				c.fail(fmt.Errorf("worker %d: release: %w", worker, err))
+			}
+		}
+	}()
+	for _, o := range seq {
+		if c.failed() {
+			return
+		}
+		var err error
+		switch o {
+		case opAcquire, opAcquireContext:
+			var v *T
+			if o == opAcquire {
+				v, err = c.pool.AcquireWithTimeout(timeout)
+			} else {
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				v, err = c.pool.AcquireWithContext(ctx)
+				cancel()
+			}
+			if err != nil {
+				// expected while all entries are in use
+				err = nil
+				break
+			}
+			if err = c.acquired(worker, v); err == nil {
+				held = append(held, v)
+			}
+		case opRelease, opReplace:
+			if len(held) == 0 {
+				break
+			}
+			i := rnd.Intn(len(held))
+			v := held[i]
+			held = append(held[:i], held[i+1:]...)
+			c.releasing(v)
+			if o == opRelease {
+				err = c.pool.Release(v)
+			} else {
+				err = c.pool.Replace(v)
+			}
+		case opHold:
+			time.Sleep(time.Duration(rnd.Intn(100)) * time.Microsecond)
+		}
+		if err != nil {
+			c.fail(fmt.Errorf("worker %d: %v: %w", worker, o, err))
+			return
+		}
+	}
+}
+
+// checkIdle checks the accounting of the pool once all entries got released
+func (c *checker[T]) checkIdle() error {
+	if n, capacity := c.pool.Len(), c.pool.Cap(); n > capacity {
+		return fmt.Errorf("%d idle entries exceed the capacity of %d", n, capacity)
+	}
+	sp, ok := c.pool.(interface{ Stats() pool.Stats })
+	if !ok {
+		return nil
+	}
+	s := sp.Stats()
+	if s.InUse != 0 {
+		return fmt.Errorf("%d entries in use after all entries got released", s.InUse)
+	}
+	if s.Acquired != s.Released {
+		return fmt.Errorf("%d acquired but %d released entries", s.Acquired, s.Released)
+	}
+	if s.Created < s.Destroyed || s.Created-s.Destroyed > uint64(s.Cap) {
+		return fmt.Errorf("%d created and %d destroyed entries exceed the capacity of %d", s.Created, s.Destroyed, s.Cap)
+	}
+	return nil
+}
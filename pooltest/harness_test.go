@@ -0,0 +1,51 @@
+package pooltest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/epikur-io/go-pool"
+)
+
+type entry struct{ id int }
+
+func newEntry() *entry { return &entry{} }
+
+func TestHarness(t *testing.T) {
+	Harness[entry]{New: func() Pool[entry] {
+		return pool.NewPool(3, newEntry)
+	}}.Run(t)
+	Harness[entry]{New: func() Pool[entry] {
+		return pool.NewPool(2, newEntry, pool.WithMinSize(0), pool.WithMaxSize(4), pool.WithLIFO())
+	}}.Run(t)
+}
+
+// leaky hands out its first entry to everyone
+type leaky struct {
+	*pool.Pool[entry]
+	first *entry
+}
+
+func (l *leaky) AcquireWithTimeout(to time.Duration) (*entry, error) {
+	return l.first, nil
+}
+
+func (l *leaky) AcquireWithContext(ctx context.Context) (*entry, error) {
+	return l.first, nil
+}
+
+func (l *leaky) Release(*entry) error { return nil }
+
+func (l *leaky) Replace(*entry) error { return nil }
+
+func TestHarnessViolation(t *testing.T) {
+	h := Harness[entry]{New: func() Pool[entry] {
+		return &leaky{Pool: pool.NewPool(2, newEntry), first: &entry{}}
+	}}
+	err := h.Check(1)
+	if err == nil || !strings.Contains(err.Error(), "handed out") {
+		t.Errorf("expected double issue to be detected but got %v", err)
+	}
+}
@@ -0,0 +1,217 @@
+// Package procpool pools long-lived child processes talking over their standard input and output
+//
+// Processes are started lazily on acquire, processes that exited are replaced before they are handed
+// out. Removed processes get their standard input closed and are killed if they don't exit within the
+// grace period. Useful for helper binaries like image converters that serve requests in a loop:
+//
+//	p, err := procpool.New(procpool.Options{
+//		Command: func() *exec.Cmd { return exec.Command("convert-server", "--stdio") },
+//		Size:    runtime.NumCPU(),
+//	})
+//	...
+//	err = p.Run(ctx, func(ctx context.Context, proc *procpool.Proc) error {
+//		fmt.Fprintln(proc.Stdin, "resize in.png 100x100")
+//		reply, err := proc.Stdout.ReadString('\n')
+//		...
+//	})
+package procpool
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"sync/atomic"
+	"time"
+
+	"github.com/epikur-io/go-pool"
+	"github.com/epikur-io/go-pool/internal/adapter"
+)
+
+// default time processes get to exit after their input was closed
+const defaultGracePeriod = 5 * time.Second
+
+// Options of a process pool
+type Options struct {
+	// Command returns the command to start a process (required), its standard input and output are
+	// connected to the pool and must not be set
+	Command func() *exec.Cmd
+	// Size is the number of processes (required)
+	Size int
+	// GracePeriod is the time processes get to exit after their input was closed before they are
+	// killed (default 5 seconds)
+	GracePeriod time.Duration
+	// MaxLifetime restarts processes older than it, 0 keeps them
+	MaxLifetime time.Duration
+}
+
+// Proc is a pooled process, it's started when it's acquired for the first time
+type Proc struct {
+	cmd *exec.Cmd
+	// standard input and output of the process
+	Stdin  io.WriteCloser
+	Stdout *bufio.Reader
+	// closed once the process exited
+	exited chan struct{}
+	err    error
+}
+
+// Returns the process ID, 0 if the process isn't started
+func (p *Proc) Pid() int {
+	if p.cmd == nil || p.cmd.Process == nil {
+		return 0
+	}
+	return p.cmd.Process.Pid
+}
+
+// Exited reports whether the process exited
+func (p *Proc) Exited() bool {
+	if p.exited == nil {
+		return false
+	}
+	select {
+	case <-p.exited:
+		return true
+	default:
+		return false
+	}
+}
+
+// Returns the error the process exited with (see exec.Cmd.Wait), nil while it's running
+func (p *Proc) Err() error {
+	if !p.Exited() {
+		return nil
+	}
+	return p.err
+}
+
+// Pool is a pool of processes
+type Pool struct {
+	opts Options
+	pool *pool.Pool[Proc]
+	// number of processes started
+	starts atomic.Uint64
+}
+
+// Creates a pool of processes started by o.Command. Processes that exited on their own are replaced on
+// acquire and removed processes get their stdin closed and are killed after the grace period, opts
+// setting a validator or destroyer of their own take over these jobs.
+func New(o Options, opts ...pool.Option) (*Pool, error) {
+	if o.Command == nil {
+		return nil, errors.New("procpool: missing command")
+	}
+	if o.GracePeriod <= 0 {
+		o.GracePeriod = defaultGracePeriod
+	}
+	p := &Pool{opts: o}
+	defaults := []pool.Option{
+		pool.WithValidator(running),
+		pool.WithDestroyerContext(p.stop),
+	}
+	cfg := pool.Config{Size: o.Size, TTL: pool.Duration(o.MaxLifetime)}
+	procs, err := pool.NewPoolFromConfig(cfg, func() *Proc { return &Proc{} }, append(defaults, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	p.pool = procs
+	return p, nil
+}
+
+// running reports whether a started process is still running
+func running(proc *Proc) bool {
+	return !proc.Exited()
+}
+
+// started reports whether the process of proc was started
+func started(proc *Proc) bool {
+	return proc.cmd != nil
+}
+
+// start starts the process of proc
+func (p *Pool) start(_ context.Context, proc *Proc) error {
+	cmd := p.opts.Command()
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		stdin.Close()
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	p.starts.Add(1)
+	proc.cmd, proc.Stdin, proc.Stdout = cmd, stdin, bufio.NewReader(stdout)
+	proc.exited = make(chan struct{})
+	go func() {
+		proc.err = cmd.Wait()
+		close(proc.exited)
+	}()
+	return nil
+}
+
+// stop closes the input of a started process and kills it if it doesn't exit within the grace period
+func (p *Pool) stop(ctx context.Context, proc *Proc) error {
+	if proc.cmd == nil {
+		return nil
+	}
+	proc.Stdin.Close()
+	t := time.NewTimer(p.opts.GracePeriod)
+	defer t.Stop()
+	select {
+	case <-proc.exited:
+		return nil
+	case <-t.C:
+	case <-ctx.Done():
+	}
+	if err := proc.cmd.Process.Kill(); err != nil {
+		return err
+	}
+	<-proc.exited
+	return nil
+}
+
+// Returns the pool holding the processes. Its entries are created before their process is started, so
+// its stats count entries rather than processes (see Starts).
+func (p *Pool) Pool() *pool.Pool[Proc] {
+	return p.pool
+}
+
+// Returns the number of processes started
+func (p *Pool) Starts() uint64 {
+	return p.starts.Load()
+}
+
+// Acquire acquires a process, starting it if necessary, waiting until ctx is done
+func (p *Pool) Acquire(ctx context.Context) (*Proc, error) {
+	return adapter.Acquire(ctx, p.pool, started, p.start)
+}
+
+// Releases a process to the pool
+func (p *Pool) Release(proc *Proc) error {
+	return p.pool.Release(proc)
+}
+
+// Discard stops a process that shouldn't be reused (e.g. after a protocol error),
+// the next acquire of its slot starts a fresh process
+func (p *Pool) Discard(proc *Proc) error {
+	return p.pool.Replace(proc)
+}
+
+// Run acquires a process and runs fn with it. The process is stopped if fn fails or panics: it may
+// have stopped halfway through a request, leaving unread output in its pipes.
+func (p *Pool) Run(ctx context.Context, fn func(ctx context.Context, proc *Proc) error) error {
+	proc, err := p.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	return adapter.Run(p.pool, proc, p.Release, nil, func() error { return fn(ctx, proc) })
+}
+
+// Close closes the pool and stops all idle processes
+func (p *Pool) Close() error {
+	return p.pool.Close()
+}
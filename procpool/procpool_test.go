@@ -0,0 +1,120 @@
+package procpool
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMain runs the test binary as helper process if PROCPOOL_HELPER is set
+func TestMain(m *testing.M) {
+	switch os.Getenv("PROCPOOL_HELPER") {
+	case "echo":
+		s := bufio.NewScanner(os.Stdin)
+		for s.Scan() {
+			if s.Text() == "exit" {
+				os.Exit(1)
+			}
+			fmt.Println(strings.ToUpper(s.Text()))
+		}
+		os.Exit(0)
+	case "stubborn":
+		// ignores its input being closed
+		for {
+			time.Sleep(time.Hour)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+func helper(mode string) func() *exec.Cmd {
+	return func() *exec.Cmd {
+		cmd := exec.Command(os.Args[0])
+		cmd.Env = append(os.Environ(), "PROCPOOL_HELPER="+mode)
+		return cmd
+	}
+}
+
+func call(ctx context.Context, proc *Proc, line string) (string, error) {
+	if _, err := fmt.Fprintln(proc.Stdin, line); err != nil {
+		return "", err
+	}
+	reply, err := proc.Stdout.ReadString('\n')
+	return strings.TrimSpace(reply), err
+}
+
+func TestRun(t *testing.T) {
+	p, err := New(Options{Command: helper("echo"), Size: 1})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	pids := map[int]bool{}
+	for i := 0; i < 3; i++ {
+		if err := p.Run(context.Background(), func(ctx context.Context, proc *Proc) error {
+			pids[proc.Pid()] = true
+			reply, err := call(ctx, proc, "hello")
+			if err == nil && reply != "HELLO" {
+				err = fmt.Errorf("unexpected reply %q", reply)
+			}
+			return err
+		}); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+	}
+	if len(pids) != 1 || p.Starts() != 1 {
+		t.Errorf("expected the process to be reused but got %d processes and %d starts", len(pids), p.Starts())
+	}
+
+	// exited processes get replaced
+	proc, _ := p.Acquire(context.Background())
+	fmt.Fprintln(proc.Stdin, "exit")
+	<-proc.exited
+	if proc.Err() == nil {
+		t.Errorf("expected exit error")
+	}
+	p.Release(proc)
+	if err := p.Run(context.Background(), func(ctx context.Context, proc *Proc) error {
+		if pids[proc.Pid()] {
+			return errors.New("expected a new process")
+		}
+		_, err := call(ctx, proc, "hello")
+		return err
+	}); err != nil {
+		t.Errorf("expected no error but got %v", err)
+	}
+	if n := p.Starts(); n != 2 {
+		t.Errorf("expected 2 starts but got %d", n)
+	}
+	if err := p.Close(); err != nil {
+		t.Errorf("expected no error but got %v", err)
+	}
+	if _, err := New(Options{Size: 1}); err == nil {
+		t.Errorf("expected error for a missing command")
+	}
+}
+
+func TestGracePeriod(t *testing.T) {
+	p, err := New(Options{Command: helper("stubborn"), Size: 1, GracePeriod: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	proc, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	p.Release(proc)
+	start := time.Now()
+	p.Close()
+	if !proc.Exited() {
+		t.Errorf("expected the process to be killed")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("expected the process to be killed after the grace period but took %v", d)
+	}
+}
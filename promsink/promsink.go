@@ -0,0 +1,183 @@
+// Package promsink implements pool.MetricsSink serving the metrics of pools in the Prometheus text format,
+// without depending on the Prometheus client library. Metrics are updated using atomics as the pools report
+// them and rendered on scrape:
+//
+//	sink := promsink.New(nil)
+//	p := pool.NewPool(10, factory, pool.WithName("vms"), pool.WithMetricsSink(sink))
+//	http.Handle("/metrics", sink)
+//
+// The metrics are written by the same code as the ones served by the admin package and use the same
+// names, a pool should only be scraped from one of them.
+//
+// The OpenTelemetry sink is the otelsink module, which keeps the OpenTelemetry API out of the dependencies
+// of this module.
+package promsink
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/epikur-io/go-pool"
+	"github.com/epikur-io/go-pool/internal/promtext"
+)
+
+// DefaultBuckets are the upper bounds of the histograms of New(nil)
+var DefaultBuckets = []time.Duration{
+	100 * time.Microsecond, time.Millisecond, 10 * time.Millisecond, 100 * time.Millisecond,
+	time.Second, 10 * time.Second,
+}
+
+// Sink collects the metrics of any number of pools, labeled by the name of the pool
+type Sink struct {
+	buckets []time.Duration
+	// pool name -> *metrics
+	pools sync.Map
+}
+
+var _ pool.MetricsSink = (*Sink)(nil)
+
+// New returns a sink whose histograms use the given upper bounds (DefaultBuckets if empty)
+func New(buckets []time.Duration) *Sink {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	return &Sink{buckets: slices.Compact(buckets)}
+}
+
+// metrics of a pool
+type metrics struct {
+	idle, inUse                  atomic.Int64
+	timeouts, created, destroyed atomic.Uint64
+	waits, factory               histogram
+}
+
+// histogram counts durations in buckets, the last bucket counts durations beyond all bounds
+type histogram struct {
+	counts []atomic.Uint64
+	// nanoseconds
+	sum atomic.Int64
+}
+
+func (h *histogram) observe(bounds []time.Duration, d time.Duration) {
+	i, _ := slices.BinarySearch(bounds, d)
+	h.counts[i].Add(1)
+	h.sum.Add(int64(d))
+}
+
+// snapshot returns the cumulative buckets of the histogram
+func (h *histogram) snapshot(bounds []time.Duration) *pool.WaitHistogram {
+	snap := &pool.WaitHistogram{Buckets: make([]pool.WaitBucket, len(h.counts)), Sum: time.Duration(h.sum.Load())}
+	for i := range h.counts {
+		snap.Count += h.counts[i].Load()
+		bound := time.Duration(math.MaxInt64)
+		if i < len(bounds) {
+			bound = bounds[i]
+		}
+		snap.Buckets[i] = pool.WaitBucket{UpperBound: bound, Count: snap.Count}
+	}
+	return snap
+}
+
+// pool returns the metrics of the pool called name, creating them on first use
+func (s *Sink) pool(name string) *metrics {
+	if m, ok := s.pools.Load(name); ok {
+		return m.(*metrics)
+	}
+	m := &metrics{}
+	m.waits.counts = make([]atomic.Uint64, len(s.buckets)+1)
+	m.factory.counts = make([]atomic.Uint64, len(s.buckets)+1)
+	actual, _ := s.pools.LoadOrStore(name, m)
+	return actual.(*metrics)
+}
+
+func (s *Sink) ObserveAcquireWait(name string, d time.Duration) {
+	s.pool(name).waits.observe(s.buckets, d)
+}
+
+func (s *Sink) IncTimeout(name string) {
+	s.pool(name).timeouts.Add(1)
+}
+
+func (s *Sink) SetIdle(name string, n int) {
+	s.pool(name).idle.Store(int64(n))
+}
+
+func (s *Sink) SetInUse(name string, n int) {
+	s.pool(name).inUse.Store(int64(n))
+}
+
+func (s *Sink) IncCreated(name string) {
+	s.pool(name).created.Add(1)
+}
+
+func (s *Sink) IncDestroyed(name string) {
+	s.pool(name).destroyed.Add(1)
+}
+
+func (s *Sink) ObserveFactory(name string, d time.Duration) {
+	s.pool(name).factory.observe(s.buckets, d)
+}
+
+// ServeHTTP serves the metrics of all pools in the Prometheus text format
+func (s *Sink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", promtext.ContentType)
+	s.WriteTo(w)
+}
+
+// scalars are the counters and gauges reported to a sink
+var scalars = []promtext.Metric{promtext.Idle, promtext.InUse, promtext.Created, promtext.Destroyed, promtext.Timeouts}
+
+// WriteTo writes the metrics of all pools in the Prometheus text format, ordered by the name of the pool
+func (s *Sink) WriteTo(w io.Writer) (int64, error) {
+	var names []string
+	s.pools.Range(func(name, _ any) bool {
+		names = append(names, name.(string))
+		return true
+	})
+	sort.Strings(names)
+	stats := make([]pool.Stats, len(names))
+	factory := make(map[string]*pool.WaitHistogram, len(names))
+	for i, name := range names {
+		m := s.pool(name)
+		stats[i] = pool.Stats{
+			Name:      name,
+			Idle:      int(m.idle.Load()),
+			InUse:     int(m.inUse.Load()),
+			Created:   m.created.Load(),
+			Destroyed: m.destroyed.Load(),
+			Timeouts:  m.timeouts.Load(),
+			Waits:     m.waits.snapshot(s.buckets),
+		}
+		factory[name] = m.factory.snapshot(s.buckets)
+	}
+	cw := &countingWriter{w: w}
+	promtext.Write(cw, scalars, stats)
+	promtext.WriteHistogram(cw, promtext.AcquireWait, stats, func(st pool.Stats) *pool.WaitHistogram { return st.Waits })
+	promtext.WriteHistogram(cw, promtext.Factory, stats, func(st pool.Stats) *pool.WaitHistogram { return factory[st.Name] })
+	return cw.n, cw.err
+}
+
+// countingWriter counts the bytes written and keeps the first error
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
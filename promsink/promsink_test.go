@@ -0,0 +1,43 @@
+package promsink
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/epikur-io/go-pool"
+)
+
+type entry struct{}
+
+func TestSink(t *testing.T) {
+	sink := New([]time.Duration{time.Second, time.Millisecond})
+	p := pool.NewPool(1, func() *entry { return &entry{} }, pool.WithName(`v"ms`), pool.WithMetricsSink(sink))
+	defer p.Close()
+	v := p.Acquire()
+	if _, err := p.AcquireWithTimeout(time.Millisecond); err == nil {
+		t.Fatalf("expected a timeout")
+	}
+	p.Release(v)
+
+	rec := httptest.NewRecorder()
+	sink.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, line := range []string{
+		"# TYPE pool_idle gauge",
+		`pool_idle{pool="v\"ms"} 1`,
+		`pool_in_use{pool="v\"ms"} 0`,
+		`pool_created_total{pool="v\"ms"} 1`,
+		`pool_timeouts_total{pool="v\"ms"} 1`,
+		"# TYPE pool_acquire_wait_seconds histogram",
+		`pool_acquire_wait_seconds_bucket{pool="v\"ms",le="0.001"} 1`,
+		`pool_acquire_wait_seconds_bucket{pool="v\"ms",le="+Inf"} 1`,
+		`pool_acquire_wait_seconds_count{pool="v\"ms"} 1`,
+		`pool_factory_seconds_count{pool="v\"ms"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("expected %q in\n%s", line, body)
+		}
+	}
+}
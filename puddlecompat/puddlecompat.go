@@ -0,0 +1,323 @@
+// Package puddlecompat provides the API of jackc/puddle (v2) on top of a pool.Pool, code written against
+// puddle can switch by replacing the import:
+//
+//	p, err := puddlecompat.NewPool(&puddlecompat.Config[*Conn]{
+//		Constructor: func(ctx context.Context) (*Conn, error) { return dial(ctx) },
+//		Destructor:  func(c *Conn) { c.Close() },
+//		MaxSize:     10,
+//	})
+//	...
+//	res, err := p.Acquire(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	defer res.Release()
+//	res.Value().Ping()
+//
+// Resources are constructed lazily on acquire using the context of the caller like in puddle, the options
+// of the underlying pool (e.g. pool.WithIdleTimeout) can be passed to NewPool.
+package puddlecompat
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/epikur-io/go-pool"
+)
+
+var (
+	// ErrClosedPool is wrapped by errors of acquires on a closed pool
+	ErrClosedPool = pool.ErrPoolClosed
+	// ErrNotAvailable is returned by TryAcquire if no resource is available right away
+	ErrNotAvailable = errors.New("resource not available")
+)
+
+// Config of a pool
+type Config[T any] struct {
+	// Constructor creates a resource (required)
+	Constructor func(ctx context.Context) (res T, err error)
+	// Destructor cleans up a removed resource (optional)
+	Destructor func(res T)
+	// MaxSize is the maximum number of resources (required)
+	MaxSize int32
+}
+
+// slot is an entry of the underlying pool, it holds a resource once the resource got constructed
+type slot[T any] struct {
+	value       T
+	constructed bool
+	createdAt   time.Time
+}
+
+// Pool is a pool of resources of type T
+type Pool[T any] struct {
+	pool        *pool.Pool[slot[T]]
+	constructor func(ctx context.Context) (T, error)
+	destructor  func(T)
+
+	// constructed resources, including the ones being constructed
+	total        atomic.Int32
+	constructing atomic.Int32
+
+	acquires         atomic.Int64
+	emptyAcquires    atomic.Int64
+	canceledAcquires atomic.Int64
+	acquireDuration  atomic.Int64
+}
+
+// NewPool creates a pool of up to config.MaxSize resources, opts are applied to the underlying pool
+func NewPool[T any](config *Config[T], opts ...pool.Option) (*Pool[T], error) {
+	if config.Constructor == nil {
+		return nil, errors.New("puddlecompat: missing constructor")
+	}
+	if config.MaxSize < 1 {
+		return nil, errors.New("puddlecompat: MaxSize must be >= 1")
+	}
+	p := &Pool[T]{constructor: config.Constructor, destructor: config.Destructor}
+	// constructed resources are released on top of the empty slots
+	// LastUsedNanotime and IdleDuration report the use of the resources
+	defaults := []pool.Option{pool.WithDestroyer(p.destroy), pool.WithLIFO(), pool.WithUsageTracking()}
+	var err error
+	p.pool, err = pool.NewPoolFromConfig(pool.Config{Size: int(config.MaxSize)}, func() *slot[T] { return &slot[T]{} }, append(defaults, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// destroy calls the destructor for constructed resources
+func (p *Pool[T]) destroy(s *slot[T]) {
+	if !s.constructed {
+		return
+	}
+	p.total.Add(-1)
+	if p.destructor != nil {
+		p.destructor(s.value)
+	}
+}
+
+// construct creates the resource of the empty slot s
+func (p *Pool[T]) construct(ctx context.Context, s *slot[T]) error {
+	p.total.Add(1)
+	p.constructing.Add(1)
+	defer p.constructing.Add(-1)
+	v, err := p.constructor(ctx)
+	if err != nil {
+		p.total.Add(-1)
+		return err
+	}
+	s.value, s.constructed, s.createdAt = v, true, time.Now()
+	return nil
+}
+
+// Acquire acquires a resource, constructing it if necessary, waiting until ctx is done
+func (p *Pool[T]) Acquire(ctx context.Context) (*Resource[T], error) {
+	start := time.Now()
+	s, err := p.pool.AcquireWithContext(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			p.canceledAcquires.Add(1)
+		}
+		return nil, err
+	}
+	if !s.constructed {
+		p.emptyAcquires.Add(1)
+		if err := p.construct(ctx, s); err != nil {
+			p.pool.Release(s)
+			return nil, err
+		}
+	}
+	p.acquires.Add(1)
+	p.acquireDuration.Add(int64(time.Since(start)))
+	return &Resource[T]{pool: p, slot: s}, nil
+}
+
+// TryAcquire acquires an idle resource without blocking, ErrNotAvailable is returned if no resource is idle.
+// A resource gets constructed in the background then if the pool holds less resources than it may.
+func (p *Pool[T]) TryAcquire(ctx context.Context) (*Resource[T], error) {
+	s, ok := p.pool.TryTakeIdle()
+	if !ok {
+		return nil, ErrNotAvailable
+	}
+	if !s.constructed {
+		go func() {
+			p.construct(context.WithoutCancel(ctx), s)
+			p.pool.Release(s)
+		}()
+		return nil, ErrNotAvailable
+	}
+	p.acquires.Add(1)
+	return &Resource[T]{pool: p, slot: s}, nil
+}
+
+// AcquireAllIdle acquires all idle resources without blocking
+func (p *Pool[T]) AcquireAllIdle() []*Resource[T] {
+	var resources []*Resource[T]
+	var empty []*slot[T]
+	for {
+		s, ok := p.pool.TryTakeIdle()
+		if !ok {
+			break
+		}
+		if !s.constructed {
+			empty = append(empty, s)
+			continue
+		}
+		p.acquires.Add(1)
+		resources = append(resources, &Resource[T]{pool: p, slot: s})
+	}
+	for _, s := range empty {
+		p.pool.Release(s)
+	}
+	return resources
+}
+
+// CreateResource constructs a resource and puts it into the pool without acquiring it,
+// ErrNotAvailable is returned if the pool can't hold another resource
+func (p *Pool[T]) CreateResource(ctx context.Context) error {
+	var constructed []*slot[T]
+	defer func() {
+		for _, s := range constructed {
+			p.pool.Release(s)
+		}
+	}()
+	for {
+		s, ok := p.pool.TryTakeIdle()
+		if !ok {
+			return ErrNotAvailable
+		}
+		if s.constructed {
+			constructed = append(constructed, s)
+			continue
+		}
+		err := p.construct(ctx, s)
+		p.pool.Release(s)
+		return err
+	}
+}
+
+// Reset destroys all resources, resources in use get destroyed once released
+func (p *Pool[T]) Reset() {
+	p.pool.RefreshAll(context.Background())
+}
+
+// Close closes the pool and waits until all resources got released and destroyed
+func (p *Pool[T]) Close() {
+	p.pool.Drain(context.Background())
+}
+
+// Stat returns the statistics of the pool
+func (p *Pool[T]) Stat() *Stat {
+	stats := p.pool.Stats()
+	total := p.total.Load()
+	constructing := p.constructing.Load()
+	// slots are acquired while their resource is constructed
+	acquired := max(int32(stats.InUse)-constructing, 0)
+	return &Stat{
+		constructing:     constructing,
+		acquired:         acquired,
+		idle:             max(total-constructing-acquired, 0),
+		total:            total,
+		max:              int32(stats.Cap),
+		acquires:         p.acquires.Load(),
+		emptyAcquires:    p.emptyAcquires.Load(),
+		canceledAcquires: p.canceledAcquires.Load(),
+		acquireDuration:  time.Duration(p.acquireDuration.Load()),
+	}
+}
+
+// Stat is a snapshot of the statistics of a pool
+type Stat struct {
+	constructing     int32
+	acquired         int32
+	idle             int32
+	total            int32
+	max              int32
+	acquires         int64
+	emptyAcquires    int64
+	canceledAcquires int64
+	acquireDuration  time.Duration
+}
+
+// Returns the number of resources being constructed
+func (s *Stat) ConstructingResources() int32 { return s.constructing }
+
+// Returns the number of acquired resources
+func (s *Stat) AcquiredResources() int32 { return s.acquired }
+
+// Returns the number of idle resources
+func (s *Stat) IdleResources() int32 { return s.idle }
+
+// Returns the number of resources, including the ones being constructed
+func (s *Stat) TotalResources() int32 { return s.total }
+
+// Returns the maximum number of resources
+func (s *Stat) MaxResources() int32 { return s.max }
+
+// Returns the total number of successful acquires
+func (s *Stat) AcquireCount() int64 { return s.acquires }
+
+// Returns the total number of acquires that had to construct a resource
+func (s *Stat) EmptyAcquireCount() int64 { return s.emptyAcquires }
+
+// Returns the total number of acquires canceled by their context
+func (s *Stat) CanceledAcquireCount() int64 { return s.canceledAcquires }
+
+// Returns the total duration of all successful acquires
+func (s *Stat) AcquireDuration() time.Duration { return s.acquireDuration }
+
+// Resource is an acquired resource
+type Resource[T any] struct {
+	pool *Pool[T]
+	slot *slot[T]
+}
+
+// Returns the resource
+func (r *Resource[T]) Value() T {
+	return r.slot.value
+}
+
+// Releases the resource to the pool
+func (r *Resource[T]) Release() {
+	r.pool.pool.Release(r.slot)
+}
+
+// ReleaseUnused releases the resource like Release, the last use of resources
+// is tracked on acquire so there's no difference
+func (r *Resource[T]) ReleaseUnused() {
+	r.Release()
+}
+
+// Destroy destroys the resource instead of releasing it
+func (r *Resource[T]) Destroy() {
+	r.pool.pool.Replace(r.slot)
+}
+
+// Hijack takes the resource over from the pool, the caller is responsible for cleaning it up
+func (r *Resource[T]) Hijack() {
+	if r.pool.pool.Hijack(r.slot) == nil {
+		r.pool.total.Add(-1)
+	}
+}
+
+// Returns the time the resource was constructed
+func (r *Resource[T]) CreationTime() time.Time {
+	return r.slot.createdAt
+}
+
+// Returns the time the resource was last acquired in nanoseconds since the unix epoch
+func (r *Resource[T]) LastUsedNanotime() int64 {
+	info, _ := r.pool.pool.EntryInfo(r.slot)
+	return info.LastUsed.UnixNano()
+}
+
+// Returns the time the resource was idle before it got acquired
+func (r *Resource[T]) IdleDuration() time.Duration {
+	info, _ := r.pool.pool.EntryInfo(r.slot)
+	if info.IdleSince.IsZero() {
+		return 0
+	}
+	return info.LastUsed.Sub(info.IdleSince)
+}
@@ -0,0 +1,130 @@
+package puddlecompat
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type conn struct {
+	id     int64
+	closed bool
+}
+
+func newTestPool(t *testing.T, size int32) (*Pool[*conn], *atomic.Int64) {
+	var created atomic.Int64
+	p, err := NewPool(&Config[*conn]{
+		Constructor: func(ctx context.Context) (*conn, error) {
+			return &conn{id: created.Add(1)}, ctx.Err()
+		},
+		Destructor: func(c *conn) { c.closed = true },
+		MaxSize:    size,
+	})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	return p, &created
+}
+
+func TestAcquireRelease(t *testing.T) {
+	p, created := newTestPool(t, 2)
+	defer p.Close()
+	ctx := context.Background()
+
+	res, err := p.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	c := res.Value()
+	if created.Load() != 1 || res.CreationTime().IsZero() {
+		t.Errorf("expected the resource to be constructed on acquire but got %d resources", created.Load())
+	}
+	res.Release()
+	res, _ = p.Acquire(ctx)
+	if res.Value() != c {
+		t.Errorf("expected the released resource to be reused")
+	}
+	stat := p.Stat()
+	if stat.AcquiredResources() != 1 || stat.TotalResources() != 1 || stat.MaxResources() != 2 || stat.AcquireCount() != 2 || stat.EmptyAcquireCount() != 1 {
+		t.Errorf("unexpected stat: %+v", *stat)
+	}
+	res.Destroy()
+	if !c.closed || p.Stat().TotalResources() != 0 {
+		t.Errorf("expected the destroyed resource to be closed")
+	}
+}
+
+func TestTryAcquire(t *testing.T) {
+	p, _ := newTestPool(t, 1)
+	defer p.Close()
+	ctx := context.Background()
+
+	if _, err := p.TryAcquire(ctx); !errors.Is(err, ErrNotAvailable) {
+		t.Errorf("expected ErrNotAvailable but got %v", err)
+	}
+	var res *Resource[*conn]
+	deadline := time.Now().Add(time.Second)
+	for res == nil && time.Now().Before(deadline) {
+		res, _ = p.TryAcquire(ctx)
+		time.Sleep(time.Millisecond)
+	}
+	if res == nil {
+		t.Fatalf("expected the resource to be constructed in the background")
+	}
+	if _, err := p.TryAcquire(ctx); !errors.Is(err, ErrNotAvailable) {
+		t.Errorf("expected ErrNotAvailable but got %v", err)
+	}
+	res.Release()
+	all := p.AcquireAllIdle()
+	if len(all) != 1 {
+		t.Errorf("expected 1 idle resource but got %d", len(all))
+	}
+	for _, res := range all {
+		res.Release()
+	}
+}
+
+func TestHijack(t *testing.T) {
+	p, created := newTestPool(t, 1)
+	ctx := context.Background()
+
+	res, _ := p.Acquire(ctx)
+	c := res.Value()
+	res.Hijack()
+	if p.Stat().TotalResources() != 0 {
+		t.Errorf("expected the hijacked resource to leave the pool but got %d resources", p.Stat().TotalResources())
+	}
+	res, err := p.Acquire(ctx)
+	if err != nil || res.Value() == c || created.Load() != 2 {
+		t.Fatalf("expected a new resource but got %v", err)
+	}
+	res.Release()
+	p.Close()
+	if c.closed {
+		t.Errorf("expected the hijacked resource not to be destroyed")
+	}
+	if _, err := p.Acquire(ctx); !errors.Is(err, ErrClosedPool) {
+		t.Errorf("expected ErrClosedPool but got %v", err)
+	}
+}
+
+func TestConstructorError(t *testing.T) {
+	p, _ := newTestPool(t, 1)
+	defer p.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.Acquire(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled but got %v", err)
+	}
+	if err := p.CreateResource(context.Background()); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	if stat := p.Stat(); stat.IdleResources() != 1 || stat.TotalResources() != 1 {
+		t.Errorf("expected 1 idle resource but got %+v", *stat)
+	}
+	if err := p.CreateResource(context.Background()); !errors.Is(err, ErrNotAvailable) {
+		t.Errorf("expected ErrNotAvailable but got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package pool
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrInvalidEntry is the error of entries quarantined because the validator rejected them
+var ErrInvalidEntry = fmt.Errorf("entry failed validation")
+
+// Quarantined is an entry that failed validation or a health check (see WithQuarantine)
+type Quarantined[T any] struct {
+	Entry *T
+	// ErrInvalidEntry or the error of the health check
+	Err error
+	At  time.Time
+	// lifecycle of the entry until it was quarantined
+	Info EntryInfo
+}
+
+// WithQuarantine keeps up to n entries that failed validation or a health check in a side buffer
+// instead of destroying them right away, so their state can be inspected (e.g. to find out why
+// pooled VMs keep getting corrupted). inspect (optional) is called synchronously for every
+// quarantined entry and must not use the pool. Quarantined entries don't count against the size
+// of the pool, the oldest one gets destroyed once the buffer is full. Its type must match the
+// pools type or else NewPool panics.
+func WithQuarantine[T any](n int, inspect func(q Quarantined[T])) Option {
+	return func(o *options) {
+		o.quarantineSize = n
+		o.quarantineInspect = inspect
+	}
+}
+
+// quarantine is the side buffer of WithQuarantine
+type quarantine[T any] struct {
+	mux sync.Mutex
+	// oldest first
+	entries []Quarantined[T]
+	size    int
+}
+
+// reject destroys v which failed validation or a health check, or quarantines it
+func (p *Pool[T]) reject(v *T, err error) {
+	if p.quarantine == nil || p.closed.Load() {
+		p.destroy(v)
+		return
+	}
+	q := Quarantined[T]{Entry: v, Err: err, At: time.Now(), Info: p.describe(v, p.meta(v))}
+	// v is replaced like a destroyed entry but only its destroyer is delayed
+	p.live.Add(-1)
+	p.untrack(v)
+	p.updateState()
+	if p.quarantineFunc != nil {
+		p.quarantineFunc(q)
+	}
+	qr := p.quarantine
+	qr.mux.Lock()
+	var evicted *T
+	if len(qr.entries) == qr.size {
+		evicted = qr.entries[0].Entry
+		qr.entries = append(qr.entries[:0], qr.entries[1:]...)
+	}
+	qr.entries = append(qr.entries, q)
+	qr.mux.Unlock()
+	if evicted != nil {
+		p.disposeQuarantined(evicted)
+	}
+	if p.closed.Load() {
+		// closed concurrently
+		p.ClearQuarantine()
+	}
+}
+
+// Returns the entries held in quarantine, oldest first (see WithQuarantine),
+// the entries must not be used after they were cleared or evicted
+func (p *Pool[T]) Quarantined() []Quarantined[T] {
+	if p.quarantine == nil {
+		return nil
+	}
+	p.quarantine.mux.Lock()
+	defer p.quarantine.mux.Unlock()
+	return append([]Quarantined[T](nil), p.quarantine.entries...)
+}
+
+// ClearQuarantine destroys all quarantined entries, it's called when the pool gets closed
+func (p *Pool[T]) ClearQuarantine() {
+	if p.quarantine == nil {
+		return
+	}
+	p.quarantine.mux.Lock()
+	entries := p.quarantine.entries
+	p.quarantine.entries = nil
+	p.quarantine.mux.Unlock()
+	for _, q := range entries {
+		p.disposeQuarantined(q.Entry)
+	}
+}
+
+// disposeQuarantined calls the destroyer for a quarantined entry
+func (p *Pool[T]) disposeQuarantined(v *T) {
+	p.countDestroyed()
+	p.runDestroyer(v)
+}
+
+// quarantined returns the number of quarantined entries
+func (p *Pool[T]) quarantined() int {
+	if p.quarantine == nil {
+		return 0
+	}
+	p.quarantine.mux.Lock()
+	defer p.quarantine.mux.Unlock()
+	return len(p.quarantine.entries)
+}
@@ -0,0 +1,46 @@
+package pool
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestQuarantine(t *testing.T) {
+	bad := map[*poolItem]bool{}
+	var inspected []Quarantined[poolItem]
+	var destroyed []*poolItem
+	pool := NewPool(1, poolFactory,
+		WithValidator(func(e *poolItem) bool { return !bad[e] }),
+		WithDestroyer(func(e *poolItem) { destroyed = append(destroyed, e) }),
+		WithQuarantine(1, func(q Quarantined[poolItem]) { inspected = append(inspected, q) }),
+	)
+	a := pool.Acquire()
+	bad[a] = true
+	pool.Release(a)
+	b := pool.Acquire()
+	if b == a {
+		t.Fatalf("expected the invalid entry to be replaced")
+	}
+	if len(inspected) != 1 || inspected[0].Entry != a || !errors.Is(inspected[0].Err, ErrInvalidEntry) || inspected[0].Info.UseCount != 1 {
+		t.Errorf("expected the invalid entry to be inspected but got %+v", inspected)
+	}
+	if q := pool.Quarantined(); len(q) != 1 || q[0].Entry != a || len(destroyed) != 0 {
+		t.Errorf("expected the invalid entry to be quarantined but got %+v, destroyed %v", q, destroyed)
+	}
+	if stats := pool.Stats(); stats.Quarantined != 1 || stats.Invalid != 1 || stats.Idle != 0 || stats.InUse != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	// the buffer is full, the oldest entry gets destroyed
+	bad[b] = true
+	pool.Release(b)
+	pool.Release(pool.Acquire())
+	if q := pool.Quarantined(); len(q) != 1 || q[0].Entry != b || len(destroyed) != 1 || destroyed[0] != a {
+		t.Errorf("expected the oldest entry to be destroyed but got %+v, destroyed %v", q, destroyed)
+	}
+
+	pool.Close()
+	if len(pool.Quarantined()) != 0 || len(destroyed) != 3 {
+		t.Errorf("expected the quarantined entries to be destroyed on close but got %v", destroyed)
+	}
+}
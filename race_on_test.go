@@ -0,0 +1,6 @@
+//go:build race
+
+package pool
+
+// the race detector randomly drops items put into a sync.Pool
+const raceEnabled = true
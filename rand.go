@@ -0,0 +1,49 @@
+package pool
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// WithSeed makes the pool deterministic for reproducible tests: random decisions of the pool (e.g. the faults
+// injected by WithChaos without a seed of their own) use a pseudo random number generator seeded with seed
+// instead of the current time. Choices between equivalent entries don't depend on map iteration order anyway.
+func WithSeed(seed int64) Option {
+	return func(o *options) {
+		o.seed = seed
+		o.seeded = true
+	}
+}
+
+// prng is a pseudo random number generator safe for concurrent use
+type prng struct {
+	mux sync.Mutex
+	rnd *rand.Rand
+}
+
+func newPRNG(seed int64) *prng {
+	return &prng{rnd: rand.New(rand.NewSource(seed))}
+}
+
+// float64 returns a number in [0, 1)
+func (r *prng) float64() float64 {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.rnd.Float64()
+}
+
+// int63n returns a number in [0, n)
+func (r *prng) int63n(n int64) int64 {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.rnd.Int63n(n)
+}
+
+// newRand returns the pseudo random number generator of a pool, seeded using WithSeed or the current time
+func (o *options) newRand() *prng {
+	if o.seeded {
+		return newPRNG(o.seed)
+	}
+	return newPRNG(time.Now().UnixNano())
+}
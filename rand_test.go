@@ -0,0 +1,37 @@
+package pool
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithSeed(t *testing.T) {
+	a := NewPool(1, poolFactory, WithSeed(42))
+	defer a.Close()
+	b := NewPool(1, poolFactory, WithSeed(42))
+	defer b.Close()
+	for i := 0; i < 10; i++ {
+		if x, y := a.rnd.int63n(1000), b.rnd.int63n(1000); x != y {
+			t.Fatalf("expected pools with the same seed to make the same decisions but got %d and %d", x, y)
+		}
+	}
+}
+
+func TestSharedPoolTieBreak(t *testing.T) {
+	pool := NewSharedPool(2, poolFactory, 2)
+	defer pool.Close()
+	ctx := context.Background()
+	first, _ := pool.Acquire(ctx)
+	pool.Acquire(ctx)
+	second, _ := pool.Acquire(ctx)
+	if first == second {
+		t.Fatalf("expected a second entry to be acquired")
+	}
+	for i := 0; i < 10; i++ {
+		// both entries have 1 lease, the one acquired first gets leased
+		pool.Release(first)
+		if v, _ := pool.Acquire(ctx); v != first {
+			t.Fatalf("expected the entry acquired first to be leased")
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// Package goredis adapts go-redis clients to redissem.Client:
+//
+//	rdb := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+//	p, err := redissem.New(goredis.New(rdb), redissem.PoolOptions[Conn]{...})
+//
+// It's a module of its own, so go-redis isn't a dependency of the pool module.
+package goredis
+
+import (
+	"context"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/epikur-io/go-pool/redissem"
+)
+
+// Client runs the scripts of redissem using a go-redis client, scripts are sent once and run by their
+// hash afterwards (see redis.Script)
+type Client struct {
+	rdb redis.Scripter
+	// script -> *redis.Script
+	scripts sync.Map
+}
+
+var _ redissem.Client = (*Client)(nil)
+
+// New adapts rdb, e.g. a *redis.Client, *redis.ClusterClient or *redis.Ring
+func New(rdb redis.Scripter) *Client {
+	return &Client{rdb: rdb}
+}
+
+func (c *Client) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+	s, ok := c.scripts.Load(script)
+	if !ok {
+		s, _ = c.scripts.LoadOrStore(script, redis.NewScript(script))
+	}
+	return s.(*redis.Script).Run(ctx, c.rdb, keys, args...).Result()
+}
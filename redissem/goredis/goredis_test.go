@@ -0,0 +1,104 @@
+package goredis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/epikur-io/go-pool/redissem"
+)
+
+type conn struct {
+	closed bool
+}
+
+func newClient(t *testing.T) (*Client, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return New(rdb), mr
+}
+
+func TestSemaphore(t *testing.T) {
+	c, mr := newClient(t)
+	ctx := context.Background()
+	a, err := redissem.NewSemaphore(c, redissem.Options{Key: "permits", Limit: 1, TTL: time.Second})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	defer a.Close()
+	b, err := redissem.NewSemaphore(c, redissem.Options{Key: "permits", Limit: 1, TTL: time.Second})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	defer b.Close()
+
+	permit, err := a.TryAcquire(ctx)
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	if members, err := mr.ZMembers("permits"); err != nil || len(members) != 1 || members[0] != permit.ID() {
+		t.Errorf("expected the permit in redis but got %v, %v", members, err)
+	}
+	if _, err := b.TryAcquire(ctx); !errors.Is(err, redissem.ErrNoPermit) {
+		t.Errorf("expected %v but got %v", redissem.ErrNoPermit, err)
+	}
+	if err := permit.Release(ctx); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	if permit, err = b.TryAcquire(ctx); err != nil {
+		t.Fatalf("expected the released permit but got %v", err)
+	}
+
+	// the refresh reports permits dropped from redis as lost
+	mr.Del("permits")
+	deadline := time.Now().Add(5 * time.Second)
+	for !permit.Lost() {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the permit to be lost")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPool(t *testing.T) {
+	c, _ := newClient(t)
+	newPool := func() *redissem.Pool[conn] {
+		p, err := redissem.New(c, redissem.PoolOptions[conn]{
+			Options: redissem.Options{Key: "permits", Limit: 1, PollInterval: time.Millisecond},
+			Size:    1,
+			Open:    func(ctx context.Context) (*conn, error) { return &conn{}, nil },
+			Close:   func(c *conn) error { c.closed = true; return nil },
+		})
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		return p
+	}
+	a, b := newPool(), newPool()
+	defer b.Close()
+	ctx := context.Background()
+
+	e, err := a.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	a.Release(e)
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := b.Acquire(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the other pool to wait for a permit but got %v", err)
+	}
+	a.Close()
+	if !e.Value.closed {
+		t.Errorf("expected the entry to be closed")
+	}
+	if e, err = b.Acquire(ctx); err != nil {
+		t.Fatalf("expected the released permit but got %v", err)
+	}
+	b.Release(e)
+}
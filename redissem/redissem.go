@@ -0,0 +1,170 @@
+// Package redissem gates the entries of a pool by a distributed semaphore stored in redis, enforcing a
+// cluster-wide cap on the entries of all processes (e.g. the connections to a fragile upstream).
+//
+// The local pool still stores the entries, but an entry holds a permit of the semaphore while it exists.
+// Entries are opened lazily on acquire once a permit is free, waiting until the context of the caller is
+// done. The package doesn't depend on a redis client, see Client (the goredis module adapts go-redis):
+//
+//	p, err := redissem.New(client, redissem.PoolOptions[Conn]{
+//		Options: redissem.Options{Key: "upstream:permits", Limit: 100}, // across the cluster
+//		Size:    10,                                                    // per process
+//		Open:    dialUpstream,
+//		Close:   func(c *Conn) error { return c.Close() },
+//	})
+//	...
+//	c, err := p.Acquire(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	defer p.Release(c)
+package redissem
+
+import (
+	"context"
+	"errors"
+
+	"github.com/epikur-io/go-pool"
+	"github.com/epikur-io/go-pool/internal/adapter"
+)
+
+// PoolOptions of a pool
+type PoolOptions[T any] struct {
+	// Options of the semaphore
+	Options
+	// Size is the number of entries of this process (required)
+	Size int
+	// Open creates an entry once a permit is held (required)
+	Open func(ctx context.Context) (*T, error)
+	// Close cleans up a removed entry (optional)
+	Close func(e *T) error
+}
+
+// Entry is a pooled entry, Value is nil until the entry is opened
+type Entry[T any] struct {
+	Value  *T
+	permit *Permit
+}
+
+// Pool is a pool whose entries hold permits of a Semaphore
+type Pool[T any] struct {
+	sem   *Semaphore
+	open  func(ctx context.Context) (*T, error)
+	close func(e *T) error
+	pool  *pool.Pool[Entry[T]]
+}
+
+// Creates a pool whose entries hold a permit of the semaphore shared through redis. Removed entries are
+// closed using o.Close and give their permit back, a destroyer passed in opts must release the permit
+// itself. Entries whose permit got lost (see Permit.Lost) are closed right away if they're idle,
+// entries in use once they get released.
+func New[T any](c Client, o PoolOptions[T], opts ...pool.Option) (*Pool[T], error) {
+	if o.Open == nil {
+		return nil, errors.New("redissem: missing open function")
+	}
+	p := &Pool[T]{open: o.Open, close: o.Close}
+	semOpts := o.Options
+	semOpts.OnLost = func(permit *Permit) {
+		p.dropLost()
+		if o.OnLost != nil {
+			o.OnLost(permit)
+		}
+	}
+	sem, err := NewSemaphore(c, semOpts)
+	if err != nil {
+		return nil, err
+	}
+	p.sem = sem
+	defaults := []pool.Option{pool.WithDestroyerContext(p.destroy), pool.WithValidator(held[T]), pool.WithShouldRetain(held[T])}
+	p.pool, err = pool.NewPoolFromConfig(pool.Config{Size: o.Size}, func() *Entry[T] { return &Entry[T]{} }, append(defaults, opts...)...)
+	if err != nil {
+		sem.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// held reports whether e doesn't hold a lost permit
+func held[T any](e *Entry[T]) bool {
+	return !e.Lost()
+}
+
+// Lost reports whether the permit of the entry expired (see Permit.Lost), a lost entry must not be used anymore
+// and gets closed once released
+func (e *Entry[T]) Lost() bool {
+	return e.permit != nil && e.permit.Lost()
+}
+
+// dropLost closes the idle entries holding lost permits, the pool creates new (unopened) entries for them
+func (p *Pool[T]) dropLost() {
+	for {
+		e, err := p.pool.AcquireWith(context.Background(), pool.WithPredicate(func(e *Entry[T]) bool { return e.Lost() }), pool.WithNoWait())
+		if err != nil {
+			return
+		}
+		p.pool.Replace(e)
+	}
+}
+
+// destroy closes opened entries and releases their permit
+func (p *Pool[T]) destroy(ctx context.Context, e *Entry[T]) error {
+	if e.permit == nil {
+		return nil
+	}
+	var err error
+	if p.close != nil && e.Value != nil {
+		err = p.close(e.Value)
+	}
+	return errors.Join(err, e.permit.Release(ctx))
+}
+
+// Returns the pool of this process, entries count as idle before they took a permit
+func (p *Pool[T]) Pool() *pool.Pool[Entry[T]] {
+	return p.pool
+}
+
+// Returns the semaphore shared with the other processes
+func (p *Pool[T]) Semaphore() *Semaphore {
+	return p.sem
+}
+
+// Acquire acquires an entry, opening it once a permit is free if necessary, waiting until ctx is done
+func (p *Pool[T]) Acquire(ctx context.Context) (*Entry[T], error) {
+	return adapter.Acquire(ctx, p.pool, hasPermit[T], p.openEntry)
+}
+
+// hasPermit reports whether e took a permit and was opened
+func hasPermit[T any](e *Entry[T]) bool {
+	return e.permit != nil
+}
+
+// openEntry takes a permit for e and opens it
+func (p *Pool[T]) openEntry(ctx context.Context, e *Entry[T]) error {
+	permit, err := p.sem.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	v, err := p.open(ctx)
+	if err != nil {
+		permit.Release(context.WithoutCancel(ctx))
+		return err
+	}
+	e.Value, e.permit = v, permit
+	return nil
+}
+
+// Releases an entry to the pool
+func (p *Pool[T]) Release(e *Entry[T]) error {
+	return p.pool.Release(e)
+}
+
+// Discard closes a broken entry and hands its permit back to redis right away instead of
+// waiting for it to expire, the next acquire of the entry takes a new permit
+func (p *Pool[T]) Discard(e *Entry[T]) error {
+	return p.pool.Replace(e)
+}
+
+// Close closes the pool and all idle entries and releases all permits, entries in use no longer count
+// against the limit and get closed once released
+func (p *Pool[T]) Close() error {
+	return errors.Join(p.pool.Close(), p.sem.Close())
+}
@@ -0,0 +1,282 @@
+package redissem
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis implements the scripts of the semaphore in memory
+type fakeRedis struct {
+	mux     sync.Mutex
+	now     time.Time
+	permits map[string]time.Time
+	calls   map[string]int
+	// fails all calls while set, like a partition
+	down bool
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{now: time.Now(), permits: make(map[string]time.Time), calls: make(map[string]int)}
+}
+
+func (r *fakeRedis) advance(d time.Duration) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.now = r.now.Add(d)
+}
+
+func (r *fakeRedis) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.down {
+		return nil, errors.New("connection refused")
+	}
+	switch script {
+	case acquireScript:
+		r.calls["acquire"]++
+		ttl := time.Duration(args[1].(int64)) * time.Millisecond
+		for id, at := range r.permits {
+			if r.now.Sub(at) >= ttl {
+				delete(r.permits, id)
+			}
+		}
+		if len(r.permits) >= args[0].(int) {
+			return int64(0), nil
+		}
+		r.permits[args[2].(string)] = r.now
+		return int64(1), nil
+	case refreshScript:
+		r.calls["refresh"]++
+		lost := []any{}
+		for _, id := range args[1:] {
+			if _, ok := r.permits[id.(string)]; ok {
+				r.permits[id.(string)] = r.now
+			} else {
+				lost = append(lost, id)
+			}
+		}
+		return lost, nil
+	case releaseScript:
+		r.calls["release"]++
+		_, ok := r.permits[args[0].(string)]
+		delete(r.permits, args[0].(string))
+		if ok {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	}
+	return nil, errors.New("unknown script")
+}
+
+func TestSemaphore(t *testing.T) {
+	r := newFakeRedis()
+	a, _ := NewSemaphore(r, Options{Key: "permits", Limit: 2, TTL: time.Second, PollInterval: time.Millisecond})
+	b, _ := NewSemaphore(r, Options{Key: "permits", Limit: 2, TTL: time.Second, PollInterval: time.Millisecond})
+	defer b.Close()
+	ctx := context.Background()
+
+	p1, err := a.TryAcquire(ctx)
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	if _, err := a.TryAcquire(ctx); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	if _, err := b.TryAcquire(ctx); !errors.Is(err, ErrNoPermit) {
+		t.Errorf("expected %v but got %v", ErrNoPermit, err)
+	}
+	p1.Release(ctx)
+	p1.Release(ctx)
+	if _, err := b.TryAcquire(ctx); err != nil {
+		t.Errorf("expected the released permit but got %v", err)
+	}
+	if a.Held() != 1 || b.Held() != 1 {
+		t.Errorf("expected every semaphore to hold 1 permit but got %d and %d", a.Held(), b.Held())
+	}
+
+	// permits of a crashed process expire
+	a.mux.Lock()
+	a.held = map[string]*Permit{}
+	a.mux.Unlock()
+	r.advance(time.Second)
+	timeout, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if _, err := b.Acquire(timeout); err != nil {
+		t.Errorf("expected the expired permit to be dropped but got %v", err)
+	}
+	a.Close()
+	if _, err := a.TryAcquire(ctx); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected %v but got %v", ErrClosed, err)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	r := newFakeRedis()
+	s, _ := NewSemaphore(r, Options{Key: "permits", Limit: 1, TTL: 30 * time.Millisecond})
+	defer s.Close()
+	if _, err := s.TryAcquire(context.Background()); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	r.mux.Lock()
+	refreshed := r.calls["refresh"]
+	r.mux.Unlock()
+	if refreshed == 0 {
+		t.Errorf("expected the held permit to be refreshed")
+	}
+}
+
+func TestLostPermit(t *testing.T) {
+	r := newFakeRedis()
+	lost := make(chan *Permit, 2)
+	s, _ := NewSemaphore(r, Options{Key: "permits", Limit: 2, TTL: 30 * time.Millisecond, OnLost: func(p *Permit) { lost <- p }})
+	defer s.Close()
+	ctx := context.Background()
+	expired, _ := s.TryAcquire(ctx)
+	kept, _ := s.TryAcquire(ctx)
+
+	// redis dropped the permit, e.g. after a pause longer than the ttl
+	r.mux.Lock()
+	delete(r.permits, expired.ID())
+	r.mux.Unlock()
+	select {
+	case p := <-lost:
+		if p != expired || !p.Lost() || kept.Lost() || s.Held() != 1 {
+			t.Errorf("expected only the expired permit to be lost")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected the expired permit to be reported")
+	}
+
+	// permits that can't be refreshed within the ttl are lost as well
+	r.mux.Lock()
+	r.down = true
+	r.mux.Unlock()
+	select {
+	case p := <-lost:
+		if p != kept || s.Held() != 0 {
+			t.Errorf("expected the permit that couldn't be refreshed to be lost")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected the permit that couldn't be refreshed to be reported")
+	}
+	r.mux.Lock()
+	r.down = false
+	r.mux.Unlock()
+
+}
+
+func TestAcquireWhileClosing(t *testing.T) {
+	r := newFakeRedis()
+	var s *Semaphore
+	// closes the semaphore while the permit is being acquired
+	closing := EvalFunc(func(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+		res, err := r.Eval(ctx, script, keys, args...)
+		if script == acquireScript {
+			s.Close()
+		}
+		return res, err
+	})
+	s, _ = NewSemaphore(closing, Options{Key: "permits", Limit: 1})
+	if _, err := s.TryAcquire(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected %v but got %v", ErrClosed, err)
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if len(r.permits) != 0 {
+		t.Errorf("expected the permit acquired while closing to be released")
+	}
+}
+
+type conn struct {
+	closed bool
+}
+
+func TestPool(t *testing.T) {
+	r := newFakeRedis()
+	newPool := func() *Pool[conn] {
+		p, err := New(r, PoolOptions[conn]{
+			Options: Options{Key: "permits", Limit: 1, PollInterval: time.Millisecond},
+			Size:    1,
+			Open:    func(ctx context.Context) (*conn, error) { return &conn{}, nil },
+			Close:   func(c *conn) error { c.closed = true; return nil },
+		})
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		return p
+	}
+	a, b := newPool(), newPool()
+	defer b.Close()
+	ctx := context.Background()
+
+	e, err := a.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	a.Release(e)
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := b.Acquire(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the other pool to wait for a permit but got %v", err)
+	}
+	a.Close()
+	if !e.Value.closed {
+		t.Errorf("expected the entry to be closed")
+	}
+	if e, err = b.Acquire(ctx); err != nil {
+		t.Fatalf("expected the released permit but got %v", err)
+	}
+	b.Release(e)
+}
+
+func TestPoolLostPermit(t *testing.T) {
+	r := newFakeRedis()
+	closed := make(chan *conn, 4)
+	p, err := New(r, PoolOptions[conn]{
+		Options: Options{Key: "permits", Limit: 2, TTL: 30 * time.Millisecond},
+		Size:    2,
+		Open:    func(ctx context.Context) (*conn, error) { return &conn{}, nil },
+		Close:   func(c *conn) error { closed <- c; return nil },
+	})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	defer p.Close()
+	ctx := context.Background()
+	idle, _ := p.Acquire(ctx)
+	inUse, _ := p.Acquire(ctx)
+	p.Release(idle)
+
+	r.mux.Lock()
+	r.permits = map[string]time.Time{}
+	r.mux.Unlock()
+	deadline := time.Now().Add(time.Second)
+	for !inUse.Lost() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if !inUse.Lost() {
+		t.Fatalf("expected the permit of the entry in use to be lost")
+	}
+	select {
+	case c := <-closed:
+		if c != idle.Value {
+			t.Errorf("expected the idle entry to be closed first")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("expected the idle entry holding a lost permit to be closed")
+	}
+	p.Release(inUse)
+	if c := <-closed; c != inUse.Value {
+		t.Errorf("expected the lost entry to be closed once released")
+	}
+	// new entries take new permits
+	e, err := p.Acquire(ctx)
+	if err != nil || e.Lost() {
+		t.Fatalf("expected a new entry but got %v", err)
+	}
+	p.Release(e)
+}
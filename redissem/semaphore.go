@@ -0,0 +1,332 @@
+package redissem
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrNoPermit is returned by TryAcquire if all permits are held
+	ErrNoPermit = errors.New("redissem: all permits are held")
+	// ErrClosed is returned by acquires of a closed semaphore
+	ErrClosed = errors.New("redissem: semaphore is closed")
+)
+
+const (
+	// default time after which permits that weren't refreshed get dropped
+	defaultTTL = 30 * time.Second
+	// default interval of polling for a free permit
+	defaultPollInterval = 50 * time.Millisecond
+)
+
+// Client runs Lua scripts on redis, e.g. go-redis adapted by the goredis module or using EvalFunc:
+//
+//	redissem.EvalFunc(func(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+//		return rdb.Eval(ctx, script, keys, args...).Result()
+//	})
+//
+// Integer replies must be returned as int64, array replies as []any holding strings.
+type Client interface {
+	Eval(ctx context.Context, script string, keys []string, args ...any) (any, error)
+}
+
+// EvalFunc is a Client implemented by a function
+type EvalFunc func(ctx context.Context, script string, keys []string, args ...any) (any, error)
+
+func (f EvalFunc) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+	return f(ctx, script, keys, args...)
+}
+
+// Options of a semaphore
+type Options struct {
+	// Key of the sorted set holding the permits (required), all processes sharing the limit must use the same key
+	Key string
+	// Limit is the number of permits across all processes (required)
+	Limit int
+	// TTL drops permits of crashed processes that weren't refreshed within it (default 30 seconds),
+	// held permits are refreshed every third of it
+	TTL time.Duration
+	// PollInterval is the interval of checking for a free permit while all are held (default 50ms)
+	PollInterval time.Duration
+	// OnLost is called (by the refresher) for held permits that expired without being released, e.g. while
+	// redis couldn't be reached within the ttl, other processes may hold them already (optional)
+	OnLost func(*Permit)
+}
+
+// acquireScript takes a permit unless the limit is reached, dropping expired permits first.
+// KEYS[1] is the sorted set of the permits scored by the time they were last refreshed,
+// ARGV is the limit, the ttl in milliseconds and the id of the permit.
+const acquireScript = `
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - tonumber(ARGV[2]))
+if redis.call('ZCARD', KEYS[1]) < tonumber(ARGV[1]) then
+	redis.call('ZADD', KEYS[1], now, ARGV[3])
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+return 0
+`
+
+// refreshScript refreshes the permits that are still held and returns the ids of the expired ones,
+// ARGV is the ttl in milliseconds followed by the ids of the permits
+const refreshScript = `
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local lost = {}
+for i = 2, #ARGV do
+	if redis.call('ZSCORE', KEYS[1], ARGV[i]) then
+		redis.call('ZADD', KEYS[1], now, ARGV[i])
+	else
+		table.insert(lost, ARGV[i])
+	end
+end
+redis.call('PEXPIRE', KEYS[1], ARGV[1])
+return lost
+`
+
+// releaseScript frees a permit, ARGV is the id of the permit
+const releaseScript = `return redis.call('ZREM', KEYS[1], ARGV[1])`
+
+// Semaphore is a counting semaphore shared by all processes using the same redis key. Permits are members
+// of a sorted set scored by the time they were last refreshed, permits of crashed processes are dropped
+// once they weren't refreshed within the ttl.
+type Semaphore struct {
+	client Client
+	opts   Options
+
+	mux sync.Mutex
+	// permits held by this process
+	held   map[string]*Permit
+	closed bool
+	// stops the refresher
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Permit is a held permit of a Semaphore
+type Permit struct {
+	sem  *Semaphore
+	id   string
+	once sync.Once
+	// unix nanoseconds of the last refresh (or the acquire) that was sent to redis and succeeded
+	refreshed atomic.Int64
+	lost      atomic.Bool
+}
+
+// NewSemaphore returns the semaphore of o.Limit permits stored at o.Key. Held permits are refreshed in the
+// background until the semaphore gets closed.
+func NewSemaphore(c Client, o Options) (*Semaphore, error) {
+	if c == nil || o.Key == "" {
+		return nil, errors.New("redissem: missing client or key")
+	}
+	if o.Limit <= 0 {
+		return nil, fmt.Errorf("redissem: limit %d isn't positive", o.Limit)
+	}
+	if o.TTL <= 0 {
+		o.TTL = defaultTTL
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = defaultPollInterval
+	}
+	s := &Semaphore{client: c, opts: o, held: make(map[string]*Permit), done: make(chan struct{})}
+	s.wg.Add(1)
+	go s.refresher()
+	return s, nil
+}
+
+// Returns the number of permits across all processes
+func (s *Semaphore) Limit() int {
+	return s.opts.Limit
+}
+
+// Returns the number of permits held by this process
+func (s *Semaphore) Held() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return len(s.held)
+}
+
+// TryAcquire takes a free permit without waiting, ErrNoPermit is returned if all permits are held
+func (s *Semaphore) TryAcquire(ctx context.Context) (*Permit, error) {
+	s.mux.Lock()
+	closed := s.closed
+	s.mux.Unlock()
+	if closed {
+		return nil, ErrClosed
+	}
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	res, err := s.client.Eval(ctx, acquireScript, []string{s.opts.Key}, s.opts.Limit, s.opts.TTL.Milliseconds(), id)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.(int64); n != 1 {
+		return nil, ErrNoPermit
+	}
+	p := &Permit{sem: s, id: id}
+	p.refreshed.Store(start.UnixNano())
+	s.mux.Lock()
+	if s.closed {
+		// Close already released the held permits, don't leave this one behind until it expires
+		s.mux.Unlock()
+		p.Release(context.WithoutCancel(ctx))
+		return nil, ErrClosed
+	}
+	s.held[id] = p
+	s.mux.Unlock()
+	return p, nil
+}
+
+// Acquire takes a free permit, polling until one is free or ctx is done
+func (s *Semaphore) Acquire(ctx context.Context) (*Permit, error) {
+	for {
+		p, err := s.TryAcquire(ctx)
+		if !errors.Is(err, ErrNoPermit) {
+			return p, err
+		}
+		timer := time.NewTimer(s.opts.PollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// refresher refreshes the held permits until the semaphore gets closed
+func (s *Semaphore) refresher() {
+	defer s.wg.Done()
+	t := time.NewTicker(s.opts.TTL / 3)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-t.C:
+			s.refresh()
+		}
+	}
+}
+
+// refresh extends the ttl of the held permits and drops the permits that expired: the ones redis reports as
+// expired and, while redis can't be reached, the ones that weren't refreshed within the ttl
+func (s *Semaphore) refresh() {
+	s.mux.Lock()
+	args := []any{s.opts.TTL.Milliseconds()}
+	held := make([]*Permit, 0, len(s.held))
+	for id, p := range s.held {
+		args = append(args, id)
+		held = append(held, p)
+	}
+	s.mux.Unlock()
+	if len(held) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.TTL/3)
+	defer cancel()
+	start := time.Now()
+	// failures are retried with the next tick
+	res, err := s.client.Eval(ctx, refreshScript, []string{s.opts.Key}, args...)
+	lost := make(map[string]bool)
+	if err == nil {
+		ids, _ := res.([]any)
+		for _, id := range ids {
+			if id, ok := id.(string); ok {
+				lost[id] = true
+			}
+		}
+	}
+	for _, p := range held {
+		if err == nil && !lost[p.id] {
+			p.refreshed.Store(start.UnixNano())
+		} else if time.Since(time.Unix(0, p.refreshed.Load())) >= s.opts.TTL {
+			// redis dropped the permit by now even if it can't tell
+			lost[p.id] = true
+		}
+	}
+	for _, p := range held {
+		if lost[p.id] {
+			s.lose(p)
+		}
+	}
+}
+
+// lose marks the expired permit p as lost unless it got released in the meantime
+func (s *Semaphore) lose(p *Permit) {
+	s.mux.Lock()
+	_, ok := s.held[p.id]
+	delete(s.held, p.id)
+	s.mux.Unlock()
+	if !ok {
+		return
+	}
+	p.lost.Store(true)
+	if s.opts.OnLost != nil {
+		s.opts.OnLost(p)
+	}
+}
+
+// Close stops refreshing the held permits and releases them
+func (s *Semaphore) Close() error {
+	s.mux.Lock()
+	if s.closed {
+		s.mux.Unlock()
+		return nil
+	}
+	s.closed = true
+	held := make([]*Permit, 0, len(s.held))
+	for _, p := range s.held {
+		held = append(held, p)
+	}
+	s.mux.Unlock()
+	close(s.done)
+	s.wg.Wait()
+	var errs []error
+	for _, p := range held {
+		errs = append(errs, p.Release(context.Background()))
+	}
+	return errors.Join(errs...)
+}
+
+// Returns the id of the permit
+func (p *Permit) ID() string {
+	return p.id
+}
+
+// Lost reports whether the permit expired without being released (see Options.OnLost), whatever it guards
+// must not be used anymore as another process may hold the permit already
+func (p *Permit) Lost() bool {
+	return p.lost.Load()
+}
+
+// Release frees the permit, releasing a permit more than once has no effect
+func (p *Permit) Release(ctx context.Context) error {
+	var err error
+	p.once.Do(func() {
+		p.sem.mux.Lock()
+		delete(p.sem.held, p.id)
+		p.sem.mux.Unlock()
+		_, err = p.sem.client.Eval(ctx, releaseScript, []string{p.sem.opts.Key}, p.id)
+	})
+	return err
+}
+
+// newID returns a random id of a permit
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,150 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrUnnamedPool       = fmt.Errorf("pool has no name")
+	ErrDuplicatePoolName = fmt.Errorf("pool name already registered")
+)
+
+// DefaultRegistry tracks all pools created using WithName
+var DefaultRegistry = NewRegistry()
+
+// RegisteredPool is the type independent view of a pool tracked by a Registry
+type RegisteredPool interface {
+	Name() string
+	Stats() Stats
+}
+
+// ManagedPool is the type independent view of a pool that can be managed at runtime
+type ManagedPool interface {
+	RegisteredPool
+	RefreshAll(context.Context) error
+	Resize(int) error
+	Pause()
+	Resume()
+	Drain(context.Context) error
+}
+
+var _ ManagedPool = &Pool[any]{}
+
+// Registry keeps track of named pools so they can be enumerated
+// (e.g. for dashboards or admin endpoints)
+type Registry struct {
+	mux   sync.RWMutex
+	pools map[string]RegisteredPool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{pools: map[string]RegisteredPool{}}
+}
+
+// Adds a pool to the registry, names must be unique
+func (r *Registry) Register(p RegisteredPool) error {
+	name := p.Name()
+	if name == "" {
+		return ErrUnnamedPool
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if _, ok := r.pools[name]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicatePoolName, name)
+	}
+	r.pools[name] = p
+	return nil
+}
+
+// Removes the pool with the given name from the registry
+func (r *Registry) Unregister(name string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	delete(r.pools, name)
+}
+
+// Returns the pool registered with the given name
+func (r *Registry) Get(name string) (RegisteredPool, bool) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	p, ok := r.pools[name]
+	return p, ok
+}
+
+// Returns the registered pools sorted by name
+func (r *Registry) Pools() []RegisteredPool {
+	r.mux.RLock()
+	pools := make([]RegisteredPool, 0, len(r.pools))
+	for _, p := range r.pools {
+		pools = append(pools, p)
+	}
+	r.mux.RUnlock()
+	sort.Slice(pools, func(i, j int) bool {
+		return pools[i].Name() < pools[j].Name()
+	})
+	return pools
+}
+
+// Returns the stats of all registered pools sorted by name
+func (r *Registry) ListPools() []Stats {
+	pools := r.Pools()
+	stats := make([]Stats, 0, len(pools))
+	for _, p := range pools {
+		stats = append(stats, p.Stats())
+	}
+	return stats
+}
+
+// Returns the stats of all pools registered in the DefaultRegistry
+func ListPools() []Stats {
+	return DefaultRegistry.ListPools()
+}
+
+// CloseAll drains all registered pools concurrently (see Drain) until ctx is done, pools that only
+// support closing are closed. The errors of all pools are joined, e.g. pools whose entries weren't
+// released in time. Meant for the shutdown path of a service:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	if err := pool.DefaultRegistry.CloseAll(ctx); err != nil {
+//		log.Printf("shutdown: %v", err)
+//	}
+func (r *Registry) CloseAll(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	pools := r.Pools()
+	errs := make([]error, len(pools))
+	var wg sync.WaitGroup
+	for i, p := range pools {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = closePool(ctx, p)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// closePool drains or closes p
+func closePool(ctx context.Context, p RegisteredPool) error {
+	if d, ok := p.(interface{ Drain(context.Context) error }); ok {
+		return d.Drain(ctx)
+	}
+	if c, ok := p.(interface{ Close() error }); ok {
+		if err := c.Close(); err != nil {
+			return fmt.Errorf("pool %q: %w", p.Name(), err)
+		}
+	}
+	return nil
+}
+
+// CloseAll drains all pools registered in the DefaultRegistry (see Registry.CloseAll)
+func CloseAll(ctx context.Context) error {
+	return DefaultRegistry.CloseAll(ctx)
+}
@@ -0,0 +1,81 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	reg := NewRegistry()
+	NewPool(1, poolFactory, WithName("b"), WithRegistry(reg))
+	a := NewPool(2, poolFactory, WithName("a"), WithRegistry(reg))
+	// unnamed pools don't get registered
+	NewPool(1, poolFactory, WithRegistry(reg))
+
+	a.Acquire()
+	stats := reg.ListPools()
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 registered pools but got %d", len(stats))
+	}
+	if stats[0].Name != "a" || stats[1].Name != "b" {
+		t.Errorf("expected pools sorted by name but got %q, %q", stats[0].Name, stats[1].Name)
+	}
+	if stats[0].InUse != 1 || stats[0].Cap != 2 {
+		t.Errorf("unexpected stats for pool a: %+v", stats[0])
+	}
+
+	if p, ok := reg.Get("a"); !ok || p != RegisteredPool(a) {
+		t.Errorf("expected to find pool a")
+	}
+	if err := reg.Register(a); !errors.Is(err, ErrDuplicatePoolName) {
+		t.Errorf("expected %v but got %v", ErrDuplicatePoolName, err)
+	}
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("expected NewPool to panic on a duplicate name")
+			}
+		}()
+		NewPool(1, poolFactory, WithName("a"), WithRegistry(reg))
+	}()
+
+	reg.Unregister("a")
+	if _, ok := reg.Get("a"); ok {
+		t.Errorf("expected pool a to be unregistered")
+	}
+}
+
+func TestCloseAll(t *testing.T) {
+	reg := NewRegistry()
+	idle := NewPool(1, poolFactory, WithName("idle"), WithRegistry(reg))
+	busy := NewPool(1, poolFactory, WithName("busy"), WithRegistry(reg))
+	busy.Acquire()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := reg.CloseAll(ctx)
+	var perr *PoolError
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.As(err, &perr) || perr.Pool != "busy" {
+		t.Errorf("expected the busy pool to time out but got %v", err)
+	}
+	if !idle.Stats().Closed || !busy.Stats().Closed || len(reg.Pools()) != 0 {
+		t.Errorf("expected all pools to be closed and unregistered")
+	}
+	if err := reg.CloseAll(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	pool := NewPool(1, poolFactory, WithName("default-registry-test"))
+	defer DefaultRegistry.Unregister(pool.Name())
+
+	for _, s := range ListPools() {
+		if s.Name == pool.Name() {
+			return
+		}
+	}
+	t.Errorf("expected pool %q to be listed", pool.Name())
+}
@@ -0,0 +1,70 @@
+package pool
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrInvalidRelease is wrapped by the ReleaseError of releases rejected by WithCheckedRelease
+var ErrInvalidRelease = fmt.Errorf("invalid release")
+
+// WithCheckedRelease makes releases verify that the released entry is acquired: every entry holds a state
+// word (idle or checked out) that a release flips atomically, so the same entry released twice is detected
+// even if the releases race each other. Rejected releases fail with a ReleaseError wrapping ErrInvalidRelease
+// and leave the pool untouched, only entries acquired from the pool may be released then.
+// Diagnostics identify goroutines using their stack trace, which makes acquiring and releasing more expensive.
+func WithCheckedRelease() Option {
+	return func(o *options) {
+		o.checkedRelease = true
+	}
+}
+
+// ReleaseError describes a release rejected by WithCheckedRelease
+type ReleaseError struct {
+	// address of the entry
+	Entry string
+	// goroutine of the rejected release
+	Goroutine int64
+	// the entry isn't an entry of the pool (anymore), e.g. it got destroyed by an earlier release
+	Unknown bool
+	// goroutines that acquired and released the entry before and the time of the release, zero if unknown
+	AcquiredBy int64
+	ReleasedBy int64
+	ReleasedAt time.Time
+}
+
+func (e *ReleaseError) Error() string {
+	if e.Unknown {
+		return fmt.Sprintf("%v: entry %s isn't an entry of the pool (released by goroutine %d)", ErrInvalidRelease, e.Entry, e.Goroutine)
+	}
+	return fmt.Sprintf("%v: entry %s acquired by goroutine %d was released by goroutine %d at %s and again by goroutine %d",
+		ErrInvalidRelease, e.Entry, e.AcquiredBy, e.ReleasedBy, e.ReleasedAt.Format(time.RFC3339Nano), e.Goroutine)
+}
+
+func (e *ReleaseError) Unwrap() error {
+	return ErrInvalidRelease
+}
+
+// checkRelease flips the entry v from checked out to idle, failing if it isn't checked out (nil is skipped)
+func (p *Pool[T]) checkRelease(v *T) error {
+	if !p.opts.checkedRelease || v == nil {
+		return nil
+	}
+	g := goid()
+	meta, ok := p.lookup(v)
+	if !ok {
+		p.stats.invalidReleases.Add(1)
+		return &ReleaseError{Entry: fmt.Sprintf("%p", v), Goroutine: g, Unknown: true}
+	}
+	if !meta.inUse.CompareAndSwap(true, false) {
+		p.stats.invalidReleases.Add(1)
+		e := &ReleaseError{Entry: fmt.Sprintf("%p", v), Goroutine: g, AcquiredBy: meta.holder.Load(), ReleasedBy: meta.releasedBy.Load()}
+		if at := meta.releasedAt.Load(); at > 0 {
+			e.ReleasedAt = time.Unix(0, at)
+		}
+		return e
+	}
+	meta.releasedBy.Store(g)
+	meta.releasedAt.Store(time.Now().UnixNano())
+	return nil
+}
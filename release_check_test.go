@@ -0,0 +1,58 @@
+package pool
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestCheckedRelease(t *testing.T) {
+	pool := NewPool(2, poolFactory, WithCheckedRelease())
+	defer pool.Close()
+	e := pool.Acquire()
+	if err := pool.Release(e); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := pool.Release(e)
+	var releaseErr *ReleaseError
+	if !errors.Is(err, ErrInvalidRelease) || !errors.As(err, &releaseErr) {
+		t.Fatalf("expected a ReleaseError but got %v", err)
+	}
+	if releaseErr.Unknown || releaseErr.AcquiredBy == 0 || releaseErr.ReleasedBy != releaseErr.Goroutine || releaseErr.ReleasedAt.IsZero() {
+		t.Errorf("expected diagnostics of the earlier release but got %+v", releaseErr)
+	}
+	if err := pool.TryRelease(new(poolItem)); !errors.As(err, &releaseErr) || !releaseErr.Unknown {
+		t.Errorf("expected a ReleaseError for an unknown entry but got %v", err)
+	}
+	if stats := pool.Stats(); stats.Idle != 2 || stats.InUse != 0 || stats.InvalidReleases != 2 {
+		t.Errorf("expected the pool to be untouched but got %+v", stats)
+	}
+
+	// of releases racing each other only one succeeds
+	for i := 0; i < 100; i++ {
+		e := pool.Acquire()
+		var wg sync.WaitGroup
+		errs := make(chan error, 2)
+		for j := 0; j < 2; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- pool.Release(e)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		failed := 0
+		for err := range errs {
+			if errors.Is(err, ErrInvalidRelease) {
+				failed++
+			}
+		}
+		if failed != 1 {
+			t.Fatalf("expected exactly one release to fail but %d did", failed)
+		}
+	}
+	if stats := pool.Stats(); stats.Idle != 2 || stats.InUse != 0 {
+		t.Errorf("expected 2 idle entries but got %+v", stats)
+	}
+}
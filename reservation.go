@@ -0,0 +1,64 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+)
+
+var ErrReservationDone = fmt.Errorf("reservation already acquired or cancelled")
+
+// Reservation is a slot of the pool reserved using Reserve, it holds either
+// an idle entry or space for an entry that gets created on Acquire
+type Reservation[T any] struct {
+	pool *Pool[T]
+	// idle entry taken for the reservation, nil if space was reserved
+	entry *T
+	done  bool
+}
+
+// Reserve reserves a slot of the pool without creating an entry, waiting until ctx is done.
+// The reservation must either be acquired or cancelled.
+func (p *Pool[T]) Reserve(ctx context.Context) (*Reservation[T], error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var v *T
+	ok := false
+	if !p.chaosActive() {
+		v, ok = p.tryReserve()
+	}
+	if !ok {
+		var err error
+		if v, err = p.reserve(ctx, nil, false); err != nil {
+			return nil, p.wrapErr(opReserve, err)
+		}
+	}
+	return &Reservation[T]{pool: p, entry: v}, nil
+}
+
+// Acquire returns the entry of the reservation, creating it if necessary
+func (r *Reservation[T]) Acquire() (*T, error) {
+	if r.done {
+		return nil, r.pool.wrapErr(opAcquire, ErrReservationDone)
+	}
+	if r.pool.closed.Load() {
+		r.Cancel()
+		return nil, r.pool.wrapErr(opAcquire, ErrPoolClosed)
+	}
+	r.done = true
+	return r.pool.materialize(r.entry), nil
+}
+
+// Cancel frees the reserved slot
+func (r *Reservation[T]) Cancel() {
+	if r.done {
+		return
+	}
+	r.done = true
+	p := r.pool
+	if r.entry != nil {
+		p.put(r.entry)
+		return
+	}
+	p.unreserve()
+}
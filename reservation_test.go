@@ -0,0 +1,65 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReservation(t *testing.T) {
+	pool := NewPool(1, poolFactory, WithMinSize(0))
+	r, err := pool.Reserve(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats := pool.Stats(); stats.Created != 0 || stats.Size != 1 {
+		t.Errorf("expected a reserved slot without an entry but got %+v", stats)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Reserve(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v but got %v", context.DeadlineExceeded, err)
+	}
+
+	e, err := r.Acquire()
+	if err != nil || e == nil {
+		t.Fatalf("expected an entry but got %v", err)
+	}
+	if _, err := r.Acquire(); !errors.Is(err, ErrReservationDone) {
+		t.Errorf("expected %v but got %v", ErrReservationDone, err)
+	}
+	pool.Release(e)
+
+	// reserves the idle entry
+	r, _ = pool.Reserve(context.Background())
+	if pool.Len() != 0 {
+		t.Errorf("expected the idle entry to be reserved")
+	}
+	r.Cancel()
+	if pool.Len() != 1 {
+		t.Errorf("expected the idle entry to be returned")
+	}
+}
+
+func TestReservationCancel(t *testing.T) {
+	pool := NewPool(1, poolFactory, WithMinSize(0))
+	r, _ := pool.Reserve(context.Background())
+	acquired := make(chan *poolItem)
+	go func() {
+		acquired <- pool.Acquire()
+	}()
+	time.Sleep(10 * time.Millisecond)
+	r.Cancel()
+
+	select {
+	case e := <-acquired:
+		pool.Release(e)
+	case <-time.After(time.Second):
+		t.Fatalf("expected the waiting acquire to get an entry")
+	}
+	if stats := pool.Stats(); stats.Created != 1 || stats.Size != 1 {
+		t.Errorf("expected a single entry but got %+v", stats)
+	}
+}
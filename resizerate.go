@@ -0,0 +1,96 @@
+package pool
+
+import "time"
+
+// WithResizeRate limits the entries created and destroyed by Resize and ApplyConfig to n per second, so
+// resizing a pool from 10 to 5000 entries doesn't stampede a database with simultaneous dials. The size of
+// the pool moves toward the new size one entry at a time in the background, Stats.Size reports the current
+// size and Stats.ResizeTo the size the pool is resized to. Entries created for waiting acquires aren't
+// throttled, but they don't exceed the current size.
+func WithResizeRate(n int) Option {
+	return func(o *options) {
+		o.resizeRate = n
+	}
+}
+
+// startResizer starts stepping the size of the pool toward its requested size if it's rate limited
+func (p *Pool[T]) startResizer() {
+	if p.opts.resizeRate <= 0 {
+		return
+	}
+	p.resizeTo.Store(int64(p.size))
+	p.resizeWake = make(chan struct{}, 1)
+	go p.resizer()
+}
+
+// resizeGradually makes the resizer move the size of the pool toward size, the caller must hold p.mux
+func (p *Pool[T]) resizeGradually(size int) {
+	p.resizeTo.Store(int64(size))
+	select {
+	case p.resizeWake <- struct{}{}:
+	default:
+	}
+}
+
+// requestedSize returns the size the pool is resized to, the current size unless the resize is rate limited
+func (p *Pool[T]) requestedSize() int {
+	if p.opts.resizeRate > 0 {
+		return int(p.resizeTo.Load())
+	}
+	return int(p.target.Load())
+}
+
+// resizer changes the size of the pool by one entry per tick until it reached the requested size
+func (p *Pool[T]) resizer() {
+	t := time.NewTicker(time.Second / time.Duration(p.opts.resizeRate))
+	defer t.Stop()
+	for {
+		if p.target.Load() == p.resizeTo.Load() {
+			select {
+			case <-p.resizeWake:
+			case <-p.done:
+				return
+			}
+			continue
+		}
+		select {
+		case <-t.C:
+		case <-p.done:
+			return
+		}
+		p.mux.Lock()
+		p.stepSize()
+		p.mux.Unlock()
+	}
+}
+
+// stepSize moves the size of the pool one entry toward the requested size, the caller must hold p.mux
+func (p *Pool[T]) stepSize() {
+	if p.closed.Load() {
+		return
+	}
+	defer p.updateState()
+	size, goal := p.target.Load(), p.resizeTo.Load()
+	switch {
+	case size < goal:
+		p.target.Store(size + 1)
+		if p.grow() {
+			p.put(p.newEntry())
+		}
+	case size > goal:
+		p.target.Store(size - 1)
+		if p.live.Load() <= size-1 {
+			return
+		}
+		// surplus entries in use get destroyed once released
+		v, ok := p.idle.tryGetOldest()
+		if !ok {
+			return
+		}
+		if !p.shrink() {
+			p.idle.put(v)
+			return
+		}
+		p.destroyEntry(v)
+	}
+}
@@ -0,0 +1,50 @@
+package pool
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResizeRate(t *testing.T) {
+	var created atomic.Int32
+	pool := NewPool(1, func() *poolItem { created.Add(1); return new(poolItem) }, WithMaxSize(20), WithResizeRate(100))
+	defer pool.Close()
+
+	start := time.Now()
+	if err := pool.Resize(11); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats := pool.Stats(); stats.ResizeTo != 11 || stats.Size >= 11 {
+		t.Errorf("expected the resize to be in progress but got %+v", stats)
+	}
+	if cfg := pool.Config(); cfg.Size != 11 {
+		t.Errorf("expected the config to report the requested size but got %d", cfg.Size)
+	}
+	deadline := time.Now().Add(time.Second)
+	for pool.Len() < 11 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	// 10 entries at 100 per second take about 100ms
+	if d := time.Since(start); pool.Len() != 11 || d < 80*time.Millisecond {
+		t.Errorf("expected 11 entries after at least 80ms but got %d after %v", pool.Len(), d)
+	}
+	if n := created.Load(); n != 11 {
+		t.Errorf("expected 11 created entries but got %d", n)
+	}
+
+	held := pool.Acquire()
+	pool.Resize(0)
+	deadline = time.Now().Add(time.Second)
+	for pool.Stats().Size > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if stats := pool.Stats(); stats.Size != 0 || stats.Idle != 0 || stats.InUse != 1 {
+		t.Errorf("expected the idle entries to be destroyed but got %+v", stats)
+	}
+	// surplus entries in use are destroyed once released
+	pool.Release(held)
+	if stats := pool.Stats(); stats.Idle != 0 || stats.Destroyed != 11 {
+		t.Errorf("expected the released entry to be destroyed but got %+v", stats)
+	}
+}
@@ -0,0 +1,149 @@
+package pool
+
+import (
+	"context"
+	"sync"
+)
+
+// SharedPool leases entries to multiple holders concurrently (e.g. read-only snapshots supporting concurrent
+// readers): an acquired entry is shared by up to maxShares leases and only released to the underlying pool
+// once all of its leases returned. New entries are only acquired if every shared entry has maxShares leases.
+type SharedPool[T any] struct {
+	pool      *Pool[T]
+	maxShares int
+
+	mux sync.Mutex
+	// acquired entry -> leases
+	leases map[*T]*shares
+	// number of entries acquired so far
+	acquired uint64
+	// closed once a lease of a full entry returns
+	freed chan struct{}
+}
+
+// shares are the leases of an acquired entry
+type shares struct {
+	n int
+	// order the entry was acquired in, entries with the same number of leases are leased in that order
+	seq uint64
+}
+
+// SharedStats are the stats of a SharedPool
+type SharedStats struct {
+	// stats of the underlying pool, acquired entries count as in use
+	Pool Stats `json:"pool"`
+	// number of acquired entries
+	Shared int `json:"shared"`
+	// number of leases of the acquired entries
+	Leases int `json:"leases"`
+}
+
+// Creates a new shared pool of the given size, each entry is leased up to maxShares times concurrently
+func NewSharedPool[T any](size int, factoryFunc func() *T, maxShares int, opts ...Option) *SharedPool[T] {
+	return &SharedPool[T]{
+		pool:      NewPool(size, factoryFunc, opts...),
+		maxShares: max(maxShares, 1),
+		leases:    map[*T]*shares{},
+		freed:     make(chan struct{}),
+	}
+}
+
+// Returns the underlying pool
+func (s *SharedPool[T]) Pool() *Pool[T] {
+	return s.pool
+}
+
+// Acquire leases the acquired entry with the least leases if it has less than maxShares,
+// otherwise a new entry is acquired, waiting until ctx is done
+func (s *SharedPool[T]) Acquire(ctx context.Context) (*T, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	p := s.pool
+	for {
+		if p.closed.Load() {
+			return nil, p.wrapErr(opAcquire, ErrPoolClosed)
+		}
+		s.mux.Lock()
+		if v := s.leastShared(); v != nil {
+			s.leases[v].n++
+			s.mux.Unlock()
+			return v, nil
+		}
+		freed := s.freed
+		s.mux.Unlock()
+		// subscribe before acquiring to not miss entries released in the meantime
+		released := p.idleSignal()
+		if v, ok := p.tryAcquire(ctx); ok {
+			s.mux.Lock()
+			s.acquired++
+			s.leases[v] = &shares{n: 1, seq: s.acquired}
+			s.mux.Unlock()
+			return v, nil
+		}
+		var gate <-chan struct{}
+		if g := p.gate.Load(); g != nil {
+			gate = *g
+		}
+		select {
+		case <-freed:
+		case <-released:
+		case <-gate:
+		case <-p.done:
+		case <-ctx.Done():
+			p.timedOut()
+			return nil, p.wrapErr(opAcquire, ctx.Err())
+		}
+	}
+}
+
+// leastShared returns the acquired entry with the least leases if it can be leased again, the entry
+// acquired first if several have the least leases, the caller must hold s.mux
+func (s *SharedPool[T]) leastShared() *T {
+	var least *T
+	var fewest *shares
+	for v, sh := range s.leases {
+		if sh.n < s.maxShares && (fewest == nil || sh.n < fewest.n || (sh.n == fewest.n && sh.seq < fewest.seq)) {
+			least, fewest = v, sh
+		}
+	}
+	return least
+}
+
+// Release returns a lease of v, the entry is released to the underlying pool once all of its leases returned
+func (s *SharedPool[T]) Release(v *T) error {
+	s.mux.Lock()
+	sh, ok := s.leases[v]
+	if !ok {
+		s.mux.Unlock()
+		return s.pool.wrapErr(opRelease, ErrFailedToRelease)
+	}
+	if sh.n > 1 {
+		sh.n--
+		if sh.n+1 == s.maxShares {
+			close(s.freed)
+			s.freed = make(chan struct{})
+		}
+		s.mux.Unlock()
+		return nil
+	}
+	delete(s.leases, v)
+	s.mux.Unlock()
+	return s.pool.Release(v)
+}
+
+func (s *SharedPool[T]) Stats() SharedStats {
+	stats := SharedStats{Pool: s.pool.Stats()}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	stats.Shared = len(s.leases)
+	for _, sh := range s.leases {
+		stats.Leases += sh.n
+	}
+	return stats
+}
+
+// Close closes the underlying pool, shared entries get destroyed once all of their leases returned
+func (s *SharedPool[T]) Close() error {
+	return s.pool.Close()
+}
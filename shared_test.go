@@ -0,0 +1,50 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSharedPool(t *testing.T) {
+	pool := NewSharedPool(1, poolFactory, 2)
+	defer pool.Close()
+	ctx := context.Background()
+
+	a, _ := pool.Acquire(ctx)
+	b, _ := pool.Acquire(ctx)
+	if a != b {
+		t.Errorf("expected the entry to be shared")
+	}
+	if stats := pool.Stats(); stats.Shared != 1 || stats.Leases != 2 || stats.Pool.InUse != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	timeout, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Acquire(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v but got %v", context.DeadlineExceeded, err)
+	}
+
+	acquired := make(chan *poolItem)
+	go func() {
+		v, _ := pool.Acquire(ctx)
+		acquired <- v
+	}()
+	time.Sleep(5 * time.Millisecond)
+	pool.Release(a)
+	if c := <-acquired; c != a {
+		t.Errorf("expected the returned lease to be reused")
+	}
+	pool.Release(b)
+	if stats := pool.Stats(); stats.Leases != 1 || stats.Pool.Idle != 0 {
+		t.Errorf("expected the entry to stay acquired while it's leased but got %+v", stats)
+	}
+	pool.Release(a)
+	if stats := pool.Stats(); stats.Shared != 0 || stats.Pool.Idle != 1 {
+		t.Errorf("expected the entry to be released once all leases returned but got %+v", stats)
+	}
+	if err := pool.Release(a); !errors.Is(err, ErrFailedToRelease) {
+		t.Errorf("expected %v but got %v", ErrFailedToRelease, err)
+	}
+}
@@ -0,0 +1,78 @@
+package pool
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrOverloaded is wrapped by the OverloadError of acquires shed by WithLoadShedding
+var ErrOverloaded = fmt.Errorf("pool is overloaded")
+
+// WithLoadShedding makes acquires fail right away with an OverloadError instead of queueing once the estimated
+// wait for an entry exceeds maxWait, so callers can push back (e.g. respond with 503 and Retry-After) instead of
+// queueing requests that would time out anyway. The wait is estimated as the number of acquires queued ahead
+// times the average time entries are held, divided by the number of entries in use (which get released
+// concurrently). Acquires getting an idle entry are never shed, neither are Acquire calls, which can't report
+// the error. Shed acquires are counted by Stats.Shed.
+func WithLoadShedding(maxWait time.Duration) Option {
+	return func(o *options) {
+		o.maxEstimatedWait = maxWait
+	}
+}
+
+// OverloadError describes an acquire shed by WithLoadShedding
+type OverloadError struct {
+	// estimated wait of the acquire for an entry
+	EstimatedWait time.Duration
+	// the bound set by WithLoadShedding
+	MaxWait time.Duration
+}
+
+func (e *OverloadError) Error() string {
+	return fmt.Sprintf("%v: estimated wait %v exceeds %v", ErrOverloaded, e.EstimatedWait, e.MaxWait)
+}
+
+func (e *OverloadError) Unwrap() error {
+	return ErrOverloaded
+}
+
+// shed returns an OverloadError if an acquire that has to wait should be shed
+func (p *Pool[T]) shed() error {
+	maxWait := p.opts.maxEstimatedWait
+	if maxWait <= 0 {
+		return nil
+	}
+	if wait := p.estimatedWait(); wait > maxWait {
+		p.stats.shed.Add(1)
+		return &OverloadError{EstimatedWait: wait, MaxWait: maxWait}
+	}
+	return nil
+}
+
+// estimatedWait estimates how long an acquire queued now waits for an entry, 0 until an entry got released
+func (p *Pool[T]) estimatedWait() time.Duration {
+	hold := p.holdAvg.Load()
+	inUse := p.stats.inUse.Load()
+	if hold <= 0 || inUse <= 0 {
+		return 0
+	}
+	return time.Duration((p.waiters.Load() + 1) * hold / inUse)
+}
+
+// observeHold adds the time an entry was held until its release to the moving average of the hold times
+func (p *Pool[T]) observeHold(d time.Duration) {
+	if p.opts.maxEstimatedWait <= 0 {
+		return
+	}
+	for {
+		avg := p.holdAvg.Load()
+		next := int64(d)
+		if avg > 0 {
+			// exponentially weighted, the last 8 releases weigh the most
+			next = avg + (int64(d)-avg)/8
+		}
+		if p.holdAvg.CompareAndSwap(avg, max(next, 1)) {
+			return
+		}
+	}
+}
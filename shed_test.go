@@ -0,0 +1,42 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoadShedding(t *testing.T) {
+	pool := NewPool(1, poolFactory, WithLoadShedding(10*time.Millisecond))
+	defer pool.Close()
+	v := pool.Acquire()
+	time.Sleep(20 * time.Millisecond)
+	pool.Release(v)
+
+	// idle entries are handed out regardless of the estimate
+	v, err := pool.AcquireWithTimeout(time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	start := time.Now()
+	_, err = pool.AcquireWithContext(context.Background())
+	var overload *OverloadError
+	if !errors.Is(err, ErrOverloaded) || !errors.As(err, &overload) {
+		t.Fatalf("expected an OverloadError but got %v", err)
+	}
+	if overload.EstimatedWait < 20*time.Millisecond || overload.MaxWait != 10*time.Millisecond {
+		t.Errorf("expected an estimated wait of at least 20ms but got %+v", overload)
+	}
+	if time.Since(start) > 10*time.Millisecond {
+		t.Errorf("expected the acquire to fail right away")
+	}
+	if stats := pool.Stats(); stats.Shed != 1 || stats.Waiters != 0 {
+		t.Errorf("expected 1 shed acquire but got %+v", stats)
+	}
+	pool.Release(v)
+
+	if _, err := NewPoolE(1, poolFactory, WithLoadShedding(-time.Second)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("expected ErrInvalidOption but got %v", err)
+	}
+}
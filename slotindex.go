@@ -0,0 +1,69 @@
+package pool
+
+import (
+	"math/bits"
+	"unsafe"
+)
+
+// slotIndex maps the idle entries of a ringStore to their slots. It's an open addressing hash table with
+// linear probing that holds at least twice as many keys as the store has slots, pointers are hashed by
+// multiplication which spares the hashing and bookkeeping of a map on every get and put.
+type slotIndex[T any] struct {
+	keys  []*T
+	slots []int
+	mask  uint64
+	shift uint
+}
+
+func newSlotIndex[T any](capacity int) slotIndex[T] {
+	n := bits.Len(uint(2 * capacity))
+	return slotIndex[T]{
+		keys:  make([]*T, 1<<n),
+		slots: make([]int, 1<<n),
+		mask:  1<<n - 1,
+		shift: 64 - uint(n),
+	}
+}
+
+// home returns the position v is stored at unless it collided
+func (x *slotIndex[T]) home(v *T) uint64 {
+	// Fibonacci hashing, the upper bits of the product depend on all bits of the pointer
+	return uint64(uintptr(unsafe.Pointer(v))) * 0x9e3779b97f4a7c15 >> x.shift
+}
+
+// find returns the position of v or of the free position it would be stored at
+func (x *slotIndex[T]) find(v *T) uint64 {
+	i := x.home(v)
+	for x.keys[i] != nil && x.keys[i] != v {
+		i = (i + 1) & x.mask
+	}
+	return i
+}
+
+func (x *slotIndex[T]) get(v *T) (int, bool) {
+	i := x.find(v)
+	return x.slots[i], x.keys[i] != nil
+}
+
+func (x *slotIndex[T]) set(v *T, slot int) {
+	i := x.find(v)
+	x.keys[i], x.slots[i] = v, slot
+}
+
+// del removes v and moves the keys that collided with it back so lookups don't stop early at the gap
+func (x *slotIndex[T]) del(v *T) {
+	i := x.find(v)
+	if x.keys[i] == nil {
+		return
+	}
+	x.keys[i] = nil
+	for j := (i + 1) & x.mask; x.keys[j] != nil; j = (j + 1) & x.mask {
+		// the key at j can fill the gap at i unless its home lies cyclically in (i, j]
+		h := x.home(x.keys[j])
+		if (i < j && (h <= i || h > j)) || (i > j && h <= i && h > j) {
+			x.keys[i], x.slots[i] = x.keys[j], x.slots[j]
+			x.keys[j] = nil
+			i = j
+		}
+	}
+}
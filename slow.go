@@ -0,0 +1,39 @@
+package pool
+
+import "time"
+
+// SlowAcquire describes an acquire that is waiting longer than the threshold set by WithSlowAcquireThreshold
+type SlowAcquire struct {
+	// name of the pool
+	Pool string
+	// time the acquire is waiting so far
+	Waited time.Duration
+	// number of acquires waiting for an entry
+	Waiters int
+	Stats   Stats
+}
+
+// WithSlowAcquireThreshold sets a function that gets called (in its own goroutine) once an acquire
+// is waiting for longer than d, giving early warning of pool starvation before acquires time out
+func WithSlowAcquireThreshold(d time.Duration, fn func(SlowAcquire)) Option {
+	return func(o *options) {
+		o.slowAcquireThreshold = d
+		o.slowAcquireFunc = fn
+	}
+}
+
+// watchSlow starts the timer reporting a slow acquire, nil is returned if no threshold is set
+func (p *Pool[T]) watchSlow() *time.Timer {
+	d, fn := p.opts.slowAcquireThreshold, p.opts.slowAcquireFunc
+	if d <= 0 || fn == nil {
+		return nil
+	}
+	return time.AfterFunc(d, func() {
+		fn(SlowAcquire{
+			Pool:    p.opts.name,
+			Waited:  d,
+			Waiters: int(p.waiters.Load()),
+			Stats:   p.Stats(),
+		})
+	})
+}
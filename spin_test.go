@@ -0,0 +1,55 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSpinAcquire(t *testing.T) {
+	pool := NewPool(1, poolFactory, WithSpinAcquire(time.Second))
+	defer pool.Close()
+	held := pool.Acquire()
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		pool.Release(held)
+	}()
+	v := pool.Acquire()
+	if v != held {
+		t.Errorf("expected the released entry")
+	}
+	if stats := pool.Stats(); stats.SpinHits != 1 || stats.Timeouts != 0 {
+		t.Errorf("expected the acquire to get the entry while spinning but got %+v", stats)
+	}
+
+	// spinning can be disabled per acquire
+	_, err := pool.AcquireWith(context.Background(), WithSpin(0), WithTimeout(10*time.Millisecond))
+	if !errors.Is(err, ErrAcquireTimeout) {
+		t.Errorf("expected ErrAcquireTimeout but got %v", err)
+	}
+	// and ends with the context of the acquire
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := pool.AcquireWithContext(ctx); !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("expected the acquire to stop spinning once its context is done but got %v", err)
+	}
+	// and with the timeout of the acquire
+	start = time.Now()
+	if _, err := pool.AcquireWithTimeout(time.Millisecond); !errors.Is(err, ErrAcquireTimeout) || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("expected the acquire to stop spinning once it timed out but got %v after %v", err, time.Since(start))
+	}
+	pool.Release(v)
+	// a negative spin duration is rejected even if an entry is idle
+	if _, err := pool.AcquireWith(context.Background(), WithSpin(-1)); !errors.Is(err, ErrInvalidAcquireOption) {
+		t.Errorf("expected ErrInvalidAcquireOption but got %v", err)
+	}
+	if stats := pool.Stats(); stats.SpinHits != 1 {
+		t.Errorf("expected 1 spin hit but got %d", stats.SpinHits)
+	}
+
+	if _, err := NewPoolE(1, poolFactory, WithSpinAcquire(-time.Second)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("expected ErrInvalidOption but got %v", err)
+	}
+}
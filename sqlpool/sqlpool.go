@@ -0,0 +1,140 @@
+// Package sqlpool pools dedicated database connections (*sql.Conn) of a *sql.DB
+//
+// Connections are established lazily on acquire using the context of the caller,
+// validated using a ping before they are handed out and closed when they are removed
+// from the pool. Connections that returned driver.ErrBadConn should be discarded:
+//
+//	p, err := sqlpool.New(db, 10)
+//	...
+//	err = p.Run(ctx, func(ctx context.Context, c *sql.Conn) error {
+//		_, err := c.ExecContext(ctx, "SET search_path TO tenant")
+//		return err
+//	})
+package sqlpool
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"time"
+
+	"github.com/epikur-io/go-pool"
+	"github.com/epikur-io/go-pool/internal/adapter"
+)
+
+const (
+	// timeout of the ping validating a connection
+	pingTimeout = 5 * time.Second
+	// connections are pinged at most once per interval
+	validationInterval = 10 * time.Second
+)
+
+// Conn is a pooled connection, Conn is nil until the connection is established
+type Conn struct {
+	*sql.Conn
+}
+
+// Pool is a pool of database connections
+type Pool struct {
+	db   *sql.DB
+	pool *pool.Pool[Conn]
+}
+
+// Creates a pool holding up to size connections of db. Connections are pinged at most every 10 seconds
+// before they are handed out and returned to db once removed, opts may change the interval
+// (pool.WithValidationInterval) or bring their own validator.
+func New(db *sql.DB, size int, opts ...pool.Option) (*Pool, error) {
+	if db == nil {
+		return nil, errors.New("sqlpool: nil database")
+	}
+	defaults := []pool.Option{
+		pool.WithValidator(ping),
+		pool.WithValidationInterval(validationInterval),
+		pool.WithDestroyerContext(closeConn),
+	}
+	p, err := pool.NewPoolFromConfig(pool.Config{Size: size}, func() *Conn { return &Conn{} }, append(defaults, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &Pool{db: db, pool: p}, nil
+}
+
+// ping validates established connections
+func ping(c *Conn) bool {
+	if c.Conn == nil {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	return c.PingContext(ctx) == nil
+}
+
+// closeConn returns established connections to the database
+func closeConn(_ context.Context, c *Conn) error {
+	if c.Conn == nil {
+		return nil
+	}
+	err := c.Close()
+	if errors.Is(err, sql.ErrConnDone) {
+		return nil
+	}
+	return err
+}
+
+// Returns the pool holding the connections, they count as connections in use by db
+// (see sql.DBStats) even while they are idle in this pool
+func (p *Pool) Pool() *pool.Pool[Conn] {
+	return p.pool
+}
+
+// Conn acquires a connection, connecting it if necessary, waiting until ctx is done
+func (p *Pool) Conn(ctx context.Context) (*Conn, error) {
+	return adapter.Acquire(ctx, p.pool, connected, p.connect)
+}
+
+// connected reports whether c is established
+func connected(c *Conn) bool {
+	return c.Conn != nil
+}
+
+// connect takes a connection of db for c
+func (p *Pool) connect(ctx context.Context, c *Conn) error {
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	c.Conn = conn
+	return nil
+}
+
+// Releases a connection to the pool
+func (p *Pool) Release(c *Conn) error {
+	return p.pool.Release(c)
+}
+
+// Discard closes a broken connection (e.g. one that returned driver.ErrBadConn), db opens
+// a new one on the next acquire of its slot
+func (p *Pool) Discard(c *Conn) error {
+	return p.pool.Replace(c)
+}
+
+// Run acquires a connection and runs fn with it, the connection gets discarded if fn fails with
+// driver.ErrBadConn or sql.ErrConnDone or panics (e.g. within a transaction it didn't finish)
+func (p *Pool) Run(ctx context.Context, fn func(ctx context.Context, c *sql.Conn) error) error {
+	c, err := p.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	return adapter.Run(p.pool, c, p.Release, badConn, func() error { return fn(ctx, c.Conn) })
+}
+
+// badConn reports whether err leaves the connection unusable
+func badConn(err error) bool {
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone)
+}
+
+// Close closes the pool and all idle connections
+func (p *Pool) Close() error {
+	return p.pool.Close()
+}
@@ -0,0 +1,173 @@
+package sqlpool
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/epikur-io/go-pool"
+)
+
+// testDriver is a minimal driver counting its connections
+type testDriver struct {
+	opened atomic.Int64
+	closed atomic.Int64
+	broken atomic.Bool
+}
+
+type testConn struct {
+	d *testDriver
+}
+
+func (d *testDriver) Open(name string) (driver.Conn, error) {
+	d.opened.Add(1)
+	return &testConn{d: d}, nil
+}
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *testConn) Close() error {
+	c.d.closed.Add(1)
+	return nil
+}
+
+func (c *testConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *testConn) Ping(ctx context.Context) error {
+	if c.d.broken.Load() {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+func newTestDB(t *testing.T) (*sql.DB, *testDriver) {
+	d := &testDriver{}
+	db := sql.OpenDB(connector{d})
+	// don't keep connections returned by the pool
+	db.SetMaxIdleConns(0)
+	t.Cleanup(func() { db.Close() })
+	return db, d
+}
+
+type connector struct {
+	d *testDriver
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) {
+	return c.d.Open("")
+}
+
+func (c connector) Driver() driver.Driver {
+	return c.d
+}
+
+func TestConn(t *testing.T) {
+	db, d := newTestDB(t)
+	p, err := New(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.opened.Load() != 0 {
+		t.Errorf("expected connections to be established lazily but got %d", d.opened.Load())
+	}
+
+	ctx := context.Background()
+	c, err := p.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Release(c)
+	if c2, _ := p.Conn(ctx); c2.Conn != c.Conn {
+		p.Release(c2)
+		t.Errorf("expected the connection to be reused")
+	} else {
+		p.Release(c2)
+	}
+	if d.opened.Load() != 1 {
+		t.Errorf("expected 1 connection but got %d", d.opened.Load())
+	}
+
+	p.Close()
+	if d.closed.Load() != 1 {
+		t.Errorf("expected 1 closed connection but got %d", d.closed.Load())
+	}
+}
+
+func TestRunDiscardsBadConn(t *testing.T) {
+	db, d := newTestDB(t)
+	p, err := New(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	ctx := context.Background()
+	if err := p.Run(ctx, func(ctx context.Context, c *sql.Conn) error {
+		return driver.ErrBadConn
+	}); err != driver.ErrBadConn {
+		t.Errorf("expected %v but got %v", driver.ErrBadConn, err)
+	}
+	if d.closed.Load() != 1 {
+		t.Errorf("expected the bad connection to be closed")
+	}
+	if err := p.Run(ctx, func(ctx context.Context, c *sql.Conn) error { return nil }); err != nil {
+		t.Error(err)
+	}
+	if d.opened.Load() != 2 {
+		t.Errorf("expected 2 connections but got %d", d.opened.Load())
+	}
+}
+
+func TestRunPanic(t *testing.T) {
+	db, d := newTestDB(t)
+	p, err := New(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expected the panic to be passed on")
+			}
+		}()
+		p.Run(context.Background(), func(ctx context.Context, c *sql.Conn) error { panic("fn") })
+	}()
+	if stats := p.Pool().Stats(); stats.InUse != 0 || d.closed.Load() != 1 {
+		t.Errorf("expected the connection to be given back and closed but got %+v", stats)
+	}
+}
+
+func TestValidation(t *testing.T) {
+	db, d := newTestDB(t)
+	p, err := New(db, 1, pool.WithValidationInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	ctx := context.Background()
+	c, _ := p.Conn(ctx)
+	p.Release(c)
+	d.broken.Store(true)
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	// the broken connection gets replaced by a new one
+	c, err = p.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Release(c)
+	if stats := p.Pool().Stats(); stats.Invalid != 1 {
+		t.Errorf("expected 1 invalid connection but got %d", stats.Invalid)
+	}
+}
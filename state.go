@@ -0,0 +1,82 @@
+package pool
+
+import "fmt"
+
+// PoolState tells how well a pool can serve acquires (see State and EventStateChanged)
+type PoolState int32
+
+const (
+	// the pool holds less entries than its minimum size, e.g. while it gets filled
+	// or before destroyed entries are replaced
+	PoolCold PoolState = iota
+	// entries are idle or can be created
+	PoolWarm
+	// all entries are in use and the pool can't grow, acquires have to wait
+	PoolSaturated
+)
+
+func (s PoolState) String() string {
+	switch s {
+	case PoolCold:
+		return "cold"
+	case PoolWarm:
+		return "warm"
+	case PoolSaturated:
+		return "saturated"
+	}
+	return fmt.Sprintf("PoolState(%d)", int32(s))
+}
+
+// MarshalText encodes the state by its name
+func (s PoolState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes the name of a state
+func (s *PoolState) UnmarshalText(text []byte) error {
+	for _, state := range []PoolState{PoolCold, PoolWarm, PoolSaturated} {
+		if state.String() == string(text) {
+			*s = state
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown pool state %q", text)
+}
+
+// Returns the current state of the pool
+func (p *Pool[T]) State() PoolState {
+	// entries being created are already part of the accounting
+	entries := p.live.Load() - p.creating.Load()
+	if entries < int64(p.minSize()) {
+		return PoolCold
+	}
+	if p.opts.unbounded || !p.full(p.live.Load()) {
+		return PoolWarm
+	}
+	if entries-p.stats.inUse.Load() <= 0 || p.waiters.Load() > 0 {
+		return PoolSaturated
+	}
+	return PoolWarm
+}
+
+// Ready reports whether the pool holds its minimum number of entries and hands them out,
+// i.e. it's neither cold, paused nor closed (e.g. for readiness probes, see admin.NewReadyHandler)
+func (p *Pool[T]) Ready() bool {
+	return !p.closed.Load() && !p.Paused() && p.State() != PoolCold
+}
+
+// updateState emits an EventStateChanged if the state of the pool changed and reports the gauges to the
+// metrics sink, it's called when entries are acquired, put into the pool or destroyed
+func (p *Pool[T]) updateState() {
+	p.reportGauges()
+	if len(p.opts.eventHooks) == 0 {
+		return
+	}
+	s := p.State()
+	if PoolState(p.state.Load()) == s {
+		return
+	}
+	if prev := PoolState(p.state.Swap(int32(s))); prev != s {
+		p.emit(Event{Type: EventStateChanged, State: s, Previous: prev})
+	}
+}
@@ -0,0 +1,85 @@
+package pool
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStateEvents(t *testing.T) {
+	var states []PoolState
+	pool := NewPool(2, poolFactory, WithEventHook(func(e Event) {
+		if e.Type == EventStateChanged {
+			states = append(states, e.State)
+		}
+	}))
+	defer pool.Close()
+	if !pool.Ready() || pool.State() != PoolWarm {
+		t.Errorf("expected a filled pool to be ready but got %v", pool.State())
+	}
+	a, b := pool.Acquire(), pool.Acquire()
+	if s := pool.Stats().State; s != PoolSaturated {
+		t.Errorf("expected %v but got %v", PoolSaturated, s)
+	}
+	pool.Release(a)
+	// replacing an entry doesn't make the pool cold
+	pool.Replace(b)
+	expected := []PoolState{PoolWarm, PoolSaturated, PoolWarm}
+	if len(states) != len(expected) {
+		t.Fatalf("expected states %v but got %v", expected, states)
+	}
+	for i, s := range expected {
+		if states[i] != s {
+			t.Errorf("expected states %v but got %v", expected, states)
+			break
+		}
+	}
+
+	pool.Pause()
+	if pool.Ready() {
+		t.Errorf("expected a paused pool not to be ready")
+	}
+	pool.Resume()
+	pool.Close()
+	if pool.Ready() {
+		t.Errorf("expected a closed pool not to be ready")
+	}
+}
+
+func TestStateCold(t *testing.T) {
+	block := make(chan struct{})
+	created := 0
+	factory := func() *poolItem {
+		if created++; created > 1 {
+			<-block
+		}
+		return new(poolItem)
+	}
+	events := make(chan Event, 10)
+	pool := NewPool(1, factory, WithTTL(time.Millisecond), WithEventHook(func(e Event) {
+		if e.Type == EventStateChanged {
+			events <- e
+		}
+	}))
+	defer pool.Close()
+	if e := <-events; e.State != PoolWarm || e.Previous != PoolCold {
+		t.Errorf("expected the pool to get warm but got %v -> %v", e.Previous, e.State)
+	}
+	e := pool.Acquire()
+	<-events
+	time.Sleep(2 * time.Millisecond)
+	// the expired entry gets destroyed and the factory blocks while replacing it
+	go pool.Release(e)
+	if e := <-events; e.State != PoolCold {
+		t.Errorf("expected %v but got %v", PoolCold, e.State)
+	}
+	if pool.Ready() {
+		t.Errorf("expected a cold pool not to be ready")
+	}
+	close(block)
+	if e := <-events; e.State != PoolWarm {
+		t.Errorf("expected %v but got %v", PoolWarm, e.State)
+	}
+	if !pool.Ready() {
+		t.Errorf("expected the pool to be ready once the entry was replaced")
+	}
+}
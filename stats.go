@@ -0,0 +1,222 @@
+package pool
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the state and counters of a pool
+type Stats struct {
+	Name string `json:"name,omitempty"`
+	// capacity of the pool
+	Cap int `json:"cap"`
+	// number of entries the pool should hold
+	Size int `json:"size"`
+	// requested size, differs from Size while a rate limited resize is running (see WithResizeRate)
+	ResizeTo int `json:"resize_to"`
+	// entries currently waiting in the pool
+	Idle int `json:"idle"`
+	// entries currently acquired
+	InUse int `json:"in_use"`
+	// total number of acquired entries
+	Acquired uint64 `json:"acquired"`
+	// total number of released entries
+	Released uint64 `json:"released"`
+	// total number of entries created by the factory function
+	Created uint64 `json:"created"`
+	// total number of entries destroyed by the pool
+	Destroyed uint64 `json:"destroyed"`
+	// total number of entries destroyed because they exceeded their ttl or idle timeout
+	Expired uint64 `json:"expired"`
+	// total number of entries destroyed because they failed validation
+	Invalid uint64 `json:"invalid"`
+	// total number of idle entries destroyed because they failed the health check (dead entries)
+	Unhealthy uint64 `json:"unhealthy"`
+	// entries that passed their last health check (or weren't checked yet)
+	Healthy int `json:"healthy"`
+	// entries exempt from eviction (see Lease.Pin)
+	Pinned int `json:"pinned"`
+	// entries whose last health check failed with ErrDegraded
+	Degraded int `json:"degraded"`
+	// total number of degraded entries repaired (see WithRepair)
+	Repaired uint64 `json:"repaired"`
+	// total number of refreshes triggered by an exhausted error budget (see WithErrorBudget)
+	BudgetRefreshes uint64 `json:"budget_refreshes"`
+	// total number of entries of an old generation destroyed when released (see RefreshAll)
+	Stale uint64 `json:"stale"`
+	// total number of released entries destroyed because they shouldn't be retained (see WithShouldRetain)
+	Rejected uint64 `json:"rejected"`
+	// total cost of the entries (see WithCostBudget)
+	Cost int64 `json:"cost"`
+	// total number of idle entries destroyed to stay within the cost budget
+	CostEvicted uint64 `json:"cost_evicted"`
+	// total number of reset or destroy hooks exceeding the maintenance timeout
+	HookTimeouts uint64 `json:"hook_timeouts"`
+	// total number of acquires rejected with ErrPoolSaturated (see WithMaxWaiters)
+	Saturated uint64 `json:"saturated"`
+	// total number of acquires that got the entry preferred for their key (see AcquireWithAffinity)
+	AffinityHits uint64 `json:"affinity_hits"`
+	// total number of entries released while the queue of WithAsyncRelease was full
+	ReleaseOverflows uint64 `json:"release_overflows"`
+	// total number of acquires that timed out or got canceled
+	Timeouts uint64 `json:"timeouts"`
+	// total number of entries held longer than the max hold time (see WithMaxHoldTime)
+	HoldsExpired uint64 `json:"holds_expired"`
+	// total number of entries abandoned because they were held too long (see WithReclaimExpiredHolds)
+	Reclaimed uint64 `json:"reclaimed"`
+	// total number of acquired entries taken over by their caller (see Hijack)
+	Hijacked uint64 `json:"hijacked"`
+	// total number of releases rejected by WithCheckedRelease, e.g. entries released twice
+	InvalidReleases uint64 `json:"invalid_releases"`
+	// total number of acquired entries lost without being released (see WithLeakDetection)
+	Leaked uint64 `json:"leaked"`
+	// total number of acquires failed with ErrOverloaded (see WithLoadShedding)
+	Shed uint64 `json:"shed"`
+	// total number of acquires that got an entry while spinning (see WithSpinAcquire)
+	SpinHits uint64 `json:"spin_hits"`
+	// total number of retried acquires queued ahead of the other waiting acquires (see WithPriority)
+	Retries uint64 `json:"retries"`
+	// acquires currently waiting for an entry, canceled acquires stop counting right away
+	Waiters int `json:"waiters"`
+	// removed entries waiting for the destroyer (see WithAsyncDestroy)
+	PendingDestroys int `json:"pending_destroys"`
+	// entries held in quarantine (see WithQuarantine)
+	Quarantined int `json:"quarantined"`
+	// cold, warm or saturated (see PoolState)
+	State PoolState `json:"state"`
+	// duration of the factory calls
+	Factory FactoryStats `json:"factory"`
+	// distribution of the wait times of acquires, nil unless the pool was created WithWaitBuckets
+	Waits *WaitHistogram `json:"waits,omitempty"`
+	// incremented on every RefreshAll
+	Generation uint64 `json:"generation"`
+	// percentage of the entries of the current generation, less than 100 while entries in use
+	// or entries not acquired since RefreshLazy are of an old generation
+	Migrated float64 `json:"migrated"`
+	Paused   bool    `json:"paused"`
+	Closed   bool    `json:"closed"`
+}
+
+// cacheLineSize separates counters written by every acquire and release, large enough
+// for the adjacent cache lines prefetched together on amd64 and the cache lines of arm64
+const cacheLineSize = 128
+
+// counters of a pool, all updated using atomics. The counters written by every acquire and
+// release are kept on their own cache lines so concurrent updates don't invalidate each other
+// (or the read-mostly fields of the pool next to them).
+type counters struct {
+	_        [cacheLineSize]byte
+	acquired atomic.Uint64
+	_        [cacheLineSize - 8]byte
+	released atomic.Uint64
+	_        [cacheLineSize - 8]byte
+	inUse    atomic.Int64
+	_        [cacheLineSize - 8]byte
+
+	created          atomic.Uint64
+	destroyed        atomic.Uint64
+	expired          atomic.Uint64
+	invalid          atomic.Uint64
+	unhealthy        atomic.Uint64
+	stale            atomic.Uint64
+	hookTimeouts     atomic.Uint64
+	timeouts         atomic.Uint64
+	saturated        atomic.Uint64
+	affinityHits     atomic.Uint64
+	releaseOverflows atomic.Uint64
+	rejected         atomic.Uint64
+	costEvicted      atomic.Uint64
+	holdsExpired     atomic.Uint64
+	reclaimed        atomic.Uint64
+	retries          atomic.Uint64
+	repaired         atomic.Uint64
+	budgetRefreshes  atomic.Uint64
+	hijacked         atomic.Uint64
+	invalidReleases  atomic.Uint64
+	leaked           atomic.Uint64
+	spinHits         atomic.Uint64
+	shed             atomic.Uint64
+}
+
+// Returns a snapshot of the pools statistics
+func (p *Pool[T]) Stats() Stats {
+	s := Stats{
+		Name:             p.opts.name,
+		Cap:              p.Cap(),
+		Size:             int(p.target.Load()),
+		ResizeTo:         p.requestedSize(),
+		Idle:             p.Len(),
+		InUse:            p.InUse(),
+		Acquired:         p.stats.acquired.Load(),
+		Released:         p.stats.released.Load(),
+		Created:          p.stats.created.Load(),
+		Destroyed:        p.stats.destroyed.Load(),
+		Expired:          p.stats.expired.Load(),
+		Invalid:          p.stats.invalid.Load(),
+		Unhealthy:        p.stats.unhealthy.Load(),
+		Healthy:          p.healthy(),
+		Pinned:           int(p.pinned.Load()),
+		Degraded:         int(p.degradedCount.Load()),
+		Repaired:         p.stats.repaired.Load(),
+		BudgetRefreshes:  p.stats.budgetRefreshes.Load(),
+		Stale:            p.stats.stale.Load(),
+		Rejected:         p.stats.rejected.Load(),
+		Cost:             p.cost.Load(),
+		CostEvicted:      p.stats.costEvicted.Load(),
+		HookTimeouts:     p.stats.hookTimeouts.Load(),
+		Timeouts:         p.stats.timeouts.Load(),
+		HoldsExpired:     p.stats.holdsExpired.Load(),
+		Reclaimed:        p.stats.reclaimed.Load(),
+		Hijacked:         p.stats.hijacked.Load(),
+		InvalidReleases:  p.stats.invalidReleases.Load(),
+		Leaked:           p.stats.leaked.Load(),
+		SpinHits:         p.stats.spinHits.Load(),
+		Shed:             p.stats.shed.Load(),
+		Retries:          p.stats.retries.Load(),
+		Waiters:          int(p.waiters.Load()),
+		PendingDestroys:  int(p.destroying.Load()),
+		Quarantined:      p.quarantined(),
+		State:            p.State(),
+		Saturated:        p.stats.saturated.Load(),
+		AffinityHits:     p.stats.affinityHits.Load(),
+		ReleaseOverflows: p.stats.releaseOverflows.Load(),
+		Generation:       p.generation.Load(),
+		Migrated:         p.migrated(),
+		Factory:          p.FactoryStats(),
+		Paused:           p.Paused(),
+		Closed:           p.closed.Load(),
+	}
+	if p.waitHist != nil {
+		s.Waits = p.waitHist.snapshot()
+	}
+	return s
+}
+
+func (p *Pool[T]) onAcquire(v *T) {
+	p.stats.acquired.Add(1)
+	p.stats.inUse.Add(1)
+	if p.trackUse {
+		m := p.meta(v)
+		m.uses.Add(1)
+		m.lastUsed.Store(time.Now().UnixNano())
+		m.inUse.Store(true)
+		p.trace(m, TraceAcquire, nil)
+		if p.opts.deadlockDetection || p.opts.checkedRelease {
+			m.holder.Store(goid())
+		}
+		p.issue(v, m)
+	}
+	p.startHold(v)
+	p.updateState()
+}
+
+func (p *Pool[T]) onRelease() {
+	p.stats.released.Add(1)
+	p.stats.inUse.Add(-1)
+}
+
+// unrelease reverts onRelease for a release the idle entries rejected
+func (p *Pool[T]) unrelease() {
+	p.stats.released.Add(^uint64(0))
+	p.stats.inUse.Add(1)
+}
@@ -0,0 +1,64 @@
+package pool
+
+import (
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestStats(t *testing.T) {
+	pool := NewPool(2, poolFactory, WithNilReplacement())
+	a := pool.Acquire()
+	pool.Acquire()
+	if _, err := pool.AcquireWithTimeout(time.Millisecond); err == nil {
+		t.Errorf("expected timeout error but got %v", err)
+	}
+	pool.Release(a)
+	pool.Release(nil)
+
+	stats := pool.Stats()
+	if stats.Factory.Calls != 3 || stats.Factory.Min > stats.Factory.Avg || stats.Factory.Avg > stats.Factory.Max {
+		t.Errorf("unexpected factory stats: %+v", stats.Factory)
+	}
+	stats.Factory = FactoryStats{}
+	expected := Stats{Cap: 2, Size: 2, ResizeTo: 2, Idle: 2, InUse: 0, Acquired: 2, Released: 2, Created: 3, Timeouts: 1, Healthy: 2, State: PoolWarm, Migrated: 100}
+	if stats != expected {
+		t.Errorf("expected %+v but got %+v", expected, stats)
+	}
+
+	pool.Acquire()
+	if stats := pool.Stats(); stats.InUse != 1 || stats.Idle != 1 {
+		t.Errorf("expected 1 entry in use and 1 idle but got %+v", stats)
+	}
+}
+
+func TestInUse(t *testing.T) {
+	pool := NewPool(2, poolFactory)
+	e := pool.Acquire()
+	if pool.InUse() != 1 || pool.Len() != 1 {
+		t.Errorf("expected 1 entry in use and 1 idle but got %d and %d", pool.InUse(), pool.Len())
+	}
+	pool.Release(e)
+	if pool.InUse() != 0 || pool.Len() != 2 {
+		t.Errorf("expected no entry in use and 2 idle but got %d and %d", pool.InUse(), pool.Len())
+	}
+}
+
+func TestEntryMetaPadding(t *testing.T) {
+	if size := unsafe.Sizeof(paddedMeta{}); size%cacheLineSize != 0 {
+		t.Errorf("expected the metadata of an entry to fill whole cache lines but got %d bytes", size)
+	}
+}
+
+func TestCountersPadding(t *testing.T) {
+	var c counters
+	offsets := []uintptr{unsafe.Offsetof(c.acquired), unsafe.Offsetof(c.released), unsafe.Offsetof(c.inUse), unsafe.Offsetof(c.created)}
+	if offsets[0] < cacheLineSize {
+		t.Errorf("expected padding before the first counter but got offset %d", offsets[0])
+	}
+	for i := 1; i < len(offsets); i++ {
+		if offsets[i]-offsets[i-1] < cacheLineSize {
+			t.Errorf("expected counters %d and %d on separate cache lines but got offsets %v", i-1, i, offsets)
+		}
+	}
+}
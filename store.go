@@ -0,0 +1,614 @@
+package pool
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// waitResult tells why idleStore.get returned
+type waitResult int
+
+const (
+	waitOK waitResult = iota
+	waitDone
+	waitTimeout
+	waitClosed
+)
+
+// idleStore holds the idle entries of a pool
+type idleStore[T any] interface {
+	// tryGet takes an idle entry without blocking
+	tryGet() (*T, bool)
+	// tryGetOldest takes the entry that is idle the longest without blocking
+	tryGetOldest() (*T, bool)
+	// get waits for an idle entry until done, timeout or closed fire (all optional)
+	get(done <-chan struct{}, timeout <-chan time.Time, closed <-chan struct{}) (*T, waitResult)
+	// getFirst is like get but gets queued ahead of the waiting gets (see WithPriority)
+	getFirst(done <-chan struct{}, timeout <-chan time.Time, closed <-chan struct{}) (*T, waitResult)
+	// getQueued is like get, the get is queued as w by the wait queue of the store (see WithWaitQueue)
+	getQueued(w *Waiter, done <-chan struct{}, timeout <-chan time.Time, closed <-chan struct{}) (*T, waitResult)
+	// put adds v, waiting for space if the store is full (false is returned instead if it can't wait
+	// or v is idle already)
+	put(v *T) bool
+	// tryPut adds v without blocking, false is returned if the store is full
+	tryPut(v *T) bool
+	// putWait adds v, waiting for space until done fires
+	putWait(v *T, done <-chan struct{}) bool
+	// putAll adds the entries of vs in order like put, the entries added are moved to the front of vs,
+	// n is their number
+	putAll(vs []*T) (n int)
+	// takeMatch takes the first idle entry for which match returns true
+	takeMatch(match func(*T) bool) *T
+	// snapshot returns the idle entries oldest first without taking them, maintenance inspects the
+	// snapshot and takes the entries it works on one by one using remove, so gets and puts only
+	// wait for single removals
+	snapshot() []*T
+	// remove takes v if it's still idle
+	remove(v *T) bool
+	// contains reports whether v is idle
+	contains(v *T) bool
+	len() int
+	cap() int
+}
+
+// nilIndex marks the end of a list in ringStore
+const nilIndex = -1
+
+// ringStore is an idle store based on a fixed number of slots linked in the order their entries
+// were put, free slots are kept in a free list. Blocked gets are served in FIFO order by handing
+// released entries over directly.
+type ringStore[T any] struct {
+	mux  sync.Mutex
+	lifo bool
+	// slots of the idle entries
+	slots []slot[T]
+	// oldest and newest idle entry
+	head, tail int
+	// first unused slot
+	free int
+	// entry -> slot index
+	index slotIndex[T]
+	// entries of zero sized types share their pointer, so entries idle already can't be told apart
+	shared bool
+	// gets waiting for an entry
+	waiters waitQueue[T]
+	// orders the waiting gets instead of waiters if set (see WithWaitQueue)
+	queue WaitQueue
+	// number of idle entries, written while holding mux but read without it (see len)
+	// and kept on its own cache line so polling it doesn't slow down gets and puts
+	_ [cacheLineSize]byte
+	n atomic.Int64
+	_ [cacheLineSize - 8]byte
+}
+
+type slot[T any] struct {
+	v          *T
+	prev, next int
+}
+
+func newRingStore[T any](capacity int, lifo bool) *ringStore[T] {
+	s := &ringStore[T]{
+		lifo:   lifo,
+		slots:  make([]slot[T], capacity),
+		head:   nilIndex,
+		tail:   nilIndex,
+		index:  newSlotIndex[T](capacity),
+		shared: zeroSized[T](),
+	}
+	for i := range s.slots {
+		s.slots[i].next = i + 1
+	}
+	if capacity > 0 {
+		s.slots[capacity-1].next = nilIndex
+	} else {
+		s.free = nilIndex
+	}
+	return s
+}
+
+// push appends v to the list of idle entries, the caller must hold s.mux. v is rejected if it's idle
+// already (it was released twice) or no slot is free (it wasn't acquired from the pool).
+func (s *ringStore[T]) push(v *T) bool {
+	i := s.free
+	if i == nilIndex {
+		return false
+	}
+	if _, ok := s.index.get(v); ok && !s.shared {
+		return false
+	}
+	s.free = s.slots[i].next
+	s.slots[i] = slot[T]{v: v, prev: s.tail, next: nilIndex}
+	if s.tail != nilIndex {
+		s.slots[s.tail].next = i
+	} else {
+		s.head = i
+	}
+	s.tail = i
+	s.index.set(v, i)
+	s.n.Add(1)
+	return true
+}
+
+// unlink removes the entry of slot i, the caller must hold s.mux
+func (s *ringStore[T]) unlink(i int) *T {
+	sl := s.slots[i]
+	if sl.prev != nilIndex {
+		s.slots[sl.prev].next = sl.next
+	} else {
+		s.head = sl.next
+	}
+	if sl.next != nilIndex {
+		s.slots[sl.next].prev = sl.prev
+	} else {
+		s.tail = sl.prev
+	}
+	s.slots[i] = slot[T]{prev: nilIndex, next: s.free}
+	s.free = i
+	s.index.del(sl.v)
+	s.n.Add(-1)
+	return sl.v
+}
+
+// pop takes the next entry to hand out, the caller must hold s.mux
+func (s *ringStore[T]) pop() (*T, bool) {
+	if s.n.Load() == 0 {
+		return nil, false
+	}
+	if s.lifo {
+		return s.unlink(s.tail), true
+	}
+	return s.unlink(s.head), true
+}
+
+func (s *ringStore[T]) tryGet() (*T, bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.pop()
+}
+
+func (s *ringStore[T]) tryGetOldest() (*T, bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.n.Load() == 0 {
+		return nil, false
+	}
+	return s.unlink(s.head), true
+}
+
+func (s *ringStore[T]) get(done <-chan struct{}, timeout <-chan time.Time, closed <-chan struct{}) (*T, waitResult) {
+	return s.wait(nil, done, timeout, closed, false)
+}
+
+func (s *ringStore[T]) getFirst(done <-chan struct{}, timeout <-chan time.Time, closed <-chan struct{}) (*T, waitResult) {
+	return s.wait(nil, done, timeout, closed, true)
+}
+
+func (s *ringStore[T]) getQueued(w *Waiter, done <-chan struct{}, timeout <-chan time.Time, closed <-chan struct{}) (*T, waitResult) {
+	return s.wait(w, done, timeout, closed, w.Retry)
+}
+
+// wait implements get, gets with priority are queued behind the other gets with priority only.
+// ext describes the get to the custom wait queue of the store, if any.
+func (s *ringStore[T]) wait(ext *Waiter, done <-chan struct{}, timeout <-chan time.Time, closed <-chan struct{}, priority bool) (*T, waitResult) {
+	s.mux.Lock()
+	if v, ok := s.pop(); ok {
+		s.mux.Unlock()
+		return v, waitOK
+	}
+	var w *waiter[T]
+	if s.queue != nil {
+		w = s.waiters.alloc()
+		w.ext = s.describe(ext, priority)
+		w.ext.w = w
+		s.queue.Push(w.ext)
+	} else {
+		w = s.waiters.push(priority)
+	}
+	s.mux.Unlock()
+
+	var res waitResult
+	select {
+	case v := <-w.ch:
+		s.waiters.release(w)
+		return v, waitOK
+	case <-done:
+		res = waitDone
+	case <-timeout:
+		res = waitTimeout
+	case <-closed:
+		res = waitClosed
+	}
+	s.mux.Lock()
+	queued := s.dequeue(w)
+	s.mux.Unlock()
+	if !queued {
+		// an entry was handed over concurrently, use it
+		v := <-w.ch
+		s.waiters.release(w)
+		return v, waitOK
+	}
+	s.waiters.release(w)
+	return nil, res
+}
+
+// describe completes the description of a get for the custom wait queue
+func (s *ringStore[T]) describe(ext *Waiter, priority bool) *Waiter {
+	if ext == nil {
+		ext = &Waiter{Retry: priority}
+	}
+	if ext.Context == nil {
+		ext.Context = context.Background()
+	}
+	if ext.Since.IsZero() {
+		ext.Since = time.Now()
+	}
+	return ext
+}
+
+// next removes the waiter that gets the next entry, the caller must hold s.mux
+func (s *ringStore[T]) next() *waiter[T] {
+	if s.queue == nil {
+		return s.waiters.pop()
+	}
+	if ext := s.queue.Pop(); ext != nil {
+		return ext.w.(*waiter[T])
+	}
+	return nil
+}
+
+// dequeue removes w if it's still queued, the caller must hold s.mux
+func (s *ringStore[T]) dequeue(w *waiter[T]) bool {
+	if w.ext != nil {
+		return s.queue.Remove(w.ext)
+	}
+	return s.waiters.remove(w)
+}
+
+func (s *ringStore[T]) put(v *T) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if w := s.next(); w != nil {
+		w.ch <- v
+		return true
+	}
+	return s.push(v)
+}
+
+// putAll takes the lock once for all entries
+func (s *ringStore[T]) putAll(vs []*T) int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	var rejected []*T
+	n := 0
+	for _, v := range vs {
+		if w := s.next(); w != nil {
+			w.ch <- v
+		} else if !s.push(v) {
+			rejected = append(rejected, v)
+			continue
+		}
+		vs[n] = v
+		n++
+	}
+	copy(vs[n:], rejected)
+	return n
+}
+
+func (s *ringStore[T]) tryPut(v *T) bool {
+	return s.put(v)
+}
+
+func (s *ringStore[T]) putWait(v *T, done <-chan struct{}) bool {
+	// the pools accounting guarantees space for every entry
+	return s.put(v)
+}
+
+func (s *ringStore[T]) takeMatch(match func(*T) bool) *T {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.lifo {
+		for i := s.tail; i != nilIndex; i = s.slots[i].prev {
+			if match(s.slots[i].v) {
+				return s.unlink(i)
+			}
+		}
+		return nil
+	}
+	for i := s.head; i != nilIndex; i = s.slots[i].next {
+		if match(s.slots[i].v) {
+			return s.unlink(i)
+		}
+	}
+	return nil
+}
+
+func (s *ringStore[T]) snapshot() []*T {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	idle := make([]*T, 0, s.n.Load())
+	for i := s.head; i != nilIndex; i = s.slots[i].next {
+		idle = append(idle, s.slots[i].v)
+	}
+	return idle
+}
+
+func (s *ringStore[T]) remove(v *T) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	i, ok := s.index.get(v)
+	if ok {
+		s.unlink(i)
+	}
+	return ok
+}
+
+func (s *ringStore[T]) contains(v *T) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	_, ok := s.index.get(v)
+	return ok
+}
+
+// len doesn't lock the store so frequent polling doesn't contend with gets and puts
+func (s *ringStore[T]) len() int {
+	return int(s.n.Load())
+}
+
+func (s *ringStore[T]) cap() int {
+	return len(s.slots)
+}
+
+// waiting returns the number of queued gets
+func (s *ringStore[T]) waiting() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.queue != nil {
+		return s.queue.Len()
+	}
+	return s.waiters.n
+}
+
+// waiter is a get waiting for an entry
+type waiter[T any] struct {
+	ch         chan *T
+	prev, next *waiter[T]
+	queued     bool
+	// description of the get if it's queued by a custom wait queue
+	ext *Waiter
+}
+
+// waitQueue is a FIFO queue of waiters, guarded by the mutex of its store. Waiters with
+// priority are queued ahead of the others (in FIFO order among themselves). Waiters that
+// give up (e.g. because their context got canceled) remove themselves right away wherever
+// they are queued, so the length of the queue only counts actual waiters.
+type waitQueue[T any] struct {
+	head, tail *waiter[T]
+	// last waiter with priority
+	last *waiter[T]
+	n    int
+	// unused waiters
+	pool sync.Pool
+}
+
+// push appends a new waiter, a waiter with priority is inserted behind the last waiter with priority
+func (q *waitQueue[T]) push(priority bool) *waiter[T] {
+	w := q.alloc()
+	w.queued = true
+	prev := q.tail
+	if priority {
+		prev = q.last
+		q.last = w
+	}
+	w.prev = prev
+	if prev != nil {
+		w.next = prev.next
+		prev.next = w
+	} else {
+		w.next = q.head
+		q.head = w
+	}
+	if w.next != nil {
+		w.next.prev = w
+	} else {
+		q.tail = w
+	}
+	q.n++
+	return w
+}
+
+// alloc returns an unused waiter
+func (q *waitQueue[T]) alloc() *waiter[T] {
+	w, _ := q.pool.Get().(*waiter[T])
+	if w == nil {
+		w = &waiter[T]{ch: make(chan *T, 1)}
+	}
+	return w
+}
+
+// pop removes the first waiter
+func (q *waitQueue[T]) pop() *waiter[T] {
+	w := q.head
+	if w != nil {
+		q.remove(w)
+	}
+	return w
+}
+
+// remove removes w if it's still queued
+func (q *waitQueue[T]) remove(w *waiter[T]) bool {
+	if !w.queued {
+		return false
+	}
+	if q.last == w {
+		// waiters with priority are queued first, so the previous one has priority as well
+		q.last = w.prev
+	}
+	if w.prev != nil {
+		w.prev.next = w.next
+	} else {
+		q.head = w.next
+	}
+	if w.next != nil {
+		w.next.prev = w.prev
+	} else {
+		q.tail = w.prev
+	}
+	w.prev, w.next, w.queued = nil, nil, false
+	q.n--
+	return true
+}
+
+// release makes w reusable, its channel must be empty
+func (q *waitQueue[T]) release(w *waiter[T]) {
+	if w.ext != nil {
+		w.ext.w, w.ext = nil, nil
+	}
+	q.pool.Put(w)
+}
+
+// chanStore is an idle store based on a channel, used if the pool allows unsafe access to it
+type chanStore[T any] struct {
+	ch chan *T
+}
+
+func newChanStore[T any](capacity int) *chanStore[T] {
+	return &chanStore[T]{ch: make(chan *T, capacity)}
+}
+
+func (s *chanStore[T]) tryGet() (*T, bool) {
+	select {
+	case v := <-s.ch:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+func (s *chanStore[T]) tryGetOldest() (*T, bool) {
+	return s.tryGet()
+}
+
+func (s *chanStore[T]) get(done <-chan struct{}, timeout <-chan time.Time, closed <-chan struct{}) (*T, waitResult) {
+	select {
+	case v := <-s.ch:
+		return v, waitOK
+	case <-done:
+		return nil, waitDone
+	case <-timeout:
+		return nil, waitTimeout
+	case <-closed:
+		return nil, waitClosed
+	}
+}
+
+// getQueued can't use a custom wait queue (see WithWaitQueue)
+func (s *chanStore[T]) getQueued(w *Waiter, done <-chan struct{}, timeout <-chan time.Time, closed <-chan struct{}) (*T, waitResult) {
+	return s.get(done, timeout, closed)
+}
+
+// getFirst can't jump the queue of the channel
+func (s *chanStore[T]) getFirst(done <-chan struct{}, timeout <-chan time.Time, closed <-chan struct{}) (*T, waitResult) {
+	return s.get(done, timeout, closed)
+}
+
+func (s *chanStore[T]) put(v *T) bool {
+	s.ch <- v
+	return true
+}
+
+func (s *chanStore[T]) putAll(vs []*T) int {
+	for i, v := range vs {
+		if !s.tryPut(v) {
+			return i
+		}
+	}
+	return len(vs)
+}
+
+func (s *chanStore[T]) tryPut(v *T) bool {
+	select {
+	case s.ch <- v:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *chanStore[T]) putWait(v *T, done <-chan struct{}) bool {
+	select {
+	case s.ch <- v:
+		return true
+	case <-done:
+		return false
+	}
+}
+
+func (s *chanStore[T]) takeMatch(match func(*T) bool) *T {
+	var found *T
+	s.rotate(func(v *T) bool {
+		if found == nil && match(v) {
+			found = v
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// snapshot can't look into the channel, the idle entries are rotated through it instead (see rotate)
+func (s *chanStore[T]) snapshot() []*T {
+	idle := make([]*T, 0, len(s.ch))
+	seen := make(map[*T]bool, len(s.ch))
+	s.rotate(func(v *T) bool {
+		if !seen[v] {
+			seen[v] = true
+			idle = append(idle, v)
+		}
+		return true
+	})
+	return idle
+}
+
+// rotate takes the idle entries one by one, oldest first, and puts them back at the end of the channel
+// unless fn returns false. The channel ends up in its original order and at most one entry is missing
+// from it at any time, so concurrent gets don't find the pool empty. Entries put concurrently may be
+// passed to fn and concurrent gets may make fn see an entry twice.
+func (s *chanStore[T]) rotate(fn func(v *T) bool) {
+	for n := len(s.ch); n > 0; n-- {
+		v, ok := s.tryGet()
+		if !ok {
+			return
+		}
+		if fn(v) {
+			// can't block for long: the pool never holds more entries than the channel fits
+			s.ch <- v
+		}
+	}
+}
+
+func (s *chanStore[T]) remove(v *T) bool {
+	removed := false
+	s.rotate(func(e *T) bool {
+		if !removed && e == v {
+			removed = true
+			return false
+		}
+		return true
+	})
+	return removed
+}
+
+func (s *chanStore[T]) contains(v *T) bool {
+	return slices.Contains(s.snapshot(), v)
+}
+
+func (s *chanStore[T]) len() int {
+	return len(s.ch)
+}
+
+func (s *chanStore[T]) cap() int {
+	return cap(s.ch)
+}
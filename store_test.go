@@ -0,0 +1,240 @@
+package pool
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStoreOrder(t *testing.T) {
+	for _, lifo := range []bool{false, true} {
+		s := newRingStore[int](3, lifo)
+		a, b, c := new(int), new(int), new(int)
+		for _, v := range []*int{a, b, c} {
+			if !s.put(v) {
+				t.Fatalf("expected put to succeed")
+			}
+		}
+		if s.tryPut(new(int)) {
+			t.Errorf("expected put to fail on a full store")
+		}
+		want := a
+		if lifo {
+			want = c
+		}
+		if v, _ := s.tryGet(); v != want {
+			t.Errorf("lifo=%v: expected %p but got %p", lifo, want, v)
+		}
+		if !s.remove(b) || s.remove(b) {
+			t.Errorf("expected b to be removed once")
+		}
+		if s.len() != 1 {
+			t.Errorf("expected 1 idle entry but got %d", s.len())
+		}
+	}
+}
+
+func TestSlotIndex(t *testing.T) {
+	x := newSlotIndex[int](64)
+	want := map[*int]int{}
+	var keys []*int
+	for i := range 64 {
+		keys = append(keys, new(int))
+		x.set(keys[i], i)
+		want[keys[i]] = i
+	}
+	// deleting keys in the middle of collision chains must keep the keys behind them reachable
+	for i := 0; i < 64; i += 3 {
+		x.del(keys[i])
+		delete(want, keys[i])
+	}
+	for i, v := range keys {
+		slot, ok := x.get(v)
+		if w, found := want[v]; ok != found || slot != w && found {
+			t.Errorf("key %d: expected %d, %v but got %d, %v", i, w, found, slot, ok)
+		}
+	}
+	if _, ok := x.get(new(int)); ok {
+		t.Errorf("expected no slot for an unknown key")
+	}
+}
+
+func TestStoreSnapshot(t *testing.T) {
+	s := newRingStore[int](4, true)
+	for i := range 4 {
+		v := i
+		s.put(&v)
+	}
+	idle := s.snapshot()
+	if len(idle) != 4 || *idle[0] != 0 || *idle[3] != 3 || s.len() != 4 {
+		t.Errorf("expected a snapshot of all entries oldest first")
+	}
+	for _, v := range idle {
+		if *v%2 == 0 && !s.remove(v) {
+			t.Errorf("expected %d to be removed", *v)
+		}
+	}
+	if v, _ := s.tryGetOldest(); *v != 1 {
+		t.Errorf("expected 1 to be the oldest entry but got %d", *v)
+	}
+	// reuses the freed slots
+	for range 3 {
+		if !s.put(new(int)) {
+			t.Errorf("expected put to succeed")
+		}
+	}
+}
+
+func TestChanStoreSnapshot(t *testing.T) {
+	s := newChanStore[int](4)
+	for i := range 4 {
+		v := i
+		s.put(&v)
+	}
+	idle := s.snapshot()
+	if len(idle) != 4 || *idle[0] != 0 || *idle[3] != 3 || s.len() != 4 {
+		t.Errorf("expected a snapshot of all entries oldest first")
+	}
+	if !s.remove(idle[1]) || s.remove(idle[1]) {
+		t.Errorf("expected 1 to be removed once")
+	}
+	// rotating the entries keeps their order
+	for _, expected := range []int{0, 2, 3} {
+		if v, _ := s.tryGet(); *v != expected {
+			t.Errorf("expected %d but got %d", expected, *v)
+		}
+	}
+}
+
+func TestStoreWaiters(t *testing.T) {
+	s := newRingStore[int](1, false)
+	got := []chan *int{make(chan *int, 1), make(chan *int, 1)}
+	for i := range got {
+		go func() {
+			v, _ := s.get(nil, nil, nil)
+			got[i] <- v
+		}()
+		time.Sleep(10 * time.Millisecond)
+	}
+	// timed out waiters leave the queue
+	if _, res := s.get(nil, time.After(time.Millisecond), nil); res != waitTimeout {
+		t.Errorf("expected a timeout but got %v", res)
+	}
+
+	a, b := new(int), new(int)
+	s.put(a)
+	s.put(b)
+	if v := <-got[0]; v != a {
+		t.Errorf("expected the first waiter to get the first entry")
+	}
+	if v := <-got[1]; v != b {
+		t.Errorf("expected the second waiter to get the second entry")
+	}
+	if s.len() != 0 {
+		t.Errorf("expected entries to be handed over directly")
+	}
+}
+
+func TestStoreLenWithoutLock(t *testing.T) {
+	s := newRingStore[int](2, false)
+	s.put(new(int))
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	// doesn't wait for the lock
+	if n := s.len(); n != 1 {
+		t.Errorf("expected 1 idle entry but got %d", n)
+	}
+}
+
+func TestStoreWaiterCancel(t *testing.T) {
+	s := newRingStore[int](1, false)
+	got := []chan *int{make(chan *int, 1), make(chan *int, 1)}
+	cancel := make(chan struct{})
+	canceled := make(chan waitResult)
+	for i := range got {
+		go func() {
+			v, _ := s.get(nil, nil, nil)
+			got[i] <- v
+		}()
+		time.Sleep(10 * time.Millisecond)
+		if i == 0 {
+			// queued between the other waiters
+			go func() {
+				_, res := s.get(cancel, nil, nil)
+				canceled <- res
+			}()
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	if n := s.waiting(); n != 3 {
+		t.Fatalf("expected 3 waiters but got %d", n)
+	}
+	close(cancel)
+	if res := <-canceled; res != waitDone {
+		t.Errorf("expected the canceled get to return but got %v", res)
+	}
+	if n := s.waiting(); n != 2 {
+		t.Errorf("expected the canceled waiter to leave the queue right away but got %d waiters", n)
+	}
+	a, b := new(int), new(int)
+	s.put(a)
+	s.put(b)
+	if <-got[0] != a || <-got[1] != b {
+		t.Errorf("expected the remaining waiters to get the entries in order")
+	}
+}
+
+func TestWaitQueuePriority(t *testing.T) {
+	var q waitQueue[int]
+	a, b, c, d := q.push(false), q.push(true), q.push(true), q.push(false)
+	q.remove(c)
+	e := q.push(true)
+	for i, expected := range []*waiter[int]{b, e, a, d} {
+		if w := q.pop(); w != expected {
+			t.Errorf("expected waiter %d to be served next", i)
+		}
+	}
+	if q.n != 0 || q.head != nil || q.tail != nil || q.last != nil {
+		t.Errorf("expected the queue to be empty")
+	}
+}
+
+func TestAcquireRetry(t *testing.T) {
+	pool := NewPool(1, poolFactory)
+	e := pool.Acquire()
+	got := make(chan string, 2)
+	go func() {
+		v := pool.Acquire()
+		got <- "first"
+		pool.Release(v)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	go func() {
+		v, err := pool.AcquireWithOpts(context.Background(), AcquireOpts{Retry: true})
+		got <- "retry"
+		if err == nil {
+			pool.Release(v)
+		}
+	}()
+	time.Sleep(10 * time.Millisecond)
+	pool.Release(e)
+	if first := <-got; first != "retry" {
+		t.Errorf("expected the retry to be served first but got %s", first)
+	}
+	<-got
+	if stats := pool.Stats(); stats.Retries != 1 {
+		t.Errorf("expected 1 retry but got %d", stats.Retries)
+	}
+}
+
+func TestLIFO(t *testing.T) {
+	pool := NewPool(3, poolFactory, WithLIFO())
+	a := pool.Acquire()
+	pool.Release(a)
+	if b := pool.Acquire(); b != a {
+		t.Errorf("expected the most recently released entry")
+	}
+	if _, err := newPool(1, poolFactory, []Option{WithLIFO(), WithUnsafeAccess()}); err == nil {
+		t.Errorf("expected an error for LIFO order with unsafe access")
+	}
+}
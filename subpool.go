@@ -0,0 +1,94 @@
+package pool
+
+import (
+	"context"
+	"sync"
+)
+
+// SubPool is a view of a pool with its own limit of concurrent checkouts, backed by the entries of the pool.
+// Sub-pools give different code paths different concurrency budgets over one set of resources.
+type SubPool[T any] struct {
+	parent *Pool[T]
+	// holds a token per checkout
+	sem chan struct{}
+
+	mux sync.Mutex
+	// entries acquired using the sub-pool
+	held map[*T]struct{}
+}
+
+// SubPool returns a view of the pool limited to n concurrent checkouts,
+// n is capped by the capacity of the pool unless the pool is unbounded
+func (p *Pool[T]) SubPool(n int) *SubPool[T] {
+	if !p.opts.unbounded {
+		n = min(n, p.Cap())
+	}
+	return &SubPool[T]{parent: p, sem: make(chan struct{}, max(n, 1)), held: map[*T]struct{}{}}
+}
+
+// Returns the pool backing the sub-pool
+func (s *SubPool[T]) Parent() *Pool[T] {
+	return s.parent
+}
+
+// Returns the maximum number of concurrent checkouts
+func (s *SubPool[T]) Cap() int {
+	return cap(s.sem)
+}
+
+// Returns the number of checkouts of the sub-pool, including acquires waiting for the parent pool
+func (s *SubPool[T]) InUse() int {
+	return len(s.sem)
+}
+
+// Acquire acquires an entry of the parent pool once the sub-pool is below its limit, waiting until ctx is done
+func (s *SubPool[T]) Acquire(ctx context.Context) (*T, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	p := s.parent
+	select {
+	case s.sem <- struct{}{}:
+	default:
+		select {
+		case s.sem <- struct{}{}:
+		case <-p.done:
+			return nil, p.wrapErr(opAcquire, ErrPoolClosed)
+		case <-ctx.Done():
+			p.timedOut()
+			return nil, p.wrapErr(opAcquire, ctx.Err())
+		}
+	}
+	v, err := p.AcquireWithContext(ctx)
+	if err != nil {
+		<-s.sem
+		return nil, err
+	}
+	s.mux.Lock()
+	s.held[v] = struct{}{}
+	s.mux.Unlock()
+	return v, nil
+}
+
+// Release releases an entry acquired using the sub-pool to the parent pool
+func (s *SubPool[T]) Release(v *T) error {
+	s.mux.Lock()
+	_, ok := s.held[v]
+	delete(s.held, v)
+	s.mux.Unlock()
+	if !ok {
+		return s.parent.wrapErr(opRelease, ErrFailedToRelease)
+	}
+	defer func() { <-s.sem }()
+	return s.parent.Release(v)
+}
+
+// Run acquires an entry using the sub-pool and runs fn with it
+func (s *SubPool[T]) Run(ctx context.Context, fn func(ctx context.Context, e *T) error) error {
+	v, err := s.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.Release(v)
+	return fn(ctx, v)
+}
@@ -0,0 +1,38 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSubPool(t *testing.T) {
+	pool := NewPool(3, poolFactory)
+	defer pool.Close()
+	sub := pool.SubPool(1)
+	ctx := context.Background()
+
+	a, err := sub.Acquire(ctx)
+	if err != nil || sub.InUse() != 1 {
+		t.Fatalf("expected an entry but got %v", err)
+	}
+	timeout, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	if _, err := sub.Acquire(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v but got %v", context.DeadlineExceeded, err)
+	}
+	// the parent pool isn't limited by the sub-pool
+	if _, ok := pool.TryTakeIdle(); !ok {
+		t.Errorf("expected the parent pool to hand out entries")
+	}
+	if err := sub.Release(new(poolItem)); !errors.Is(err, ErrFailedToRelease) {
+		t.Errorf("expected %v but got %v", ErrFailedToRelease, err)
+	}
+	if err := sub.Release(a); err != nil || sub.InUse() != 0 {
+		t.Errorf("expected the entry to be released but got %v", err)
+	}
+	if pool.SubPool(10).Cap() != 3 {
+		t.Errorf("expected the sub-pool to be capped by the parent")
+	}
+}
@@ -0,0 +1,95 @@
+package pool
+
+import "fmt"
+
+// ErrSwapSelf is returned by SwapWith if a pool is swapped with itself
+var ErrSwapSelf = fmt.Errorf("pool can't be swapped with itself")
+
+// NewStandby creates an empty pool that fills up to size entries in the background, to warm up the next
+// generation of entries (e.g. with a new config) before cutting over to them using SwapWith. Len reports the
+// progress of the warm up, acquires don't wait for it.
+func NewStandby[T any](size int, factoryFunc func() *T, opts ...Option) (*Pool[T], error) {
+	p, err := NewPoolE(size, factoryFunc, append([]Option{WithMinSize(0)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for p.grow() {
+			p.put(p.newEntry())
+		}
+	}()
+	return p, nil
+}
+
+// SwapWith cuts p over to the standby pool other at once: the idle entries of other become entries of p
+// and entries created from now on use the factory function of other. The previous entries of p are an old
+// generation: idle ones get destroyed one by one in the background, entries in use once released. Entries
+// of other beyond the size of p and entries in use get destroyed, other is closed afterwards.
+func (p *Pool[T]) SwapWith(other *Pool[T]) (err error) {
+	defer func() { err = p.wrapErr(opSwap, err) }()
+	if other == p {
+		return ErrSwapSelf
+	}
+	if err := p.lock(); err != nil {
+		return err
+	}
+	defer p.unlock()
+	if p.closed.Load() || other.closed.Load() {
+		return ErrPoolClosed
+	}
+	if err := other.lock(); err != nil {
+		return err
+	}
+	p.generation.Add(1)
+	p.migrating.Store(true)
+	p.factoryFunc.Store(other.factoryFunc.Load())
+	var old []*T
+	for {
+		v, ok := p.idle.tryGet()
+		if !ok {
+			break
+		}
+		old = append(old, v)
+	}
+	// the old entries stop counting against the size right away
+	p.live.Add(-int64(len(old)))
+	p.destroying.Add(int64(len(old)))
+	for p.grow() {
+		v, ok := other.idle.tryGetOldest()
+		if !ok {
+			p.live.Add(-1)
+			break
+		}
+		p.takeOver(other, v)
+	}
+	other.unlock()
+	other.Close()
+	p.updateState()
+	p.refill()
+	go p.retireOld(old)
+	return nil
+}
+
+// takeOver moves the idle entry v of other to p, space must already be reserved in the accounting of p
+func (p *Pool[T]) takeOver(other *Pool[T], v *T) {
+	createdAt := other.meta(v).createdAt
+	other.abandon(v)
+	other.updateState()
+	m := p.newMeta()
+	// the ttl still counts from the creation of the entry
+	m.createdAt = createdAt
+	p.entries.Store(v, m)
+	p.measure(v, m)
+	p.put(v)
+}
+
+// retireOld destroys the idle entries replaced by SwapWith one by one, they're already removed from the
+// accounting apart from being counted as pending destroys
+func (p *Pool[T]) retireOld(old []*T) {
+	for _, v := range old {
+		p.stats.stale.Add(1)
+		p.destroyEntry(v)
+		p.destroying.Add(-1)
+		p.checkDrained()
+	}
+}
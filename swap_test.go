@@ -0,0 +1,76 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSwapWith(t *testing.T) {
+	var destroyed atomic.Int32
+	factory := func(gen string) func() *poolItem {
+		return func() *poolItem {
+			var v poolItem = gen
+			return &v
+		}
+	}
+	pool := NewPool(3, factory("old"), WithDestroyer(func(*poolItem) { destroyed.Add(1) }))
+	inUse := pool.Acquire()
+
+	standby, err := NewStandby(3, factory("new"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for standby.Len() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if standby.Len() != 3 {
+		t.Fatalf("expected the standby pool to be warmed up but got %d entries", standby.Len())
+	}
+
+	if err := pool.SwapWith(standby); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !standby.closed.Load() {
+		t.Errorf("expected the standby pool to be closed")
+	}
+	// the entry in use still counts, so only 2 entries of the standby pool fit
+	if stats := pool.Stats(); stats.Idle != 2 || stats.InUse != 1 {
+		t.Errorf("expected 2 idle entries and 1 in use but got %+v", stats)
+	}
+	for _, e := range []*poolItem{pool.Acquire(), pool.Acquire()} {
+		if *e != "new" {
+			t.Errorf("expected an entry of the standby pool but got %v", *e)
+		}
+		pool.Release(e)
+	}
+	// the old entry in use gets replaced by one of the new factory once released
+	pool.Release(inUse)
+	if e, ok := pool.TryTakeIdle(); !ok || *e != "new" {
+		t.Errorf("expected a new entry to replace the released one")
+	} else {
+		pool.Release(e)
+	}
+	deadline = time.Now().Add(time.Second)
+	for destroyed.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := destroyed.Load(); n != 3 {
+		t.Errorf("expected the 3 old entries to be destroyed but got %d", n)
+	}
+	if f := pool.FactoryFunc(); *f() != "new" {
+		t.Errorf("expected the factory of the standby pool")
+	}
+
+	var perr *PoolError
+	if err := pool.SwapWith(pool); !errors.Is(err, ErrSwapSelf) || !errors.As(err, &perr) || perr.Op != opSwap {
+		t.Errorf("expected ErrSwapSelf wrapped into a PoolError but got %v", err)
+	}
+	pool.Drain(context.Background())
+	if err := pool.SwapWith(NewPool(1, poolFactory)); err == nil {
+		t.Errorf("expected an error for a closed pool")
+	}
+}
@@ -0,0 +1,56 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+var ErrMissingTemplate = fmt.Errorf("missing template")
+
+// TemplatePool is a pool whose entries are clones of a pre-built template (e.g. a Lua state with
+// its libraries preloaded), which is usually much cheaper than building every entry from scratch
+type TemplatePool[T any] struct {
+	*Pool[T]
+	template atomic.Pointer[T]
+	clone    func(*T) *T
+}
+
+// Creates a new pool of size entries produced by cloning template using clone,
+// the call panics if template or clone is missing
+func NewTemplatePool[T any](template *T, clone func(*T) *T, size int, opts ...Option) *TemplatePool[T] {
+	if template == nil {
+		panic(ErrMissingTemplate)
+	}
+	if clone == nil {
+		panic(ErrMissingFactoryFunction)
+	}
+	tp := &TemplatePool[T]{clone: clone}
+	tp.template.Store(template)
+	tp.Pool = NewPool(size, func() *T {
+		return tp.clone(tp.template.Load())
+	}, opts...)
+	return tp
+}
+
+// Returns the template entries are cloned from
+func (tp *TemplatePool[T]) Template() *T {
+	return tp.template.Load()
+}
+
+// RefreshTemplate replaces the template and all entries with clones of the new template (see RefreshAll),
+// the previous template is passed to the destroyer of the pool
+func (tp *TemplatePool[T]) RefreshTemplate(ctx context.Context, template *T) error {
+	if template == nil {
+		return tp.wrapErr(opRefresh, ErrMissingTemplate)
+	}
+	if tp.closed.Load() {
+		return tp.wrapErr(opRefresh, ErrPoolClosed)
+	}
+	old := tp.template.Swap(template)
+	err := tp.RefreshAll(ctx)
+	if old != template {
+		tp.runDestroyer(old)
+	}
+	return err
+}
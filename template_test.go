@@ -0,0 +1,47 @@
+package pool
+
+import (
+	"context"
+	"testing"
+)
+
+type script struct {
+	version int
+	libs    []string
+}
+
+func cloneScript(s *script) *script {
+	c := *s
+	c.libs = append([]string(nil), s.libs...)
+	return &c
+}
+
+func TestTemplatePool(t *testing.T) {
+	var destroyed []*script
+	tmpl := &script{version: 1, libs: []string{"string", "table"}}
+	pool := NewTemplatePool(tmpl, cloneScript, 2, WithDestroyer(func(s *script) {
+		destroyed = append(destroyed, s)
+	}))
+	defer pool.Close()
+
+	held := pool.Acquire()
+	if held == tmpl || held.version != 1 || len(held.libs) != 2 {
+		t.Errorf("expected a clone of the template but got %+v", held)
+	}
+
+	next := &script{version: 2}
+	if err := pool.RefreshTemplate(context.Background(), next); err != nil {
+		t.Errorf("expected no error but got %v", err)
+	}
+	if pool.Template() != next || len(destroyed) != 2 || destroyed[1] != tmpl {
+		t.Errorf("expected idle entry and old template to be destroyed but got %v", destroyed)
+	}
+	if v := pool.Acquire(); v.version != 2 {
+		t.Errorf("expected a clone of the new template but got %+v", v)
+	}
+	// the entry cloned from the old template is replaced once released
+	pool.Release(held)
+	if stats := pool.Stats(); stats.Stale != 1 {
+		t.Errorf("expected 1 stale entry but got %+v", stats)
+	}
+}
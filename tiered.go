@@ -0,0 +1,184 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Tier identifies a class of entries of a TieredPool
+type Tier int
+
+const (
+	// the cheap class of entries (e.g. VMs with a small memory limit)
+	TierLow Tier = iota
+	// the expensive class of entries (e.g. VMs with a large memory limit)
+	TierHigh
+)
+
+func (t Tier) String() string {
+	switch t {
+	case TierLow:
+		return "low"
+	case TierHigh:
+		return "high"
+	}
+	return fmt.Sprintf("Tier(%d)", int(t))
+}
+
+// TierConfig configures a tier of a TieredPool
+type TierConfig[T any] struct {
+	Size    int
+	Factory func() *T
+	Options []Option
+}
+
+// TierPolicy decides which tier serves a workload of a TieredPool
+type TierPolicy struct {
+	// Promote reports whether an error returned by Run in the low tier promotes
+	// the workload to the high tier, every error does if Promote is nil
+	Promote func(err error) bool
+	// DemoteAfter is the time after which promoted workloads are tried in the low tier again,
+	// promoted workloads stay in the high tier if it's 0
+	DemoteAfter time.Duration
+	// Overflow lets acquires of the low tier use an idle entry of the high tier if the low tier has none
+	Overflow bool
+}
+
+// TieredPool serves mixed workloads from two classes of entries (e.g. cheap and expensive ones).
+// Workloads are identified by a key (e.g. a script name) and start in the low tier, those that fail
+// there get promoted to the high tier (see TierPolicy). The number of keys should be bounded.
+type TieredPool[T any] struct {
+	tiers  [2]*Pool[T]
+	policy TierPolicy
+	// workload key -> *atomic.Int64 holding the unix nanoseconds of its promotion, 0 if it's not promoted
+	workloads sync.Map
+
+	promotions atomic.Uint64
+	demotions  atomic.Uint64
+	overflows  atomic.Uint64
+}
+
+// TieredStats are the stats of a TieredPool
+type TieredStats struct {
+	Low  Stats `json:"low"`
+	High Stats `json:"high"`
+	// number of workloads promoted to the high tier
+	Promotions uint64 `json:"promotions"`
+	// number of workloads tried in the low tier again
+	Demotions uint64 `json:"demotions"`
+	// number of low tier acquires served by the high tier
+	Overflows uint64 `json:"overflows"`
+}
+
+// Creates a tiered pool from the configs of its low and high tier
+func NewTieredPool[T any](low, high TierConfig[T], policy TierPolicy) (*TieredPool[T], error) {
+	t := &TieredPool[T]{policy: policy}
+	for i, cfg := range []TierConfig[T]{low, high} {
+		p, err := NewPoolE(cfg.Size, cfg.Factory, cfg.Options...)
+		if err != nil {
+			for _, p := range t.tiers[:i] {
+				p.Close()
+			}
+			return nil, fmt.Errorf("%s tier: %w", Tier(i), err)
+		}
+		t.tiers[i] = p
+	}
+	return t, nil
+}
+
+// Returns the pool of a tier
+func (t *TieredPool[T]) Tier(tier Tier) *Pool[T] {
+	return t.tiers[tier]
+}
+
+// TierOf returns the tier serving the workload with the given key
+func (t *TieredPool[T]) TierOf(key string) Tier {
+	w, ok := t.workloads.Load(key)
+	if !ok {
+		return TierLow
+	}
+	promoted := w.(*atomic.Int64)
+	at := promoted.Load()
+	if at == 0 {
+		return TierLow
+	}
+	if d := t.policy.DemoteAfter; d > 0 && time.Since(time.Unix(0, at)) >= d {
+		if promoted.CompareAndSwap(at, 0) {
+			t.demotions.Add(1)
+		}
+		return TierLow
+	}
+	return TierHigh
+}
+
+// Promote moves the workload with the given key to the high tier
+func (t *TieredPool[T]) Promote(key string) {
+	w, _ := t.workloads.LoadOrStore(key, new(atomic.Int64))
+	if w.(*atomic.Int64).Swap(time.Now().UnixNano()) == 0 {
+		t.promotions.Add(1)
+	}
+}
+
+// AcquireTier acquires an entry of the given tier waiting until ctx is done, the tier that
+// served the acquire is returned as well (see TierPolicy.Overflow)
+func (t *TieredPool[T]) AcquireTier(ctx context.Context, tier Tier) (*T, Tier, error) {
+	p := t.tiers[tier]
+	if v, ok := p.tryAcquire(ctx); ok {
+		return v, tier, nil
+	}
+	if tier == TierLow && t.policy.Overflow {
+		if v, ok := t.tiers[TierHigh].TryTakeIdle(); ok {
+			t.overflows.Add(1)
+			return v, TierHigh, nil
+		}
+	}
+	v, err := p.AcquireWithContext(ctx)
+	return v, tier, err
+}
+
+// Acquire acquires an entry of the tier serving the workload with the given key
+func (t *TieredPool[T]) Acquire(ctx context.Context, key string) (*T, Tier, error) {
+	return t.AcquireTier(ctx, t.TierOf(key))
+}
+
+// Releases an entry to the tier it belongs to
+func (t *TieredPool[T]) Release(v *T) error {
+	if _, ok := t.tiers[TierHigh].lookup(v); ok {
+		return t.tiers[TierHigh].Release(v)
+	}
+	return t.tiers[TierLow].Release(v)
+}
+
+// Run acquires an entry for the workload with the given key and runs fn with it,
+// errors of the low tier promote the workload according to the policy
+func (t *TieredPool[T]) Run(ctx context.Context, key string, fn func(e *T) error) error {
+	v, tier, err := t.Acquire(ctx, key)
+	if err != nil {
+		return err
+	}
+	err = fn(v)
+	t.Release(v)
+	if err != nil && tier == TierLow && (t.policy.Promote == nil || t.policy.Promote(err)) {
+		t.Promote(key)
+	}
+	return err
+}
+
+func (t *TieredPool[T]) Stats() TieredStats {
+	return TieredStats{
+		Low:        t.tiers[TierLow].Stats(),
+		High:       t.tiers[TierHigh].Stats(),
+		Promotions: t.promotions.Load(),
+		Demotions:  t.demotions.Load(),
+		Overflows:  t.overflows.Load(),
+	}
+}
+
+// Close closes both tiers
+func (t *TieredPool[T]) Close() error {
+	t.tiers[TierLow].Close()
+	return t.tiers[TierHigh].Close()
+}
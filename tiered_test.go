@@ -0,0 +1,62 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTieredPool(t *testing.T) {
+	errTooLarge := errors.New("memory limit exceeded")
+	pool, err := NewTieredPool(
+		TierConfig[poolItem]{Size: 1, Factory: poolFactory},
+		TierConfig[poolItem]{Size: 1, Factory: poolFactory},
+		TierPolicy{Promote: func(err error) bool { return errors.Is(err, errTooLarge) }, DemoteAfter: 20 * time.Millisecond, Overflow: true},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer pool.Close()
+	ctx := context.Background()
+
+	// unrelated errors don't promote a workload
+	pool.Run(ctx, "big", func(e *poolItem) error { return errors.New("syntax error") })
+	if tier := pool.TierOf("big"); tier != TierLow {
+		t.Errorf("expected %v but got %v", TierLow, tier)
+	}
+	if err := pool.Run(ctx, "big", func(e *poolItem) error { return errTooLarge }); err != errTooLarge {
+		t.Errorf("expected %v but got %v", errTooLarge, err)
+	}
+	if tier := pool.TierOf("big"); tier != TierHigh {
+		t.Errorf("expected %v but got %v", TierHigh, tier)
+	}
+	// the entry of the small workload is kept to exhaust the low tier
+	var served Tier
+	pool.Run(ctx, "big", func(e *poolItem) error {
+		_, served, _ = pool.Acquire(ctx, "small")
+		return nil
+	})
+	if served != TierLow {
+		t.Errorf("expected the small workload to be served by the %v tier but got %v", TierLow, served)
+	}
+
+	// the low tier is exhausted, acquires overflow to the high tier
+	v, served, err := pool.AcquireTier(ctx, TierLow)
+	if err != nil || served != TierHigh {
+		t.Errorf("expected an overflow to the %v tier but got %v, %v", TierHigh, served, err)
+	}
+	pool.Release(v)
+	if stats := pool.Stats(); stats.High.Idle != 1 || stats.Low.InUse != 1 || stats.Promotions != 1 || stats.Overflows != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if tier := pool.TierOf("big"); tier != TierLow || pool.Stats().Demotions != 1 {
+		t.Errorf("expected the workload to be demoted but got %v", tier)
+	}
+
+	if _, err := NewTieredPool(TierConfig[poolItem]{Size: 1, Factory: poolFactory}, TierConfig[poolItem]{Size: 1}, TierPolicy{}); !errors.Is(err, ErrMissingFactoryFunction) {
+		t.Errorf("expected %v but got %v", ErrMissingFactoryFunction, err)
+	}
+}
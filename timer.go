@@ -0,0 +1,31 @@
+package pool
+
+import (
+	"sync"
+	"time"
+)
+
+// timerPool recycles timers used by blocking acquires, time.After would keep
+// a timer alive for the full duration even if an entry was received early
+var timerPool sync.Pool
+
+// acquireTimer returns a started timer that fires after d
+func acquireTimer(d time.Duration) *time.Timer {
+	if t, ok := timerPool.Get().(*time.Timer); ok {
+		t.Reset(d)
+		return t
+	}
+	return time.NewTimer(d)
+}
+
+// releaseTimer stops t and puts it back for reuse, t must not be used afterwards
+func releaseTimer(t *time.Timer) {
+	if !t.Stop() {
+		// drain the channel if the timer already fired and nobody received the value
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	timerPool.Put(t)
+}
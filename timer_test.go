@@ -0,0 +1,49 @@
+package pool
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimerReuse(t *testing.T) {
+	tm := acquireTimer(time.Millisecond)
+	<-tm.C
+	releaseTimer(tm)
+
+	// a reused timer must not deliver a stale value
+	tm = acquireTimer(time.Hour)
+	select {
+	case <-tm.C:
+		t.Errorf("unexpected stale timer value")
+	case <-time.After(10 * time.Millisecond):
+	}
+	releaseTimer(tm)
+
+	// releasing a fired but unreceived timer must drain it
+	tm = acquireTimer(time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	releaseTimer(tm)
+	tm = acquireTimer(time.Hour)
+	select {
+	case <-tm.C:
+		t.Errorf("unexpected stale timer value")
+	default:
+	}
+	releaseTimer(tm)
+}
+
+func TestAcquireWithTimeoutAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("timers aren't reliably reused with the race detector enabled")
+	}
+	pool := NewPool(0, poolFactory)
+	allocs := testing.AllocsPerRun(20, func() {
+		if _, err := pool.AcquireWithTimeout(time.Microsecond); !errors.Is(err, ErrAcquireTimeout) {
+			t.Errorf("expected %v but got %v", ErrAcquireTimeout, err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("expected 0 allocations but got %v", allocs)
+	}
+}
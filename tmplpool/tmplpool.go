@@ -0,0 +1,187 @@
+// Package tmplpool pools parsed html/template sets that get reparsed when their files change
+//
+// The templates are parsed once into a master set, entries of the pool are clones of it that callers
+// may extend (e.g. using Funcs or AddParseTree) without affecting each other. Refresh reparses the files
+// and replaces all sets using the generational refresh of the pool (see pool.RefreshAll), sets in use
+// keep rendering with the previous templates until they are released. Watch triggers Refresh when the
+// files change by polling the modification time and size of every file, which works with any fs.FS.
+// The tmplwatch module calls Refresh on fsnotify events instead, for templates on the local disk:
+//
+//	p, err := tmplpool.New(tmplpool.Options{FS: os.DirFS("templates"), Patterns: []string{"*.html"}, Size: 8})
+//	...
+//	go p.Watch(ctx, time.Second, nil)
+//	err = p.Execute(ctx, w, "index.html", data)
+package tmplpool
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"io"
+	"io/fs"
+	"maps"
+	"sync/atomic"
+	"time"
+
+	"github.com/epikur-io/go-pool"
+)
+
+// Options of a template pool
+type Options struct {
+	// FS holds the template files (required)
+	FS fs.FS
+	// Patterns select the template files (see template.ParseFS, required)
+	Patterns []string
+	// Funcs are added to the templates before they are parsed (optional)
+	Funcs template.FuncMap
+	// Size is the number of template sets (required)
+	Size int
+}
+
+// Set is a pooled template set
+type Set struct {
+	*template.Template
+}
+
+// Pool is a pool of template sets
+type Pool struct {
+	opts Options
+	// set the entries are cloned from
+	master atomic.Pointer[template.Template]
+	// state of the files the master set was parsed from
+	files atomic.Pointer[fileState]
+	pool  *pool.Pool[Set]
+}
+
+// Creates a pool of template sets, opts configure the underlying pool
+func New(o Options, opts ...pool.Option) (*Pool, error) {
+	if o.FS == nil || len(o.Patterns) == 0 {
+		return nil, errors.New("tmplpool: missing file system or patterns")
+	}
+	p := &Pool{opts: o}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	sets, err := pool.NewPoolFromConfig(pool.Config{Size: o.Size}, p.clone, opts...)
+	if err != nil {
+		return nil, err
+	}
+	p.pool = sets
+	return p, nil
+}
+
+// parse parses the template files into the master set
+func (p *Pool) parse() error {
+	// taken before parsing to not miss changes made in the meantime
+	state, err := p.modified()
+	if err != nil {
+		return err
+	}
+	master, err := template.New("").Funcs(p.opts.Funcs).ParseFS(p.opts.FS, p.opts.Patterns...)
+	if err != nil {
+		return err
+	}
+	p.master.Store(master)
+	p.files.Store(&state)
+	return nil
+}
+
+// clone is the factory of the pool, the master set is never executed so it can always be cloned
+func (p *Pool) clone() *Set {
+	return &Set{Template: template.Must(p.master.Load().Clone())}
+}
+
+// Returns the pool holding the cloned sets, Refresh replaces them all
+func (p *Pool) Pool() *pool.Pool[Set] {
+	return p.pool
+}
+
+// Acquire acquires a template set waiting until ctx is done
+func (p *Pool) Acquire(ctx context.Context) (*Set, error) {
+	return p.pool.AcquireWithContext(ctx)
+}
+
+// Releases a template set to the pool
+func (p *Pool) Release(s *Set) error {
+	return p.pool.Release(s)
+}
+
+// Execute renders the template name of a pooled set to w
+func (p *Pool) Execute(ctx context.Context, w io.Writer, name string, data any) error {
+	s, err := p.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer p.Release(s)
+	return s.ExecuteTemplate(w, name, data)
+}
+
+// Refresh reparses the template files and replaces all sets, the current templates
+// stay in use if parsing fails
+func (p *Pool) Refresh(ctx context.Context) error {
+	if err := p.parse(); err != nil {
+		return err
+	}
+	return p.pool.RefreshAll(ctx)
+}
+
+// Watch polls the template files every interval (see the tmplwatch module for fsnotify) and calls Refresh
+// once a file was modified (its modification time or size changed), added or removed until ctx is done,
+// failed refreshes are passed to onError (may be nil)
+func (p *Pool) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	// the last state that failed to refresh
+	var failed fileState
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		state, err := p.modified()
+		if err == nil && (maps.Equal(state, *p.files.Load()) || (failed != nil && maps.Equal(state, failed))) {
+			continue
+		}
+		if err == nil {
+			if err = p.Refresh(ctx); err != nil {
+				failed = state
+			}
+		}
+		if err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+// fileState is the modification time and size of the template files by path, to detect changes
+type fileState map[string]fileInfo
+
+type fileInfo struct {
+	modTime int64
+	size    int64
+}
+
+// modified returns the modification time and size of every template file
+func (p *Pool) modified() (fileState, error) {
+	state := fileState{}
+	for _, pattern := range p.opts.Patterns {
+		files, err := fs.Glob(p.opts.FS, pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			fi, err := fs.Stat(p.opts.FS, f)
+			if err != nil {
+				return nil, err
+			}
+			state[f] = fileInfo{modTime: fi.ModTime().UnixNano(), size: fi.Size()}
+		}
+	}
+	return state, nil
+}
+
+// Close closes the pool
+func (p *Pool) Close() error {
+	return p.pool.Close()
+}
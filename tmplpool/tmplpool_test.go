@@ -0,0 +1,118 @@
+package tmplpool
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTemplate(t *testing.T, dir, content string) {
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte(content), 0o644); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+}
+
+func render(t *testing.T, p *Pool) string {
+	var b bytes.Buffer
+	if err := p.Execute(context.Background(), &b, "index.html", "epikur"); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	return b.String()
+}
+
+func TestRefresh(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "Hello {{.}}")
+	p, err := New(Options{FS: os.DirFS(dir), Patterns: []string{"*.html"}, Size: 2})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	defer p.Close()
+	if out := render(t, p); out != "Hello epikur" {
+		t.Errorf("expected rendered template but got %q", out)
+	}
+
+	held, _ := p.Acquire(context.Background())
+	writeTemplate(t, dir, "Hi {{.}}")
+	if err := p.Refresh(context.Background()); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	var b bytes.Buffer
+	held.ExecuteTemplate(&b, "index.html", "epikur")
+	if b.String() != "Hello epikur" {
+		t.Errorf("expected the set in use to keep its templates but got %q", b.String())
+	}
+	p.Release(held)
+	for i := 0; i < 3; i++ {
+		if out := render(t, p); out != "Hi epikur" {
+			t.Errorf("expected refreshed template but got %q", out)
+		}
+	}
+
+	writeTemplate(t, dir, "{{.")
+	if err := p.Refresh(context.Background()); err == nil {
+		t.Errorf("expected parse error")
+	}
+	if out := render(t, p); out != "Hi epikur" {
+		t.Errorf("expected the previous templates to stay in use but got %q", out)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "Hello {{.}}")
+	p, err := New(Options{FS: os.DirFS(dir), Patterns: []string{"*.html"}, Size: 1})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	defer p.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.Watch(ctx, 5*time.Millisecond, nil)
+
+	writeTemplate(t, dir, "Hi {{.}}")
+	// make sure the modification time changes on file systems with a coarse resolution
+	later := time.Now().Add(time.Second)
+	os.Chtimes(filepath.Join(dir, "index.html"), later, later)
+	deadline := time.Now().Add(time.Second)
+	for render(t, p) != "Hi epikur" {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the templates to be refreshed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWatchOlderFile(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, `{{template "greeting" .}}`)
+	greeting := filepath.Join(dir, "greeting.html")
+	if err := os.WriteFile(greeting, []byte(`{{define "greeting"}}Hello {{.}}{{end}}`), 0o644); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	// index.html stays the newest file while greeting.html changes
+	newest := time.Now().Add(time.Hour)
+	os.Chtimes(filepath.Join(dir, "index.html"), newest, newest)
+	p, err := New(Options{FS: os.DirFS(dir), Patterns: []string{"*.html"}, Size: 1})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	defer p.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.Watch(ctx, 5*time.Millisecond, nil)
+
+	if err := os.WriteFile(greeting, []byte(`{{define "greeting"}}Hi {{.}}{{end}}`), 0o644); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for render(t, p) != "Hi epikur" {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the templates to be refreshed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
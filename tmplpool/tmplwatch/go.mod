@@ -0,0 +1,15 @@
+module github.com/epikur-io/go-pool/tmplpool/tmplwatch
+
+go 1.24
+
+require (
+	github.com/epikur-io/go-pool v0.0.0
+	github.com/fsnotify/fsnotify v1.9.0
+)
+
+require (
+	golang.org/x/sync v0.11.0 // indirect
+	golang.org/x/sys v0.13.0 // indirect
+)
+
+replace github.com/epikur-io/go-pool => ../../
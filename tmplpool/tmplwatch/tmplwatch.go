@@ -0,0 +1,63 @@
+// Package tmplwatch refreshes a tmplpool.Pool once its template files change, using fsnotify instead of
+// polling the files like tmplpool.Pool.Watch:
+//
+//	p, err := tmplpool.New(tmplpool.Options{FS: os.DirFS("templates"), Patterns: []string{"*.html"}, Size: 8})
+//	...
+//	go tmplwatch.Watch(ctx, p, []string{"templates"}, 50*time.Millisecond, nil)
+//
+// It's a module of its own, so fsnotify isn't a dependency of the pool module.
+package tmplwatch
+
+import (
+	"context"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/epikur-io/go-pool/tmplpool"
+)
+
+// Watch calls p.Refresh once files in dirs (the directories backing the file system of p) are written,
+// created, removed or renamed until ctx is done. Events within delay of each other are coalesced into one
+// refresh, editors often write a file in several steps. Errors of the watcher and failed refreshes are
+// passed to onError (may be nil), an error is only returned if dirs can't be watched.
+func Watch(ctx context.Context, p *tmplpool.Pool, dirs []string, delay time.Duration, onError func(error)) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	for _, dir := range dirs {
+		if err := w.Add(dir); err != nil {
+			return err
+		}
+	}
+	report := func(err error) {
+		if err != nil && onError != nil {
+			onError(err)
+		}
+	}
+	t := time.NewTimer(delay)
+	t.Stop()
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if e.Has(fsnotify.Write) || e.Has(fsnotify.Create) || e.Has(fsnotify.Remove) || e.Has(fsnotify.Rename) {
+				t.Reset(delay)
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			report(err)
+		case <-t.C:
+			report(p.Refresh(ctx))
+		}
+	}
+}
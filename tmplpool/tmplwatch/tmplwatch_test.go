@@ -0,0 +1,59 @@
+package tmplwatch
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/epikur-io/go-pool/tmplpool"
+)
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	index := filepath.Join(dir, "index.html")
+	if err := os.WriteFile(index, []byte("Hello {{.}}"), 0o644); err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	p, err := tmplpool.New(tmplpool.Options{FS: os.DirFS(dir), Patterns: []string{"*.html"}, Size: 1})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	defer p.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watching := make(chan error, 1)
+	go func() {
+		watching <- Watch(ctx, p, []string{dir}, 10*time.Millisecond, func(err error) { t.Errorf("expected no error but got %v", err) })
+	}()
+
+	render := func() string {
+		var buf bytes.Buffer
+		if err := p.Execute(ctx, &buf, "index.html", "epikur"); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		return buf.String()
+	}
+	// the modification time doesn't matter, the write event triggers the refresh (written again until
+	// then in case the watcher wasn't set up yet)
+	deadline := time.Now().Add(5 * time.Second)
+	for render() != "Hi epikur" {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the templates to be refreshed")
+		}
+		if err := os.WriteFile(index, []byte("Hi {{.}}"), 0o644); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	cancel()
+	if err := <-watching; err != nil {
+		t.Errorf("expected no error but got %v", err)
+	}
+
+	if err := Watch(context.Background(), p, []string{filepath.Join(dir, "missing")}, time.Millisecond, nil); err == nil {
+		t.Errorf("expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,78 @@
+package pool
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+)
+
+var ErrInvalidTotals = fmt.Errorf("invalid totals")
+
+// totalsVersion is the version of the format written by ExportTotals
+const totalsVersion = 1
+
+// exportedTotals is the format of ExportTotals
+type exportedTotals struct {
+	Version int               `json:"version"`
+	Totals  map[string]uint64 `json:"totals"`
+}
+
+// totals returns the cumulative counters of the pool by the name of their field in the JSON encoding of Stats
+func (p *Pool[T]) totals() map[string]*atomic.Uint64 {
+	return map[string]*atomic.Uint64{
+		"acquired":          &p.stats.acquired,
+		"released":          &p.stats.released,
+		"created":           &p.stats.created,
+		"destroyed":         &p.stats.destroyed,
+		"expired":           &p.stats.expired,
+		"invalid":           &p.stats.invalid,
+		"unhealthy":         &p.stats.unhealthy,
+		"repaired":          &p.stats.repaired,
+		"budget_refreshes":  &p.stats.budgetRefreshes,
+		"stale":             &p.stats.stale,
+		"rejected":          &p.stats.rejected,
+		"cost_evicted":      &p.stats.costEvicted,
+		"hook_timeouts":     &p.stats.hookTimeouts,
+		"saturated":         &p.stats.saturated,
+		"affinity_hits":     &p.stats.affinityHits,
+		"release_overflows": &p.stats.releaseOverflows,
+		"timeouts":          &p.stats.timeouts,
+		"holds_expired":     &p.stats.holdsExpired,
+		"reclaimed":         &p.stats.reclaimed,
+		"hijacked":          &p.stats.hijacked,
+		"invalid_releases":  &p.stats.invalidReleases,
+		"leaked":            &p.stats.leaked,
+		"spin_hits":         &p.stats.spinHits,
+		"shed":              &p.stats.shed,
+		"retries":           &p.stats.retries,
+	}
+}
+
+// ExportTotals encodes the cumulative counters of Stats (e.g. Created and Timeouts) as JSON, to be passed to
+// ImportTotals after a restart so long-term counters of capacity planning dashboards don't start over
+func (p *Pool[T]) ExportTotals() ([]byte, error) {
+	e := exportedTotals{Version: totalsVersion, Totals: map[string]uint64{}}
+	for name, c := range p.totals() {
+		e.Totals[name] = c.Load()
+	}
+	return json.Marshal(e)
+}
+
+// ImportTotals adds the counters exported by ExportTotals to the counters of the pool, counters unknown to
+// this version of the pool are ignored. Nothing is imported if b isn't valid.
+func (p *Pool[T]) ImportTotals(b []byte) error {
+	var e exportedTotals
+	if err := json.Unmarshal(b, &e); err != nil {
+		return p.wrapErr(opImportTotals, fmt.Errorf("%w: %v", ErrInvalidTotals, err))
+	}
+	if e.Version != totalsVersion {
+		return p.wrapErr(opImportTotals, fmt.Errorf("%w: unsupported version %d", ErrInvalidTotals, e.Version))
+	}
+	counters := p.totals()
+	for name, n := range e.Totals {
+		if c, ok := counters[name]; ok {
+			c.Add(n)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package pool
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExportTotals(t *testing.T) {
+	pool := NewPool(2, poolFactory)
+	pool.Release(pool.Acquire())
+	b, err := pool.ExportTotals()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// counters of the restarted pool add up with the imported ones
+	restarted := NewPool(2, poolFactory)
+	if err := restarted.ImportTotals(b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats := restarted.Stats(); stats.Created != 4 || stats.Acquired != 1 || stats.Released != 1 || stats.InUse != 0 {
+		t.Errorf("expected the imported totals to be added but got %+v", stats)
+	}
+
+	for _, b := range []string{"", "{", `{"version":2,"totals":{"created":1}}`} {
+		if err := restarted.ImportTotals([]byte(b)); !errors.Is(err, ErrInvalidTotals) {
+			t.Errorf("expected ErrInvalidTotals for %q but got %v", b, err)
+		}
+	}
+	if err := restarted.ImportTotals([]byte(`{"version":1,"totals":{"unknown":1,"timeouts":3}}`)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if stats := restarted.Stats(); stats.Created != 4 || stats.Timeouts != 3 {
+		t.Errorf("expected unknown counters to be ignored but got %+v", stats)
+	}
+}
@@ -0,0 +1,143 @@
+package pool
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TraceOp is the kind of a TraceEvent
+type TraceOp int
+
+const (
+	TraceCreate TraceOp = iota
+	TraceAcquire
+	TraceRelease
+	TraceValidate
+	TraceReset
+)
+
+func (op TraceOp) String() string {
+	switch op {
+	case TraceCreate:
+		return "create"
+	case TraceAcquire:
+		return "acquire"
+	case TraceRelease:
+		return "release"
+	case TraceValidate:
+		return "validate"
+	case TraceReset:
+		return "reset"
+	}
+	return fmt.Sprintf("TraceOp(%d)", int(op))
+}
+
+func (op TraceOp) MarshalText() ([]byte, error) {
+	return []byte(op.String()), nil
+}
+
+// UnmarshalText decodes the name of an operation
+func (op *TraceOp) UnmarshalText(text []byte) error {
+	for _, o := range []TraceOp{TraceCreate, TraceAcquire, TraceRelease, TraceValidate, TraceReset} {
+		if o.String() == string(text) {
+			*op = o
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown trace op %q", text)
+}
+
+// TraceEvent is an event of the lifecycle of an entry (see WithLifecycleTrace)
+type TraceEvent struct {
+	Op TraceOp   `json:"op"`
+	At time.Time `json:"at"`
+	// goroutine that caused the event
+	Goroutine int64 `json:"goroutine"`
+	// error of a failed validation or reset
+	Err string `json:"err,omitempty"`
+}
+
+// EntryTrace is the lifecycle trace of an entry
+type EntryTrace struct {
+	// address of the entry
+	Entry  string       `json:"entry"`
+	Info   EntryInfo    `json:"info"`
+	Events []TraceEvent `json:"events"`
+}
+
+// WithLifecycleTrace records the last n lifecycle events (create, acquire, release, validate and reset)
+// of every entry with their time and goroutine, to debug which code path corrupted an entry. The trace
+// is available using Trace and Traces (and the admin handler). Identifying goroutines uses their stack
+// trace, which makes acquiring and releasing more expensive.
+func WithLifecycleTrace(n int) Option {
+	return func(o *options) {
+		o.traceSize = n
+	}
+}
+
+// traceRing holds the most recent lifecycle events of an entry
+type traceRing struct {
+	mux    sync.Mutex
+	events []TraceEvent
+	// total number of recorded events
+	n int
+}
+
+func (r *traceRing) add(e TraceEvent) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if len(r.events) < cap(r.events) {
+		r.events = append(r.events, e)
+	} else {
+		r.events[r.n%cap(r.events)] = e
+	}
+	r.n++
+}
+
+// list returns the events oldest first
+func (r *traceRing) list() []TraceEvent {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	i := r.n % cap(r.events)
+	if len(r.events) < cap(r.events) {
+		i = 0
+	}
+	return append(append([]TraceEvent(nil), r.events[i:]...), r.events[:i]...)
+}
+
+// trace records a lifecycle event of the entry of m, err is the error of a failed validation or reset
+func (p *Pool[T]) trace(m *entryMeta, op TraceOp, err error) {
+	if m.trace == nil {
+		return
+	}
+	e := TraceEvent{Op: op, At: time.Now(), Goroutine: goid()}
+	if err != nil {
+		e.Err = err.Error()
+	}
+	m.trace.add(e)
+}
+
+// Trace returns the recorded lifecycle events of the entry v oldest first, nil unless the pool was created
+// WithLifecycleTrace or if v isn't an entry of the pool (anymore)
+func (p *Pool[T]) Trace(v *T) []TraceEvent {
+	m, ok := p.lookup(v)
+	if !ok || m.trace == nil {
+		return nil
+	}
+	return m.trace.list()
+}
+
+// Traces returns the lifecycle traces of all entries of the pool, nil unless the pool was created WithLifecycleTrace
+func (p *Pool[T]) Traces() []EntryTrace {
+	if p.opts.traceSize <= 0 {
+		return nil
+	}
+	var traces []EntryTrace
+	p.entries.Range(func(v, meta any) bool {
+		m := meta.(*entryMeta)
+		traces = append(traces, EntryTrace{Entry: fmt.Sprintf("%p", v), Info: p.describe(v.(*T), m), Events: m.trace.list()})
+		return true
+	})
+	return traces
+}
@@ -0,0 +1,35 @@
+package pool
+
+import "testing"
+
+func TestLifecycleTrace(t *testing.T) {
+	bad := false
+	pool := NewPool(1, poolFactory, WithLifecycleTrace(3), WithValidator(func(e *poolItem) bool { return !bad }))
+	defer pool.Close()
+	e := pool.Acquire()
+	pool.Release(e)
+	pool.Release(pool.Acquire())
+
+	trace := pool.Trace(e)
+	if len(trace) != 3 {
+		t.Fatalf("expected the last 3 events but got %+v", trace)
+	}
+	// create, acquire, release, validate, acquire, release
+	for i, op := range []TraceOp{TraceValidate, TraceAcquire, TraceRelease} {
+		if trace[i].Op != op || trace[i].Goroutine == 0 {
+			t.Errorf("expected event %d to be %v but got %+v", i, op, trace[i])
+		}
+	}
+	if traces := pool.Traces(); len(traces) != 1 || len(traces[0].Events) != 3 {
+		t.Errorf("expected the trace of 1 entry but got %+v", traces)
+	}
+
+	bad = true
+	pool.Release(pool.Acquire())
+	if trace := pool.Trace(e); trace != nil {
+		t.Errorf("expected the invalid entry to be destroyed but got %+v", trace)
+	}
+	if NewPool(1, poolFactory).Traces() != nil {
+		t.Errorf("expected no traces without WithLifecycleTrace")
+	}
+}
@@ -0,0 +1,188 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+)
+
+var (
+	ErrTxDone       = fmt.Errorf("transaction already finished")
+	ErrNotInTx      = fmt.Errorf("entry wasn't taken in this transaction")
+	ErrTxRolledBack = fmt.Errorf("transaction rolled back")
+)
+
+// Tx is a restricted view of the pool passed to the function run by Transaction
+type Tx[T any] struct {
+	pool *Pool[T]
+	ctx  context.Context
+	// idle entries taken in the transaction
+	taken []*T
+	// taken entry -> replacement
+	replaced map[*T]*T
+	done     bool
+}
+
+// Transaction runs fn holding the lock of the pool (see LockedRun), so it doesn't overlap with Resize,
+// ApplyConfig, SwapWith or other transactions. Acquires, releases and maintenance keep running: only the
+// entries taken in fn are exclusive to it, idle entries it didn't take yet may get acquired or destroyed
+// (e.g. by the reaper) concurrently and released entries may show up.
+// Entries taken in fn are returned to the pool once fn returns and replacements are applied.
+// If fn fails, panics or ctx is done before fn returns, all replacements are discarded
+// and the taken entries are returned unchanged.
+func (p *Pool[T]) Transaction(ctx context.Context, fn func(tx *Tx[T]) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := p.lock(); err != nil {
+		return p.wrapErr(opTransaction, err)
+	}
+	defer p.unlock()
+	if p.closed.Load() {
+		return p.wrapErr(opTransaction, ErrPoolClosed)
+	}
+	tx := &Tx[T]{pool: p, ctx: ctx}
+	committed := false
+	defer func() {
+		if !committed {
+			tx.rollback()
+		}
+	}()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return p.wrapErr(opTransaction, fmt.Errorf("%w: %w", ErrTxRolledBack, err))
+	}
+	committed = true
+	tx.commit()
+	return nil
+}
+
+// Returns the context of the transaction
+func (tx *Tx[T]) Context() context.Context {
+	return tx.ctx
+}
+
+// TakeIdle takes an idle entry out of the pool for the duration of the transaction,
+// waiting until an entry gets released or the context of the transaction is done
+func (tx *Tx[T]) TakeIdle() (*T, error) {
+	p := tx.pool
+	if tx.done {
+		return nil, p.wrapErr(opTransaction, ErrTxDone)
+	}
+	for {
+		v, res := p.idle.get(tx.ctx.Done(), nil, p.done)
+		switch res {
+		case waitClosed:
+			return nil, p.wrapErr(opTransaction, ErrPoolClosed)
+		case waitDone:
+			return nil, p.wrapErr(opTransaction, tx.ctx.Err())
+		}
+		if !p.checkout(v) {
+			continue
+		}
+		tx.taken = append(tx.taken, v)
+		return v, nil
+	}
+}
+
+// TryTakeIdle is like TakeIdle but doesn't wait, false is returned if no entry is idle
+func (tx *Tx[T]) TryTakeIdle() (*T, bool) {
+	if tx.done {
+		return nil, false
+	}
+	p := tx.pool
+	for {
+		v, ok := p.idle.tryGet()
+		if !ok {
+			return nil, false
+		}
+		if p.checkout(v) {
+			tx.taken = append(tx.taken, v)
+			return v, true
+		}
+	}
+}
+
+// PutBack returns the taken entry v to the pool right away, a pending replacement of v is discarded
+func (tx *Tx[T]) PutBack(v *T) error {
+	if tx.done {
+		return tx.pool.wrapErr(opTransaction, ErrTxDone)
+	}
+	i := tx.index(v)
+	if i < 0 {
+		return tx.pool.wrapErr(opTransaction, ErrNotInTx)
+	}
+	tx.taken = append(tx.taken[:i], tx.taken[i+1:]...)
+	if nv, ok := tx.replaced[v]; ok {
+		delete(tx.replaced, v)
+		tx.pool.discardReplacement(nv)
+	}
+	tx.pool.put(v)
+	return nil
+}
+
+// ReplaceWith replaces the taken entry v with nv once the transaction succeeds,
+// if nv is nil a new entry is created using the factory function
+func (tx *Tx[T]) ReplaceWith(v *T, nv *T) error {
+	if tx.done {
+		return tx.pool.wrapErr(opTransaction, ErrTxDone)
+	}
+	if tx.index(v) < 0 {
+		return tx.pool.wrapErr(opTransaction, ErrNotInTx)
+	}
+	if nv == nil {
+		nv = tx.pool.construct()
+	}
+	if tx.replaced == nil {
+		tx.replaced = map[*T]*T{}
+	}
+	if prev, ok := tx.replaced[v]; ok {
+		tx.pool.discardReplacement(prev)
+	}
+	tx.replaced[v] = nv
+	return nil
+}
+
+// index returns the position of v in the taken entries or -1
+func (tx *Tx[T]) index(v *T) int {
+	for i, e := range tx.taken {
+		if e == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// commit applies the replacements and returns all taken entries
+func (tx *Tx[T]) commit() {
+	tx.done = true
+	p := tx.pool
+	for _, v := range tx.taken {
+		nv, ok := tx.replaced[v]
+		if !ok {
+			p.put(v)
+			continue
+		}
+		p.destroy(v)
+		p.live.Add(1)
+		p.adopt(nv)
+		p.put(nv)
+	}
+}
+
+// rollback discards the replacements and returns all taken entries unchanged
+func (tx *Tx[T]) rollback() {
+	tx.done = true
+	for _, nv := range tx.replaced {
+		tx.pool.discardReplacement(nv)
+	}
+	for _, v := range tx.taken {
+		tx.pool.put(v)
+	}
+}
+
+// discardReplacement destroys a replacement that never became part of the pool
+func (p *Pool[T]) discardReplacement(v *T) {
+	p.runDestroyer(v)
+}
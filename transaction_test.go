@@ -0,0 +1,92 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTransaction(t *testing.T) {
+	destroyed := 0
+	pool := NewPool(3, poolFactory, WithDestroyer(func(e *poolItem) { destroyed++ }))
+	var old *poolItem
+	nv := new(poolItem)
+	if err := pool.Transaction(context.Background(), func(tx *Tx[poolItem]) error {
+		a, err := tx.TakeIdle()
+		if err != nil {
+			return err
+		}
+		b, _ := tx.TakeIdle()
+		old = a
+		if err := tx.ReplaceWith(a, nv); err != nil {
+			return err
+		}
+		if err := tx.PutBack(b); err != nil {
+			return err
+		}
+		if err := tx.PutBack(b); !errors.Is(err, ErrNotInTx) {
+			t.Errorf("expected %v but got %v", ErrNotInTx, err)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if pool.Len() != 3 || destroyed != 1 {
+		t.Errorf("expected 3 idle and 1 destroyed entry but got %d and %d", pool.Len(), destroyed)
+	}
+	if _, ok := pool.EntryInfo(old); ok {
+		t.Errorf("expected replaced entry to be removed")
+	}
+	if _, ok := pool.EntryInfo(nv); !ok {
+		t.Errorf("expected replacement to be part of the pool")
+	}
+}
+
+func TestTransactionRollback(t *testing.T) {
+	destroyed := 0
+	pool := NewPool(2, poolFactory, WithDestroyer(func(e *poolItem) { destroyed++ }))
+	errFailed := errors.New("failed")
+	if err := pool.Transaction(context.Background(), func(tx *Tx[poolItem]) error {
+		for range 2 {
+			v, _ := tx.TakeIdle()
+			tx.ReplaceWith(v, nil)
+		}
+		return errFailed
+	}); err != errFailed {
+		t.Errorf("expected %v but got %v", errFailed, err)
+	}
+
+	if stats := pool.Stats(); stats.Idle != 2 || stats.Created != 2 || stats.Destroyed != 0 {
+		t.Errorf("expected the pool to be unchanged but got %+v", stats)
+	}
+	if destroyed != 2 {
+		t.Errorf("expected 2 discarded replacements but got %d", destroyed)
+	}
+}
+
+func TestTransactionDeadline(t *testing.T) {
+	pool := NewPool(1, poolFactory)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	var tx *Tx[poolItem]
+	err := pool.Transaction(ctx, func(t *Tx[poolItem]) error {
+		tx = t
+		t.TakeIdle()
+		// waits for the deadline instead of deadlocking
+		_, err := t.TakeIdle()
+		return err
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v but got %v", context.DeadlineExceeded, err)
+	}
+	if pool.Len() != 1 {
+		t.Errorf("expected the taken entry to be returned but got %d idle entries", pool.Len())
+	}
+	var perr *PoolError
+	if _, err := tx.TakeIdle(); !errors.Is(err, ErrTxDone) || !errors.As(err, &perr) || perr.Op != opTransaction {
+		t.Errorf("expected %v wrapped into a PoolError but got %v", ErrTxDone, err)
+	}
+}
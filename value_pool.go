@@ -0,0 +1,158 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ValuePool is a pool for non-pointer element types (small structs, handles
+// like file descriptors, ...) that would otherwise have to be boxed to be used with Pool
+type ValuePool[T any] struct {
+	// size of the pool
+	size int
+	// factory function to fill the pool
+	factoryFunc func() T
+	pool        chan T
+	// returned by timed out acquires without allocating
+	errTimeout error
+}
+
+// Creates a new value pool with the given size/capacity
+// factoryFunc must be provided or else the call will panic, a negative size panics with ErrInvalidSize
+func NewValuePool[T any](size int, factoryFunc func() T) *ValuePool[T] {
+	vp, err := NewValuePoolE(size, factoryFunc)
+	if err != nil {
+		panic(err)
+	}
+	return vp
+}
+
+// Creates a new value pool like NewValuePool but returns an error instead of panicking
+func NewValuePoolE[T any](size int, factoryFunc func() T) (*ValuePool[T], error) {
+	if factoryFunc == nil {
+		return nil, ErrMissingFactoryFunction
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("%w: size %d is negative", ErrInvalidSize, size)
+	}
+	vp := &ValuePool[T]{size: size, factoryFunc: factoryFunc}
+	vp.errTimeout = &PoolError{Op: opAcquire, Err: ErrAcquireTimeout}
+	vp.pool = make(chan T, size)
+	// fill the pool
+	for i := 0; i < size; i++ {
+		vp.pool <- factoryFunc()
+	}
+	return vp, nil
+}
+
+// wrapErr wraps err into a PoolError like Pool does
+func (p *ValuePool[T]) wrapErr(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if err == ErrAcquireTimeout && op == opAcquire {
+		return p.errTimeout
+	}
+	return &PoolError{Op: op, Err: err}
+}
+
+func (p *ValuePool[T]) Len() int {
+	return len(p.pool)
+}
+
+func (p *ValuePool[T]) Cap() int {
+	return cap(p.pool)
+}
+
+func (p *ValuePool[T]) FactoryFunc() func() T {
+	return p.factoryFunc
+}
+
+// Acquire an entry from the pool (blocking)
+func (p *ValuePool[T]) Acquire() T {
+	return <-p.pool
+}
+
+func (p *ValuePool[T]) AcquireWithTimeout(to time.Duration) (T, error) {
+	select {
+	case v := <-p.pool:
+		return v, nil
+	default:
+	}
+	t := acquireTimer(to)
+	defer releaseTimer(t)
+	select {
+	case v := <-p.pool:
+		return v, nil
+	case <-t.C:
+		var zero T
+		return zero, p.wrapErr(opAcquire, ErrAcquireTimeout)
+	}
+}
+
+func (p *ValuePool[T]) AcquireWithContext(ctx context.Context) (T, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	select {
+	case v := <-p.pool:
+		return v, nil
+	default:
+	}
+	select {
+	case <-ctx.Done():
+		var zero T
+		return zero, p.wrapErr(opAcquire, ctx.Err())
+	case v := <-p.pool:
+		return v, nil
+	}
+}
+
+// Releases an entry to the pool (blocking)
+func (p *ValuePool[T]) Release(v T) {
+	p.pool <- v
+}
+
+// Try to release an entry to the pool (non-blocking)
+func (p *ValuePool[T]) TryRelease(v T) error {
+	select {
+	case p.pool <- v:
+	default:
+		return p.wrapErr(opRelease, ErrFailedToRelease)
+	}
+	return nil
+}
+
+// Try to release an entry to the pool, waiting until ctx is done
+func (p *ValuePool[T]) TryReleaseWithContext(ctx context.Context, v T) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	select {
+	case p.pool <- v:
+	case <-ctx.Done():
+		return p.wrapErr(opRelease, ctx.Err())
+	}
+	return nil
+}
+
+// Acquires an entry, runs fn with it and releases the entry afterwards
+func (p *ValuePool[T]) Run(fn func(e T) error) error {
+	e := p.Acquire()
+	defer p.Release(e)
+	return fn(e)
+}
+
+// Like Run but the acquire respects the given context
+func (p *ValuePool[T]) RunWithContext(ctx context.Context, fn func(ctx context.Context, e T) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	e, err := p.AcquireWithContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer p.Release(e)
+	return fn(ctx, e)
+}
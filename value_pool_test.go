@@ -0,0 +1,73 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type handle struct {
+	fd int
+}
+
+func TestValuePool(t *testing.T) {
+	next := 0
+	pool := NewValuePool(2, func() handle {
+		next++
+		return handle{fd: next}
+	})
+	if pool.Len() != 2 || pool.Cap() != 2 {
+		t.Errorf("expected a full pool of 2 but got %d/%d", pool.Len(), pool.Cap())
+	}
+
+	a := pool.Acquire()
+	b, err := pool.AcquireWithTimeout(time.Second)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if a.fd == b.fd {
+		t.Errorf("expected distinct entries but got %v twice", a)
+	}
+
+	if _, err := pool.AcquireWithTimeout(10 * time.Millisecond); !errors.Is(err, ErrAcquireTimeout) {
+		t.Errorf("expected %v but got %v", ErrAcquireTimeout, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	_, err = pool.AcquireWithContext(ctx)
+	cancel()
+	var perr *PoolError
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.As(err, &perr) || perr.Op != opAcquire {
+		t.Errorf("expected a wrapped timeout error but got %v", err)
+	}
+
+	pool.Release(a)
+	if err := pool.TryRelease(b); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := pool.TryRelease(handle{}); !errors.Is(err, ErrFailedToRelease) {
+		t.Errorf("expected %v but got %v", ErrFailedToRelease, err)
+	}
+
+	err = pool.RunWithContext(context.Background(), func(ctx context.Context, e handle) error {
+		if e.fd == 0 {
+			t.Errorf("expected a handle from the pool but got %v", e)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if pool.Len() != 2 {
+		t.Errorf("expected pool to be full but got %d entries", pool.Len())
+	}
+}
+
+func TestValuePoolInvalidSize(t *testing.T) {
+	if _, err := NewValuePoolE(-1, func() handle { return handle{} }); !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("expected %v but got %v", ErrInvalidSize, err)
+	}
+	if _, err := NewValuePoolE[handle](1, nil); !errors.Is(err, ErrMissingFactoryFunction) {
+		t.Errorf("expected %v but got %v", ErrMissingFactoryFunction, err)
+	}
+}
@@ -0,0 +1,74 @@
+package pool
+
+import "context"
+
+// Returns the number of acquires currently waiting for an entry (see Stats.Waiters)
+func (p *Pool[T]) WaiterCount() int {
+	return int(p.waiters.Load())
+}
+
+// WatchWaiters returns a channel receiving the number of waiting acquires whenever it changes (starting with
+// the current number) until ctx is done or the pool gets closed, then the channel gets closed. Changes happening
+// while the receiver is busy are coalesced, so autoscalers and admission controllers always see the latest number.
+func (p *Pool[T]) WatchWaiters(ctx context.Context) <-chan int {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ch := make(chan int)
+	go func() {
+		defer close(ch)
+		last := -1
+		for {
+			// subscribe before reading to not miss changes in the meantime
+			changed := p.waitersSignal()
+			if n := p.WaiterCount(); n != last {
+				select {
+				case ch <- n:
+					last = n
+					continue
+				case <-ctx.Done():
+					return
+				case <-p.done:
+					return
+				}
+			}
+			select {
+			case <-changed:
+			case <-ctx.Done():
+				return
+			case <-p.done:
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// waitersSignal returns a channel that gets closed once the number of waiting acquires changes
+func (p *Pool[T]) waitersSignal() <-chan struct{} {
+	for {
+		if c := p.waitersNotify.Load(); c != nil {
+			return *c
+		}
+		c := make(chan struct{})
+		if p.waitersNotify.CompareAndSwap(nil, &c) {
+			return c
+		}
+	}
+}
+
+// notifyWaiters wakes up WatchWaiters calls
+func (p *Pool[T]) notifyWaiters() {
+	if p.waitersNotify.Load() == nil {
+		return
+	}
+	if c := p.waitersNotify.Swap(nil); c != nil {
+		close(*c)
+	}
+}
+
+// removeWaiter unregisters a waiting acquire (see addWaiter)
+func (p *Pool[T]) removeWaiter() {
+	p.waiters.Add(-1)
+	p.notifyWaiters()
+}
@@ -0,0 +1,41 @@
+package pool
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWatchWaiters(t *testing.T) {
+	pool := NewPool(1, poolFactory)
+	defer pool.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	watch := pool.WatchWaiters(ctx)
+	if n := <-watch; n != 0 {
+		t.Errorf("expected no waiters but got %d", n)
+	}
+
+	e := pool.Acquire()
+	acquired := make(chan *poolItem)
+	go func() {
+		acquired <- pool.Acquire()
+	}()
+	if n := <-watch; n != 1 || pool.WaiterCount() != 1 {
+		t.Errorf("expected 1 waiter but got %d", n)
+	}
+	pool.Release(e)
+	pool.Release(<-acquired)
+	select {
+	case n := <-watch:
+		if n != 0 {
+			t.Errorf("expected no waiters but got %d", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected the waiter to leave")
+	}
+
+	cancel()
+	if _, ok := <-watch; ok {
+		t.Errorf("expected the channel to be closed once ctx is done")
+	}
+}
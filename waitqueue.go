@@ -0,0 +1,126 @@
+package pool
+
+import (
+	"container/heap"
+	"context"
+	"time"
+)
+
+// Waiter is an acquire waiting for an entry, queued by a WaitQueue
+type Waiter struct {
+	// context of the acquire, e.g. to order waiters by their deadline or by a tenant stored in it
+	Context context.Context
+	// time the acquire started waiting
+	Since time.Time
+	// the acquire is a retry (see WithPriority)
+	Retry bool
+	// waiter of the idle store the entry is handed to
+	w any
+}
+
+// WaitQueue decides which waiting acquire gets the next released entry (see WithWaitQueue), e.g. to serve
+// the acquire with the earliest deadline first or to serve tenants fairly. Its methods are called while the
+// idle entries of the pool are locked, they mustn't block or call methods of the pool.
+type WaitQueue interface {
+	// Push queues w
+	Push(w *Waiter)
+	// Pop removes and returns the waiter that gets the next entry, nil if no waiter is queued
+	Pop() *Waiter
+	// Remove removes w if it's queued (e.g. because its context got canceled) and reports whether it was,
+	// false must be returned for waiters that were popped already
+	Remove(w *Waiter) bool
+	// Len returns the number of queued waiters
+	Len() int
+}
+
+// WithWaitQueue replaces the FIFO queue of acquires waiting for an entry by q, q must not be shared between
+// pools. Waiters are pushed with the context of their acquire, acquires without one (e.g. Acquire) get a
+// background context.
+func WithWaitQueue(q WaitQueue) Option {
+	return func(o *options) {
+		o.waitQueue = q
+	}
+}
+
+// NewDeadlineQueue returns a WaitQueue serving the waiter whose context has the earliest deadline first,
+// waiters without a deadline are served after them in FIFO order. Retries (see WithPriority) are
+// served ahead of both.
+func NewDeadlineQueue() WaitQueue {
+	return &deadlineQueue{h: waiterHeap{index: make(map[*Waiter]int)}}
+}
+
+// deadlineQueue is a WaitQueue based on a heap of the waiters
+type deadlineQueue struct {
+	h waiterHeap
+}
+
+func (q *deadlineQueue) Push(w *Waiter) {
+	heap.Push(&q.h, w)
+}
+
+func (q *deadlineQueue) Pop() *Waiter {
+	if q.h.Len() == 0 {
+		return nil
+	}
+	return heap.Pop(&q.h).(*Waiter)
+}
+
+func (q *deadlineQueue) Remove(w *Waiter) bool {
+	i, ok := q.h.index[w]
+	if ok {
+		heap.Remove(&q.h, i)
+	}
+	return ok
+}
+
+func (q *deadlineQueue) Len() int {
+	return q.h.Len()
+}
+
+// waiterHeap is a min-heap of waiters ordered by retry, deadline and the time they started waiting
+type waiterHeap struct {
+	waiters []*Waiter
+	// waiter -> index in waiters
+	index map[*Waiter]int
+}
+
+func (h *waiterHeap) Len() int {
+	return len(h.waiters)
+}
+
+func (h *waiterHeap) Less(i, j int) bool {
+	a, b := h.waiters[i], h.waiters[j]
+	if a.Retry != b.Retry {
+		return a.Retry
+	}
+	da, oka := a.Context.Deadline()
+	db, okb := b.Context.Deadline()
+	if oka != okb {
+		return oka
+	}
+	if oka && !da.Equal(db) {
+		return da.Before(db)
+	}
+	return a.Since.Before(b.Since)
+}
+
+func (h *waiterHeap) Swap(i, j int) {
+	h.waiters[i], h.waiters[j] = h.waiters[j], h.waiters[i]
+	h.index[h.waiters[i]] = i
+	h.index[h.waiters[j]] = j
+}
+
+func (h *waiterHeap) Push(x any) {
+	w := x.(*Waiter)
+	h.index[w] = len(h.waiters)
+	h.waiters = append(h.waiters, w)
+}
+
+func (h *waiterHeap) Pop() any {
+	n := len(h.waiters) - 1
+	w := h.waiters[n]
+	h.waiters[n] = nil
+	h.waiters = h.waiters[:n]
+	delete(h.index, w)
+	return w
+}
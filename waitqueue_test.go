@@ -0,0 +1,47 @@
+package pool
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDeadlineQueue(t *testing.T) {
+	pool := NewPool(1, poolFactory, WithWaitQueue(NewDeadlineQueue()))
+	e := pool.Acquire()
+	got := make(chan string, 3)
+	acquire := func(name string, timeout time.Duration) {
+		ctx := context.Background()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		v, err := pool.AcquireWithContext(ctx)
+		got <- name
+		if err == nil {
+			pool.Release(v)
+		}
+	}
+	go acquire("none", 0)
+	go acquire("late", time.Minute)
+	go acquire("early", time.Second)
+	for pool.WaiterCount() != 3 {
+		time.Sleep(time.Millisecond)
+	}
+	// a canceled waiter leaves the queue
+	ctx, cancel := context.WithCancel(context.Background())
+	go cancel()
+	if _, err := pool.AcquireWithContext(ctx); err == nil {
+		t.Fatalf("expected the acquire to be canceled")
+	}
+	pool.Release(e)
+	for _, expected := range []string{"early", "late", "none"} {
+		if name := <-got; name != expected {
+			t.Errorf("expected %s but got %s", expected, name)
+		}
+	}
+	if _, err := newPool(1, poolFactory, []Option{WithWaitQueue(NewDeadlineQueue()), WithUnsafeAccess()}); err == nil {
+		t.Errorf("expected an error for a wait queue with unsafe access")
+	}
+}
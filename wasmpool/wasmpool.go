@@ -0,0 +1,170 @@
+// Package wasmpool pools instances of a compiled WebAssembly module (e.g. wazero's api.Module)
+//
+// Instantiating a module is much cheaper than compiling it but still costly for hot paths, the pool
+// keeps instances of a compiled module and instantiates new ones lazily using the context of the
+// caller. Released instances are prepared for reuse using Options.Reset (e.g. calling an exported
+// reset function), without it every instance is used only once and re-instantiated. MaxLifetime
+// and MaxMemory bound the memory creep of long-lived instances, since linear memory never shrinks.
+// The package doesn't depend on a runtime, instances are created using Options.Instantiate:
+//
+//	r := wazero.NewRuntime(ctx)
+//	compiled, err := r.CompileModule(ctx, wasm)
+//	...
+//	p, err := wasmpool.New(wasmpool.Options[api.Module]{
+//		Instantiate: func(ctx context.Context) (api.Module, error) {
+//			return r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithName(""))
+//		},
+//		Reset: func(ctx context.Context, m api.Module) error {
+//			_, err := m.ExportedFunction("reset").Call(ctx)
+//			return err
+//		},
+//		Memory:    func(m api.Module) uint64 { return uint64(m.Memory().Size()) },
+//		MaxMemory: 64 << 20,
+//		Size:      runtime.NumCPU(),
+//	})
+//
+// The pool only manages the lifecycle of the instances it gets from Instantiate, the integration with a
+// runtime (the example above) isn't part of the package and isn't covered by its tests, which use a fake
+// Instance. The behavior of a runtime (e.g. whether a reset function restores the state of an instance or
+// what Memory reports) is up to the caller.
+package wasmpool
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/epikur-io/go-pool"
+	"github.com/epikur-io/go-pool/internal/adapter"
+)
+
+// Instance is a module instance, api.Module of wazero implements it
+type Instance interface {
+	Close(ctx context.Context) error
+}
+
+// Options of an instance pool
+type Options[I Instance] struct {
+	// Instantiate creates a new instance of the compiled module (required)
+	Instantiate func(ctx context.Context) (I, error)
+	// Size is the number of instances (required)
+	Size int
+	// Reset prepares a released instance for reuse, instances are re-instantiated if it's
+	// nil or fails (optional)
+	Reset func(ctx context.Context, i I) error
+	// Memory returns the size of the linear memory of an instance in bytes (optional)
+	Memory func(i I) uint64
+	// MaxMemory re-instantiates released instances whose memory grew beyond it (requires Memory)
+	MaxMemory uint64
+	// MaxLifetime re-instantiates instances older than it, 0 keeps them
+	MaxLifetime time.Duration
+}
+
+// Module is a pooled module instance, Instance is the zero value until the module is instantiated
+type Module[I Instance] struct {
+	Instance I
+	ready    bool
+}
+
+// Pool is a pool of module instances
+type Pool[I Instance] struct {
+	opts Options[I]
+	pool *pool.Pool[Module[I]]
+}
+
+// errRecycle is returned by reset for instances that must not be reused
+var errRecycle = errors.New("wasmpool: instance not reusable")
+
+// Creates a pool of module instances created by o.Instantiate. Released instances are reset using o.Reset
+// (or recycled without one) and removed instances get closed, opts with a reset or destroyer of their own
+// take precedence.
+func New[I Instance](o Options[I], opts ...pool.Option) (*Pool[I], error) {
+	if o.Instantiate == nil {
+		return nil, errors.New("wasmpool: missing instantiate function")
+	}
+	p := &Pool[I]{opts: o}
+	defaults := []pool.Option{
+		pool.WithReset(p.reset),
+		pool.WithDestroyerContext(closeModule[I]),
+	}
+	cfg := pool.Config{Size: o.Size, TTL: pool.Duration(o.MaxLifetime)}
+	modules, err := pool.NewPoolFromConfig(cfg, func() *Module[I] { return &Module[I]{} }, append(defaults, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	p.pool = modules
+	return p, nil
+}
+
+// reset prepares released instances for reuse
+func (p *Pool[I]) reset(ctx context.Context, m *Module[I]) error {
+	if !m.ready {
+		return nil
+	}
+	if p.opts.Reset == nil {
+		return errRecycle
+	}
+	if p.opts.Memory != nil && p.opts.MaxMemory > 0 && p.opts.Memory(m.Instance) > p.opts.MaxMemory {
+		return errRecycle
+	}
+	return p.opts.Reset(ctx, m.Instance)
+}
+
+// closeModule closes instantiated modules
+func closeModule[I Instance](ctx context.Context, m *Module[I]) error {
+	if !m.ready {
+		return nil
+	}
+	return m.Instance.Close(ctx)
+}
+
+// Returns the pool holding the instances, its idle entries include slots not instantiated yet
+func (p *Pool[I]) Pool() *pool.Pool[Module[I]] {
+	return p.pool
+}
+
+// Acquire acquires an instance, instantiating the module if necessary, waiting until ctx is done
+func (p *Pool[I]) Acquire(ctx context.Context) (*Module[I], error) {
+	return adapter.Acquire(ctx, p.pool, instantiated[I], p.instantiate)
+}
+
+// instantiated reports whether the module of m was instantiated
+func instantiated[I Instance](m *Module[I]) bool {
+	return m.ready
+}
+
+// instantiate instantiates the module of m
+func (p *Pool[I]) instantiate(ctx context.Context, m *Module[I]) error {
+	i, err := p.opts.Instantiate(ctx)
+	if err != nil {
+		return err
+	}
+	m.Instance, m.ready = i, true
+	return nil
+}
+
+// Releases an instance to the pool, it's reset or re-instantiated
+func (p *Pool[I]) Release(m *Module[I]) error {
+	return p.pool.Release(m)
+}
+
+// Discard closes a broken instance (e.g. after a trap), the module is instantiated again
+// on the next acquire of its slot
+func (p *Pool[I]) Discard(m *Module[I]) error {
+	return p.pool.Replace(m)
+}
+
+// Run acquires an instance and runs fn with it, the instance is closed if fn fails or panics
+// since a trapped instance may be left in an inconsistent state
+func (p *Pool[I]) Run(ctx context.Context, fn func(ctx context.Context, i I) error) error {
+	m, err := p.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	return adapter.Run(p.pool, m, p.Release, nil, func() error { return fn(ctx, m.Instance) })
+}
+
+// Close closes the pool and all idle instances
+func (p *Pool[I]) Close() error {
+	return p.pool.Close()
+}
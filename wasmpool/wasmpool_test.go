@@ -0,0 +1,112 @@
+package wasmpool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// testInstance fakes a module instance with a growing linear memory
+type testInstance struct {
+	memory uint64
+	closed bool
+}
+
+func (i *testInstance) Close(ctx context.Context) error {
+	i.closed = true
+	return nil
+}
+
+func newTestPool(t *testing.T, o Options[*testInstance]) (*Pool[*testInstance], *atomic.Int64) {
+	var created atomic.Int64
+	o.Instantiate = func(ctx context.Context) (*testInstance, error) {
+		created.Add(1)
+		return &testInstance{memory: 1}, nil
+	}
+	if o.Size == 0 {
+		o.Size = 1
+	}
+	p, err := New(o)
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p, &created
+}
+
+func TestReinstantiate(t *testing.T) {
+	p, created := newTestPool(t, Options[*testInstance]{})
+	var first *testInstance
+	for i := 0; i < 3; i++ {
+		if err := p.Run(context.Background(), func(ctx context.Context, i *testInstance) error {
+			if first == nil {
+				first = i
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+	}
+	if created.Load() != 3 || !first.closed {
+		t.Errorf("expected a new instance for every use but got %d instances", created.Load())
+	}
+}
+
+func TestReset(t *testing.T) {
+	var resets atomic.Int64
+	p, created := newTestPool(t, Options[*testInstance]{
+		Reset: func(ctx context.Context, i *testInstance) error {
+			resets.Add(1)
+			return nil
+		},
+		Memory:    func(i *testInstance) uint64 { return i.memory },
+		MaxMemory: 10,
+	})
+	grow := func(n uint64) func(ctx context.Context, i *testInstance) error {
+		return func(ctx context.Context, i *testInstance) error {
+			i.memory += n
+			return nil
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if err := p.Run(context.Background(), grow(1)); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+	}
+	if created.Load() != 1 || resets.Load() != 3 {
+		t.Errorf("expected 1 reused instance but got %d instances and %d resets", created.Load(), resets.Load())
+	}
+	// outgrows MaxMemory
+	p.Run(context.Background(), grow(100))
+	p.Run(context.Background(), grow(0))
+	if created.Load() != 2 {
+		t.Errorf("expected the grown instance to be replaced but got %d instances", created.Load())
+	}
+	// traps discard the instance
+	p.Run(context.Background(), func(ctx context.Context, i *testInstance) error { return errors.New("trap") })
+	p.Run(context.Background(), grow(0))
+	if created.Load() != 3 {
+		t.Errorf("expected the trapped instance to be replaced but got %d instances", created.Load())
+	}
+}
+
+func TestInstantiateError(t *testing.T) {
+	p, err := New(Options[*testInstance]{
+		Size:        1,
+		Instantiate: func(ctx context.Context) (*testInstance, error) { return nil, errors.New("invalid module") },
+	})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+	defer p.Close()
+	if _, err := p.Acquire(context.Background()); err == nil {
+		t.Errorf("expected instantiation error")
+	}
+	if stats := p.Pool().Stats(); stats.Idle != 1 {
+		t.Errorf("expected the slot to be released but got %+v", stats)
+	}
+	if _, err := New(Options[*testInstance]{Size: 1}); err == nil {
+		t.Errorf("expected error for a missing instantiate function")
+	}
+}
@@ -0,0 +1,162 @@
+// Package workers provides a bounded worker pool where every worker owns an entry of a resource pool
+// (e.g. one Lua VM per worker) for its whole lifetime:
+//
+//	w := workers.New(vms, 8)
+//	defer w.Shutdown(context.Background())
+//	err := w.Do(ctx, func(ctx context.Context, vm *lua.LState) error {
+//		return vm.DoString(script)
+//	})
+package workers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/epikur-io/go-pool"
+)
+
+var ErrClosed = fmt.Errorf("worker pool is shut down")
+
+// Task is run by a worker using the entry the worker owns
+type Task[T any] func(ctx context.Context, e *T) error
+
+// Option configures a worker pool
+type Option func(*options)
+
+type options struct {
+	errorHandler func(error)
+}
+
+// WithErrorHandler sets a function that gets called with the errors of tasks started using Submit
+func WithErrorHandler(fn func(error)) Option {
+	return func(o *options) {
+		o.errorHandler = fn
+	}
+}
+
+// Pool is a pool of workers
+type Pool[T any] struct {
+	res   *pool.Pool[T]
+	opts  options
+	tasks chan task[T]
+	// closed on shutdown
+	quit     chan struct{}
+	quitOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+type task[T any] struct {
+	ctx context.Context
+	fn  Task[T]
+	// receives the result of fn, nil for tasks started using Submit
+	result chan error
+}
+
+// Creates a worker pool with n workers, each worker acquires an entry of res once it
+// runs its first task and releases it on shutdown
+func New[T any](res *pool.Pool[T], n int, opts ...Option) *Pool[T] {
+	p := &Pool[T]{
+		res:   res,
+		tasks: make(chan task[T]),
+		quit:  make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(&p.opts)
+	}
+	p.wg.Add(n)
+	for range n {
+		go p.work()
+	}
+	return p
+}
+
+// work runs tasks until the pool gets shut down
+func (p *Pool[T]) work() {
+	defer p.wg.Done()
+	var e *T
+	defer func() {
+		if e != nil {
+			p.res.Release(e)
+		}
+	}()
+	for {
+		select {
+		case t := <-p.tasks:
+			var err error
+			if e == nil {
+				e, err = p.res.AcquireWithContext(t.ctx)
+			}
+			if err == nil {
+				err = t.fn(t.ctx, e)
+			}
+			p.done(t, err)
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// done reports the result of t
+func (p *Pool[T]) done(t task[T], err error) {
+	if t.result != nil {
+		t.result <- err
+	} else if err != nil && p.opts.errorHandler != nil {
+		p.opts.errorHandler(err)
+	}
+}
+
+// submit hands t over to a worker, waiting until a worker is available or ctx is done
+func (p *Pool[T]) submit(t task[T]) error {
+	if t.ctx == nil {
+		t.ctx = context.Background()
+	}
+	select {
+	case <-p.quit:
+		return ErrClosed
+	default:
+	}
+	select {
+	case p.tasks <- t:
+		return nil
+	case <-p.quit:
+		return ErrClosed
+	case <-t.ctx.Done():
+		return t.ctx.Err()
+	}
+}
+
+// Submit starts fn on the next available worker without waiting for it to finish,
+// errors of fn are passed to the error handler (see WithErrorHandler)
+func (p *Pool[T]) Submit(ctx context.Context, fn Task[T]) error {
+	return p.submit(task[T]{ctx: ctx, fn: fn})
+}
+
+// Do runs fn on the next available worker and returns its error
+func (p *Pool[T]) Do(ctx context.Context, fn Task[T]) error {
+	t := task[T]{ctx: ctx, fn: fn, result: make(chan error, 1)}
+	if err := p.submit(t); err != nil {
+		return err
+	}
+	return <-t.result
+}
+
+// Shutdown stops accepting tasks and waits until the running tasks finished
+// and the workers released their entries or ctx is done
+func (p *Pool[T]) Shutdown(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	p.quitOnce.Do(func() { close(p.quit) })
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,103 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/epikur-io/go-pool"
+)
+
+type resource struct {
+	runs int
+}
+
+func TestWorkers(t *testing.T) {
+	res := pool.NewPool(4, func() *resource { return &resource{} })
+	w := New(res, 2)
+
+	var mux sync.Mutex
+	used := map[*resource]bool{}
+	var running, maxRunning atomic.Int64
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := w.Do(context.Background(), func(ctx context.Context, r *resource) error {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					m := maxRunning.Load()
+					if n <= m || maxRunning.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				mux.Lock()
+				used[r] = true
+				r.runs++
+				mux.Unlock()
+				time.Sleep(time.Millisecond)
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxRunning.Load() > 2 || len(used) > 2 {
+		t.Errorf("expected at most 2 concurrent tasks on 2 resources but got %d on %d", maxRunning.Load(), len(used))
+	}
+	if err := w.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+	if stats := res.Stats(); stats.InUse != 0 {
+		t.Errorf("expected all resources to be released but got %d in use", stats.InUse)
+	}
+	if err := w.Submit(context.Background(), func(ctx context.Context, r *resource) error { return nil }); err != ErrClosed {
+		t.Errorf("expected %v but got %v", ErrClosed, err)
+	}
+}
+
+func TestSubmitErrors(t *testing.T) {
+	res := pool.NewPool(1, func() *resource { return &resource{} })
+	errs := make(chan error, 1)
+	w := New(res, 1, WithErrorHandler(func(err error) { errs <- err }))
+	defer w.Shutdown(context.Background())
+
+	errFailed := errors.New("failed")
+	if err := w.Submit(context.Background(), func(ctx context.Context, r *resource) error { return errFailed }); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errs; err != errFailed {
+		t.Errorf("expected %v but got %v", errFailed, err)
+	}
+	if err := w.Do(context.Background(), func(ctx context.Context, r *resource) error { return errFailed }); err != errFailed {
+		t.Errorf("expected %v but got %v", errFailed, err)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	res := pool.NewPool(1, func() *resource { return &resource{} })
+	w := New(res, 1)
+	block := make(chan struct{})
+	w.Submit(context.Background(), func(ctx context.Context, r *resource) error {
+		<-block
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := w.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected %v but got %v", context.DeadlineExceeded, err)
+	}
+	close(block)
+	if err := w.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+}